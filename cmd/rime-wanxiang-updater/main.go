@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/term"
+	"rime-wanxiang-updater/internal/cli"
 	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/controller"
 	"rime-wanxiang-updater/internal/i18n"
@@ -253,6 +255,11 @@ func printBootSequence(locale i18n.Locale) {
 }
 
 func main() {
+	// 非交互子命令：不初始化终端界面，直接执行并以退出码结束
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	// 初始化终端颜色检测（自动检测终端背景色）
 	termcolor.InitLipgloss()
 
@@ -268,6 +275,9 @@ func main() {
 		fmt.Println(errorStyle.Render("⚠ FATAL ERROR: " + err.Error()))
 		os.Exit(1)
 	}
+	for _, notice := range cfg.Notices {
+		fmt.Println(notice)
+	}
 
 	bootLocale := i18n.Normalize(cfg.Config.Language)
 
@@ -338,3 +348,18 @@ func main() {
 	clearScreen()
 	fmt.Print(exitBlock)
 }
+
+// runCLI 加载配置并执行非交互子命令；加载配置时的提示输出到 stderr，
+// 以免混入 --json 的输出
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	cfg, err := config.NewManager()
+	if err != nil {
+		fmt.Fprintf(stderr, "加载配置失败: %v\n", err)
+		return cli.ExitFailed
+	}
+	for _, notice := range cfg.Notices {
+		fmt.Fprintln(stderr, notice)
+	}
+
+	return cli.Run(cfg, args, stdout, stderr)
+}
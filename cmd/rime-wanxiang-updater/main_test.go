@@ -1,6 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -45,3 +49,31 @@ func TestRenderExitScreenIncludesBothLines(t *testing.T) {
 		}
 	}
 }
+
+func TestRunCLIJSONKeepsConfigNoticesOffStdout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".rime-updater")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 旧版 engine 字段需要迁移、引擎未安装、语言为空，加载时会迁移并自动更新配置
+	legacy := `{"engine": "fcitx5", "language": ""}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	runCLI([]string{"status", "--json"}, &stdout, &stderr)
+
+	var doc map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if doc["command"] != "status" {
+		t.Errorf("command = %v, want status", doc["command"])
+	}
+	if !strings.Contains(stderr.String(), "配置已自动更新") {
+		t.Errorf("stderr = %q, want config notices", stderr.String())
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -13,14 +14,22 @@ import (
 
 // Client API 客户端
 type Client struct {
-	httpClient  *http.Client
-	config      *types.Config
-	githubToken string
-	cnbBaseURL  string
-	mu          sync.Mutex
-
-	cnbTagsPageCache   map[string]cnbTagsPageResult
-	cnbReleaseTagCache map[string]types.GitHubRelease
+	httpClient    *http.Client
+	config        *types.Config
+	githubToken   string
+	githubBaseURL string // 可通过 Config.GithubAPIURL 指向 api.github.com 的反向代理
+	cnbBaseURL    string
+	mu            sync.Mutex
+
+	// 元数据请求：相同请求只发送一次，并发的调用方等待同一结果，成功结果在客户端的生命周期内复用
+	requests map[string]*request
+}
+
+// request 一次正在进行或已完成的元数据请求
+type request struct {
+	done  chan struct{}
+	value any
+	err   error
 }
 
 type cnbTagsPageResult struct {
@@ -31,13 +40,40 @@ type cnbTagsPageResult struct {
 // NewClient 创建新的 API 客户端
 func NewClient(config *types.Config) *Client {
 	return &Client{
-		httpClient:         getHTTPClient(config),
-		config:             config,
-		githubToken:        config.GithubToken,
-		cnbBaseURL:         "https://cnb.cool",
-		cnbTagsPageCache:   make(map[string]cnbTagsPageResult),
-		cnbReleaseTagCache: make(map[string]types.GitHubRelease),
+		httpClient:    getHTTPClient(config),
+		config:        config,
+		githubToken:   config.GithubToken,
+		githubBaseURL: GitHubAPIURL(config),
+		cnbBaseURL:    "https://cnb.cool",
+		requests:      make(map[string]*request),
+	}
+}
+
+// once 对相同 key 的请求只执行一次 fetch；失败的请求不会被保留，下次调用时重新请求。
+// 等待其他调用方的请求时 ctx 被取消会立即返回。
+func (c *Client) once(ctx context.Context, key string, fetch func() (any, error)) (any, error) {
+	c.mu.Lock()
+	if r, ok := c.requests[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-r.done:
+			return r.value, r.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 	}
+	r := &request{done: make(chan struct{})}
+	c.requests[key] = r
+	c.mu.Unlock()
+
+	r.value, r.err = fetch()
+	if r.err != nil {
+		c.mu.Lock()
+		delete(c.requests, key)
+		c.mu.Unlock()
+	}
+	close(r.done)
+	return r.value, r.err
 }
 
 // GetHTTPClient 返回配置了代理的 HTTP 客户端
@@ -101,8 +137,8 @@ func buildHTTPClient(config *types.Config, timeout time.Duration) *http.Client {
 }
 
 // Get 发送 GET 请求
-func (c *Client) Get(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -122,8 +158,8 @@ func (c *Client) Get(url string) (*http.Response, error) {
 }
 
 // Head 发送 HEAD 请求
-func (c *Client) Head(url string) (*http.Response, error) {
-	req, err := http.NewRequest("HEAD", url, nil)
+func (c *Client) Head(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -136,3 +172,16 @@ func (c *Client) Head(url string) (*http.Response, error) {
 
 	return c.httpClient.Do(req)
 }
+
+// Sleep 等待 d，ctx 被取消时提前返回 ctx.Err()；用于重试间隔，使取消不必等到退避结束
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,8 +1,15 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"rime-wanxiang-updater/internal/types"
 )
@@ -34,3 +41,87 @@ func TestNewDownloadHTTPClientUsesHTTPProxyConfig(t *testing.T) {
 		t.Fatal("Proxy = nil, want configured proxy function")
 	}
 }
+
+func TestFetchGitHubReleasesSharesConcurrentRequests(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, `[{"tag_name": "v1.0.0", "assets": [{"name": "scheme.zip"}]}]`)
+	}))
+	defer server.Close()
+
+	targetURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("url.Parse(server.URL) error = %v", err)
+	}
+
+	client := NewClient(getTestConfig())
+	client.httpClient = &http.Client{
+		Transport: rewriteHostTransport{target: targetURL, base: http.DefaultTransport},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			releases, err := client.FetchGitHubReleases(context.Background(), "amzxyz", "rime_wanxiang", "")
+			if err != nil || len(releases) != 1 || releases[0].TagName != "v1.0.0" {
+				t.Errorf("FetchGitHubReleases() = %v, %v, want one v1.0.0 release", releases, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := client.FetchGitHubReleases(context.Background(), "amzxyz", "rime_wanxiang", ""); err != nil {
+		t.Fatalf("FetchGitHubReleases() error = %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("server hits = %d, want 1", hits)
+	}
+}
+
+func TestFetchCNBReleaseByTagRetriesAfterFailure(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 前两次请求（第一次调用及其重试）失败
+		if atomic.AddInt32(&hits, 1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"release": {"tag_ref": "refs/tags/v1.0.0", "assets": []}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(getTestConfig())
+	client.cnbBaseURL = server.URL
+	client.httpClient = server.Client()
+
+	if _, err := client.FetchCNBReleaseByTag(context.Background(), "amzxyz", "rime-wanxiang", "v1.0.0"); err == nil {
+		t.Fatal("first FetchCNBReleaseByTag() error = nil, want failure")
+	}
+	release, err := client.FetchCNBReleaseByTag(context.Background(), "amzxyz", "rime-wanxiang", "v1.0.0")
+	if err != nil {
+		t.Fatalf("second FetchCNBReleaseByTag() error = %v", err)
+	}
+	if release.TagName != "v1.0.0" {
+		t.Fatalf("release.TagName = %q, want %q", release.TagName, "v1.0.0")
+	}
+}
+
+func TestSleepReturnsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := Sleep(ctx, time.Minute); err != context.Canceled {
+		t.Fatalf("Sleep() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Sleep() took %v after cancel, want immediate return", elapsed)
+	}
+	if err := Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("Sleep() error = %v, want nil", err)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,11 +11,22 @@ import (
 	"time"
 
 	"github.com/cloudflare/backoff"
+	"rime-wanxiang-updater/internal/releaseutil"
 	"rime-wanxiang-updater/internal/types"
 )
 
-// FetchCNBReleases 获取 CNB Releases
-func (c *Client) FetchCNBReleases(owner, repo, tag string) ([]types.GitHubRelease, error) {
+// FetchCNBReleases 获取 CNB Releases，相同的请求在客户端的生命周期内只发送一次
+func (c *Client) FetchCNBReleases(ctx context.Context, owner, repo, tag string) ([]types.GitHubRelease, error) {
+	value, err := c.once(ctx, fmt.Sprintf("cnb:%s/%s:%s", owner, repo, tag), func() (any, error) {
+		return c.fetchCNBReleases(ctx, owner, repo, tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]types.GitHubRelease(nil), value.([]types.GitHubRelease)...), nil
+}
+
+func (c *Client) fetchCNBReleases(ctx context.Context, owner, repo, tag string) ([]types.GitHubRelease, error) {
 	var releases []types.GitHubRelease
 
 	baseURL := fmt.Sprintf(
@@ -31,7 +43,7 @@ func (c *Client) FetchCNBReleases(owner, repo, tag string) ([]types.GitHubReleas
 			return nil, fmt.Errorf("构建 CNB 分页链接失败: %w", err)
 		}
 
-		resp, err := c.fetchCNBPageWithRetry(pageURL)
+		resp, err := c.fetchCNBPageWithRetry(ctx, pageURL)
 		if err != nil {
 			return nil, err
 		}
@@ -60,20 +72,23 @@ func (c *Client) FetchCNBReleases(owner, repo, tag string) ([]types.GitHubReleas
 }
 
 // FetchCNBReleaseByTag 获取指定 tag 的单个 CNB release。
-func (c *Client) FetchCNBReleaseByTag(owner, repo, tag string) (*types.GitHubRelease, error) {
+func (c *Client) FetchCNBReleaseByTag(ctx context.Context, owner, repo, tag string) (*types.GitHubRelease, error) {
 	if tag == "" {
 		return nil, fmt.Errorf("tag 不能为空")
 	}
 
-	cacheKey := fmt.Sprintf("%s/%s:%s", owner, repo, tag)
-	c.mu.Lock()
-	if cached, ok := c.cnbReleaseTagCache[cacheKey]; ok {
-		c.mu.Unlock()
-		releaseCopy := cached
-		return &releaseCopy, nil
+	value, err := c.once(ctx, fmt.Sprintf("cnb-release:%s/%s:%s", owner, repo, tag), func() (any, error) {
+		return c.fetchCNBReleaseByTag(ctx, owner, repo, tag)
+	})
+	if err != nil {
+		return nil, err
 	}
-	c.mu.Unlock()
 
+	releaseCopy := value.(types.GitHubRelease)
+	return &releaseCopy, nil
+}
+
+func (c *Client) fetchCNBReleaseByTag(ctx context.Context, owner, repo, tag string) (types.GitHubRelease, error) {
 	rawURL := fmt.Sprintf(
 		"%s/%s/%s/-/releases/tags/%s",
 		strings.TrimRight(c.cnbBaseURL, "/"),
@@ -82,9 +97,9 @@ func (c *Client) FetchCNBReleaseByTag(owner, repo, tag string) (*types.GitHubRel
 		url.PathEscape(tag),
 	)
 
-	resp, err := c.fetchCNBPageWithRetry(rawURL)
+	resp, err := c.fetchCNBPageWithRetry(ctx, rawURL)
 	if err != nil {
-		return nil, err
+		return types.GitHubRelease{}, err
 	}
 	defer resp.Body.Close()
 
@@ -93,36 +108,35 @@ func (c *Client) FetchCNBReleaseByTag(owner, repo, tag string) (*types.GitHubRel
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
+		return types.GitHubRelease{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	releases := convertCNBReleases([]types.CNBRelease{result.Release}, tag, c.cnbBaseURL)
 	if len(releases) == 0 {
-		return nil, fmt.Errorf("未找到 tag 为 %s 的 CNB release", tag)
+		return types.GitHubRelease{}, fmt.Errorf("未找到 tag 为 %s 的 CNB release", tag)
 	}
 
-	c.mu.Lock()
-	c.cnbReleaseTagCache[cacheKey] = releases[0]
-	c.mu.Unlock()
-
-	releaseCopy := releases[0]
-	return &releaseCopy, nil
+	return releases[0], nil
 }
 
 // FetchCNBReleaseTagsPage 获取一页带 release 的 CNB tag 列表。
-func (c *Client) FetchCNBReleaseTagsPage(owner, repo string, page int) ([]string, int, error) {
+func (c *Client) FetchCNBReleaseTagsPage(ctx context.Context, owner, repo string, page int) ([]string, int, error) {
 	if page < 1 {
 		page = 1
 	}
 
-	cacheKey := fmt.Sprintf("%s/%s:%d", owner, repo, page)
-	c.mu.Lock()
-	if cached, ok := c.cnbTagsPageCache[cacheKey]; ok {
-		c.mu.Unlock()
-		return append([]string(nil), cached.tags...), cached.totalPages, nil
+	value, err := c.once(ctx, fmt.Sprintf("cnb-tags:%s/%s:%d", owner, repo, page), func() (any, error) {
+		return c.fetchCNBReleaseTagsPage(ctx, owner, repo, page)
+	})
+	if err != nil {
+		return nil, 0, err
 	}
-	c.mu.Unlock()
 
+	result := value.(cnbTagsPageResult)
+	return append([]string(nil), result.tags...), result.totalPages, nil
+}
+
+func (c *Client) fetchCNBReleaseTagsPage(ctx context.Context, owner, repo string, page int) (cnbTagsPageResult, error) {
 	rawURL := fmt.Sprintf(
 		"%s/%s/%s/-/git/tags",
 		strings.TrimRight(c.cnbBaseURL, "/"),
@@ -132,7 +146,7 @@ func (c *Client) FetchCNBReleaseTagsPage(owner, repo string, page int) ([]string
 	if page > 1 {
 		parsedURL, err := url.Parse(rawURL)
 		if err != nil {
-			return nil, 0, fmt.Errorf("解析 tags 链接失败: %w", err)
+			return cnbTagsPageResult{}, fmt.Errorf("解析 tags 链接失败: %w", err)
 		}
 
 		query := parsedURL.Query()
@@ -141,9 +155,9 @@ func (c *Client) FetchCNBReleaseTagsPage(owner, repo string, page int) ([]string
 		rawURL = parsedURL.String()
 	}
 
-	resp, err := c.fetchCNBPageWithRetry(rawURL)
+	resp, err := c.fetchCNBPageWithRetry(ctx, rawURL)
 	if err != nil {
-		return nil, 0, err
+		return cnbTagsPageResult{}, err
 	}
 	defer resp.Body.Close()
 
@@ -155,7 +169,7 @@ func (c *Client) FetchCNBReleaseTagsPage(owner, repo string, page int) ([]string
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, 0, fmt.Errorf("解析响应失败: %w", err)
+		return cnbTagsPageResult{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	var tags []string
@@ -166,20 +180,12 @@ func (c *Client) FetchCNBReleaseTagsPage(owner, repo string, page int) ([]string
 		tags = append(tags, normalizeCNBTag(item.Tag))
 	}
 
-	totalPages := cnbTotalPages(resp.Header)
-
-	c.mu.Lock()
-	c.cnbTagsPageCache[cacheKey] = cnbTagsPageResult{
-		tags:       append([]string(nil), tags...),
-		totalPages: totalPages,
-	}
-	c.mu.Unlock()
-
-	return tags, totalPages, nil
+	return cnbTagsPageResult{tags: tags, totalPages: cnbTotalPages(resp.Header)}, nil
 }
 
 // FindLatestCNBAssetInfo 根据 tag 列表查找最新匹配资源，必要时回退到指定 tag。
 func (c *Client) FindLatestCNBAssetInfo(
+	ctx context.Context,
 	owner string,
 	repo string,
 	match func(string) bool,
@@ -187,7 +193,7 @@ func (c *Client) FindLatestCNBAssetInfo(
 ) (*types.UpdateInfo, error) {
 	totalPages := 1
 	for page := 1; page <= totalPages; page++ {
-		tags, pages, err := c.FetchCNBReleaseTagsPage(owner, repo, page)
+		tags, pages, err := c.FetchCNBReleaseTagsPage(ctx, owner, repo, page)
 		if err != nil {
 			return nil, err
 		}
@@ -200,7 +206,7 @@ func (c *Client) FindLatestCNBAssetInfo(
 				continue
 			}
 
-			release, err := c.FetchCNBReleaseByTag(owner, repo, tag)
+			release, err := c.FetchCNBReleaseByTag(ctx, owner, repo, tag)
 			if err != nil {
 				return nil, err
 			}
@@ -210,22 +216,13 @@ func (c *Client) FindLatestCNBAssetInfo(
 					continue
 				}
 
-				return &types.UpdateInfo{
-					Name:        asset.Name,
-					URL:         asset.BrowserDownloadURL,
-					UpdateTime:  asset.UpdatedAt,
-					Tag:         release.TagName,
-					Description: release.Body,
-					SHA256:      asset.SHA256,
-					ID:          asset.ID,
-					Size:        asset.Size,
-				}, nil
+				return releaseutil.AssetInfo(*release, asset), nil
 			}
 		}
 	}
 
 	if fallbackTag != "" {
-		release, err := c.FetchCNBReleaseByTag(owner, repo, fallbackTag)
+		release, err := c.FetchCNBReleaseByTag(ctx, owner, repo, fallbackTag)
 		if err != nil {
 			return nil, err
 		}
@@ -235,16 +232,7 @@ func (c *Client) FindLatestCNBAssetInfo(
 				continue
 			}
 
-			return &types.UpdateInfo{
-				Name:        asset.Name,
-				URL:         asset.BrowserDownloadURL,
-				UpdateTime:  asset.UpdatedAt,
-				Tag:         release.TagName,
-				Description: release.Body,
-				SHA256:      asset.SHA256,
-				ID:          asset.ID,
-				Size:        asset.Size,
-			}, nil
+			return releaseutil.AssetInfo(*release, asset), nil
 		}
 	}
 
@@ -252,8 +240,8 @@ func (c *Client) FindLatestCNBAssetInfo(
 }
 
 // FetchLatestCNBReleaseTag 获取最新的带 release 的 tag。
-func (c *Client) FetchLatestCNBReleaseTag(owner, repo string) (string, error) {
-	tags, _, err := c.FetchCNBReleaseTagsPage(owner, repo, 1)
+func (c *Client) FetchLatestCNBReleaseTag(ctx context.Context, owner, repo string) (string, error) {
+	tags, _, err := c.FetchCNBReleaseTagsPage(ctx, owner, repo, 1)
 	if err != nil {
 		return "", err
 	}
@@ -265,13 +253,13 @@ func (c *Client) FetchLatestCNBReleaseTag(owner, repo string) (string, error) {
 	return tags[0], nil
 }
 
-func (c *Client) fetchCNBPageWithRetry(rawURL string) (*http.Response, error) {
+func (c *Client) fetchCNBPageWithRetry(ctx context.Context, rawURL string) (*http.Response, error) {
 	b := backoff.New(500*time.Millisecond, 2*time.Second)
 	var resp *http.Response
 	var err error
 
 	for attempt := 1; attempt <= 2; attempt++ {
-		req, reqErr := http.NewRequest(http.MethodGet, rawURL, nil)
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 		if reqErr != nil {
 			return nil, fmt.Errorf("创建 CNB 请求失败: %w", reqErr)
 		}
@@ -287,8 +275,13 @@ func (c *Client) fetchCNBPageWithRetry(rawURL string) (*http.Response, error) {
 			resp.Body.Close()
 		}
 
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if attempt < 2 {
-			time.Sleep(b.Duration())
+			if err := Sleep(ctx, b.Duration()); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -357,7 +350,7 @@ func convertCNBReleases(
 		for _, cnbAsset := range cnbRelease.Assets {
 			sha256 := ""
 			if strings.EqualFold(cnbAsset.HashAlgo, "sha256") {
-				sha256 = cnbAsset.HashValue
+				sha256 = releaseutil.NormalizeSHA256(cnbAsset.HashValue)
 			}
 
 			assets = append(assets, types.GitHubAsset{
@@ -371,9 +364,10 @@ func convertCNBReleases(
 		}
 
 		releases = append(releases, types.GitHubRelease{
-			TagName: tagName,
-			Body:    cnbRelease.Body,
-			Assets:  assets,
+			TagName:    tagName,
+			Body:       cnbRelease.Body,
+			Assets:     assets,
+			Prerelease: cnbRelease.Prerelease,
 		})
 	}
 
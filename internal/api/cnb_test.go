@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -40,7 +41,7 @@ func TestFetchCNBReleases(t *testing.T) {
 	client := NewClient(getTestConfig())
 
 	// 测试获取 rime-wanxiang 仓库的所有 releases
-	releases, err := client.FetchCNBReleases("amzxyz", "rime-wanxiang", "")
+	releases, err := client.FetchCNBReleases(context.Background(), "amzxyz", "rime-wanxiang", "")
 	if err != nil {
 		t.Fatalf("获取 CNB releases 失败: %v", err)
 	}
@@ -106,7 +107,7 @@ func TestFetchCNBReleases(t *testing.T) {
 func TestFetchCNBReleasesDebug(t *testing.T) {
 	client := NewClient(getTestConfig())
 
-	releases, err := client.FetchCNBReleases("amzxyz", "rime-wanxiang", "v1.0.0")
+	releases, err := client.FetchCNBReleases(context.Background(), "amzxyz", "rime-wanxiang", "v1.0.0")
 	if err != nil {
 		t.Fatalf("获取 CNB releases 失败: %v", err)
 	}
@@ -193,7 +194,7 @@ func TestFetchCNBReleasesPaginatesUntilTagFound(t *testing.T) {
 		},
 	}
 
-	releases, err := client.FetchCNBReleases("amzxyz", "rime-wanxiang", "model")
+	releases, err := client.FetchCNBReleases(context.Background(), "amzxyz", "rime-wanxiang", "model")
 	if err != nil {
 		t.Fatalf("FetchCNBReleases() error = %v", err)
 	}
@@ -271,6 +272,7 @@ func TestFindLatestCNBAssetInfoUsesTagAndReleaseEndpoints(t *testing.T) {
 	}
 
 	info, err := client.FindLatestCNBAssetInfo(
+		context.Background(),
 		"amzxyz",
 		"rime-wanxiang",
 		func(name string) bool { return name == "rime-wanxiang-base.zip" },
@@ -288,3 +290,23 @@ func TestFindLatestCNBAssetInfoUsesTagAndReleaseEndpoints(t *testing.T) {
 		t.Fatalf("info.Name = %q, want %q", info.Name, "rime-wanxiang-base.zip")
 	}
 }
+
+func TestFetchCNBReleaseByTagParsesPrerelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"release": {"tag_ref": "refs/tags/v16.0.0-beta.1", "prerelease": true, "assets": []}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(getTestConfig())
+	client.cnbBaseURL = server.URL
+	client.httpClient = server.Client()
+
+	release, err := client.FetchCNBReleaseByTag(context.Background(), "amzxyz", "rime-wanxiang", "v16.0.0-beta.1")
+	if err != nil {
+		t.Fatalf("FetchCNBReleaseByTag() error = %v", err)
+	}
+	if release.TagName != "v16.0.0-beta.1" || !release.Prerelease {
+		t.Fatalf("release = %+v, want prerelease v16.0.0-beta.1", release)
+	}
+}
@@ -1,26 +1,38 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/cloudflare/backoff"
+	"rime-wanxiang-updater/internal/releaseutil"
 	"rime-wanxiang-updater/internal/types"
 )
 
-// FetchGitHubReleases 获取 GitHub Releases
-func (c *Client) FetchGitHubReleases(owner, repo, tag string) ([]types.GitHubRelease, error) {
+// FetchGitHubReleases 获取 GitHub Releases，相同的请求在客户端的生命周期内只发送一次
+func (c *Client) FetchGitHubReleases(ctx context.Context, owner, repo, tag string) ([]types.GitHubRelease, error) {
+	value, err := c.once(ctx, fmt.Sprintf("github:%s/%s:%s", owner, repo, tag), func() (any, error) {
+		return c.fetchGitHubReleases(ctx, owner, repo, tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]types.GitHubRelease(nil), value.([]types.GitHubRelease)...), nil
+}
+
+func (c *Client) fetchGitHubReleases(ctx context.Context, owner, repo, tag string) ([]types.GitHubRelease, error) {
 	var url string
 	if tag != "" {
-		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/tags/%s", owner, repo, tag)
+		url = fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", c.githubBaseURL, owner, repo, tag)
 	} else {
-		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", owner, repo)
+		url = fmt.Sprintf("%s/repos/%s/%s/releases", c.githubBaseURL, owner, repo)
 	}
 
 	// 使用重试机制
-	resp, err := c.fetchWithRetry(url)
+	resp, err := c.fetchWithRetry(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -31,19 +43,36 @@ func (c *Client) FetchGitHubReleases(owner, repo, tag string) ([]types.GitHubRel
 		if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
 			return nil, fmt.Errorf("解析响应失败: %w", err)
 		}
-		return []types.GitHubRelease{release}, nil
+		releases := []types.GitHubRelease{release}
+		applyGitHubDigests(releases)
+		c.rewriteDownloadURLs(releases)
+		return releases, nil
 	}
 
 	var releases []types.GitHubRelease
 	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
 		return nil, fmt.Errorf("解析响应失败: %w", err)
 	}
+	applyGitHubDigests(releases)
+	c.rewriteDownloadURLs(releases)
 
 	return releases, nil
 }
 
+// applyGitHubDigests 将 GitHub 资源的 digest（"sha256:<hash>"）填入 SHA256，用于下载后校验
+func applyGitHubDigests(releases []types.GitHubRelease) {
+	for i := range releases {
+		for j := range releases[i].Assets {
+			asset := &releases[i].Assets[j]
+			if asset.SHA256 == "" {
+				asset.SHA256 = releaseutil.NormalizeSHA256(asset.Digest)
+			}
+		}
+	}
+}
+
 // fetchWithRetry 带重试的 HTTP 请求，使用 cloudflare backoff
-func (c *Client) fetchWithRetry(url string) (*http.Response, error) {
+func (c *Client) fetchWithRetry(ctx context.Context, url string) (*http.Response, error) {
 	b := backoff.New(time.Second, 10*time.Second)
 	var resp *http.Response
 	var err error
@@ -52,7 +81,7 @@ func (c *Client) fetchWithRetry(url string) (*http.Response, error) {
 
 	for attempts < maxAttempts {
 		attempts++
-		resp, err = c.Get(url)
+		resp, err = c.Get(ctx, url)
 		if err == nil && resp.StatusCode == http.StatusOK {
 			return resp, nil
 		}
@@ -61,8 +90,13 @@ func (c *Client) fetchWithRetry(url string) (*http.Response, error) {
 			resp.Body.Close()
 		}
 
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if attempts < maxAttempts {
-			time.Sleep(b.Duration())
+			if err := Sleep(ctx, b.Duration()); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// DefaultGitHubAPIURL 未配置 GithubAPIURL 时使用的 GitHub API 地址
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// ValidateBaseURL 检查配置的 API 地址或下载代理前缀：须为 http(s) 地址，且不带查询参数；空字符串表示使用默认值
+func ValidateBaseURL(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("地址无效: %s", value)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("地址须以 http:// 或 https:// 开头: %s", value)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("地址不能包含查询参数: %s", value)
+	}
+	return nil
+}
+
+// GitHubAPIURL 返回配置的 GitHub API 地址（不带结尾的 /），未配置时为 DefaultGitHubAPIURL
+func GitHubAPIURL(config *types.Config) string {
+	if config != nil {
+		if base := strings.TrimRight(strings.TrimSpace(config.GithubAPIURL), "/"); base != "" {
+			return base
+		}
+	}
+	return DefaultGitHubAPIURL
+}
+
+// RewriteDownloadURL 配置了 GithubProxy 时，将 GitHub 的下载地址改写为 "<代理前缀>/<原地址>"，其他地址原样返回
+func RewriteDownloadURL(config *types.Config, rawURL string) string {
+	if config == nil {
+		return rawURL
+	}
+	prefix := strings.TrimRight(strings.TrimSpace(config.GithubProxy), "/")
+	if prefix == "" || !isGitHubDownload(rawURL) {
+		return rawURL
+	}
+	return prefix + "/" + rawURL
+}
+
+// isGitHubDownload 判断地址是否指向 github.com 或 GitHub 的文件服务器
+func isGitHubDownload(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "github.com" || strings.HasSuffix(host, ".githubusercontent.com")
+}
+
+// rewriteDownloadURLs 按 GithubProxy 改写 releases 中所有资源的下载地址
+func (c *Client) rewriteDownloadURLs(releases []types.GitHubRelease) {
+	for i := range releases {
+		for j := range releases[i].Assets {
+			asset := &releases[i].Assets[j]
+			asset.BrowserDownloadURL = RewriteDownloadURL(c.config, asset.BrowserDownloadURL)
+		}
+	}
+}
+
+// CheckGitHubAPI 测试能否通过配置的 GitHub API 地址获取万象仓库的信息
+func (c *Client) CheckGitHubAPI(ctx context.Context) error {
+	return c.checkURL(ctx, fmt.Sprintf("%s/repos/%s/%s", c.githubBaseURL, types.OWNER, types.REPO), c.Get)
+}
+
+// CheckGitHubProxy 测试能否通过配置的下载代理访问万象仓库的发布页面
+func (c *Client) CheckGitHubProxy(ctx context.Context) error {
+	target := RewriteDownloadURL(c.config, fmt.Sprintf("https://github.com/%s/%s/releases/latest", types.OWNER, types.REPO))
+	client := NewDownloadHTTPClient(c.config)
+	return c.checkURL(ctx, target, func(ctx context.Context, target string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %w", err)
+		}
+		req.Header.Set("User-Agent", "RIME-Updater/1.0")
+		return client.Do(req)
+	})
+}
+
+func (c *Client) checkURL(ctx context.Context, target string, get func(context.Context, string) (*http.Response, error)) error {
+	resp, err := get(ctx, target)
+	if err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("连接失败，HTTP 状态码: %d (%s)", resp.StatusCode, target)
+	}
+	return nil
+}
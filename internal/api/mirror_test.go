@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestValidateBaseURL(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://gh-api.example.com", false},
+		{"http://127.0.0.1:8080/github/", false},
+		{"gh-api.example.com", true},
+		{"ftp://example.com", true},
+		{"https://", true},
+		{"https://proxy.example/?url=", true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateBaseURL(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateBaseURL(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRewriteDownloadURL(t *testing.T) {
+	cfg := &types.Config{GithubProxy: "https://proxy.example/"}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/amzxyz/rime_wanxiang/releases/download/v1/a.zip", "https://proxy.example/https://github.com/amzxyz/rime_wanxiang/releases/download/v1/a.zip"},
+		{"https://objects.githubusercontent.com/a.zip", "https://proxy.example/https://objects.githubusercontent.com/a.zip"},
+		{"https://cnb.cool/amzxyz/rime-wanxiang/-/releases/download/v1/a.zip", "https://cnb.cool/amzxyz/rime-wanxiang/-/releases/download/v1/a.zip"},
+	}
+
+	for _, tt := range tests {
+		if got := RewriteDownloadURL(cfg, tt.url); got != tt.want {
+			t.Errorf("RewriteDownloadURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+	if got := RewriteDownloadURL(&types.Config{}, tests[0].url); got != tests[0].url {
+		t.Errorf("RewriteDownloadURL() without proxy = %q, want unchanged", got)
+	}
+}
+
+func TestFetchGitHubReleasesUsesConfiguredURLs(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewEncoder(w).Encode([]types.GitHubRelease{{
+			TagName: "v1.0.0",
+			Assets:  []types.GitHubAsset{{Name: "a.zip", BrowserDownloadURL: "https://github.com/o/r/releases/download/v1.0.0/a.zip"}},
+		}})
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.Config{GithubAPIURL: server.URL + "/github/", GithubProxy: "https://proxy.example"})
+	releases, err := client.FetchGitHubReleases(context.Background(), "o", "r", "")
+	if err != nil {
+		t.Fatalf("FetchGitHubReleases() error = %v", err)
+	}
+	if path != "/github/repos/o/r/releases" {
+		t.Errorf("request path = %q, want /github/repos/o/r/releases", path)
+	}
+	want := "https://proxy.example/https://github.com/o/r/releases/download/v1.0.0/a.zip"
+	if got := releases[0].Assets[0].BrowserDownloadURL; got != want {
+		t.Errorf("BrowserDownloadURL = %q, want %q", got, want)
+	}
+}
+
+func TestCheckGitHubAPI(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/"+types.OWNER+"/"+types.REPO {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.Config{GithubAPIURL: server.URL})
+	if err := client.CheckGitHubAPI(context.Background()); err != nil {
+		t.Errorf("CheckGitHubAPI() error = %v, want nil", err)
+	}
+
+	status = http.StatusForbidden
+	if err := client.CheckGitHubAPI(context.Background()); err == nil {
+		t.Errorf("CheckGitHubAPI() error = nil, want an error for HTTP %d", status)
+	}
+}
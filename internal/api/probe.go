@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+const (
+	// probeBytes 测速时下载的字节数
+	probeBytes = 256 * 1024
+	// probeReferenceSize 比较下载源时估算下载耗时所用的文件大小
+	probeReferenceSize = 20 * 1024 * 1024
+)
+
+// ProbeResult 一个下载源的测速结果
+type ProbeResult struct {
+	Source     string        // "GitHub" 或 "CNB"
+	UseMirror  bool          // 选用该下载源时 Config.UseMirror 的值
+	Metadata   time.Duration // 获取发布信息的耗时
+	DNS        time.Duration // 下载地址的域名解析耗时
+	TLS        time.Duration // TLS 握手耗时
+	FirstByte  time.Duration // 发出下载请求到收到第一个字节的耗时
+	Bytes      int64         // 实际下载的字节数
+	Throughput float64       // 下载速度（字节/秒）
+	Err        error
+}
+
+// Estimate 估算从该下载源下载一个典型更新包的耗时，用于比较下载源；测速失败时返回 0
+func (r ProbeResult) Estimate() time.Duration {
+	if r.Err != nil || r.Throughput <= 0 {
+		return 0
+	}
+	return r.Metadata + r.FirstByte + time.Duration(probeReferenceSize/r.Throughput*float64(time.Second))
+}
+
+// FastestProbe 返回估算耗时最短的成功结果；全部失败时 ok 为 false
+func FastestProbe(results []ProbeResult) (best ProbeResult, ok bool) {
+	for _, result := range results {
+		if result.Estimate() == 0 {
+			continue
+		}
+		if !ok || result.Estimate() < best.Estimate() {
+			best, ok = result, true
+		}
+	}
+	return best, ok
+}
+
+// ProbeSources 同时测试 GitHub 和 CNB 镜像：获取模型的发布信息，再从模型文件下载一小段，记录各阶段耗时和下载速度。
+// 结果按 GitHub、CNB 的顺序返回
+func ProbeSources(ctx context.Context, config *types.Config) []ProbeResult {
+	results := []ProbeResult{{Source: "GitHub"}, {Source: "CNB", UseMirror: true}}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(result *ProbeResult) {
+			defer wg.Done()
+			probeConfig := *config
+			probeConfig.UseMirror = result.UseMirror
+			result.Err = probeSource(ctx, &probeConfig, result)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probeSource 用 config 指定的下载源测速，结果写入 result
+func probeSource(ctx context.Context, config *types.Config, result *ProbeResult) error {
+	client := NewClient(config)
+	match := func(name string) bool { return name == types.MODEL_FILE }
+
+	start := time.Now()
+	var (
+		releases []types.GitHubRelease
+		tag      string
+		err      error
+	)
+	if config.UseMirror {
+		tag = types.CNB_MODEL_TAG
+		var release *types.GitHubRelease
+		if release, err = client.FetchCNBReleaseByTag(ctx, types.OWNER, types.CNB_REPO, tag); err == nil {
+			releases = []types.GitHubRelease{*release}
+		}
+	} else {
+		tag = types.MODEL_TAG
+		releases, err = client.FetchGitHubReleases(ctx, types.OWNER, types.MODEL_REPO, tag)
+	}
+	if err != nil {
+		return fmt.Errorf("获取版本信息失败: %w", err)
+	}
+	result.Metadata = time.Since(start)
+
+	info, ok := releaseutil.FindAssetInfoByTag(releases, match, tag)
+	if !ok {
+		return fmt.Errorf("未找到测速文件: %s", types.MODEL_FILE)
+	}
+	return probeDownload(ctx, NewDownloadHTTPClient(config), info.URL, result)
+}
+
+// probeDownload 请求 url 的前 probeBytes 字节，记录域名解析、TLS 握手、首字节耗时和下载速度。
+// 服务器忽略 Range 时同样只读取 probeBytes 字节
+func probeDownload(ctx context.Context, client *http.Client, url string, result *ProbeResult) error {
+	// 重定向时会建立多个连接，只记录第一个
+	var dnsStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			if result.DNS == 0 {
+				result.DNS = time.Since(dnsStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			if result.TLS == 0 {
+				result.TLS = time.Since(tlsStart)
+			}
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", "RIME-Updater/1.0")
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", probeBytes-1))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("下载请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("下载失败，HTTP 状态码: %d", resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, probeBytes)
+	buf := make([]byte, 32*1024)
+	var (
+		firstByte  time.Time
+		firstChunk int64
+	)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 && firstByte.IsZero() {
+			firstByte = time.Now()
+			firstChunk = int64(n)
+			result.FirstByte = firstByte.Sub(start)
+		}
+		result.Bytes += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取数据失败: %w", err)
+		}
+	}
+
+	if result.Bytes == 0 {
+		return fmt.Errorf("下载失败: 未收到数据")
+	}
+	// 速度按首字节之后的传输计算，不含建立连接的时间；数据在第一次读取时就已全部到达时按总耗时计算
+	if elapsed := time.Since(firstByte); result.Bytes > firstChunk && elapsed > 0 {
+		result.Throughput = float64(result.Bytes-firstChunk) / elapsed.Seconds()
+	} else {
+		result.Throughput = float64(result.Bytes) / time.Since(start).Seconds()
+	}
+	return nil
+}
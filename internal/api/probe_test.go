@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFastestProbe(t *testing.T) {
+	github := ProbeResult{Source: "GitHub", Metadata: 300 * time.Millisecond, FirstByte: 200 * time.Millisecond, Throughput: 2 * 1024 * 1024}
+	cnb := ProbeResult{Source: "CNB", UseMirror: true, Metadata: 100 * time.Millisecond, FirstByte: 50 * time.Millisecond, Throughput: 1024 * 1024}
+	fastCNB := cnb
+	fastCNB.Throughput = 10 * 1024 * 1024
+	failed := ProbeResult{Source: "GitHub", Err: errors.New("timeout")}
+
+	tests := []struct {
+		name    string
+		results []ProbeResult
+		want    string
+		wantOK  bool
+	}{
+		{"higher throughput wins", []ProbeResult{github, cnb}, "GitHub", true},
+		{"lower latency and throughput", []ProbeResult{github, fastCNB}, "CNB", true},
+		{"failed source skipped", []ProbeResult{failed, cnb}, "CNB", true},
+		{"all failed", []ProbeResult{failed}, "", false},
+		{"none", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		best, ok := FastestProbe(tt.results)
+		if ok != tt.wantOK || best.Source != tt.want {
+			t.Errorf("%s: FastestProbe() = %q, %v, want %q, %v", tt.name, best.Source, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestProbeDownload(t *testing.T) {
+	var gotRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		// 忽略 Range，返回比测速范围更大的内容
+		w.Write([]byte(strings.Repeat("x", probeBytes*2)))
+	}))
+	defer server.Close()
+
+	var result ProbeResult
+	if err := probeDownload(context.Background(), server.Client(), server.URL, &result); err != nil {
+		t.Fatalf("probeDownload() error = %v", err)
+	}
+	if gotRange != "bytes=0-262143" {
+		t.Errorf("Range = %q, want bytes=0-262143", gotRange)
+	}
+	if result.Bytes != probeBytes {
+		t.Errorf("Bytes = %d, want %d", result.Bytes, probeBytes)
+	}
+	if result.Throughput <= 0 || result.FirstByte <= 0 {
+		t.Errorf("Throughput = %v, FirstByte = %v, want positive values", result.Throughput, result.FirstByte)
+	}
+}
+
+func TestProbeDownloadHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	var result ProbeResult
+	if err := probeDownload(context.Background(), server.Client(), server.URL, &result); err == nil {
+		t.Error("probeDownload() error = nil, want an HTTP status error")
+	}
+}
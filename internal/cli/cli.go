@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/version"
+)
+
+// 退出码
+const (
+	ExitOK      = 0  // 执行成功，或所有组件已是最新版本
+	ExitFailed  = 1  // 执行失败
+	ExitUsage   = 2  // 命令行参数错误
+	ExitPartial = 3  // 部分组件更新失败
+	ExitIssues  = 4  // verify: 存在未修复的缺失或被修改文件
+	ExitUpdated = 10 // update: 已应用更新；check / update --dry-run: 存在可用更新
+)
+
+// command 非交互子命令
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(env *Env, args []string) int
+}
+
+// Env 子命令运行环境
+type Env struct {
+	Config *config.Manager
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Context 取消时中止正在进行的网络请求和下载；为 nil 时不可取消
+	Context context.Context
+}
+
+func (e *Env) context() context.Context {
+	if e.Context == nil {
+		return context.Background()
+	}
+	return e.Context
+}
+
+func (e *Env) locale() i18n.Locale {
+	if e.Config == nil || e.Config.Config == nil {
+		return i18n.DefaultLocale
+	}
+
+	return i18n.Normalize(e.Config.Config.Language)
+}
+
+func (e *Env) printf(format string, args ...any) {
+	fmt.Fprintf(e.Stdout, format, args...)
+}
+
+func (e *Env) errorf(format string, args ...any) {
+	fmt.Fprintf(e.Stderr, format, args...)
+}
+
+func commands() []command {
+	return []command{
+		{
+			name:    "check",
+			usage:   "check [--json]",
+			summary: "检查方案、词库和模型是否有可用更新",
+			run:     runCheck,
+		},
+		{
+			name:    "update",
+			usage:   "update [scheme|dict|model|all] [--dry-run] [--json]",
+			summary: "下载并应用更新（默认 all），完成后自动部署",
+			run:     runUpdate,
+		},
+		{
+			name:    "status",
+			usage:   "status [--json]",
+			summary: "显示各组件的本地与远程版本信息",
+			run:     runStatus,
+		},
+		{
+			name:    "uninstall",
+			usage:   "uninstall <scheme|dict|model|all> --yes [--json]",
+			summary: "按安装清单删除本程序安装的文件，保留用户文件",
+			run:     runUninstall,
+		},
+		{
+			name:    "verify",
+			usage:   "verify [scheme|dict|all] [--repair] [--json]",
+			summary: "校验已安装文件，可从缓存的更新包修复缺失或被修改的文件",
+			run:     runVerify,
+		},
+		{
+			name:    "rollback",
+			usage:   "rollback <scheme|dict|model> [--list] [--to <版本>] [--json]",
+			summary: "从缓存重新安装组件的历史版本（默认为上一个版本），不访问网络",
+			run:     runRollback,
+		},
+		{
+			name:    "import",
+			usage:   "import <文件|目录|离线更新包>... [--json]",
+			summary: "从本地的更新包、模型文件或离线更新包安装，不访问网络",
+			run:     runImport,
+		},
+		{
+			name:    "export",
+			usage:   "export <目录|文件.tar.gz> [--json]",
+			summary: "下载各组件的最新版本并生成离线更新包，供无法联网的机器导入",
+			run:     runExport,
+		},
+		{
+			name:    "serve",
+			usage:   "serve [--addr <地址>] [--interval <间隔>]",
+			summary: "作为局域网缓存服务器运行，其他机器将 github_api_url 设为本机地址即可共享下载",
+			run:     runServe,
+		},
+		{
+			name:    "history",
+			usage:   "history [scheme|dict|model] [--limit <n>] [--json]",
+			summary: "显示更新和回滚历史（从新到旧），包括失败的记录",
+			run:     runHistory,
+		},
+	}
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// IsCommand 判断参数是否为非交互子命令（或帮助/版本参数）
+func IsCommand(arg string) bool {
+	switch arg {
+	case "help", "-h", "--help", "version", "--version":
+		return true
+	}
+
+	_, ok := lookupCommand(arg)
+	return ok
+}
+
+// Run 执行非交互子命令并返回退出码
+func Run(cfg *config.Manager, args []string, stdout, stderr io.Writer) int {
+	// Ctrl+C 取消正在进行的更新，已开始的安装会完成或回滚，已下载的部分保留在缓存目录中
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	env := &Env{Config: cfg, Stdout: stdout, Stderr: stderr, Context: ctx}
+
+	if len(args) == 0 {
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage(stdout)
+		return ExitOK
+	case "version", "--version":
+		fmt.Fprintln(stdout, version.GetVersion())
+		return ExitOK
+	}
+
+	cmd, ok := lookupCommand(args[0])
+	if !ok {
+		env.errorf("未知命令: %s\n\n", args[0])
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	return cmd.run(env, args[1:])
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: rime-wanxiang-updater [命令] [参数]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "不带命令运行时启动交互式界面。可用命令:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-40s %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "退出码:")
+	fmt.Fprintf(w, "  %-3d 成功 / 已是最新版本\n", ExitOK)
+	fmt.Fprintf(w, "  %-3d 执行失败\n", ExitFailed)
+	fmt.Fprintf(w, "  %-3d 参数错误\n", ExitUsage)
+	fmt.Fprintf(w, "  %-3d 部分组件更新失败\n", ExitPartial)
+	fmt.Fprintf(w, "  %-3d 存在未修复的缺失或被修改文件 (verify)\n", ExitIssues)
+	fmt.Fprintf(w, "  %-3d 已应用更新 (update) / 存在可用更新 (check, update --dry-run)\n", ExitUpdated)
+}
+
+// parseInterspersed 解析允许出现在位置参数之后的 flag
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(env *Env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	return fs
+}
+
+// ensureConfigured 检查是否已完成初始配置
+func (e *Env) ensureConfigured() error {
+	if e.Config == nil || e.Config.Config == nil {
+		return fmt.Errorf("配置未加载")
+	}
+
+	cfg := e.Config.Config
+	if strings.TrimSpace(cfg.SchemeType) == "" || cfg.SchemeFile == "" || cfg.DictFile == "" {
+		return fmt.Errorf("尚未完成初始配置，请先不带参数运行程序完成设置向导")
+	}
+
+	return nil
+}
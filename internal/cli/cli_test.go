@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestIsCommand(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"check", true},
+		{"update", true},
+		{"status", true},
+		{"uninstall", true},
+		{"verify", true},
+		{"rollback", true},
+		{"import", true},
+		{"export", true},
+		{"serve", true},
+		{"history", true},
+		{"help", true},
+		{"--version", true},
+		{"", false},
+		{"--debug", false},
+		{"upgrade", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommand(tt.arg); got != tt.want {
+			t.Errorf("IsCommand(%q) = %v, want %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestRunUsageExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"no args", nil, ExitUsage},
+		{"help", []string{"help"}, ExitOK},
+		{"unknown command", []string{"upgrade"}, ExitUsage},
+		{"unknown update target", []string{"update", "everything"}, ExitUsage},
+		{"too many update targets", []string{"update", "scheme", "dict"}, ExitUsage},
+		{"check positional", []string{"check", "scheme"}, ExitUsage},
+		{"unknown flag", []string{"status", "--nope"}, ExitUsage},
+		{"uninstall without target", []string{"uninstall", "--yes"}, ExitUsage},
+		{"unknown uninstall target", []string{"uninstall", "everything", "--yes"}, ExitUsage},
+		{"uninstall without confirmation", []string{"uninstall", "all"}, ExitUsage},
+		{"unknown verify target", []string{"verify", "model"}, ExitUsage},
+		{"too many verify targets", []string{"verify", "scheme", "dict"}, ExitUsage},
+		{"rollback without target", []string{"rollback", "--list"}, ExitUsage},
+		{"unknown rollback target", []string{"rollback", "all"}, ExitUsage},
+		{"unknown history target", []string{"history", "all"}, ExitUsage},
+		{"import without files", []string{"import", "--json"}, ExitUsage},
+		{"export without destination", []string{"export"}, ExitUsage},
+		{"export to two destinations", []string{"export", "a", "b"}, ExitUsage},
+		{"serve positional", []string{"serve", "all"}, ExitUsage},
+		{"serve interval too short", []string{"serve", "--interval", "10s"}, ExitUsage},
+		{"negative history limit", []string{"history", "--limit", "-1"}, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := Run(nil, tt.args, &stdout, &stderr); got != tt.want {
+				t.Errorf("Run(%v) = %d, want %d (stderr: %s)", tt.args, got, tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunRequiresConfiguration(t *testing.T) {
+	cfg := &config.Manager{Config: &types.Config{}}
+
+	for _, args := range [][]string{{"check"}, {"status"}, {"update", "dict"}} {
+		var stdout, stderr bytes.Buffer
+		if got := Run(cfg, args, &stdout, &stderr); got != ExitFailed {
+			t.Errorf("Run(%v) = %d, want %d", args, got, ExitFailed)
+		}
+		if !strings.Contains(stderr.String(), "设置向导") {
+			t.Errorf("Run(%v) stderr = %q, want setup hint", args, stderr.String())
+		}
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	verbose := fs.Bool("v", false, "")
+
+	positional, err := parseInterspersed(fs, []string{"scheme", "-v", "extra"})
+	if err != nil {
+		t.Fatalf("parseInterspersed() error = %v", err)
+	}
+	if !*verbose {
+		t.Error("parseInterspersed() did not parse flag after positional argument")
+	}
+	if want := []string{"scheme", "extra"}; !reflect.DeepEqual(positional, want) {
+		t.Errorf("parseInterspersed() = %v, want %v", positional, want)
+	}
+}
+
+func TestStatusExitCode(t *testing.T) {
+	upToDate := &types.UpdateStatus{NeedsUpdate: false}
+	outdated := &types.UpdateStatus{NeedsUpdate: true}
+	failure := errors.New("network down")
+
+	tests := []struct {
+		name    string
+		results []componentStatus
+		want    int
+	}{
+		{
+			name:    "all up to date",
+			results: []componentStatus{{Status: upToDate}, {Status: upToDate}, {Status: upToDate}},
+			want:    ExitOK,
+		},
+		{
+			name:    "update available",
+			results: []componentStatus{{Status: upToDate}, {Status: outdated}, {Status: upToDate}},
+			want:    ExitUpdated,
+		},
+		{
+			name:    "some checks failed",
+			results: []componentStatus{{Status: outdated}, {Err: failure}, {Status: upToDate}},
+			want:    ExitPartial,
+		},
+		{
+			name:    "all checks failed",
+			results: []componentStatus{{Err: failure}, {Err: failure}, {Err: failure}},
+			want:    ExitFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusExitCode(tt.results); got != tt.want {
+				t.Errorf("statusExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressPrinterThrottlesDownloadLines(t *testing.T) {
+	var out bytes.Buffer
+	printer := newProgressPrinter(&out, i18n.LocaleZhCN)
+	progress := printer.component("词库")
+
+	progress("开始下载", 0, "", "", 0, 0, 0, false)
+	for downloaded := int64(0); downloaded <= 100; downloaded += 5 {
+		progress(fmt.Sprintf("下载中: %d%%", downloaded), 0, "", "", downloaded, 100, 0, true)
+	}
+	progress("下载完成", 1, "", "", 100, 100, 0, false)
+	progress("下载完成", 1, "", "", 100, 100, 0, false)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	// 开始下载 + 下载进度（0%..100% 每 10% 一行，共 11 行）+ 下载完成（去重）
+	if len(lines) != 13 {
+		t.Fatalf("printed %d lines, want 13:\n%s", len(lines), out.String())
+	}
+	if lines[0] != "[词库] 开始下载" {
+		t.Errorf("first line = %q, want %q", lines[0], "[词库] 开始下载")
+	}
+}
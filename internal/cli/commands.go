@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+// componentStatus 单个组件的检查结果
+type componentStatus struct {
+	Name   string
+	Status *types.UpdateStatus
+	Err    error
+}
+
+// collectStatus 并发获取所有组件的状态，相同的远程元数据只请求一次
+func collectStatus(ctx context.Context, combined *updater.CombinedUpdater) []componentStatus {
+	plan, _ := combined.FetchAllUpdates(ctx) // 失败原因记录在各组件的 Err 中
+	return planStatus(plan)
+}
+
+// planStatus 按组件的注册顺序列出更新计划中各组件的状态
+func planStatus(plan *updater.UpdatePlan) []componentStatus {
+	ids := types.ComponentIDs()
+	results := make([]componentStatus, 0, len(ids))
+	for _, id := range ids {
+		entry, _ := plan.Get(id)
+		results = append(results, componentStatus{Name: types.ComponentName(id), Status: entry.Status, Err: entry.Err})
+	}
+
+	return results
+}
+
+// statusExitCode 根据检查结果计算 check 命令的退出码
+func statusExitCode(results []componentStatus) int {
+	failed := 0
+	hasUpdate := false
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			continue
+		}
+		if result.Status != nil && result.Status.NeedsUpdate {
+			hasUpdate = true
+		}
+	}
+
+	switch {
+	case failed == len(results):
+		return ExitFailed
+	case failed > 0:
+		return ExitPartial
+	case hasUpdate:
+		return ExitUpdated
+	default:
+		return ExitOK
+	}
+}
+
+// fail 输出错误并返回退出码；JSON 模式下输出带 error 字段的文档
+func (e *Env) fail(asJSON bool, command string, code int, err error) int {
+	if asJSON {
+		return e.writeJSON(newJSONDocument(command, code).withError(err))
+	}
+	e.errorf("%v\n", err)
+	return code
+}
+
+func runCheck(env *Env, args []string) int {
+	fs := newFlagSet(env, "check")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		env.errorf("check 不接受位置参数\n")
+		return ExitUsage
+	}
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "check", ExitFailed, err)
+	}
+
+	results := collectStatus(env.context(), updater.NewCombinedUpdater(env.Config))
+	code := statusExitCode(results)
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("check", code).withStatus(results))
+	}
+
+	locale := env.locale()
+	for _, result := range results {
+		label := i18n.Component(locale, result.Name)
+		switch {
+		case result.Err != nil:
+			env.errorf("[%s] %s: %v\n", label, i18n.RuntimeText(locale, "检查更新失败"), result.Err)
+		case result.Status.NeedsUpdate:
+			env.printf("[%s] %s → %s\n", label, result.Status.LocalVersion, result.Status.RemoteVersion)
+		default:
+			env.printf("[%s] %s\n", label, i18n.RuntimeText(locale, result.Status.Message))
+		}
+	}
+
+	return code
+}
+
+func runStatus(env *Env, args []string) int {
+	fs := newFlagSet(env, "status")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		env.errorf("status 不接受位置参数\n")
+		return ExitUsage
+	}
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "status", ExitFailed, err)
+	}
+
+	results := collectStatus(env.context(), updater.NewCombinedUpdater(env.Config))
+	code := ExitOK
+	switch failed := countFailed(results); {
+	case failed == len(results):
+		code = ExitFailed
+	case failed > 0:
+		code = ExitPartial
+	}
+
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("status", code).withStatus(results))
+	}
+
+	locale := env.locale()
+	for _, result := range results {
+		env.printf("%s\n", i18n.Component(locale, result.Name))
+		if result.Err != nil {
+			env.printf("  错误: %v\n", result.Err)
+			continue
+		}
+
+		status := result.Status
+		env.printf("  本地版本: %s\n", orDash(status.LocalVersion))
+		env.printf("  远程版本: %s\n", orDash(status.RemoteVersion))
+		if !status.LocalTime.IsZero() {
+			env.printf("  本地时间: %s\n", status.LocalTime.Local().Format("2006-01-02 15:04:05"))
+		}
+		if !status.RemoteTime.IsZero() {
+			env.printf("  远程时间: %s\n", status.RemoteTime.Local().Format("2006-01-02 15:04:05"))
+		}
+		if status.Pinned != "" {
+			env.printf("  固定版本: %s\n", status.Pinned)
+		}
+		env.printf("  需要更新: %t\n", status.NeedsUpdate)
+		if status.Message != "" {
+			env.printf("  状态: %s\n", i18n.RuntimeText(locale, status.Message))
+		}
+	}
+
+	return code
+}
+
+func countFailed(results []componentStatus) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func orDash(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "-"
+	}
+	return value
+}
+
+func runUpdate(env *Env, args []string) int {
+	fs := newFlagSet(env, "update")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果（进度输出到 stderr）")
+	dryRun := fs.Bool("dry-run", false, "只下载并分析将要发生的文件变更，不修改 Rime 目录")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 1 {
+		env.errorf("update 最多接受一个目标参数\n")
+		return ExitUsage
+	}
+
+	target := "all"
+	if len(positional) == 1 {
+		target = strings.ToLower(positional[0])
+	}
+	if target != "all" && types.ComponentName(target) == "" {
+		env.errorf("未知的更新目标: %s（可选 %s、all）\n", target, strings.Join(types.ComponentIDs(), "、"))
+		return ExitUsage
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "update", ExitFailed, err)
+	}
+	if *dryRun {
+		return runDryRun(env, target, *asJSON)
+	}
+	if !env.Config.HasInstalledEngine() {
+		return env.fail(*asJSON, "update", ExitFailed, fmt.Errorf("未检测到已安装的 Rime 引擎，请先安装并启用 Rime 输入法"))
+	}
+
+	// JSON 模式下 stdout 只输出 JSON 文档，进度改为输出到 stderr
+	var progressOut io.Writer = env.Stdout
+	if *asJSON {
+		progressOut = env.Stderr
+	}
+	printer := newProgressPrinter(progressOut, env.locale())
+
+	if env.Config.Config.AutoSelectSource {
+		printer.report("检查", "正在测试下载源速度...", 0, 0, false)
+		if best, ok := updater.FastestSource(env.context(), env.Config); ok {
+			env.Config.Config.UseMirror = best.UseMirror
+			printer.report("检查", fmt.Sprintf("已选择较快的下载源: %s", best.Source), 0, 0, false)
+		}
+	}
+
+	var result *updater.UpdateResult
+	var code int
+	if target == "all" {
+		result, code, err = runUpdateAll(env, printer)
+	} else {
+		result, code, err = runUpdateSingle(env, printer, target)
+	}
+	if errors.Is(err, context.Canceled) {
+		err = fmt.Errorf("更新已取消，Rime 目录未被修改，已下载的部分保留在缓存目录中")
+	}
+
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("update", code).withResult(result).withError(err))
+	}
+
+	locale := env.locale()
+	if result != nil {
+		for _, component := range result.UpdatedComponents {
+			env.printf("[%s] %s → %s\n", i18n.Component(locale, component),
+				orDash(result.PreviousVersions[component]), orDash(result.ComponentVersions[component]))
+		}
+	}
+	if err != nil {
+		env.errorf("%v\n", err)
+	}
+
+	return code
+}
+
+func newUpdateResult() *updater.UpdateResult {
+	return &updater.UpdateResult{
+		UpdatedComponents: []string{},
+		SkippedComponents: []string{},
+		ComponentVersions: make(map[string]string),
+		PreviousVersions:  make(map[string]string),
+	}
+}
+
+func runUpdateSingle(env *Env, printer *progressPrinter, id string) (*updater.UpdateResult, int, error) {
+	u, err := updater.NewComponent(env.Config, id)
+	if err != nil {
+		return nil, ExitUsage, err
+	}
+	component := types.ComponentName(id)
+
+	status, err := u.GetStatus(env.context())
+	if err != nil {
+		return nil, ExitFailed, fmt.Errorf("获取状态失败: %w", err)
+	}
+
+	result := newUpdateResult()
+	if !status.NeedsUpdate {
+		printer.report(component, status.Message, 0, 0, false)
+		result.SkippedComponents = append(result.SkippedComponents, component)
+		result.ComponentVersions[component] = status.LocalVersion
+		return result, ExitOK, nil
+	}
+
+	result.PreviousVersions[component] = status.LocalVersion
+	if err = u.Run(env.context(), printer.component(component)); err == nil {
+		err = u.Deploy()
+	}
+	if err != nil {
+		return result, ExitFailed, fmt.Errorf("更新失败: %w", err)
+	}
+
+	result.UpdatedComponents = append(result.UpdatedComponents, component)
+	result.ComponentVersions[component] = status.RemoteVersion
+	return result, ExitUpdated, nil
+}
+
+func runUpdateAll(env *Env, printer *progressPrinter) (*updater.UpdateResult, int, error) {
+	combined := updater.NewCombinedUpdater(env.Config)
+	progress := printer.combined()
+
+	progress("检查", "正在检查所有更新...", 0, "", "", 0, 0, 0, false)
+	plan, err := combined.FetchAllUpdates(env.context())
+	if err != nil {
+		return nil, ExitFailed, err
+	}
+
+	if !plan.HasAnyUpdate() {
+		progress("完成", "所有组件已是最新版本", 1.0, "", "", 0, 0, 0, false)
+		result := newUpdateResult()
+		for _, status := range planStatus(plan) {
+			if status.Err == nil {
+				result.SkippedComponents = append(result.SkippedComponents, status.Name)
+				result.ComponentVersions[status.Name] = status.Status.LocalVersion
+			}
+		}
+		return result, ExitOK, nil
+	}
+
+	result, err := combined.RunAllWithProgress(env.context(), plan, progress)
+	updated := 0
+	if result != nil {
+		updated = len(result.UpdatedComponents)
+	}
+
+	switch {
+	case err != nil && updated > 0:
+		return result, ExitPartial, fmt.Errorf("更新失败: %w", err)
+	case err != nil:
+		return result, ExitFailed, fmt.Errorf("更新失败: %w", err)
+	case updated == 0:
+		return result, ExitOK, nil
+	default:
+		return result, ExitUpdated, nil
+	}
+}
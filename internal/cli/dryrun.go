@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"io"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+// runDryRun 执行 update --dry-run：下载到缓存并列出文件级变更，不修改 Rime 目录
+func runDryRun(env *Env, target string, asJSON bool) int {
+	var progressOut io.Writer = env.Stdout
+	if asJSON {
+		progressOut = env.Stderr
+	}
+	printer := newProgressPrinter(progressOut, env.locale())
+
+	combined := updater.NewCombinedUpdater(env.Config)
+	var result *updater.DryRunResult
+	switch target {
+	case "all":
+		result = combined.DryRun(env.context(), printer.combined())
+	default:
+		comp, err := combined.Component(target)
+		if err != nil {
+			return env.fail(asJSON, "update", ExitUsage, err)
+		}
+		plan := comp.DryRun(env.context(), printer.component(types.ComponentName(target)))
+		result = &updater.DryRunResult{Components: []*updater.ComponentPlan{plan}}
+	}
+
+	code := dryRunExitCode(result)
+	if asJSON {
+		return env.writeJSON(newJSONDocument("update", code).withPlan(result))
+	}
+
+	printDryRun(env, result)
+	return code
+}
+
+// dryRunExitCode 预览存在变更时返回 ExitUpdated，与 check 的约定一致
+func dryRunExitCode(result *updater.DryRunResult) int {
+	failed := 0
+	for _, plan := range result.Components {
+		if plan.Err != nil {
+			failed++
+		}
+	}
+
+	switch {
+	case failed == len(result.Components):
+		return ExitFailed
+	case failed > 0:
+		return ExitPartial
+	case result.HasChanges():
+		return ExitUpdated
+	default:
+		return ExitOK
+	}
+}
+
+func printDryRun(env *Env, result *updater.DryRunResult) {
+	locale := env.locale()
+	for _, plan := range result.Components {
+		label := i18n.Component(locale, plan.Component)
+		switch {
+		case plan.Err != nil:
+			env.errorf("[%s] %v\n", label, plan.Err)
+			continue
+		case !plan.NeedsUpdate():
+			env.printf("[%s] %s\n", label, i18n.RuntimeText(locale, plan.Status.Message))
+			continue
+		}
+
+		changes := plan.Changes
+		env.printf("[%s] %s → %s\n", label, orDash(plan.Status.LocalVersion), orDash(plan.Status.RemoteVersion))
+		env.printf("  目标目录: %s\n", plan.TargetDir)
+		env.printf("  新增 %d，覆盖 %d，删除 %d，保留 %d\n",
+			len(changes.Added), len(changes.Overwritten), len(changes.Deleted), len(changes.Excluded))
+		for _, group := range []struct {
+			mark  string
+			files []string
+		}{
+			{"+", changes.Added},
+			{"~", changes.Overwritten},
+			{"-", changes.Deleted},
+			{"=", changes.Excluded},
+		} {
+			for _, file := range group.files {
+				env.printf("  %s %s\n", group.mark, file)
+			}
+		}
+	}
+}
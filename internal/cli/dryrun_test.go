@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func TestDryRunExitCode(t *testing.T) {
+	upToDate := &updater.ComponentPlan{Component: "词库", Status: &types.UpdateStatus{}}
+	changed := &updater.ComponentPlan{
+		Component: "方案",
+		Status:    &types.UpdateStatus{NeedsUpdate: true},
+		Changes:   updater.FileChanges{Added: []string{"a.yaml"}},
+	}
+	failed := &updater.ComponentPlan{Component: "模型", Err: errors.New("timeout")}
+
+	tests := []struct {
+		name  string
+		plans []*updater.ComponentPlan
+		want  int
+	}{
+		{"up to date", []*updater.ComponentPlan{upToDate}, ExitOK},
+		{"has changes", []*updater.ComponentPlan{upToDate, changed}, ExitUpdated},
+		{"partial", []*updater.ComponentPlan{changed, failed}, ExitPartial},
+		{"all failed", []*updater.ComponentPlan{failed}, ExitFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dryRunExitCode(&updater.DryRunResult{Components: tt.plans})
+			if got != tt.want {
+				t.Errorf("dryRunExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONDocumentWithPlan(t *testing.T) {
+	result := &updater.DryRunResult{Components: []*updater.ComponentPlan{
+		{
+			Component:  "方案",
+			Status:     &types.UpdateStatus{LocalVersion: "v1", RemoteVersion: "v2", NeedsUpdate: true},
+			UpdateInfo: &types.UpdateInfo{Tag: "v2", URL: "https://example.com/a.zip"},
+			TargetDir:  "/tmp/rime",
+			Changes:    updater.FileChanges{Added: []string{"lua/new.lua"}},
+		},
+		{Component: "词库", Status: &types.UpdateStatus{LocalVersion: "d1", RemoteVersion: "d1"}},
+	}}
+
+	var buf bytes.Buffer
+	env := &Env{Stdout: &buf, Stderr: &buf}
+	env.writeJSON(newJSONDocument("update", ExitUpdated).withPlan(result))
+
+	var decoded struct {
+		Plan map[string]map[string]any `json:"plan"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	scheme := decoded.Plan["scheme"]
+	if scheme["remote_tag"] != "v2" {
+		t.Errorf("plan.scheme.remote_tag = %v, want v2", scheme["remote_tag"])
+	}
+	changes := scheme["changes"].(map[string]any)
+	if deleted, ok := changes["deleted"].([]any); !ok || len(deleted) != 0 {
+		t.Errorf("plan.scheme.changes.deleted = %v, want []", changes["deleted"])
+	}
+	if _, ok := decoded.Plan["dict"]["changes"]; ok {
+		t.Errorf("plan.dict.changes present for up-to-date component")
+	}
+}
+
+func TestPrintDryRunListsFiles(t *testing.T) {
+	var buf bytes.Buffer
+	env := &Env{Stdout: &buf, Stderr: &buf}
+	printDryRun(env, &updater.DryRunResult{Components: []*updater.ComponentPlan{{
+		Component: "方案",
+		Status:    &types.UpdateStatus{LocalVersion: "v1", RemoteVersion: "v2", NeedsUpdate: true},
+		Changes: updater.FileChanges{
+			Overwritten: []string{"default.yaml"},
+			Excluded:    []string{"user.yaml"},
+		},
+	}}})
+
+	for _, want := range []string{"v1 → v2", "~ default.yaml", "= user.yaml"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("printDryRun() output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runExport(env *Env, args []string) int {
+	fs := newFlagSet(env, "export")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果（进度输出到 stderr）")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		env.errorf("export 需要且只接受一个输出目录或 .tar.gz 文件\n")
+		return ExitUsage
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "export", ExitFailed, err)
+	}
+
+	// JSON 模式下 stdout 只输出 JSON 文档，进度改为输出到 stderr
+	var progressOut io.Writer = env.Stdout
+	if *asJSON {
+		progressOut = env.Stderr
+	}
+	printer := newProgressPrinter(progressOut, env.locale())
+
+	code := ExitOK
+	manifest, err := updater.NewCombinedUpdater(env.Config).ExportBundle(env.context(), positional[0], printer.combined())
+	if errors.Is(err, context.Canceled) {
+		err = fmt.Errorf("导出已取消")
+	}
+	if err != nil {
+		code = ExitFailed
+		err = fmt.Errorf("导出失败: %w", err)
+	}
+
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("export", code).withBundle(manifest).withError(err))
+	}
+	if err != nil {
+		env.errorf("%v\n", err)
+		return code
+	}
+
+	locale := env.locale()
+	for _, asset := range manifest.Assets {
+		env.printf("[%s] %s  %s  %s\n", i18n.Component(locale, types.ComponentName(asset.Component)),
+			asset.Info.Name, orDash(asset.Info.Tag), fileutil.FormatBytes(asset.Info.Size))
+	}
+	env.printf("离线更新包已写入 %s，在目标机器上运行 import %s 导入\n", positional[0], positional[0])
+	return code
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runHistory(env *Env, args []string) int {
+	fs := newFlagSet(env, "history")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果")
+	limit := fs.Int("limit", 20, "最多显示的记录数，0 表示全部")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 1 || *limit < 0 {
+		env.errorf("history 最多接受一个组件参数（scheme、dict、model），--limit 不能为负数\n")
+		return ExitUsage
+	}
+
+	target := ""
+	if len(positional) == 1 {
+		target = strings.ToLower(positional[0])
+		if types.ComponentName(target) == "" {
+			env.errorf("未知的组件: %s（可选 scheme、dict、model）\n", target)
+			return ExitUsage
+		}
+	}
+
+	entries, err := updater.ReadHistory(env.Config.GetHistoryPath())
+	if err != nil {
+		return env.fail(*asJSON, "history", ExitFailed, err)
+	}
+	entries = filterHistory(entries, target, *limit)
+
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("history", ExitOK).withHistory(entries))
+	}
+	if len(entries) == 0 {
+		env.printf("没有更新记录\n")
+		return ExitOK
+	}
+	for _, entry := range entries {
+		env.printf("%s\n", formatHistoryEntry(env.locale(), entry))
+	}
+	return ExitOK
+}
+
+// filterHistory 只保留组件 target（为空时不过滤）的前 limit 条记录（为 0 时不限制）
+func filterHistory(entries []types.HistoryEntry, target string, limit int) []types.HistoryEntry {
+	filtered := make([]types.HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if target != "" && entry.Component != target {
+			continue
+		}
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+func formatHistoryEntry(locale i18n.Locale, entry types.HistoryEntry) string {
+	action := "更新"
+	switch entry.Action {
+	case updater.HistoryRollback:
+		action = "回滚"
+	case updater.HistoryImport:
+		action = "导入"
+	}
+	outcome := "成功"
+	switch entry.Outcome {
+	case updater.OutcomeFailed:
+		outcome = "失败"
+	case updater.OutcomeRolledBack:
+		outcome = "已撤销"
+	}
+
+	line := fmt.Sprintf("%s  [%s] %s %s → %s  %s  %s  %s  %.2f MB",
+		entry.Time.Local().Format("2006-01-02 15:04:05"),
+		i18n.Component(locale, types.ComponentName(entry.Component)),
+		action,
+		orDash(entry.OldTag),
+		orDash(entry.NewTag),
+		outcome,
+		entry.Source,
+		(time.Duration(entry.DurationMS) * time.Millisecond).Round(100*time.Millisecond),
+		float64(entry.Bytes)/1024/1024,
+	)
+	if entry.Error != "" {
+		line += "\n    " + entry.Error
+	}
+	return line
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runImport(env *Env, args []string) int {
+	fs := newFlagSet(env, "import")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果（进度输出到 stderr）")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) == 0 {
+		env.errorf("import 需要至少一个本地文件或目录\n")
+		return ExitUsage
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "import", ExitFailed, err)
+	}
+	if !env.Config.HasInstalledEngine() {
+		return env.fail(*asJSON, "import", ExitFailed, fmt.Errorf("未检测到已安装的 Rime 引擎，请先安装并启用 Rime 输入法"))
+	}
+
+	// 打包成 .tar.gz 的离线更新包先解压到临时目录
+	paths, cleanup, err := updater.UnpackBundles(positional)
+	if err != nil {
+		return env.fail(*asJSON, "import", ExitFailed, fmt.Errorf("导入失败: %w", err))
+	}
+	defer cleanup()
+
+	// 匹配和校验在改动 Rime 目录之前完成，任一文件有问题时不导入任何文件
+	files, err := updater.FindLocalFiles(env.Config, paths)
+	if err != nil {
+		return env.fail(*asJSON, "import", ExitFailed, fmt.Errorf("导入失败: %w", err))
+	}
+
+	// JSON 模式下 stdout 只输出 JSON 文档，进度改为输出到 stderr
+	var progressOut io.Writer = env.Stdout
+	if *asJSON {
+		progressOut = env.Stderr
+	}
+	printer := newProgressPrinter(progressOut, env.locale())
+	for _, file := range files {
+		check := "未校验（同目录没有 SHA256SUMS）"
+		switch {
+		case file.Info != nil:
+			check = fmt.Sprintf("离线更新包 %s，SHA256 校验通过", orDash(file.Info.Tag))
+		case file.Verified:
+			check = "SHA256 校验通过"
+		}
+		printer.report(types.ComponentName(file.Component), fmt.Sprintf("%s: %s", file.Name, check), 0, 0, false)
+	}
+
+	code := ExitOK
+	result, err := updater.NewCombinedUpdater(env.Config).Import(files, printer.combined())
+	if err != nil {
+		code = ExitFailed
+		err = fmt.Errorf("导入失败: %w", err)
+	}
+
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("import", code).withResult(result).withError(err))
+	}
+
+	locale := env.locale()
+	for _, component := range result.UpdatedComponents {
+		env.printf("[%s] %s → %s\n", i18n.Component(locale, component),
+			orDash(result.PreviousVersions[component]), orDash(result.ComponentVersions[component]))
+	}
+	if err != nil {
+		env.errorf("%v\n", err)
+	}
+	return code
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"encoding/json"
+	"time"
+
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+	"rime-wanxiang-updater/internal/version"
+)
+
+// JSONSchemaVersion JSON 输出格式版本；字段删除或含义变化时递增，新增字段不递增
+const JSONSchemaVersion = 1
+
+// jsonDocument --json 模式输出的顶层文档
+type jsonDocument struct {
+	SchemaVersion  int                              `json:"schema_version"`
+	Command        string                           `json:"command"`
+	UpdaterVersion string                           `json:"updater_version"`
+	GeneratedAt    time.Time                        `json:"generated_at"`
+	ExitCode       int                              `json:"exit_code"`
+	Components     map[string]*jsonComponentStatus  `json:"components,omitempty"`
+	Result         *jsonUpdateResult                `json:"result,omitempty"`
+	Plan           map[string]*jsonComponentPlan    `json:"plan,omitempty"`
+	Uninstall      map[string]*jsonUninstallResult  `json:"uninstall,omitempty"`
+	Verify         map[string]*jsonVerifyReport     `json:"verify,omitempty"`
+	Versions       map[string][]jsonArchivedVersion `json:"versions,omitempty"`
+	Rollback       map[string]*jsonRollbackResult   `json:"rollback,omitempty"`
+	History        []types.HistoryEntry             `json:"history,omitempty"`
+	Bundle         *updater.BundleManifest          `json:"bundle,omitempty"`
+	Error          string                           `json:"error,omitempty"`
+}
+
+// jsonComponentStatus 单个组件的状态，键为组件 ID
+type jsonComponentStatus struct {
+	Status *types.UpdateStatus `json:"status,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// jsonUpdateResult updater.UpdateResult 的 JSON 形式，组件名统一为组件 ID
+type jsonUpdateResult struct {
+	Updated          []string          `json:"updated"`
+	Skipped          []string          `json:"skipped"`
+	PreviousVersions map[string]string `json:"previous_versions"`
+	Versions         map[string]string `json:"versions"`
+}
+
+// jsonComponentPlan updater.ComponentPlan 的 JSON 形式（update --dry-run）
+type jsonComponentPlan struct {
+	Status    *types.UpdateStatus `json:"status,omitempty"`
+	RemoteTag string              `json:"remote_tag,omitempty"`
+	URL       string              `json:"url,omitempty"`
+	Archive   string              `json:"archive,omitempty"`
+	TargetDir string              `json:"target_dir,omitempty"`
+	Changes   *jsonFileChanges    `json:"changes,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// jsonFileChanges 文件级变更，路径相对于 target_dir
+type jsonFileChanges struct {
+	Added       []string `json:"added"`
+	Overwritten []string `json:"overwritten"`
+	Deleted     []string `json:"deleted"`
+	Excluded    []string `json:"excluded"`
+}
+
+// jsonUninstallResult updater.UninstallResult 的 JSON 形式
+type jsonUninstallResult struct {
+	Removed []string `json:"removed"`
+	Kept    []string `json:"kept"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// jsonVerifyReport updater.VerifyReport 的 JSON 形式，路径相对于 root
+type jsonVerifyReport struct {
+	Root       string   `json:"root"`
+	Reference  string   `json:"reference,omitempty"`
+	Missing    []string `json:"missing"`
+	Modified   []string `json:"modified"`
+	Unexpected []string `json:"unexpected"`
+	Repaired   []string `json:"repaired"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// jsonArchivedVersion updater.ArchivedVersion 的 JSON 形式（rollback --list）
+type jsonArchivedVersion struct {
+	ID         string    `json:"id"`
+	Tag        string    `json:"tag"`
+	SHA256     string    `json:"sha256"`
+	UpdateTime time.Time `json:"update_time,omitzero"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// jsonRollbackResult 回滚结果，键为组件 ID
+type jsonRollbackResult struct {
+	PreviousVersion string               `json:"previous_version"`
+	Version         *jsonArchivedVersion `json:"version"`
+}
+
+func newJSONDocument(command string, exitCode int) *jsonDocument {
+	return &jsonDocument{
+		SchemaVersion:  JSONSchemaVersion,
+		Command:        command,
+		UpdaterVersion: version.GetVersion(),
+		GeneratedAt:    time.Now().UTC(),
+		ExitCode:       exitCode,
+	}
+}
+
+func (d *jsonDocument) withStatus(results []componentStatus) *jsonDocument {
+	d.Components = make(map[string]*jsonComponentStatus, len(results))
+	for _, result := range results {
+		entry := &jsonComponentStatus{Status: result.Status}
+		if result.Err != nil {
+			entry.Status = nil
+			entry.Error = result.Err.Error()
+		}
+		d.Components[types.ComponentID(result.Name)] = entry
+	}
+	return d
+}
+
+func (d *jsonDocument) withResult(result *updater.UpdateResult) *jsonDocument {
+	if result != nil {
+		d.Result = newJSONUpdateResult(result)
+	}
+	return d
+}
+
+func (d *jsonDocument) withPlan(result *updater.DryRunResult) *jsonDocument {
+	if result == nil {
+		return d
+	}
+
+	d.Plan = make(map[string]*jsonComponentPlan, len(result.Components))
+	for _, plan := range result.Components {
+		entry := &jsonComponentPlan{Status: plan.Status, TargetDir: plan.TargetDir, Archive: plan.Archive}
+		if plan.UpdateInfo != nil {
+			entry.RemoteTag = plan.UpdateInfo.Tag
+			entry.URL = plan.UpdateInfo.URL
+		}
+		if plan.Err != nil {
+			entry.Error = plan.Err.Error()
+		} else if plan.NeedsUpdate() {
+			entry.Changes = &jsonFileChanges{
+				Added:       nonNil(plan.Changes.Added),
+				Overwritten: nonNil(plan.Changes.Overwritten),
+				Deleted:     nonNil(plan.Changes.Deleted),
+				Excluded:    nonNil(plan.Changes.Excluded),
+			}
+		}
+		d.Plan[types.ComponentID(plan.Component)] = entry
+	}
+	return d
+}
+
+func (d *jsonDocument) withUninstall(results []*updater.UninstallResult) *jsonDocument {
+	d.Uninstall = make(map[string]*jsonUninstallResult, len(results))
+	for _, result := range results {
+		entry := &jsonUninstallResult{Removed: nonNil(result.Removed), Kept: nonNil(result.Kept)}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		}
+		d.Uninstall[types.ComponentID(result.Component)] = entry
+	}
+	return d
+}
+
+func (d *jsonDocument) withVerify(reports []*updater.VerifyReport) *jsonDocument {
+	d.Verify = make(map[string]*jsonVerifyReport, len(reports))
+	for _, report := range reports {
+		entry := &jsonVerifyReport{
+			Root:       report.Root,
+			Reference:  report.Reference,
+			Missing:    nonNil(report.Missing),
+			Modified:   nonNil(report.Modified),
+			Unexpected: nonNil(report.Unexpected),
+			Repaired:   nonNil(report.Repaired),
+		}
+		if report.Err != nil {
+			entry.Error = report.Err.Error()
+		}
+		d.Verify[types.ComponentID(report.Component)] = entry
+	}
+	return d
+}
+
+func newJSONArchivedVersion(version updater.ArchivedVersion) jsonArchivedVersion {
+	return jsonArchivedVersion{
+		ID:         version.ID,
+		Tag:        version.Record.Tag,
+		SHA256:     version.SHA256,
+		UpdateTime: version.Record.UpdateTime,
+		ArchivedAt: version.ArchivedAt,
+	}
+}
+
+func (d *jsonDocument) withVersions(componentID string, versions []updater.ArchivedVersion) *jsonDocument {
+	entries := make([]jsonArchivedVersion, 0, len(versions))
+	for _, version := range versions {
+		entries = append(entries, newJSONArchivedVersion(version))
+	}
+	d.Versions = map[string][]jsonArchivedVersion{componentID: entries}
+	return d
+}
+
+func (d *jsonDocument) withRollback(componentID, previous string, version updater.ArchivedVersion) *jsonDocument {
+	entry := newJSONArchivedVersion(version)
+	d.Rollback = map[string]*jsonRollbackResult{componentID: {PreviousVersion: previous, Version: &entry}}
+	return d
+}
+
+func (d *jsonDocument) withHistory(entries []types.HistoryEntry) *jsonDocument {
+	d.History = entries
+	return d
+}
+
+// nonNil 保证空列表序列化为 [] 而不是 null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func (d *jsonDocument) withBundle(manifest *updater.BundleManifest) *jsonDocument {
+	d.Bundle = manifest
+	return d
+}
+
+func (d *jsonDocument) withError(err error) *jsonDocument {
+	if err != nil {
+		d.Error = err.Error()
+	}
+	return d
+}
+
+func newJSONUpdateResult(result *updater.UpdateResult) *jsonUpdateResult {
+	converted := &jsonUpdateResult{
+		Updated:          componentIDs(result.UpdatedComponents),
+		Skipped:          componentIDs(result.SkippedComponents),
+		PreviousVersions: make(map[string]string, len(result.PreviousVersions)),
+		Versions:         make(map[string]string, len(result.ComponentVersions)),
+	}
+	for name, value := range result.PreviousVersions {
+		converted.PreviousVersions[types.ComponentID(name)] = value
+	}
+	for name, value := range result.ComponentVersions {
+		converted.Versions[types.ComponentID(name)] = value
+	}
+	return converted
+}
+
+func componentIDs(names []string) []string {
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		ids = append(ids, types.ComponentID(name))
+	}
+	return ids
+}
+
+// writeJSON 输出 JSON 文档并返回文档中的退出码
+func (e *Env) writeJSON(doc *jsonDocument) int {
+	encoder := json.NewEncoder(e.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		e.errorf("输出 JSON 失败: %v\n", err)
+		return ExitFailed
+	}
+	return doc.ExitCode
+}
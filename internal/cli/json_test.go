@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func TestJSONUpdateResultUsesComponentIDs(t *testing.T) {
+	result := &updater.UpdateResult{
+		UpdatedComponents: []string{"方案", "模型"},
+		SkippedComponents: []string{"词库"},
+		ComponentVersions: map[string]string{"方案": "v2", "词库": "dict-1", "模型": "m2"},
+		PreviousVersions:  map[string]string{"方案": "v1", "模型": "m1"},
+	}
+
+	got := newJSONUpdateResult(result)
+	want := &jsonUpdateResult{
+		Updated:          []string{types.ComponentScheme, types.ComponentModel},
+		Skipped:          []string{types.ComponentDict},
+		PreviousVersions: map[string]string{"scheme": "v1", "model": "m1"},
+		Versions:         map[string]string{"scheme": "v2", "dict": "dict-1", "model": "m2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newJSONUpdateResult() = %+v, want %+v", got, want)
+	}
+}
+
+func TestJSONDocumentWithStatus(t *testing.T) {
+	doc := newJSONDocument("status", ExitPartial).withStatus([]componentStatus{
+		{Name: "方案", Status: &types.UpdateStatus{LocalVersion: "v1", RemoteVersion: "v2", NeedsUpdate: true}},
+		{Name: "词库", Err: errors.New("timeout")},
+	})
+
+	var buf bytes.Buffer
+	env := &Env{Stdout: &buf, Stderr: &buf}
+	if code := env.writeJSON(doc); code != ExitPartial {
+		t.Fatalf("writeJSON() = %d, want %d", code, ExitPartial)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded["schema_version"] != float64(JSONSchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", decoded["schema_version"], JSONSchemaVersion)
+	}
+
+	components := decoded["components"].(map[string]any)
+	scheme := components["scheme"].(map[string]any)["status"].(map[string]any)
+	if scheme["remote_version"] != "v2" || scheme["needs_update"] != true {
+		t.Errorf("components.scheme.status = %v", scheme)
+	}
+	if _, ok := scheme["local_time"]; ok {
+		t.Error("zero local_time should be omitted")
+	}
+	if components["dict"].(map[string]any)["error"] != "timeout" {
+		t.Errorf("components.dict = %v, want error", components["dict"])
+	}
+}
+
+func TestRunJSONReportsConfigurationError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	cfg := &config.Manager{Config: &types.Config{}}
+
+	if code := Run(cfg, []string{"status", "--json"}, &stdout, &stderr); code != ExitFailed {
+		t.Fatalf("Run() = %d, want %d", code, ExitFailed)
+	}
+
+	var doc jsonDocument
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if doc.Command != "status" || doc.ExitCode != ExitFailed || doc.Error == "" {
+		t.Errorf("document = %+v, want status failure with error", doc)
+	}
+}
+
+func TestRollbackListJSON(t *testing.T) {
+	cacheDir := t.TempDir()
+	cfg := &config.Manager{
+		Config:   &types.Config{SchemeType: "base", SchemeFile: "scheme.zip", DictFile: "dict.zip"},
+		CacheDir: cacheDir,
+	}
+	dir := filepath.Join(cfg.GetVersionsDir(types.ComponentDict), "20260101-000000-abcdef12")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dict.zip"), []byte("dict"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info := `{"id": "20260101-000000-abcdef12", "component": "dict", "file": "dict.zip", "sha256": "abcdef12", "record": {"tag": "v1"}}`
+	if err := os.WriteFile(filepath.Join(dir, "version.json"), []byte(info), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run(cfg, []string{"rollback", "dict", "--list", "--json"}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Run() = %d, want %d (stderr: %s)", code, ExitOK, stderr.String())
+	}
+
+	var doc jsonDocument
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	versions := doc.Versions[types.ComponentDict]
+	if len(versions) != 1 || versions[0].ID != "20260101-000000-abcdef12" || versions[0].Tag != "v1" {
+		t.Errorf("versions = %+v, want the archived v1", doc.Versions)
+	}
+}
+
+func TestHistoryJSONFiltersAndLimits(t *testing.T) {
+	cfg := &config.Manager{Config: &types.Config{}, CacheDir: t.TempDir()}
+	err := updater.AppendHistory(cfg.GetHistoryPath(),
+		types.HistoryEntry{Component: types.ComponentDict, NewTag: "d1", Outcome: updater.OutcomeSuccess},
+		types.HistoryEntry{Component: types.ComponentScheme, NewTag: "v1", Outcome: updater.OutcomeSuccess},
+		types.HistoryEntry{Component: types.ComponentDict, NewTag: "d2", Outcome: updater.OutcomeFailed, Error: "boom"},
+		types.HistoryEntry{Component: types.ComponentDict, NewTag: "d3", Outcome: updater.OutcomeSuccess},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run(cfg, []string{"history", "dict", "--limit", "2", "--json"}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("Run() = %d, want %d (stderr: %s)", code, ExitOK, stderr.String())
+	}
+
+	var doc jsonDocument
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout.String())
+	}
+	if len(doc.History) != 2 || doc.History[0].NewTag != "d3" || doc.History[1].Error != "boom" {
+		t.Errorf("history = %+v, want d3 then the failed d2", doc.History)
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// progressPrinter 将进度回调转换为逐行输出的纯文本
+type progressPrinter struct {
+	out    io.Writer
+	locale i18n.Locale
+
+	mu         sync.Mutex
+	lastLine   map[string]string // 每个组件最后输出的一行，并行更新时各组件的消息交替到达
+	lastBucket map[string]int
+}
+
+func newProgressPrinter(out io.Writer, locale i18n.Locale) *progressPrinter {
+	return &progressPrinter{
+		out:        out,
+		locale:     locale,
+		lastLine:   make(map[string]string),
+		lastBucket: make(map[string]int),
+	}
+}
+
+// report 输出一条进度；下载进度每 10% 输出一次，其余消息按组件去重后输出
+func (p *progressPrinter) report(component, message string, downloaded, total int64, downloadMode bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if downloadMode {
+		bucket := -1
+		if total > 0 {
+			bucket = int(float64(downloaded) / float64(total) * 10)
+		}
+		if last, ok := p.lastBucket[component]; ok && last == bucket {
+			return
+		}
+		p.lastBucket[component] = bucket
+	} else {
+		delete(p.lastBucket, component)
+	}
+
+	line := i18n.RuntimeText(p.locale, message)
+	if component != "" {
+		line = fmt.Sprintf("[%s] %s", i18n.Component(p.locale, component), line)
+	}
+	if last, ok := p.lastLine[component]; ok && last == line {
+		return
+	}
+	p.lastLine[component] = line
+
+	fmt.Fprintln(p.out, line)
+}
+
+// component 返回单组件更新器使用的进度回调
+func (p *progressPrinter) component(component string) types.ProgressFunc {
+	return func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+		p.report(component, message, downloaded, total, downloadMode)
+	}
+}
+
+// combined 返回组合更新器使用的进度回调
+func (p *progressPrinter) combined() func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+	return func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+		p.report(component, message, downloaded, total, downloadMode)
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/i18n"
+)
+
+func TestProgressPrinterDedupesPerComponent(t *testing.T) {
+	var out bytes.Buffer
+	printer := newProgressPrinter(&out, i18n.DefaultLocale)
+
+	// 并行更新时两个组件的消息交替到达，各自未变化的状态只输出一次
+	for _, report := range []struct{ component, message string }{
+		{"方案", "正在下载"},
+		{"词库", "正在下载"},
+		{"方案", "正在下载"},
+		{"词库", "正在下载"},
+		{"方案", "正在解压"},
+	} {
+		printer.report(report.component, report.message, 0, 0, false)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Errorf("printed %d lines, want 3:\n%s", len(lines), out.String())
+	}
+}
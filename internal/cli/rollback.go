@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runRollback(env *Env, args []string) int {
+	fs := newFlagSet(env, "rollback")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果（进度输出到 stderr）")
+	list := fs.Bool("list", false, "只列出可回滚到的历史版本")
+	to := fs.String("to", "", "要回滚到的版本（ID、版本号或 SHA256 前缀），默认为最近的历史版本")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		env.errorf("rollback 需要且只接受一个目标参数（scheme、dict、model）\n")
+		return ExitUsage
+	}
+
+	target := strings.ToLower(positional[0])
+	component := types.ComponentName(target)
+	if component == "" {
+		env.errorf("未知的回滚目标: %s（可选 scheme、dict、model）\n", target)
+		return ExitUsage
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "rollback", ExitFailed, err)
+	}
+
+	combined := updater.NewCombinedUpdater(env.Config)
+	if *list {
+		versions, err := combined.Versions(target)
+		if err != nil {
+			return env.fail(*asJSON, "rollback", ExitFailed, err)
+		}
+		if *asJSON {
+			return env.writeJSON(newJSONDocument("rollback", ExitOK).withVersions(target, versions))
+		}
+		printVersions(env, component, versions)
+		return ExitOK
+	}
+
+	version, err := combined.FindVersion(target, *to)
+	if err != nil {
+		return env.fail(*asJSON, "rollback", ExitFailed, err)
+	}
+	if !env.Config.HasInstalledEngine() {
+		return env.fail(*asJSON, "rollback", ExitFailed, fmt.Errorf("未检测到已安装的 Rime 引擎，请先安装并启用 Rime 输入法"))
+	}
+
+	// JSON 模式下 stdout 只输出 JSON 文档，进度改为输出到 stderr
+	var progressOut io.Writer = env.Stdout
+	if *asJSON {
+		progressOut = env.Stderr
+	}
+	printer := newProgressPrinter(progressOut, env.locale())
+
+	previous := combined.InstalledVersion(target)
+	code := ExitOK
+	if err = combined.Rollback(version, printer.component(component)); err == nil {
+		err = combined.Deploy()
+	}
+	if err != nil {
+		code = ExitFailed
+		err = fmt.Errorf("回滚失败: %w", err)
+	}
+
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("rollback", code).withRollback(target, previous, version).withError(err))
+	}
+	if err != nil {
+		env.errorf("%v\n", err)
+		return code
+	}
+	env.printf("[%s] %s → %s\n", i18n.Component(env.locale(), component), orDash(previous), version.Label())
+	return code
+}
+
+func printVersions(env *Env, component string, versions []updater.ArchivedVersion) {
+	label := i18n.Component(env.locale(), component)
+	if len(versions) == 0 {
+		env.printf("[%s] 没有可回滚的历史版本\n", label)
+		return
+	}
+
+	env.printf("[%s] 可回滚的历史版本（从新到旧）:\n", label)
+	for _, version := range versions {
+		env.printf("  %-26s %-20s %s\n", version.ID, version.Label(), version.ArchivedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runServe(env *Env, args []string) int {
+	fs := newFlagSet(env, "serve")
+	addr := fs.String("addr", ":8080", "监听地址")
+	interval := fs.Duration("interval", updater.DefaultServeInterval, "从上游刷新发布信息和缓存文件的间隔（如 30m、2h）")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		env.errorf("serve 不接受位置参数\n")
+		return ExitUsage
+	}
+	if *interval < time.Minute {
+		env.errorf("--interval 不能小于 1m\n")
+		return ExitUsage
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(false, "serve", ExitFailed, err)
+	}
+
+	server := updater.NewCacheServer(env.Config)
+	server.Logf = func(format string, args ...any) {
+		env.printf("%s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+	}
+	// Ctrl+C 停止服务器
+	if err := server.Run(env.context(), *addr, *interval); err != nil {
+		return env.fail(false, "serve", ExitFailed, err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"strings"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runUninstall(env *Env, args []string) int {
+	fs := newFlagSet(env, "uninstall")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果")
+	yes := fs.Bool("yes", false, "确认删除由本程序安装的文件")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		env.errorf("uninstall 需要且只接受一个目标参数（scheme、dict、model、all）\n")
+		return ExitUsage
+	}
+
+	target := strings.ToLower(positional[0])
+	componentIDs := []string{target}
+	switch {
+	case target == "all":
+		componentIDs = types.ComponentIDs()
+	case types.ComponentName(target) == "":
+		env.errorf("未知的卸载目标: %s（可选 scheme、dict、model、all）\n", target)
+		return ExitUsage
+	}
+	if !*yes {
+		env.errorf("卸载会删除安装清单中记录的万象文件（用户文件和修改过的文件会保留），确认请加上 --yes\n")
+		return ExitUsage
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "uninstall", ExitFailed, err)
+	}
+
+	results, err := updater.NewCombinedUpdater(env.Config).Uninstall(componentIDs)
+	if err != nil {
+		return env.fail(*asJSON, "uninstall", ExitFailed, err)
+	}
+
+	code := uninstallExitCode(results)
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("uninstall", code).withUninstall(results))
+	}
+
+	locale := env.locale()
+	for _, result := range results {
+		label := i18n.Component(locale, result.Component)
+		if result.Err != nil {
+			env.errorf("[%s] %v\n", label, result.Err)
+		}
+		if result.Err != nil && len(result.Removed) == 0 {
+			continue
+		}
+		env.printf("[%s] 已删除 %d 个文件，保留 %d 个修改过的文件\n", label, len(result.Removed), len(result.Kept))
+		for _, path := range result.Kept {
+			env.printf("  = %s\n", path)
+		}
+	}
+	if code != ExitFailed {
+		env.printf("请重新部署 Rime 使更改生效\n")
+	}
+
+	return code
+}
+
+// uninstallExitCode 全部组件失败时返回 ExitFailed，部分失败时返回 ExitPartial
+func uninstallExitCode(results []*updater.UninstallResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	switch {
+	case failed == len(results):
+		return ExitFailed
+	case failed > 0:
+		return ExitPartial
+	default:
+		return ExitOK
+	}
+}
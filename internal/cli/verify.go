@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"strings"
+
+	"rime-wanxiang-updater/internal/i18n"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func runVerify(env *Env, args []string) int {
+	fs := newFlagSet(env, "verify")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出结果")
+	repair := fs.Bool("repair", false, "从缓存的更新包恢复缺失或被修改的文件")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 1 {
+		env.errorf("verify 最多接受一个目标参数\n")
+		return ExitUsage
+	}
+
+	componentIDs := []string{types.ComponentScheme, types.ComponentDict}
+	if len(positional) == 1 {
+		switch target := strings.ToLower(positional[0]); target {
+		case "all":
+		case types.ComponentScheme, types.ComponentDict:
+			componentIDs = []string{target}
+		default:
+			env.errorf("未知的校验目标: %s（可选 scheme、dict、all）\n", target)
+			return ExitUsage
+		}
+	}
+
+	if err := env.ensureConfigured(); err != nil {
+		return env.fail(*asJSON, "verify", ExitFailed, err)
+	}
+
+	reports := updater.NewCombinedUpdater(env.Config).Verify(componentIDs, *repair)
+	code := verifyExitCode(reports)
+	if *asJSON {
+		return env.writeJSON(newJSONDocument("verify", code).withVerify(reports))
+	}
+
+	printVerify(env, reports)
+	if code == ExitIssues && !*repair {
+		env.printf("可加上 --repair 从缓存的更新包恢复缺失或被修改的文件\n")
+	}
+	return code
+}
+
+// verifyExitCode 校验出错优先；其次存在未修复的缺失或被修改文件时返回 ExitIssues。多余文件只作提示，不影响退出码
+func verifyExitCode(reports []*updater.VerifyReport) int {
+	failed := 0
+	unresolved := false
+	for _, report := range reports {
+		if report.Err != nil {
+			failed++
+		}
+		if len(report.Unresolved()) > 0 {
+			unresolved = true
+		}
+	}
+
+	switch {
+	case failed == len(reports):
+		return ExitFailed
+	case failed > 0:
+		return ExitPartial
+	case unresolved:
+		return ExitIssues
+	default:
+		return ExitOK
+	}
+}
+
+func printVerify(env *Env, reports []*updater.VerifyReport) {
+	locale := env.locale()
+	for _, report := range reports {
+		label := i18n.Component(locale, report.Component)
+		if report.Err != nil {
+			env.errorf("[%s] %v\n", label, report.Err)
+		}
+		if report.Reference == "" {
+			continue
+		}
+
+		env.printf("[%s] %s\n", label, report.Root)
+		env.printf("  缺失 %d，修改 %d，多余 %d，已修复 %d\n",
+			len(report.Missing), len(report.Modified), len(report.Unexpected), len(report.Repaired))
+		for _, group := range []struct {
+			mark  string
+			files []string
+		}{
+			{"-", report.Missing},
+			{"~", report.Modified},
+			{"?", report.Unexpected},
+			{"+", report.Repaired},
+		} {
+			for _, file := range group.files {
+				env.printf("  %s %s\n", group.mark, file)
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"rime-wanxiang-updater/internal/updater"
+)
+
+func TestVerifyExitCode(t *testing.T) {
+	clean := &updater.VerifyReport{Component: "词库", Reference: updater.VerifyByManifest, Unexpected: []string{"mine.txt"}}
+	broken := &updater.VerifyReport{Component: "方案", Reference: updater.VerifyByManifest, Missing: []string{"default.yaml"}}
+	repaired := &updater.VerifyReport{
+		Component: "方案",
+		Reference: updater.VerifyByManifest,
+		Missing:   []string{"default.yaml"},
+		Repaired:  []string{"default.yaml"},
+	}
+	failed := &updater.VerifyReport{Component: "词库", Err: errors.New("no manifest")}
+
+	tests := []struct {
+		name    string
+		reports []*updater.VerifyReport
+		want    int
+	}{
+		{"clean with unexpected files", []*updater.VerifyReport{clean}, ExitOK},
+		{"issues", []*updater.VerifyReport{clean, broken}, ExitIssues},
+		{"repaired", []*updater.VerifyReport{repaired}, ExitOK},
+		{"partial", []*updater.VerifyReport{broken, failed}, ExitPartial},
+		{"all failed", []*updater.VerifyReport{failed}, ExitFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyExitCode(tt.reports); got != tt.want {
+				t.Errorf("verifyExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
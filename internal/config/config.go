@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,6 +33,9 @@ type Manager struct {
 	RimeDir    string
 	ZhDictsDir string
 	CacheDir   string
+
+	// Notices 加载配置时自动迁移、修复产生的提示，由调用方决定输出位置
+	Notices []string
 }
 
 // NewManager 创建配置管理器
@@ -87,7 +91,7 @@ func (m *Manager) loadOrCreateConfig() (*types.Config, error) {
 		config.Engine = ""
 		// 迁移后保存
 		if err := m.saveConfig(&config); err != nil {
-			fmt.Printf("警告：配置迁移失败: %v\n", err)
+			m.Notices = append(m.Notices, fmt.Sprintf("警告：配置迁移失败: %v", err))
 		}
 	}
 
@@ -106,11 +110,11 @@ func (m *Manager) loadOrCreateConfig() (*types.Config, error) {
 			if len(config.InstalledEngines) > 0 {
 				oldPrimary := config.PrimaryEngine
 				config.PrimaryEngine = config.InstalledEngines[0]
-				fmt.Printf("⚠️  主引擎 %s 未检测到，已切换到 %s\n", oldPrimary, config.PrimaryEngine)
+				m.Notices = append(m.Notices, fmt.Sprintf("⚠️  主引擎 %s 未检测到，已切换到 %s", oldPrimary, config.PrimaryEngine))
 				needsSave = true
 			} else {
 				config.PrimaryEngine = ""
-				fmt.Println("⚠️  未检测到任何已安装的引擎")
+				m.Notices = append(m.Notices, "⚠️  未检测到任何已安装的引擎")
 			}
 		}
 	}
@@ -150,9 +154,9 @@ func (m *Manager) loadOrCreateConfig() (*types.Config, error) {
 	// 保存更新后的配置
 	if needsSave {
 		if err := m.saveConfig(&config); err != nil {
-			fmt.Printf("警告：保存配置失败: %v\n", err)
+			m.Notices = append(m.Notices, fmt.Sprintf("警告：保存配置失败: %v", err))
 		} else {
-			fmt.Println("✓ 配置已自动更新")
+			m.Notices = append(m.Notices, "✓ 配置已自动更新")
 		}
 	}
 
@@ -409,8 +413,9 @@ func (m *Manager) GetActualFilenames(schemeKey string) (string, string, error) {
 	schemeRegex := regexp.MustCompile(schemePattern)
 	dictRegex := regexp.MustCompile(dictPattern)
 
-	// 创建 API 客户端
+	// 创建 API 客户端；初始化向导中的查询不支持取消
 	client := api.NewClient(m.Config)
+	ctx := context.Background()
 
 	// 获取方案文件
 	var releases []types.GitHubRelease
@@ -420,6 +425,7 @@ func (m *Manager) GetActualFilenames(schemeKey string) (string, string, error) {
 
 	if m.Config.UseMirror {
 		schemeInfo, fetchErr := client.FindLatestCNBAssetInfo(
+			ctx,
 			types.OWNER,
 			types.CNB_REPO,
 			schemeRegex.MatchString,
@@ -431,7 +437,7 @@ func (m *Manager) GetActualFilenames(schemeKey string) (string, string, error) {
 		schemeFile = schemeInfo.Name
 		preferredSchemeTag = schemeInfo.Tag
 	} else {
-		releases, err = client.FetchGitHubReleases(types.OWNER, types.REPO, "")
+		releases, err = client.FetchGitHubReleases(ctx, types.OWNER, types.REPO, "")
 		if err != nil {
 			return "", "", fmt.Errorf("获取版本信息失败: %w", err)
 		}
@@ -453,7 +459,7 @@ func (m *Manager) GetActualFilenames(schemeKey string) (string, string, error) {
 	// 获取词库文件
 	if m.Config.UseMirror {
 		if preferredSchemeTag != "" {
-			release, fetchErr := client.FetchCNBReleaseByTag(types.OWNER, types.CNB_REPO, preferredSchemeTag)
+			release, fetchErr := client.FetchCNBReleaseByTag(ctx, types.OWNER, types.CNB_REPO, preferredSchemeTag)
 			if fetchErr != nil {
 				return "", "", fmt.Errorf("获取词库信息失败: %w", fetchErr)
 			}
@@ -468,6 +474,7 @@ func (m *Manager) GetActualFilenames(schemeKey string) (string, string, error) {
 
 		if dictFile == "" {
 			dictInfo, fetchErr := client.FindLatestCNBAssetInfo(
+				ctx,
 				types.OWNER,
 				types.CNB_REPO,
 				dictRegex.MatchString,
@@ -480,7 +487,7 @@ func (m *Manager) GetActualFilenames(schemeKey string) (string, string, error) {
 		}
 	} else {
 		// GitHub 使用 dict-nightly tag
-		releases, err = client.FetchGitHubReleases(types.OWNER, types.REPO, types.DICT_TAG)
+		releases, err = client.FetchGitHubReleases(ctx, types.OWNER, types.REPO, types.DICT_TAG)
 		if err != nil {
 			return "", "", fmt.Errorf("获取词库信息失败: %w", err)
 		}
@@ -534,6 +541,40 @@ func (m *Manager) GetModelRecordPath() string {
 	return filepath.Join(m.CacheDir, "model_record.json")
 }
 
+// GetManifestDir 获取安装清单目录
+func (m *Manager) GetManifestDir() string {
+	return filepath.Join(m.CacheDir, "manifests")
+}
+
+// GetVersionsDir 获取组件历史版本的保存目录
+func (m *Manager) GetVersionsDir(component string) string {
+	return filepath.Join(m.CacheDir, "versions", component)
+}
+
+// GetHistoryPath 获取更新历史文件路径（JSON Lines，只追加）
+func (m *Manager) GetHistoryPath() string {
+	return filepath.Join(m.CacheDir, "history.jsonl")
+}
+
+// GetManifestPath 获取组件在指定引擎下的安装清单路径
+func (m *Manager) GetManifestPath(component, engine string) string {
+	return filepath.Join(m.GetManifestDir(), component+"_"+manifestSafeName(engine)+".json")
+}
+
+// manifestSafeName 将引擎名转换为可用作文件名的形式
+func manifestSafeName(engine string) string {
+	if engine == "" {
+		return "default"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '-'
+		}
+		return r
+	}, engine)
+}
+
 // HasInstalledEngine reports whether a real installed engine and data dir exist.
 func (m *Manager) HasInstalledEngine() bool {
 	return m != nil && m.Config != nil && len(m.Config.InstalledEngines) > 0 && m.RimeDir != ""
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// IsModelPattern 判断配置的模型文件是否为通配符模式，否则为具体的文件名
+func IsModelPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// validModelFile 判断模型文件名或模式是否可用：须为不含目录的文件名，且通配符语法正确
+func validModelFile(name string) bool {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	_, err := path.Match(name, "")
+	return err == nil
+}
+
+// ModelPatterns 返回要安装的模型文件名或通配符模式，忽略空白和无效的项；未配置时只安装 types.MODEL_FILE
+func (m *Manager) ModelPatterns() []string {
+	var patterns []string
+	for _, name := range m.Config.ModelFiles {
+		name = strings.TrimSpace(name)
+		if validModelFile(name) && !slices.Contains(patterns, name) {
+			patterns = append(patterns, name)
+		}
+	}
+	if len(patterns) == 0 {
+		return []string{types.MODEL_FILE}
+	}
+	return patterns
+}
+
+// GetModelFileRecordPath 获取模型文件的记录路径；types.MODEL_FILE 沿用 GetModelRecordPath，其他模型各自一个记录
+func (m *Manager) GetModelFileRecordPath(file string) string {
+	if file == types.MODEL_FILE {
+		return m.GetModelRecordPath()
+	}
+	return filepath.Join(m.CacheDir, "model_record_"+file+".json")
+}
+
+// ParseModelFiles 解析以逗号分隔的模型文件名或通配符模式，并验证每一项
+func ParseModelFiles(value string) ([]string, error) {
+	var files []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !validModelFile(name) {
+			return nil, fmt.Errorf("无效的模型文件: %s", name)
+		}
+		if !slices.Contains(files, name) {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestModelPatterns(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"未配置", nil, []string{types.MODEL_FILE}},
+		{"多个模型", []string{" a.gram ", "*zh-hant*.gram", "a.gram"}, []string{"a.gram", "*zh-hant*.gram"}},
+		{"忽略无效项", []string{"../evil.gram", "[", "dir/a.gram", ""}, []string{types.MODEL_FILE}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{Config: &types.Config{ModelFiles: tt.files}}
+			if got := m.ModelPatterns(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ModelPatterns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetModelFileRecordPath(t *testing.T) {
+	m := &Manager{CacheDir: "cache", Config: &types.Config{}}
+	if got := m.GetModelFileRecordPath(types.MODEL_FILE); got != m.GetModelRecordPath() {
+		t.Errorf("GetModelFileRecordPath(MODEL_FILE) = %q, want %q", got, m.GetModelRecordPath())
+	}
+	want := filepath.Join("cache", "model_record_b.gram.json")
+	if got := m.GetModelFileRecordPath("b.gram"); got != want {
+		t.Errorf("GetModelFileRecordPath(b.gram) = %q, want %q", got, want)
+	}
+}
+
+func TestParseModelFiles(t *testing.T) {
+	got, err := ParseModelFiles(" a.gram, *zh-hant*.gram ,,a.gram")
+	if err != nil {
+		t.Fatalf("ParseModelFiles() error = %v", err)
+	}
+	if want := []string{"a.gram", "*zh-hant*.gram"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseModelFiles() = %v, want %v", got, want)
+	}
+
+	for _, value := range []string{"../a.gram", "a/b.gram", "[a"} {
+		if _, err := ParseModelFiles(value); err == nil {
+			t.Errorf("ParseModelFiles(%q) error = nil, want error", value)
+		}
+	}
+}
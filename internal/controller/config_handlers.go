@@ -1,8 +1,15 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
 )
 
 // handleConfigChange handles configuration changes
@@ -71,6 +78,37 @@ func (c *Controller) handleConfigChange(cmd Command) {
 		if val, ok := payload.Value.(string); ok {
 			c.cfg.Config.PostUpdateHook = val
 		}
+	case "model_files":
+		if val, ok := payload.Value.([]string); ok {
+			c.cfg.Config.ModelFiles = val
+		} else if val, ok := payload.Value.(string); ok {
+			files, err := config.ParseModelFiles(val)
+			if err != nil {
+				c.emitError(err, "config change")
+				return
+			}
+			c.cfg.Config.ModelFiles = files
+		}
+	case "github_api_url":
+		if val, ok := payload.Value.(string); ok {
+			if err := api.ValidateBaseURL(val); err != nil {
+				c.emitError(err, "config change")
+				return
+			}
+			c.cfg.Config.GithubAPIURL = strings.TrimSpace(val)
+		}
+	case "github_proxy":
+		if val, ok := payload.Value.(string); ok {
+			if err := api.ValidateBaseURL(val); err != nil {
+				c.emitError(err, "config change")
+				return
+			}
+			c.cfg.Config.GithubProxy = strings.TrimSpace(val)
+		}
+	case "require_checksum":
+		if val, ok := payload.Value.(bool); ok {
+			c.cfg.Config.RequireChecksum = val
+		}
 	case "theme_adaptive":
 		if val, ok := payload.Value.(bool); ok {
 			c.cfg.Config.ThemeAdaptive = val
@@ -120,6 +158,66 @@ func (c *Controller) handleConfigSave(cmd Command) {
 	})
 }
 
+// connectionTestTimeout bounds a single connection test
+const connectionTestTimeout = 15 * time.Second
+
+// handleTestConnection tests the edited GitHub API address or download proxy without saving it
+func (c *Controller) handleTestConnection(cmd Command) {
+	payload, ok := cmd.Payload.(ConnectionTestPayload)
+	if !ok {
+		c.emitError(fmt.Errorf("invalid connection test payload"), "connection test")
+		return
+	}
+
+	c.mu.Lock()
+	cfg := *c.cfg.Config
+	c.mu.Unlock()
+
+	go func() {
+		start := time.Now()
+		err := testConnection(&cfg, payload)
+		c.emitEvent(EvtConnectionTested, ConnectionTestedPayload{
+			Key:      payload.Key,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+	}()
+}
+
+// handleProbeSources measures GitHub and the CNB mirror with the current settings
+func (c *Controller) handleProbeSources(cmd Command) {
+	c.mu.Lock()
+	cfg := *c.cfg.Config
+	c.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionTestTimeout)
+		defer cancel()
+		c.emitEvent(EvtProbeComplete, ProbeCompletePayload{Results: api.ProbeSources(ctx, &cfg)})
+	}()
+}
+
+// testConnection applies the edited value to a copy of the config and runs the matching check
+func testConnection(cfg *types.Config, payload ConnectionTestPayload) error {
+	if err := api.ValidateBaseURL(payload.Value); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectionTestTimeout)
+	defer cancel()
+
+	switch payload.Key {
+	case "github_api_url":
+		cfg.GithubAPIURL = payload.Value
+		return api.NewClient(cfg).CheckGitHubAPI(ctx)
+	case "github_proxy":
+		cfg.GithubProxy = payload.Value
+		return api.NewClient(cfg).CheckGitHubProxy(ctx)
+	default:
+		return fmt.Errorf("unknown connection test: %s", payload.Key)
+	}
+}
+
 // handleWizardSetScheme handles wizard scheme selection
 func (c *Controller) handleWizardSetScheme(cmd Command) {
 	payload, ok := cmd.Payload.(WizardSchemePayload)
@@ -156,6 +254,23 @@ func (c *Controller) handleWizardSetMirror(cmd Command) {
 	c.emitEvent(EvtStateUpdate, c.wizardState)
 }
 
+// handleWizardSetModel handles wizard model selection
+func (c *Controller) handleWizardSetModel(cmd Command) {
+	payload, ok := cmd.Payload.(WizardModelPayload)
+	if !ok {
+		c.emitError(fmt.Errorf("invalid wizard model payload"), "wizard model")
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.wizardState.ModelFiles = payload.ModelFiles
+	c.cfg.Config.ModelFiles = payload.ModelFiles
+
+	c.emitEvent(EvtStateUpdate, c.wizardState)
+}
+
 // handleWizardComplete handles wizard completion
 func (c *Controller) handleWizardComplete(cmd Command) {
 	c.mu.Lock()
@@ -38,6 +38,8 @@ func NewController(
 		commandChan: commandChan,
 		eventChan:   eventChan,
 		done:        make(chan struct{}),
+
+		modifiedReply: make(chan string, 1),
 	}
 }
 
@@ -59,8 +61,9 @@ func (c *Controller) Run() {
 	}
 }
 
-// Stop gracefully stops the controller
+// Stop gracefully stops the controller and cancels the running update
 func (c *Controller) Stop() {
+	c.cancelRunning()
 	close(c.done)
 }
 
@@ -69,20 +72,36 @@ func (c *Controller) handleCommand(cmd Command) {
 	switch cmd.Type {
 	case CmdAutoUpdate:
 		c.handleAutoUpdate(cmd)
-	case CmdUpdateDict:
-		c.handleUpdateDict(cmd)
-	case CmdUpdateScheme:
-		c.handleUpdateScheme(cmd)
-	case CmdUpdateModel:
-		c.handleUpdateModel(cmd)
+	case CmdUpdateComponent:
+		c.handleUpdateComponent(cmd)
+	case CmdDryRun:
+		c.handleDryRun(cmd)
+	case CmdUninstall:
+		c.handleUninstall(cmd)
+	case CmdVerify:
+		c.handleVerify(cmd)
+	case CmdListVersions:
+		c.handleListVersions(cmd)
+	case CmdRollback:
+		c.handleRollback(cmd)
+	case CmdCancelUpdate:
+		c.handleCancelUpdate(cmd)
+	case CmdResolveModified:
+		c.handleResolveModified(cmd)
 	case CmdConfigChange:
 		c.handleConfigChange(cmd)
+	case CmdTestConnection:
+		c.handleTestConnection(cmd)
+	case CmdProbeSources:
+		c.handleProbeSources(cmd)
 	case CmdConfigSave:
 		c.handleConfigSave(cmd)
 	case CmdWizardSetScheme:
 		c.handleWizardSetScheme(cmd)
 	case CmdWizardSetMirror:
 		c.handleWizardSetMirror(cmd)
+	case CmdWizardSetModel:
+		c.handleWizardSetModel(cmd)
 	case CmdWizardComplete:
 		c.handleWizardComplete(cmd)
 	case CmdThemeChange:
@@ -1,23 +1,41 @@
 package controller
 
+import (
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/updater"
+)
+
 // CommandType defines the type of command sent from UI to Controller
 type CommandType int
 
 const (
 	// Update commands
 	CmdAutoUpdate CommandType = iota
-	CmdUpdateDict
-	CmdUpdateScheme
-	CmdUpdateModel
+	// 更新单个已注册的组件，Payload 为 UpdateComponentPayload
+	CmdUpdateComponent
+	CmdDryRun       // 预览更新，不修改 Rime 目录
+	CmdUninstall    // 按安装清单卸载万象文件
+	CmdVerify       // 校验已安装文件，Payload 为 VerifyPayload
+	CmdListVersions // 列出可回滚到的历史版本
+	CmdRollback     // 重新安装历史版本，Payload 为 RollbackPayload
+	CmdCancelUpdate // 取消正在进行的更新或预览
+
+	// 回复 EvtModifiedFiles，Payload 为 ResolveModifiedPayload
+	CmdResolveModified
 
 	// Configuration commands
 	CmdConfigChange
 	CmdConfigSave
 	CmdConfigCancel
+	CmdTestConnection // 测试 GitHub API 地址或下载代理，Payload 为 ConnectionTestPayload
+	CmdProbeSources   // 测试 GitHub 和 CNB 镜像的速度，结果由 EvtProbeComplete 返回
 
 	// Wizard commands
 	CmdWizardSetScheme
 	CmdWizardSetMirror
+	CmdWizardSetModel
 	CmdWizardComplete
 
 	// View commands
@@ -41,18 +59,61 @@ type Command struct {
 	Payload any
 }
 
+// UpdateComponentPayload selects the registered component to update
+type UpdateComponentPayload struct {
+	Component string // 组件 ID，如 types.ComponentScheme
+}
+
+// VerifyPayload contains options for the verify command
+type VerifyPayload struct {
+	Repair bool // 从缓存的更新包恢复缺失或被修改的文件
+}
+
+// RollbackPayload selects the archived version to reinstall
+type RollbackPayload struct {
+	Version updater.ArchivedVersion
+}
+
+// ResolveModifiedPayload answers an EvtModifiedFiles prompt
+type ResolveModifiedPayload struct {
+	Action string // updater.ModifiedKeep / ModifiedOverwrite / ModifiedBackup
+}
+
 // ConfigChangePayload contains data for configuration changes
 type ConfigChangePayload struct {
 	Key   string
 	Value any
 }
 
+// ConnectionTestPayload selects the address to test; Value is the edited, not yet saved setting
+type ConnectionTestPayload struct {
+	Key   string // "github_api_url" 或 "github_proxy"
+	Value string
+}
+
+// ConnectionTestedPayload contains the result of a connection test
+type ConnectionTestedPayload struct {
+	Key      string
+	Err      error
+	Duration time.Duration
+}
+
+// ProbeCompletePayload contains the speed test result of every download source
+type ProbeCompletePayload struct {
+	Results []api.ProbeResult // GitHub 在前，CNB 在后
+}
+
 // WizardSchemePayload contains data for wizard scheme selection
 type WizardSchemePayload struct {
 	SchemeType string
 	Variant    string
 }
 
+// WizardModelPayload contains data for wizard model selection
+type WizardModelPayload struct {
+	ModelFiles []string // 模型文件名或通配符模式，为空时只安装简体模型
+}
+
 // ThemeChangePayload contains data for theme changes
 type ThemeChangePayload struct {
 	ThemeName string
@@ -81,10 +142,19 @@ const (
 	EvtUpdateSuccess
 	EvtUpdateFailure
 	EvtUpdateSkipped
+	EvtDryRunComplete
+	EvtUninstallComplete
+	EvtVerifyComplete
+	EvtVersionsLoaded
+
+	// Prompt events; the running update waits for a matching command
+	EvtModifiedFiles // 发现被用户修改过的万象文件，等待 CmdResolveModified
 
 	// Configuration events
 	EvtConfigUpdated
 	EvtConfigError
+	EvtConnectionTested
+	EvtProbeComplete
 
 	// Wizard events
 	EvtWizardComplete
@@ -122,11 +192,41 @@ type UpdateCompletePayload struct {
 	Success           bool
 	Skipped           bool
 	Message           string
+	Err               error // 失败时的原始错误，供 UI 判断具体原因
 	UpdatedComponents []string
 	SkippedComponents []string
 	ComponentVersions map[string]string
 }
 
+// DryRunCompletePayload contains the dry-run update plan
+type DryRunCompletePayload struct {
+	Result *updater.DryRunResult
+}
+
+// UninstallCompletePayload contains per-component uninstall results
+type UninstallCompletePayload struct {
+	Results []*updater.UninstallResult
+	Err     error // 卸载未能开始时的错误（如终止进程失败）
+}
+
+// ModifiedFilesPayload lists upstream files the user has edited since they were installed
+type ModifiedFilesPayload struct {
+	Component string   // 内部组件名（方案/词库）
+	Files     []string // 相对于安装目录的路径
+}
+
+// VerifyCompletePayload contains per-component verify reports
+type VerifyCompletePayload struct {
+	Reports []*updater.VerifyReport
+	Repair  bool
+}
+
+// VersionsLoadedPayload lists the archived versions each component can roll back to
+type VersionsLoadedPayload struct {
+	Versions map[string][]updater.ArchivedVersion // 键为组件 ID
+	Err      error
+}
+
 // ConfigUpdatedPayload contains updated configuration
 type ConfigUpdatedPayload struct {
 	Key   string
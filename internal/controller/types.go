@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"sync"
 
 	"rime-wanxiang-updater/internal/config"
@@ -30,6 +31,12 @@ type Controller struct {
 	commandChan <-chan Command
 	eventChan   chan<- Event
 
+	// Cancels the running update or dry run; nil when nothing cancellable is running
+	cancel context.CancelFunc
+
+	// Answers to EvtModifiedFiles prompts, consumed by the running update
+	modifiedReply chan string
+
 	// Shutdown
 	done chan struct{}
 
@@ -42,5 +49,6 @@ type WizardState struct {
 	SchemeType string
 	Variant    string
 	UseMirror  bool
+	ModelFiles []string
 	Completed  bool
 }
@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
+	"rime-wanxiang-updater/internal/types"
 	"rime-wanxiang-updater/internal/updater"
 )
 
@@ -44,6 +47,33 @@ func successMessageForAutoUpdate(updatedComponents []string, previousVersions ma
 	}
 }
 
+// failureMessage describes a failed update; a cancelled update gets its own message instead of the context error
+func failureMessage(prefix string, err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "更新已取消"
+	}
+	return fmt.Sprintf("%s: %v", prefix, err)
+}
+
+// preferFastestSource switches UseMirror to the faster source before an update when AutoSelectSource is set;
+// the current source is kept when every probe fails
+func (c *Controller) preferFastestSource(ctx context.Context, component string) {
+	if !c.cfg.Config.AutoSelectSource {
+		return
+	}
+
+	c.emitProgress(component, "正在测试下载源速度...", 0.0, "", "", 0, 0, 0, false)
+	best, ok := updater.FastestSource(ctx, c.cfg)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	c.cfg.Config.UseMirror = best.UseMirror
+	c.mu.Unlock()
+	c.emitProgress(component, fmt.Sprintf("已选择较快的下载源: %s", best.Source), 0.0, "", "", 0, 0, 0, false)
+}
+
 // handleAutoUpdate handles the auto update command
 func (c *Controller) handleAutoUpdate(cmd Command) {
 	c.mu.Lock()
@@ -54,6 +84,8 @@ func (c *Controller) handleAutoUpdate(cmd Command) {
 	}
 	c.updating = true
 	c.currentOperation = "auto"
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
 	c.mu.Unlock()
 
 	go func() {
@@ -61,36 +93,43 @@ func (c *Controller) handleAutoUpdate(cmd Command) {
 			c.mu.Lock()
 			c.updating = false
 			c.currentOperation = ""
+			c.cancel = nil
 			c.mu.Unlock()
+			cancel()
 		}()
 
+		c.preferFastestSource(ctx, "检查")
+
 		combined := updater.NewCombinedUpdater(c.cfg)
+		for _, comp := range combined.Components() {
+			comp.Base().ResolveModified = c.resolveModified
+		}
 
 		progressFunc := func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
 			c.emitProgress(component, message, percent, source, fileName, downloaded, total, speed, downloadMode)
 		}
 
 		progressFunc("检查", "正在检查所有更新...", 0.0, "", "", 0, 0, 0, false)
-		if err := combined.FetchAllUpdates(); err != nil {
+		plan, err := combined.FetchAllUpdates(ctx)
+		if err != nil {
 			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
 				UpdateType: "自动",
 				Success:    false,
-				Message:    fmt.Sprintf("检查更新失败: %v", err),
+				Message:    failureMessage("检查更新失败", err),
+				Err:        err,
 			})
 			return
 		}
 
-		if !combined.HasAnyUpdate() {
+		if !plan.HasAnyUpdate() {
 			progressFunc("完成", "所有组件已是最新版本", 1.0, "", "", 0, 0, 0, false)
 			componentVersions := make(map[string]string)
-			if schemeStatus, err := combined.SchemeUpdater.GetStatus(); err == nil {
-				componentVersions["方案"] = schemeStatus.LocalVersion
-			}
-			if dictStatus, err := combined.DictUpdater.GetStatus(); err == nil {
-				componentVersions["词库"] = dictStatus.LocalVersion
-			}
-			if modelStatus, err := combined.ModelUpdater.GetStatus(); err == nil {
-				componentVersions["模型"] = modelStatus.LocalVersion
+			var skippedComponents []string
+			for _, id := range types.ComponentIDs() {
+				skippedComponents = append(skippedComponents, types.ComponentName(id))
+				if entry, ok := plan.Get(id); ok && entry.Status != nil {
+					componentVersions[types.ComponentName(id)] = entry.Status.LocalVersion
+				}
 			}
 
 			c.emitEvent(EvtUpdateSkipped, UpdateCompletePayload{
@@ -99,19 +138,20 @@ func (c *Controller) handleAutoUpdate(cmd Command) {
 				Skipped:           true,
 				Message:           "所有组件已是最新版本",
 				UpdatedComponents: []string{},
-				SkippedComponents: []string{"方案", "词库", "模型"},
+				SkippedComponents: skippedComponents,
 				ComponentVersions: componentVersions,
 			})
 			return
 		}
 
-		result, err := combined.RunAllWithProgress(progressFunc)
+		result, err := combined.RunAllWithProgress(ctx, plan, progressFunc)
 
 		if err != nil {
 			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
 				UpdateType: "自动",
 				Success:    false,
-				Message:    fmt.Sprintf("更新失败: %v", err),
+				Message:    failureMessage("更新失败", err),
+				Err:        err,
 			})
 			return
 		}
@@ -138,16 +178,26 @@ func (c *Controller) handleAutoUpdate(cmd Command) {
 	}()
 }
 
-// handleUpdateDict handles the dict update command
-func (c *Controller) handleUpdateDict(cmd Command) {
+// handleUpdateComponent handles the single-component update command for any registered component
+func (c *Controller) handleUpdateComponent(cmd Command) {
+	payload, _ := cmd.Payload.(UpdateComponentPayload)
+	comp, err := updater.NewComponent(c.cfg, payload.Component)
+	if err != nil {
+		c.emitError(err, "component update")
+		return
+	}
+	name := types.ComponentName(payload.Component)
+
 	c.mu.Lock()
 	if c.updating {
 		c.mu.Unlock()
-		c.emitError(fmt.Errorf("update already in progress"), "dict update")
+		c.emitError(fmt.Errorf("update already in progress"), payload.Component+" update")
 		return
 	}
 	c.updating = true
-	c.currentOperation = "dict"
+	c.currentOperation = payload.Component
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
 	c.mu.Unlock()
 
 	go func() {
@@ -155,29 +205,33 @@ func (c *Controller) handleUpdateDict(cmd Command) {
 			c.mu.Lock()
 			c.updating = false
 			c.currentOperation = ""
+			c.cancel = nil
 			c.mu.Unlock()
+			cancel()
 		}()
 
-		dictUpdater := updater.NewDictUpdater(c.cfg)
+		comp.Base().ResolveModified = c.resolveModified
+		c.preferFastestSource(ctx, name)
 
 		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
-			c.emitProgress("词库", message, percent, source, fileName, downloaded, total, speed, downloadMode)
+			c.emitProgress(name, message, percent, source, fileName, downloaded, total, speed, downloadMode)
 		}
 
-		status, err := dictUpdater.GetStatus()
+		status, err := comp.GetStatus(ctx)
 		if err != nil {
 			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-				UpdateType: "词库",
+				UpdateType: name,
 				Success:    false,
-				Message:    fmt.Sprintf("获取状态失败: %v", err),
+				Message:    failureMessage("获取状态失败", err),
+				Err:        err,
 			})
 			return
 		}
 
 		if !status.NeedsUpdate {
-			progressFunc("词库已是最新版本，跳过更新", 1.0, "", "", 0, 0, 0, false)
+			progressFunc(fmt.Sprintf("%s已是最新版本，跳过更新", name), 1.0, "", "", 0, 0, 0, false)
 			c.emitEvent(EvtUpdateSkipped, UpdateCompletePayload{
-				UpdateType: "词库",
+				UpdateType: name,
 				Success:    true,
 				Skipped:    true,
 				Message:    status.Message,
@@ -185,38 +239,41 @@ func (c *Controller) handleUpdateDict(cmd Command) {
 			return
 		}
 
-		if err = dictUpdater.Run(progressFunc); err == nil {
-			err = dictUpdater.Deploy()
+		if err = comp.Run(ctx, progressFunc); err == nil {
+			err = comp.Deploy()
 		}
 
 		if err != nil {
 			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-				UpdateType: "词库",
+				UpdateType: name,
 				Success:    false,
-				Message:    fmt.Sprintf("更新失败: %v", err),
+				Message:    failureMessage("更新失败", err),
+				Err:        err,
 			})
 			return
 		}
 
 		c.emitEvent(EvtUpdateSuccess, UpdateCompletePayload{
-			UpdateType: "词库",
+			UpdateType: name,
 			Success:    true,
 			Skipped:    false,
-			Message:    successMessageForSingleUpdate("词库", status.LocalVersion),
+			Message:    successMessageForSingleUpdate(name, status.LocalVersion),
 		})
 	}()
 }
 
-// handleUpdateScheme handles the scheme update command
-func (c *Controller) handleUpdateScheme(cmd Command) {
+// handleDryRun handles the dry-run (update preview) command
+func (c *Controller) handleDryRun(cmd Command) {
 	c.mu.Lock()
 	if c.updating {
 		c.mu.Unlock()
-		c.emitError(fmt.Errorf("update already in progress"), "scheme update")
+		c.emitError(fmt.Errorf("update already in progress"), "dry run")
 		return
 	}
 	c.updating = true
-	c.currentOperation = "scheme"
+	c.currentOperation = "dry_run"
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
 	c.mu.Unlock()
 
 	go func() {
@@ -224,68 +281,80 @@ func (c *Controller) handleUpdateScheme(cmd Command) {
 			c.mu.Lock()
 			c.updating = false
 			c.currentOperation = ""
+			c.cancel = nil
 			c.mu.Unlock()
+			cancel()
 		}()
 
-		schemeUpdater := updater.NewSchemeUpdater(c.cfg)
+		combined := updater.NewCombinedUpdater(c.cfg)
 
-		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
-			c.emitProgress("方案", message, percent, source, fileName, downloaded, total, speed, downloadMode)
+		progressFunc := func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+			c.emitProgress(component, message, percent, source, fileName, downloaded, total, speed, downloadMode)
 		}
 
-		status, err := schemeUpdater.GetStatus()
-		if err != nil {
-			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-				UpdateType: "方案",
-				Success:    false,
-				Message:    fmt.Sprintf("获取状态失败: %v", err),
-			})
-			return
-		}
+		progressFunc("检查", "正在生成更新预览...", 0.0, "", "", 0, 0, 0, false)
+		result := combined.DryRun(ctx, progressFunc)
 
-		if !status.NeedsUpdate {
-			progressFunc("方案已是最新版本，跳过更新", 1.0, "", "", 0, 0, 0, false)
-			c.emitEvent(EvtUpdateSkipped, UpdateCompletePayload{
-				UpdateType: "方案",
-				Success:    true,
-				Skipped:    true,
-				Message:    status.Message,
-			})
-			return
-		}
+		c.emitEvent(EvtDryRunComplete, DryRunCompletePayload{Result: result})
+	}()
+}
 
-		if err = schemeUpdater.Run(progressFunc); err == nil {
-			err = schemeUpdater.Deploy()
-		}
+// handleCancelUpdate cancels the running update or dry run. Downloads stop right away and keep their
+// partial files; an install already in progress finishes, or the whole batch rolls back.
+func (c *Controller) handleCancelUpdate(cmd Command) {
+	c.cancelRunning()
+}
 
-		if err != nil {
-			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-				UpdateType: "方案",
-				Success:    false,
-				Message:    fmt.Sprintf("更新失败: %v", err),
-			})
-			return
-		}
+// cancelRunning cancels the running update, if any
+func (c *Controller) cancelRunning() {
+	c.mu.RLock()
+	cancel := c.cancel
+	c.mu.RUnlock()
 
-		c.emitEvent(EvtUpdateSuccess, UpdateCompletePayload{
-			UpdateType: "方案",
-			Success:    true,
-			Skipped:    false,
-			Message:    successMessageForSingleUpdate("方案", status.LocalVersion),
-		})
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// handleUninstall handles the uninstall command; it removes only files recorded in install manifests
+func (c *Controller) handleUninstall(cmd Command) {
+	c.mu.Lock()
+	if c.updating {
+		c.mu.Unlock()
+		c.emitError(fmt.Errorf("update already in progress"), "uninstall")
+		return
+	}
+	c.updating = true
+	c.currentOperation = "uninstall"
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.updating = false
+			c.currentOperation = ""
+			c.mu.Unlock()
+		}()
+
+		c.emitProgress("卸载", "正在卸载万象文件...", 0.0, "", "", 0, 0, 0, false)
+		results, err := updater.NewCombinedUpdater(c.cfg).Uninstall(types.ComponentIDs())
+
+		c.emitEvent(EvtUninstallComplete, UninstallCompletePayload{Results: results, Err: err})
 	}()
 }
 
-// handleUpdateModel handles the model update command
-func (c *Controller) handleUpdateModel(cmd Command) {
+// handleVerify handles the verify command, optionally repairing files from the cached archives
+func (c *Controller) handleVerify(cmd Command) {
+	payload, _ := cmd.Payload.(VerifyPayload)
+
 	c.mu.Lock()
 	if c.updating {
 		c.mu.Unlock()
-		c.emitError(fmt.Errorf("update already in progress"), "model update")
+		c.emitError(fmt.Errorf("update already in progress"), "verify")
 		return
 	}
 	c.updating = true
-	c.currentOperation = "model"
+	c.currentOperation = "verify"
 	c.mu.Unlock()
 
 	go func() {
@@ -296,57 +365,119 @@ func (c *Controller) handleUpdateModel(cmd Command) {
 			c.mu.Unlock()
 		}()
 
-		modelUpdater := updater.NewModelUpdater(c.cfg)
+		message := "正在校验已安装文件..."
+		if payload.Repair {
+			message = "正在修复已安装文件..."
+		}
+		c.emitProgress("校验", message, 0.0, "", "", 0, 0, 0, false)
+		componentIDs := []string{types.ComponentScheme, types.ComponentDict}
+		reports := updater.NewCombinedUpdater(c.cfg).Verify(componentIDs, payload.Repair)
 
-		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
-			c.emitProgress("模型", message, percent, source, fileName, downloaded, total, speed, downloadMode)
+		c.emitEvent(EvtVerifyComplete, VerifyCompletePayload{Reports: reports, Repair: payload.Repair})
+	}()
+}
+
+// handleListVersions loads the archived versions of every component; it never touches the network
+func (c *Controller) handleListVersions(cmd Command) {
+	go func() {
+		combined := updater.NewCombinedUpdater(c.cfg)
+		versions := make(map[string][]updater.ArchivedVersion)
+		var errs []error
+		for _, id := range types.ComponentIDs() {
+			list, err := combined.Versions(id)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", types.ComponentName(id), err))
+				continue
+			}
+			versions[id] = list
 		}
 
-		status, err := modelUpdater.GetStatus()
-		if err != nil {
-			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-				UpdateType: "模型",
-				Success:    false,
-				Message:    fmt.Sprintf("获取状态失败: %v", err),
-			})
-			return
+		c.emitEvent(EvtVersionsLoaded, VersionsLoadedPayload{Versions: versions, Err: errors.Join(errs...)})
+	}()
+}
+
+// handleRollback reinstalls an archived version through the normal install path, then redeploys
+func (c *Controller) handleRollback(cmd Command) {
+	payload, _ := cmd.Payload.(RollbackPayload)
+
+	c.mu.Lock()
+	if c.updating {
+		c.mu.Unlock()
+		c.emitError(fmt.Errorf("update already in progress"), "rollback")
+		return
+	}
+	c.updating = true
+	c.currentOperation = "rollback"
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.updating = false
+			c.currentOperation = ""
+			c.mu.Unlock()
+		}()
+
+		component := types.ComponentName(payload.Version.Component)
+		combined := updater.NewCombinedUpdater(c.cfg)
+		for _, comp := range combined.Components() {
+			comp.Base().ResolveModified = c.resolveModified
 		}
 
-		if !status.NeedsUpdate {
-			progressFunc("模型已是最新版本，跳过更新", 1.0, "", "", 0, 0, 0, false)
-			c.emitEvent(EvtUpdateSkipped, UpdateCompletePayload{
-				UpdateType: "模型",
-				Success:    true,
-				Skipped:    true,
-				Message:    status.Message,
-			})
-			return
+		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+			c.emitProgress(component, message, percent, source, fileName, downloaded, total, speed, downloadMode)
 		}
 
-		if err := modelUpdater.Run(progressFunc); err == nil {
-			err = modelUpdater.Deploy()
-			if err != nil {
-				c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-					UpdateType: "模型",
-					Success:    false,
-					Message:    fmt.Sprintf("更新失败: %v", err),
-				})
-				return
-			}
-		} else {
+		err := combined.Rollback(payload.Version, progressFunc)
+		if err == nil {
+			err = combined.Deploy()
+		}
+		if err != nil {
 			c.emitEvent(EvtUpdateFailure, UpdateCompletePayload{
-				UpdateType: "模型",
+				UpdateType: component,
 				Success:    false,
-				Message:    fmt.Sprintf("更新失败: %v", err),
+				Message:    fmt.Sprintf("回滚失败: %v", err),
+				Err:        err,
 			})
 			return
 		}
 
 		c.emitEvent(EvtUpdateSuccess, UpdateCompletePayload{
-			UpdateType: "模型",
+			UpdateType: component,
 			Success:    true,
-			Skipped:    false,
-			Message:    successMessageForSingleUpdate("模型", status.LocalVersion),
+			Message:    fmt.Sprintf("已回滚到版本: %s", payload.Version.Label()),
 		})
 	}()
 }
+
+// resolveModified asks the UI how to handle upstream files the user has edited and blocks until it answers.
+// If the controller shuts down first, the user's files are kept.
+func (c *Controller) resolveModified(component string, files []string) string {
+	// Drain a stale answer left over from an earlier prompt
+	select {
+	case <-c.modifiedReply:
+	default:
+	}
+
+	select {
+	case c.eventChan <- Event{Type: EvtModifiedFiles, Payload: ModifiedFilesPayload{Component: component, Files: files}}:
+	case <-c.done:
+		return updater.ModifiedKeep
+	}
+
+	select {
+	case action := <-c.modifiedReply:
+		return action
+	case <-c.done:
+		return updater.ModifiedKeep
+	}
+}
+
+// handleResolveModified passes the user's answer to the update waiting in resolveModified
+func (c *Controller) handleResolveModified(cmd Command) {
+	payload, _ := cmd.Payload.(ResolveModifiedPayload)
+	select {
+	case c.modifiedReply <- payload.Action:
+	default:
+	}
+}
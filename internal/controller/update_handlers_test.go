@@ -1,6 +1,13 @@
 package controller
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"rime-wanxiang-updater/internal/updater"
+)
 
 func TestSuccessMessageForSingleUpdate(t *testing.T) {
 	tests := []struct {
@@ -93,3 +100,74 @@ func TestSuccessMessageForAutoUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveModifiedWaitsForAnswer(t *testing.T) {
+	events := make(chan Event)
+	c := &Controller{eventChan: events, done: make(chan struct{}), modifiedReply: make(chan string, 1)}
+
+	result := make(chan string)
+	go func() {
+		result <- c.resolveModified("方案", []string{"wanxiang.schema.yaml"})
+	}()
+
+	evt := <-events
+	payload, ok := evt.Payload.(ModifiedFilesPayload)
+	if evt.Type != EvtModifiedFiles || !ok || payload.Component != "方案" {
+		t.Fatalf("event = %+v, want EvtModifiedFiles for 方案", evt)
+	}
+
+	c.handleCommand(Command{Type: CmdResolveModified, Payload: ResolveModifiedPayload{Action: updater.ModifiedBackup}})
+	if got := <-result; got != updater.ModifiedBackup {
+		t.Errorf("resolveModified() = %q, want %q", got, updater.ModifiedBackup)
+	}
+}
+
+func TestResolveModifiedKeepsFilesOnShutdown(t *testing.T) {
+	c := &Controller{eventChan: make(chan Event), done: make(chan struct{}), modifiedReply: make(chan string, 1)}
+	c.Stop()
+
+	if got := c.resolveModified("词库", []string{"base.dict.yaml"}); got != updater.ModifiedKeep {
+		t.Errorf("resolveModified() after Stop = %q, want %q", got, updater.ModifiedKeep)
+	}
+}
+
+func TestCancelUpdateCancelsRunningOperation(t *testing.T) {
+	c := &Controller{done: make(chan struct{})}
+	c.handleCommand(Command{Type: CmdCancelUpdate}) // 没有进行中的更新时不做任何事
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.cancel = cancel
+
+	c.handleCommand(Command{Type: CmdCancelUpdate})
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("ctx.Err() = %v, want %v", ctx.Err(), context.Canceled)
+	}
+}
+
+func TestFailureMessage(t *testing.T) {
+	if got := failureMessage("更新失败", fmt.Errorf("下载失败: %w", context.Canceled)); got != "更新已取消" {
+		t.Errorf("failureMessage(canceled) = %q, want %q", got, "更新已取消")
+	}
+	if got := failureMessage("更新失败", errors.New("timeout")); got != "更新失败: timeout" {
+		t.Errorf("failureMessage(timeout) = %q, want %q", got, "更新失败: timeout")
+	}
+}
+
+func TestUpdateComponentRejectsUnknownComponent(t *testing.T) {
+	events := make(chan Event, 1)
+	c := &Controller{eventChan: events, done: make(chan struct{})}
+
+	c.handleCommand(Command{Type: CmdUpdateComponent, Payload: UpdateComponentPayload{Component: "unknown"}})
+	select {
+	case evt := <-events:
+		if evt.Type != EvtError {
+			t.Fatalf("event = %v, want %v", evt.Type, EvtError)
+		}
+	default:
+		t.Fatal("no event emitted for an unknown component")
+	}
+	if c.updating {
+		t.Error("updating = true after rejecting an unknown component")
+	}
+}
@@ -2,14 +2,137 @@ package fileutil
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+
 	"rime-wanxiang-updater/internal/config"
 )
 
-// ExtractZip 解压 ZIP 文件，支持排除模式
+// 解压限制：防止被篡改或配置错误的镜像通过压缩包写满磁盘
+const (
+	MaxArchiveEntries   = 100000  // 条目数上限
+	MaxArchiveTotalSize = 4 << 30 // 解压后总大小上限
+	MaxArchiveFileSize  = 1 << 30 // 单个文件解压后大小上限
+	MaxCompressionRatio = 200     // 单个文件解压/压缩比上限
+	ratioCheckThreshold = 1 << 20 // 小于该大小的文件不检查压缩比
+)
+
+// 拒绝解压的原因，包装在 *ArchiveError 中返回
+var (
+	ErrUnsafePath       = errors.New("entry path escapes destination")
+	ErrUnsupportedEntry = errors.New("symlink or special file entry")
+	ErrTooManyEntries   = errors.New("too many entries")
+	ErrArchiveTooLarge  = errors.New("archive uncompressed size exceeds limit")
+	ErrEntryTooLarge    = errors.New("entry uncompressed size exceeds limit")
+	ErrCompressionRatio = errors.New("entry compression ratio exceeds limit")
+)
+
+// ArchiveError 压缩包因安全检查未通过而被拒绝，Err 为上面的哨兵错误之一
+type ArchiveError struct {
+	Entry string
+	Err   error
+}
+
+func (e *ArchiveError) Error() string {
+	reasons := map[error]string{
+		ErrUnsafePath:       "路径超出解压目录",
+		ErrUnsupportedEntry: "包含符号链接或特殊文件",
+		ErrTooManyEntries:   "文件数量超过上限",
+		ErrArchiveTooLarge:  "解压后总大小超过上限",
+		ErrEntryTooLarge:    "单个文件超过大小上限",
+		ErrCompressionRatio: "压缩比异常",
+	}
+	reason, ok := reasons[e.Err]
+	if !ok {
+		reason = e.Err.Error()
+	}
+	if e.Entry == "" {
+		return fmt.Sprintf("压缩包已被拒绝: %s", reason)
+	}
+	return fmt.Sprintf("压缩包已被拒绝: %s (%s)", reason, e.Entry)
+}
+
+func (e *ArchiveError) Unwrap() error {
+	return e.Err
+}
+
+// checkArchive 在写入任何文件之前检查所有条目的路径、类型与声明大小
+func checkArchive(files []*zip.File, dest string) error {
+	if len(files) > MaxArchiveEntries {
+		return &ArchiveError{Err: ErrTooManyEntries}
+	}
+
+	var total uint64
+	for _, f := range files {
+		if err := checkEntryPath(f.Name, dest); err != nil {
+			return err
+		}
+
+		mode := f.Mode()
+		if !mode.IsDir() && !mode.IsRegular() {
+			return &ArchiveError{Entry: f.Name, Err: ErrUnsupportedEntry}
+		}
+		if mode.IsDir() {
+			continue
+		}
+
+		if f.UncompressedSize64 > MaxArchiveFileSize {
+			return &ArchiveError{Entry: f.Name, Err: ErrEntryTooLarge}
+		}
+		if f.UncompressedSize64 > ratioCheckThreshold &&
+			f.UncompressedSize64 > f.CompressedSize64*MaxCompressionRatio {
+			return &ArchiveError{Entry: f.Name, Err: ErrCompressionRatio}
+		}
+		total += f.UncompressedSize64
+		if total > MaxArchiveTotalSize {
+			return &ArchiveError{Entry: f.Name, Err: ErrArchiveTooLarge}
+		}
+	}
+
+	return nil
+}
+
+// checkEntryPath 拒绝绝对路径、含 .. 的路径，以及经由 dest 内已有符号链接指向外部的路径
+func checkEntryPath(name, dest string) error {
+	// 部分 Windows 工具生成的压缩包使用 \ 作为分隔符，统一按 / 检查
+	normalized := strings.TrimSuffix(strings.ReplaceAll(name, "\\", "/"), "/")
+	if !filepath.IsLocal(filepath.FromSlash(normalized)) {
+		return &ArchiveError{Entry: name, Err: ErrUnsafePath}
+	}
+
+	current := dest
+	for _, part := range strings.Split(path.Dir(normalized), "/") {
+		if part == "." {
+			break
+		}
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if err != nil {
+			break
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return &ArchiveError{Entry: name, Err: ErrUnsafePath}
+		}
+	}
+
+	return nil
+}
+
+// safeFileMode 忽略压缩包声明的特殊权限位，只保留是否可执行
+func safeFileMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// ExtractZip 解压 ZIP 文件，支持排除模式。
+// 解压前会检查全部条目，发现路径穿越、符号链接或超出大小限制时返回 *ArchiveError，不写入任何文件。
 func ExtractZip(src, dest string, excludeFiles []string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -17,6 +140,10 @@ func ExtractZip(src, dest string, excludeFiles []string) error {
 	}
 	defer r.Close()
 
+	if err := checkArchive(r.File, dest); err != nil {
+		return err
+	}
+
 	// 解析排除模式（只需解析一次）
 	excludePatterns, parseErrors := config.ParseExcludePatterns(excludeFiles)
 	if len(parseErrors) > 0 {
@@ -26,8 +153,10 @@ func ExtractZip(src, dest string, excludeFiles []string) error {
 		}
 	}
 
+	// 声明的大小可能与实际数据不符，解压时按实际写入量再次限制
+	var written int64
 	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
+		fpath := filepath.Join(dest, filepath.FromSlash(f.Name))
 
 		// 对于非目录文件：仅在目标位置已存在时才跳过匹配排除模式的文件。
 		// 这确保首次安装时默认配置文件（如 .custom.yaml）能被正确部署，
@@ -37,36 +166,52 @@ func ExtractZip(src, dest string, excludeFiles []string) error {
 		}
 
 		if f.FileInfo().IsDir() {
-			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+			if err := os.MkdirAll(fpath, 0755); err != nil {
 				return fmt.Errorf("创建目录失败: %w", err)
 			}
 			continue
 		}
 
-		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
 			return fmt.Errorf("创建父目录失败: %w", err)
 		}
 
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+		n, err := extractFile(f, fpath, MaxArchiveTotalSize-written)
+		written += n
 		if err != nil {
-			return fmt.Errorf("创建文件失败: %w", err)
+			return err
 		}
+	}
+	return nil
+}
 
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return fmt.Errorf("打开压缩文件失败: %w", err)
-		}
+// extractFile 解压单个文件，实际写入量超过单文件或剩余总量限制时返回 *ArchiveError
+func extractFile(f *zip.File, fpath string, remaining int64) (int64, error) {
+	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, safeFileMode(f.Mode()))
+	if err != nil {
+		return 0, fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer outFile.Close()
 
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
+	rc, err := f.Open()
+	if err != nil {
+		return 0, fmt.Errorf("打开压缩文件失败: %w", err)
+	}
+	defer rc.Close()
 
-		if err != nil {
-			return fmt.Errorf("解压文件失败: %w", err)
+	limit := min(int64(MaxArchiveFileSize), remaining)
+	n, err := io.Copy(outFile, io.LimitReader(rc, limit+1))
+	if err != nil {
+		return n, fmt.Errorf("解压文件失败: %w", err)
+	}
+	if n > limit {
+		if limit < MaxArchiveFileSize {
+			return n, &ArchiveError{Entry: f.Name, Err: ErrArchiveTooLarge}
 		}
+		return n, &ArchiveError{Entry: f.Name, Err: ErrEntryTooLarge}
 	}
-	return nil
+
+	return n, nil
 }
 
 // GetZipFileList 获取 ZIP 文件中的文件列表
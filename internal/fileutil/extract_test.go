@@ -0,0 +1,170 @@
+package fileutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type zipEntry struct {
+	name string
+	mode os.FileMode
+	data []byte
+}
+
+func writeZip(t *testing.T, path string, entries ...zipEntry) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.mode != 0 {
+			header.SetMode(entry.mode)
+		}
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("create entry %s: %v", entry.name, err)
+		}
+		if _, err := w.Write(entry.data); err != nil {
+			t.Fatalf("write entry %s: %v", entry.name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+}
+
+func TestExtractZipRejectsUnsafeArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		want    error
+	}{
+		{
+			name:    "parent directory traversal",
+			entries: []zipEntry{{name: "../evil.txt", data: []byte("x")}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "nested traversal",
+			entries: []zipEntry{{name: "lua/../../evil.txt", data: []byte("x")}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "backslash traversal",
+			entries: []zipEntry{{name: `..\evil.txt`, data: []byte("x")}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "absolute path",
+			entries: []zipEntry{{name: "/tmp/evil.txt", data: []byte("x")}},
+			want:    ErrUnsafePath,
+		},
+		{
+			name:    "symlink entry",
+			entries: []zipEntry{{name: "link", mode: os.ModeSymlink | 0777, data: []byte("/etc/passwd")}},
+			want:    ErrUnsupportedEntry,
+		},
+		{
+			name:    "compression bomb",
+			entries: []zipEntry{{name: "zeros.txt", data: make([]byte, 4<<20)}},
+			want:    ErrCompressionRatio,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			src := filepath.Join(tmpDir, "bad.zip")
+			dest := filepath.Join(tmpDir, "rime")
+			writeZip(t, src, append([]zipEntry{{name: "default.yaml", data: []byte("ok")}}, tt.entries...)...)
+
+			err := ExtractZip(src, dest, nil)
+			var archiveErr *ArchiveError
+			if !errors.As(err, &archiveErr) || !errors.Is(err, tt.want) {
+				t.Fatalf("ExtractZip() error = %v, want %v", err, tt.want)
+			}
+			// 检查在写入前完成，被拒绝的压缩包不应留下任何文件
+			if FileExists(filepath.Join(dest, "default.yaml")) {
+				t.Errorf("ExtractZip() wrote files before refusing the archive")
+			}
+		})
+	}
+}
+
+func TestExtractZipRejectsSymlinkedParent(t *testing.T) {
+	tmpDir := t.TempDir()
+	dest := filepath.Join(tmpDir, "rime")
+	outside := filepath.Join(tmpDir, "outside")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "lua")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	src := filepath.Join(tmpDir, "bad.zip")
+	writeZip(t, src, zipEntry{name: "lua/evil.lua", data: []byte("x")})
+
+	if err := ExtractZip(src, dest, nil); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("ExtractZip() error = %v, want %v", err, ErrUnsafePath)
+	}
+	if FileExists(filepath.Join(outside, "evil.lua")) {
+		t.Errorf("ExtractZip() wrote through a symlinked directory")
+	}
+}
+
+func TestExtractZipSanitizesModes(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "modes.zip")
+	dest := filepath.Join(tmpDir, "rime")
+	writeZip(t, src,
+		zipEntry{name: "setuid.sh", mode: os.ModeSetuid | 0777, data: []byte("#!/bin/sh")},
+		zipEntry{name: "plain.yaml", mode: 0666, data: []byte("a: 1")},
+	)
+
+	if err := ExtractZip(src, dest, nil); err != nil {
+		t.Fatalf("ExtractZip() error = %v", err)
+	}
+
+	tests := map[string]os.FileMode{"setuid.sh": 0755, "plain.yaml": 0644}
+	for name, want := range tests {
+		info, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		// umask 只会收紧权限，检查没有多出的位即可
+		if got := info.Mode(); got&^want != 0 {
+			t.Errorf("%s mode = %v, want at most %v", name, got, want)
+		}
+	}
+}
+
+func TestExtractFileEnforcesActualSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "lying.zip")
+	writeZip(t, src, zipEntry{name: "big.txt", data: bytes.Repeat([]byte("abcdefgh"), 64)})
+
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer r.Close()
+
+	_, err = extractFile(r.File[0], filepath.Join(tmpDir, "big.txt"), 100)
+	if !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("extractFile() error = %v, want %v", err, ErrArchiveTooLarge)
+	}
+}
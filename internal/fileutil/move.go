@@ -28,6 +28,11 @@ func MoveFile(src, dst string) error {
 	return nil
 }
 
+// CopyFile 复制文件并保留权限，目标文件已存在时覆盖
+func CopyFile(src, dst string) error {
+	return copyFile(src, dst)
+}
+
 // copyFile 复制文件
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
@@ -0,0 +1,78 @@
+package fileutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"rime-wanxiang-updater/internal/config"
+)
+
+// StagingDir 返回 dest 旁边的暂存目录路径。
+// 暂存目录与 dest 位于同一父目录（通常也是同一文件系统），保证之后的重命名不需要跨分区复制。
+func StagingDir(dest, name string) string {
+	dest = filepath.Clean(dest)
+	base := fmt.Sprintf(".%s-%s-%s", filepath.Base(dest), name, strconv.FormatInt(time.Now().UnixNano(), 10))
+	return filepath.Join(filepath.Dir(dest), base)
+}
+
+// VerifyKeyFiles 检查暂存目录中是否包含所有关键文件（相对路径，使用 / 分隔）
+func VerifyKeyFiles(dir string, keyFiles ...string) error {
+	for _, keyFile := range keyFiles {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(keyFile)))
+		if err != nil || info.IsDir() {
+			return fmt.Errorf("更新包缺少关键文件: %s", keyFile)
+		}
+	}
+	return nil
+}
+
+// InstallStaged 将暂存目录中已解压并校验过的文件逐个重命名到 dest，返回实际安装的文件（相对路径，/ 分隔）。
+// 排除规则与 ExtractZip 一致：匹配排除模式且目标位置已存在的文件保持原样，不计入返回值。
+// 所有文件在移动前都已完整写入，同一文件系统内每个文件的替换都是一次原子重命名。
+func InstallStaged(stagingDir, dest string, excludeFiles []string) ([]string, error) {
+	excludePatterns, parseErrors := config.ParseExcludePatterns(excludeFiles)
+	if len(parseErrors) > 0 {
+		// 记录解析错误但继续执行
+		for _, parseErr := range parseErrors {
+			fmt.Fprintf(os.Stderr, "警告：排除模式解析失败: %v\n", parseErr)
+		}
+	}
+
+	var installed []string
+	err := filepath.WalkDir(stagingDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(stagingDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return os.MkdirAll(dest, os.ModePerm)
+		}
+
+		target := filepath.Join(dest, rel)
+		if entry.IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return fmt.Errorf("创建目录失败: %w", err)
+			}
+			return nil
+		}
+
+		if config.MatchAny(filepath.ToSlash(rel), excludePatterns) && FileExists(target) {
+			return nil
+		}
+
+		if err := MoveFile(path, target); err != nil {
+			return fmt.Errorf("移动文件 %s 失败: %w", filepath.ToSlash(rel), err)
+		}
+		installed = append(installed, filepath.ToSlash(rel))
+		return nil
+	})
+	return installed, err
+}
@@ -0,0 +1,87 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeStageFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestStagingDirIsSibling(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "rime")
+	staging := StagingDir(dest, "staging")
+
+	if filepath.Dir(staging) != filepath.Dir(dest) {
+		t.Errorf("StagingDir() = %s, want a sibling of %s", staging, dest)
+	}
+	if staging == dest {
+		t.Errorf("StagingDir() returned dest itself")
+	}
+}
+
+func TestVerifyKeyFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeStageFile(t, filepath.Join(dir, "lua", "wanxiang.lua"), "lua")
+
+	if err := VerifyKeyFiles(dir, "lua/wanxiang.lua"); err != nil {
+		t.Errorf("VerifyKeyFiles() error = %v, want nil", err)
+	}
+	if err := VerifyKeyFiles(dir, "chengyu.txt"); err == nil {
+		t.Error("VerifyKeyFiles() error = nil, want missing key file error")
+	}
+	if err := VerifyKeyFiles(dir, "lua"); err == nil {
+		t.Error("VerifyKeyFiles() accepted a directory as key file")
+	}
+}
+
+func TestInstallStaged(t *testing.T) {
+	tmpDir := t.TempDir()
+	staging := filepath.Join(tmpDir, ".rime-staging")
+	dest := filepath.Join(tmpDir, "rime")
+
+	writeStageFile(t, filepath.Join(staging, "default.yaml"), "upstream")
+	writeStageFile(t, filepath.Join(staging, "lua", "wanxiang.lua"), "upstream")
+	writeStageFile(t, filepath.Join(staging, "user.custom.yaml"), "upstream")
+	writeStageFile(t, filepath.Join(staging, "new.custom.yaml"), "upstream")
+
+	writeStageFile(t, filepath.Join(dest, "default.yaml"), "local")
+	writeStageFile(t, filepath.Join(dest, "user.custom.yaml"), "local")
+
+	installed, err := InstallStaged(staging, dest, []string{"*.custom.yaml"})
+	if err != nil {
+		t.Fatalf("InstallStaged() error = %v", err)
+	}
+	if len(installed) != 3 {
+		t.Errorf("InstallStaged() installed = %v, want 3 files", installed)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"default.yaml", "upstream"},
+		{"lua/wanxiang.lua", "upstream"},
+		{"user.custom.yaml", "local"},   // 已存在的排除文件保持原样
+		{"new.custom.yaml", "upstream"}, // 不存在的排除文件照常安装
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(tt.file)))
+		if err != nil {
+			t.Errorf("read %s: %v", tt.file, err)
+			continue
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.file, data, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package fileutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CreateTarGz 将 srcDir 第一层的普通文件打包为 dest（.tar.gz），子目录和其他类型的文件会被忽略
+func CreateTarGz(srcDir, dest string) (err error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return fmt.Errorf("读取目录失败: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("创建压缩包失败: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if err := addTarFile(tw, filepath.Join(srcDir, name), name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	return nil
+}
+
+func addTarFile(tw *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	return nil
+}
+
+// ExtractTarGz 解压 .tar.gz 文件到 dest。
+// 与 ExtractZip 相同，拒绝路径穿越、符号链接和特殊文件，并限制条目数和解压后的大小，违反时返回 *ArchiveError；
+// tar 无法预先检查全部条目，被拒绝时 dest 中可能已写入部分文件，调用方应使用临时目录
+func ExtractTarGz(src, dest string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("读取压缩包失败: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	var entries int
+	var total int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取压缩包失败: %w", err)
+		}
+
+		if entries++; entries > MaxArchiveEntries {
+			return &ArchiveError{Err: ErrTooManyEntries}
+		}
+		if err := checkEntryPath(header.Name, dest); err != nil {
+			return err
+		}
+		fpath := filepath.Join(dest, filepath.FromSlash(header.Name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fpath, 0755); err != nil {
+				return err
+			}
+			continue
+		case tar.TypeReg:
+		default:
+			return &ArchiveError{Entry: header.Name, Err: ErrUnsupportedEntry}
+		}
+
+		if header.Size > MaxArchiveFileSize {
+			return &ArchiveError{Entry: header.Name, Err: ErrEntryTooLarge}
+		}
+		if total += header.Size; total > MaxArchiveTotalSize {
+			return &ArchiveError{Entry: header.Name, Err: ErrArchiveTooLarge}
+		}
+		if err := extractTarFile(tr, fpath, header); err != nil {
+			return err
+		}
+	}
+}
+
+func extractTarFile(r io.Reader, fpath string, header *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, safeFileMode(os.FileMode(header.Mode)))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.CopyN(out, r, header.Size); err != nil {
+		return fmt.Errorf("解压文件失败: %w", err)
+	}
+	return nil
+}
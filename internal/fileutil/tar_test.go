@@ -0,0 +1,75 @@
+package fileutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarGzRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	for name, content := range map[string]string{"a.zip": "scheme", "bundle.json": "{}"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := CreateTarGz(src, archive); err != nil {
+		t.Fatalf("CreateTarGz() error = %v", err)
+	}
+	dest := t.TempDir()
+	if err := ExtractTarGz(archive, dest); err != nil {
+		t.Fatalf("ExtractTarGz() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "a.zip"))
+	if err != nil || string(data) != "scheme" {
+		t.Errorf("a.zip = %q, %v, want scheme", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "sub")); !os.IsNotExist(err) {
+		t.Errorf("sub directory should not be packed: %v", err)
+	}
+}
+
+func TestExtractTarGzRejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		header tar.Header
+		want   error
+	}{
+		{"path traversal", tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0644}, ErrUnsafePath},
+		{"absolute path", tar.Header{Name: "/etc/evil", Typeflag: tar.TypeReg, Mode: 0644}, ErrUnsafePath},
+		{"symlink", tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}, ErrUnsupportedEntry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "bad.tar.gz")
+			file, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gz := gzip.NewWriter(file)
+			tw := tar.NewWriter(gz)
+			if err := tw.WriteHeader(&tt.header); err != nil {
+				t.Fatal(err)
+			}
+			tw.Close()
+			gz.Close()
+			file.Close()
+
+			err = ExtractTarGz(archive, t.TempDir())
+			var archiveErr *ArchiveError
+			if !errors.As(err, &archiveErr) || !errors.Is(err, tt.want) {
+				t.Errorf("ExtractTarGz() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
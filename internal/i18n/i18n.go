@@ -25,6 +25,8 @@ var catalogs = map[Locale]map[string]string{
 		"menu.scheme_update.desc":                  "更新完整方案包，适合升级到新的版本发布。",
 		"menu.model_update.title":                  "模型更新",
 		"menu.model_update.desc":                   "更新语法模型文件，不影响其他资源。",
+		"menu.component_update.title":              "%s更新",
+		"menu.component_update.desc":               "只更新%s，不影响其他资源。",
 		"menu.config.title":                        "查看配置",
 		"menu.config.desc":                         "检查下载源、自动更新、代理和 Hook 等设置。",
 		"menu.theme.title":                         "切换主题 (%s)",
@@ -33,6 +35,77 @@ var catalogs = map[Locale]map[string]string{
 		"menu.custom.desc":                         "调整程序 TUI 界面，并在支持的平台写入主题 patch。",
 		"menu.wizard.title":                        "设置向导",
 		"menu.wizard.desc":                         "重新选择方案、辅助码和下载源。",
+		"menu.tools.title":                         "维护工具",
+		"menu.tools.desc":                          "预览更新内容、卸载等维护操作。",
+		"tools.menu.title":                         "维护工具",
+		"tools.menu.subtitle":                      "以下操作在你确认前不会修改 Rime 用户目录。",
+		"tools.dry_run.title":                      "预览更新",
+		"tools.dry_run.desc":                       "下载到缓存并列出将新增、覆盖、删除和保留的文件。",
+		"tools.uninstall.title":                    "卸载万象文件",
+		"tools.uninstall.desc":                     "按安装清单删除本程序安装的方案、词库和模型文件，保留用户文件。",
+		"uninstall.confirm_title":                  "确认卸载",
+		"uninstall.confirm_body":                   "将删除安装清单中记录、且安装后未被修改的方案、词库和模型文件。\n用户文件和修改过的文件会保留。\n按 Enter 确认卸载，按其他键返回。",
+		"uninstall.hint.confirm":                   "Enter 确认卸载",
+		"uninstall.hint.return":                    "其他键返回",
+		"uninstall.result.component":               "%s：已删除 %d 个文件，保留 %d 个修改过的文件",
+		"uninstall.result.no_manifest":             "%s：未找到安装清单，已跳过",
+		"uninstall.result.failed":                  "%s：%v",
+		"uninstall.result.error":                   "卸载失败: %v",
+		"uninstall.result.redeploy":                "请重新部署 Rime 使更改生效。",
+		"tools.verify.title":                       "校验文件",
+		"tools.verify.desc":                        "检查方案和词库是否有缺失、被修改或多余的文件，可从缓存的更新包修复。",
+		"verify.title":                             "文件校验",
+		"verify.error":                             "校验失败: %s",
+		"verify.reference.manifest":                "依据安装清单",
+		"verify.reference.archive":                 "依据缓存的更新包",
+		"verify.target":                            "目录: %s",
+		"verify.counts":                            "缺失 %d · 修改 %d · 多余 %d · 已修复 %d",
+		"verify.clean":                             "文件完整，没有缺失或被修改的文件",
+		"verify.hint.repair":                       "Enter 修复",
+		"tools.rollback.title":                     "回滚到历史版本",
+		"tools.rollback.desc":                      "从缓存重新安装之前的方案、词库或模型版本，不需要联网。",
+		"rollback.title":                           "回滚到历史版本",
+		"rollback.subtitle":                        "选择要重新安装的版本，当前版本会被保留，之后可以再回到它",
+		"rollback.empty":                           "没有可回滚的历史版本；每次更新前会自动保留当前版本",
+		"rollback.error":                           "读取历史版本失败: %s",
+		"rollback.hint.apply":                      "Enter 回滚",
+		"tools.pins.title":                         "固定版本",
+		"tools.pins.desc":                          "将方案、词库或模型固定在当前安装的版本，更新时不再升级它。",
+		"pins.title":                               "固定版本",
+		"pins.subtitle":                            "固定的组件在检查更新时只认固定的版本，其他组件照常更新",
+		"pins.not_installed":                       "未安装",
+		"pins.unpinned":                            "未固定",
+		"pins.pinned":                              "已固定: %s",
+		"pins.hint.toggle":                         "Enter 固定 / 取消固定",
+		"tools.channels.title":                     "发布渠道",
+		"tools.channels.desc":                      "为方案、词库和模型分别选择正式版、每日构建或预发布版本。",
+		"channels.title":                           "发布渠道",
+		"channels.subtitle":                        "正式版只用带版本号的发布；每日构建接受 dict-nightly 等滚动发布；预发布在每日构建之外还接受标记为预发布的版本",
+		"channels.stable":                          "正式版",
+		"channels.nightly":                         "每日构建",
+		"channels.prerelease":                      "预发布",
+		"channels.default":                         "（默认）",
+		"channels.hint.change":                     "←→ / Enter 切换渠道",
+		"tools.history.title":                      "更新历史",
+		"tools.history.desc":                       "查看每次更新和回滚的版本、来源、耗时与结果，包括失败的记录。",
+		"history.title":                            "更新历史",
+		"history.empty":                            "还没有更新记录",
+		"history.action.update":                    "更新",
+		"history.action.rollback":                  "回滚",
+		"history.action.import":                    "离线导入",
+		"history.outcome.success":                  "成功",
+		"history.outcome.failed":                   "失败",
+		"history.outcome.rolled_back":              "已撤销",
+		"history.error":                            "读取更新历史失败: %s",
+		"dryrun.title":                             "更新预览",
+		"dryrun.error":                             "预览失败: %s",
+		"dryrun.up_to_date":                        "已是最新版本，无文件变更",
+		"dryrun.target":                            "目标目录: %s",
+		"dryrun.counts":                            "新增 %d · 覆盖 %d · 删除 %d · 保留 %d",
+		"dryrun.position":                          "%d-%d / %d 行",
+		"dryrun.empty":                             "没有可显示的预览结果",
+		"dryrun.hint.apply":                        "Enter 应用更新",
+		"ui.hint.scroll":                           "↑↓ / PgUp PgDn 滚动",
 		"menu.quit.title":                          "退出程序",
 		"menu.quit.desc":                           "结束当前会话并返回终端。",
 		"menu.summary.scheme":                      "当前方案:",
@@ -46,8 +119,9 @@ var catalogs = map[Locale]map[string]string{
 		"menu.auto_update.countdown":               "自动更新将在 %d 秒后开始... (按 Esc 取消)",
 		"menu.auto_update.in":                      "%d 秒后自动开始",
 		"menu.auto_update.cancelled":               "已取消自动更新",
-		"menu.hint":                                "[1-8] 快捷执行 | J/K 或方向键移动 | Enter 确认 | Q 退出",
+		"menu.hint":                                "[1-9] 快捷执行 | J/K 或方向键移动 | Enter 确认 | Q 退出",
 		"updating.stage.preparing":                 "准备中",
+		"updating.checking_component":              "检查%s更新...",
 		"updating.stage":                           "当前阶段: %s",
 		"updating.state":                           "状态:",
 		"updating.source":                          "来源:",
@@ -55,9 +129,16 @@ var catalogs = map[Locale]map[string]string{
 		"updating.url":                             "下载地址:",
 		"updating.progress":                        "进度:",
 		"updating.speed":                           "速度:",
-		"updating.notice":                          "更新过程中暂不支持取消。完成后会自动返回结果页。",
-		"updating.hint":                            "[Ctrl+C] 退出程序 | 其余按键在更新中不会中断任务",
+		"updating.notice":                          "按 Esc 取消：下载立即停止，正在安装的组件会完成或回滚，Rime 目录保持一致。",
+		"updating.cancelling":                      "正在取消，等待当前步骤结束...",
+		"updating.hint":                            "[Esc] 取消更新 | [Ctrl+C] 退出程序",
 		"result.failure":                           "更新失败",
+		"result.cancelled":                         "更新已取消，Rime 目录保持更新前的状态。",
+		"result.archive.unsafe_path":               "更新包中有文件试图写到 Rime 目录之外（%s），已拒绝安装。下载源可能被篡改，建议切换下载源后重试。",
+		"result.archive.unsupported":               "更新包中包含符号链接或特殊文件（%s），已拒绝安装。",
+		"result.archive.too_many":                  "更新包中的文件数量超出上限，已拒绝安装。",
+		"result.archive.too_large":                 "更新包解压后体积超出上限（%s），已拒绝安装。",
+		"result.archive.ratio":                     "更新包中的文件压缩比异常（%s），疑似压缩炸弹，已拒绝安装。",
 		"result.success":                           "更新完成",
 		"result.skipped":                           "已经是最新状态",
 		"result.updated_count":                     "已更新:",
@@ -72,7 +153,7 @@ var catalogs = map[Locale]map[string]string{
 		"ui.badge.skipped":                         "已跳过",
 		"ui.hint.nav":                              "↑↓ / J K",
 		"ui.hint.select":                           "Enter 选择",
-		"ui.hint.shortcuts":                        "1-8 快捷操作",
+		"ui.hint.shortcuts":                        "1-9 快捷操作",
 		"ui.hint.edit":                             "Enter 编辑",
 		"ui.hint.back":                             "Esc 返回",
 		"ui.hint.apply_theme":                      "Enter 应用主题",
@@ -80,6 +161,7 @@ var catalogs = map[Locale]map[string]string{
 		"ui.hint.save":                             "Enter 保存",
 		"ui.hint.add":                              "Enter 添加",
 		"ui.hint.exit":                             "Ctrl+C 退出",
+		"ui.hint.cancel":                           "Esc 取消",
 		"ui.hint.live_progress":                    "下载详情实时刷新",
 		"ui.hint.switch_option":                    "方向键切换选项",
 		"ui.hint.menu_return":                      "Enter 返回菜单",
@@ -104,7 +186,13 @@ var catalogs = map[Locale]map[string]string{
 		"wizard.source.cnb":                        "CNB 镜像（推荐，国内访问更快）",
 		"wizard.source.github":                     "GitHub 官方源",
 		"wizard.hint.1_2":                          "[1-2] 选择 | [Q] 退出",
+		"wizard.hint.source":                       "[1-2] 选择 | [T] 重新测速 | [Q] 退出",
 		"wizard.hint.1_7":                          "[1-7] 选择 | [Q] 退出",
+		"wizard.model":                             "选择语言模型:",
+		"wizard.model.hans":                        "简体中文模型",
+		"wizard.model.hant":                        "繁体中文模型",
+		"wizard.model.both":                        "同时安装简体和繁体模型",
+		"wizard.hint.1_3":                          "[1-3] 选择 | [Q] 退出",
 		"menu.title":                               "主控制面板",
 		"config.title":                             "系统配置",
 		"config.field.engine":                      "引擎",
@@ -122,8 +210,14 @@ var catalogs = map[Locale]map[string]string{
 		"config.field.proxy_enabled":               "代理启用",
 		"config.field.proxy_type":                  "代理类型",
 		"config.field.proxy_address":               "代理地址",
+		"config.field.github_api_url":              "GitHub API 地址",
+		"config.field.github_proxy":                "GitHub 下载代理",
 		"config.field.pre_hook":                    "更新前 Hook",
 		"config.field.post_hook":                   "更新后 Hook",
+		"config.field.model_files":                 "语言模型",
+		"config.field.require_checksum":            "强制校验下载",
+		"config.field.auto_select_source":          "自动选择下载源",
+		"config.field.probe_sources":               "下载源测速",
 		"config.field.exclude":                     "管理排除文件",
 		"config.field.theme_adaptive":              "自适应主题",
 		"config.field.theme_light":                 "浅色主题",
@@ -145,13 +239,27 @@ var catalogs = map[Locale]map[string]string{
 		"config.edit.hint.proxy_type":              "输入代理类型: http/https/socks5",
 		"config.edit.hint.proxy_addr":              "输入代理地址，例如 127.0.0.1:7890",
 		"config.edit.hint.pre_hook":                "脚本路径，例如 ~/backup.sh；更新前执行，失败会取消更新",
+		"config.edit.hint.github_api_url":          "api.github.com 的反向代理地址，例如 https://gh-api.example.com；留空使用官方地址 | Ctrl+T 测试连接",
+		"config.edit.hint.github_proxy":            "下载加速前缀，下载地址改写为 <前缀>/https://github.com/...，例如 https://proxy.example；留空直连 | Ctrl+T 测试连接",
 		"config.edit.hint.post_hook":               "脚本路径，例如 ~/notify.sh；更新后执行，失败不影响更新结果",
+		"config.edit.hint.model_files":             "模型文件名或通配符，多个用逗号分隔，例如 wanxiang-lts-zh-hans.gram,*zh-hant*.gram；留空只安装简体模型",
 		"config.edit.hint.theme":                   "启用后根据终端明暗自动切换主题 | [1] 启用  [2] 禁用",
+		"config.edit.hint.require_checksum":        "启用后发布没有提供 SHA256 的文件将拒绝安装 | [1] 启用  [2] 禁用",
+		"config.edit.hint.auto_select_source":      "启用后每次更新前测速，改用较快的下载源 | [1] 启用  [2] 禁用",
 		"config.option.enable":                     "启用",
 		"config.option.disable":                    "禁用",
 		"config.language.zh":                       "简体中文",
 		"config.language.en":                       "English",
 		"config.value.unset":                       "(未设置)",
+		"config.value.default":                     "(默认)",
+		"config.connection.testing":                "正在测试连接...",
+		"config.connection.ok":                     "连接成功（%d 毫秒）",
+		"config.connection.failed":                 "测试未通过: %v",
+		"probe.testing":                            "正在测速...",
+		"probe.not_run":                            "(未测速，按回车开始)",
+		"probe.result":                             "DNS %d 毫秒 · TLS %d 毫秒 · 首字节 %d 毫秒 · %.1f MB/s",
+		"probe.failed":                             "测速失败: %v",
+		"probe.recommend":                          "测速推荐: %s",
 		"config.value.all_engines":                 "全部引擎",
 		"config.value.enabled":                     "启用",
 		"config.value.disabled":                    "禁用",
@@ -276,6 +384,15 @@ var catalogs = map[Locale]map[string]string{
 		"fcitx.backup":                             "备份后删除",
 		"fcitx.no_prompt":                          "不再提示，记住我的选择",
 		"fcitx.hint":                               "[1-2] 或方向键选择 | [Space/Enter] 切换/确认 | [Esc] 取消",
+		"modified.title":                           "本地修改的文件",
+		"modified.detected":                        "%s中有 %d 个文件在安装后被修改过，本次更新会覆盖它们:",
+		"modified.more":                            "…… 另有 %d 个文件",
+		"modified.question":                        "请选择如何处理:",
+		"modified.keep":                            "保留我的版本",
+		"modified.overwrite":                       "使用新版本",
+		"modified.backup":                          "备份为 .local 后更新",
+		"modified.remember":                        "记住我的选择，以后不再询问",
+		"modified.hint":                            "[1-3] 或方向键选择 | [Space/Enter] 切换/确认 | [Esc] 保留我的版本",
 		"updating.title":                           "正在更新",
 		"result.title":                             "更新结果",
 	},
@@ -289,6 +406,8 @@ var catalogs = map[Locale]map[string]string{
 		"menu.scheme_update.desc":                  "Update the full scheme package for new releases.",
 		"menu.model_update.title":                  "Model Update",
 		"menu.model_update.desc":                   "Update the grammar model without touching other assets.",
+		"menu.component_update.title":              "%s Update",
+		"menu.component_update.desc":               "Update the %s only without touching other assets.",
 		"menu.config.title":                        "Settings",
 		"menu.config.desc":                         "Review source, auto update, proxy, and hook settings.",
 		"menu.theme.title":                         "Theme (%s)",
@@ -297,6 +416,77 @@ var catalogs = map[Locale]map[string]string{
 		"menu.custom.desc":                         "Adjust the program TUI and write supported theme patch files.",
 		"menu.wizard.title":                        "Setup Wizard",
 		"menu.wizard.desc":                         "Re-select scheme, helper code, and download source.",
+		"menu.tools.title":                         "Maintenance",
+		"menu.tools.desc":                          "Preview pending changes, uninstall, and other maintenance tasks.",
+		"tools.menu.title":                         "Maintenance",
+		"tools.menu.subtitle":                      "Nothing here touches the Rime user directory until you confirm.",
+		"tools.dry_run.title":                      "Preview Update",
+		"tools.dry_run.desc":                       "Download into the cache and list files to be added, overwritten, deleted, or kept.",
+		"tools.uninstall.title":                    "Uninstall Wanxiang Files",
+		"tools.uninstall.desc":                     "Remove the scheme, dictionary, and model files this tool installed, using the install manifests. User files are kept.",
+		"uninstall.confirm_title":                  "Confirm Uninstall",
+		"uninstall.confirm_body":                   "Scheme, dictionary, and model files recorded in the install manifests and unchanged since installation will be deleted.\nUser files and modified files are kept.\nPress Enter to uninstall, or press any other key to return.",
+		"uninstall.hint.confirm":                   "Enter Uninstall",
+		"uninstall.hint.return":                    "Any key Return",
+		"uninstall.result.component":               "%s: deleted %d files, kept %d modified files",
+		"uninstall.result.no_manifest":             "%s: no install manifest found, skipped",
+		"uninstall.result.failed":                  "%s: %v",
+		"uninstall.result.error":                   "Uninstall failed: %v",
+		"uninstall.result.redeploy":                "Redeploy Rime for the changes to take effect.",
+		"tools.verify.title":                       "Verify Files",
+		"tools.verify.desc":                        "Check scheme and dictionary files for missing, modified, or unexpected files, and repair them from the cached package.",
+		"verify.title":                             "File Verification",
+		"verify.error":                             "Verification failed: %s",
+		"verify.reference.manifest":                "Checked against install manifest",
+		"verify.reference.archive":                 "Checked against cached package",
+		"verify.target":                            "Directory: %s",
+		"verify.counts":                            "Missing %d · Modified %d · Unexpected %d · Repaired %d",
+		"verify.clean":                             "All files intact; nothing missing or modified",
+		"verify.hint.repair":                       "Enter Repair",
+		"tools.rollback.title":                     "Roll Back",
+		"tools.rollback.desc":                      "Reinstall an earlier scheme, dictionary, or model version from the cache, without going online.",
+		"rollback.title":                           "Roll Back to an Earlier Version",
+		"rollback.subtitle":                        "Pick a version to reinstall; the current version is kept so you can return to it",
+		"rollback.empty":                           "No earlier versions yet; the current version is kept automatically before each update",
+		"rollback.error":                           "Failed to read earlier versions: %s",
+		"rollback.hint.apply":                      "Enter Roll back",
+		"tools.pins.title":                         "Pin Versions",
+		"tools.pins.desc":                          "Freeze the scheme, dictionary, or model at the installed version so updates leave it alone.",
+		"pins.title":                               "Pin Versions",
+		"pins.subtitle":                            "A pinned component only accepts its pinned version; the others keep updating",
+		"pins.not_installed":                       "Not installed",
+		"pins.unpinned":                            "Not pinned",
+		"pins.pinned":                              "Pinned: %s",
+		"pins.hint.toggle":                         "Enter Pin / Unpin",
+		"tools.channels.title":                     "Release Channels",
+		"tools.channels.desc":                      "Choose stable, nightly, or prerelease builds for the scheme, dictionary, and model separately.",
+		"channels.title":                           "Release Channels",
+		"channels.subtitle":                        "Stable uses versioned releases only; nightly also takes rolling tags like dict-nightly; prerelease takes everything nightly does plus releases marked as prerelease",
+		"channels.stable":                          "Stable",
+		"channels.nightly":                         "Nightly",
+		"channels.prerelease":                      "Prerelease",
+		"channels.default":                         " (default)",
+		"channels.hint.change":                     "←→ / Enter Change channel",
+		"tools.history.title":                      "Update History",
+		"tools.history.desc":                       "See the version, source, duration, and outcome of every update and rollback, failures included.",
+		"history.title":                            "Update History",
+		"history.empty":                            "No updates recorded yet",
+		"history.action.update":                    "Update",
+		"history.action.rollback":                  "Rollback",
+		"history.action.import":                    "Import",
+		"history.outcome.success":                  "Succeeded",
+		"history.outcome.failed":                   "Failed",
+		"history.outcome.rolled_back":              "Undone",
+		"history.error":                            "Could not read the update history: %s",
+		"dryrun.title":                             "Update Preview",
+		"dryrun.error":                             "Preview failed: %s",
+		"dryrun.up_to_date":                        "Already up to date, no file changes",
+		"dryrun.target":                            "Target directory: %s",
+		"dryrun.counts":                            "Added %d · Overwritten %d · Deleted %d · Kept %d",
+		"dryrun.position":                          "Lines %d-%d of %d",
+		"dryrun.empty":                             "No preview result to show",
+		"dryrun.hint.apply":                        "Enter Apply update",
+		"ui.hint.scroll":                           "↑↓ / PgUp PgDn Scroll",
 		"menu.quit.title":                          "Quit",
 		"menu.quit.desc":                           "Leave the updater and return to the terminal.",
 		"menu.summary.scheme":                      "Scheme:",
@@ -310,8 +500,9 @@ var catalogs = map[Locale]map[string]string{
 		"menu.auto_update.countdown":               "Auto update starts in %ds. Press Esc to cancel.",
 		"menu.auto_update.in":                      "starts in %ds",
 		"menu.auto_update.cancelled":               "Auto update cancelled",
-		"menu.hint":                                "[1-8] Quick action | J/K or arrows to move | Enter to confirm | Q to quit",
+		"menu.hint":                                "[1-9] Quick action | J/K or arrows to move | Enter to confirm | Q to quit",
 		"updating.stage.preparing":                 "Preparing",
+		"updating.checking_component":              "Checking %s updates...",
 		"updating.stage":                           "Stage: %s",
 		"updating.state":                           "Status:",
 		"updating.source":                          "Source:",
@@ -319,9 +510,16 @@ var catalogs = map[Locale]map[string]string{
 		"updating.url":                             "Download URL:",
 		"updating.progress":                        "Progress:",
 		"updating.speed":                           "Speed:",
-		"updating.notice":                          "Cancellation is not supported during updates. Results appear when the task finishes.",
-		"updating.hint":                            "[Ctrl+C] Exit program | Other keys will not interrupt the update",
+		"updating.notice":                          "Press Esc to cancel: downloads stop at once, a component being installed finishes or rolls back, and the Rime directory stays consistent.",
+		"updating.cancelling":                      "Cancelling, waiting for the current step to finish...",
+		"updating.hint":                            "[Esc] Cancel update | [Ctrl+C] Exit program",
 		"result.failure":                           "Update failed",
+		"result.cancelled":                         "The update was cancelled. The Rime directory is as it was before the update.",
+		"result.archive.unsafe_path":               "The package tried to write outside the Rime directory (%s) and was refused. The download source may be compromised; switch sources and retry.",
+		"result.archive.unsupported":               "The package contains a symlink or special file (%s) and was refused.",
+		"result.archive.too_many":                  "The package contains too many files and was refused.",
+		"result.archive.too_large":                 "The package is too large once extracted (%s) and was refused.",
+		"result.archive.ratio":                     "A file in the package has a suspicious compression ratio (%s) and was refused.",
 		"result.success":                           "Update complete",
 		"result.skipped":                           "Already up to date",
 		"result.updated_count":                     "Updated:",
@@ -336,7 +534,7 @@ var catalogs = map[Locale]map[string]string{
 		"ui.badge.skipped":                         "Skipped",
 		"ui.hint.nav":                              "↑↓ / J K",
 		"ui.hint.select":                           "Enter Select",
-		"ui.hint.shortcuts":                        "1-8 Quick actions",
+		"ui.hint.shortcuts":                        "1-9 Quick actions",
 		"ui.hint.edit":                             "Enter Edit",
 		"ui.hint.back":                             "Esc Back",
 		"ui.hint.apply_theme":                      "Enter Apply theme",
@@ -344,6 +542,7 @@ var catalogs = map[Locale]map[string]string{
 		"ui.hint.save":                             "Enter Save",
 		"ui.hint.add":                              "Enter Add",
 		"ui.hint.exit":                             "Ctrl+C Exit",
+		"ui.hint.cancel":                           "Esc Cancel",
 		"ui.hint.live_progress":                    "Live download details",
 		"ui.hint.switch_option":                    "Arrows switch options",
 		"ui.hint.menu_return":                      "Enter Main menu",
@@ -368,7 +567,13 @@ var catalogs = map[Locale]map[string]string{
 		"wizard.source.cnb":                        "CNB Mirror (recommended for domestic access)",
 		"wizard.source.github":                     "GitHub",
 		"wizard.hint.1_2":                          "[1-2] Select | [Q] Quit",
+		"wizard.hint.source":                       "[1-2] Select | [T] Test again | [Q] Quit",
 		"wizard.hint.1_7":                          "[1-7] Select | [Q] Quit",
+		"wizard.model":                             "Choose language models:",
+		"wizard.model.hans":                        "Simplified Chinese model",
+		"wizard.model.hant":                        "Traditional Chinese model",
+		"wizard.model.both":                        "Install both Simplified and Traditional models",
+		"wizard.hint.1_3":                          "[1-3] Select | [Q] Quit",
 		"menu.title":                               "Control Panel",
 		"config.title":                             "Settings",
 		"config.field.engine":                      "Engine",
@@ -386,8 +591,14 @@ var catalogs = map[Locale]map[string]string{
 		"config.field.proxy_enabled":               "Proxy enabled",
 		"config.field.proxy_type":                  "Proxy type",
 		"config.field.proxy_address":               "Proxy address",
+		"config.field.github_api_url":              "GitHub API URL",
+		"config.field.github_proxy":                "GitHub download proxy",
 		"config.field.pre_hook":                    "Pre-update hook",
 		"config.field.post_hook":                   "Post-update hook",
+		"config.field.model_files":                 "Language models",
+		"config.field.require_checksum":            "Require checksums",
+		"config.field.auto_select_source":          "Auto-select source",
+		"config.field.probe_sources":               "Source speed test",
 		"config.field.exclude":                     "Manage excluded files",
 		"config.field.theme_adaptive":              "Adaptive theme",
 		"config.field.theme_light":                 "Light theme",
@@ -409,13 +620,27 @@ var catalogs = map[Locale]map[string]string{
 		"config.edit.hint.proxy_type":              "Enter proxy type: http/https/socks5",
 		"config.edit.hint.proxy_addr":              "Enter proxy address, for example 127.0.0.1:7890",
 		"config.edit.hint.pre_hook":                "Script path, for example ~/backup.sh; runs before updates and cancels on failure",
+		"config.edit.hint.github_api_url":          "Reverse proxy of api.github.com, for example https://gh-api.example.com; leave empty for the official API | Ctrl+T to test",
+		"config.edit.hint.github_proxy":            "Download prefix; URLs become <prefix>/https://github.com/..., for example https://proxy.example; leave empty to connect directly | Ctrl+T to test",
 		"config.edit.hint.post_hook":               "Script path, for example ~/notify.sh; runs after updates and does not change the final result",
+		"config.edit.hint.model_files":             "Model file names or wildcards separated by commas, for example wanxiang-lts-zh-hans.gram,*zh-hant*.gram; leave empty for the Simplified model only",
 		"config.edit.hint.theme":                   "Switch themes automatically based on terminal background | [1] Enable  [2] Disable",
+		"config.edit.hint.require_checksum":        "Refuse to install files whose release provides no SHA256 | [1] Enable  [2] Disable",
+		"config.edit.hint.auto_select_source":      "Test both sources before each update and use the faster one | [1] Enable  [2] Disable",
 		"config.option.enable":                     "Enable",
 		"config.option.disable":                    "Disable",
 		"config.language.zh":                       "Simplified Chinese",
 		"config.language.en":                       "English",
 		"config.value.unset":                       "(not set)",
+		"config.value.default":                     "(default)",
+		"config.connection.testing":                "Testing connection...",
+		"config.connection.ok":                     "Connected (%d ms)",
+		"config.connection.failed":                 "Test failed: %v",
+		"probe.testing":                            "Testing speed...",
+		"probe.not_run":                            "(not tested, press Enter)",
+		"probe.result":                             "DNS %d ms · TLS %d ms · first byte %d ms · %.1f MB/s",
+		"probe.failed":                             "Probe failed: %v",
+		"probe.recommend":                          "Recommended by speed test: %s",
 		"config.value.all_engines":                 "All engines",
 		"config.value.enabled":                     "Enabled",
 		"config.value.disabled":                    "Disabled",
@@ -540,6 +765,15 @@ var catalogs = map[Locale]map[string]string{
 		"fcitx.backup":                             "Backup then delete",
 		"fcitx.no_prompt":                          "Remember this choice and stop asking",
 		"fcitx.hint":                               "[1-2] or arrows to choose | [Space/Enter] Toggle/Confirm | [Esc] Cancel",
+		"modified.title":                           "Locally Modified Files",
+		"modified.detected":                        "%s: %d file(s) were edited after install and will be overwritten by this update:",
+		"modified.more":                            "... and %d more",
+		"modified.question":                        "Choose how to continue:",
+		"modified.keep":                            "Keep mine",
+		"modified.overwrite":                       "Use new version",
+		"modified.backup":                          "Back up as .local, then update",
+		"modified.remember":                        "Remember this choice and stop asking",
+		"modified.hint":                            "[1-3] or arrows to choose | [Space/Enter] Toggle/Confirm | [Esc] Keep mine",
 		"updating.title":                           "Updating",
 		"result.title":                             "Update Result",
 	},
@@ -600,10 +834,18 @@ func Component(locale Locale, component string) string {
 		return "Done"
 	case "准备":
 		return "Prepare"
+	case "下载":
+		return "Download"
 	case "部署":
 		return "Deploy"
 	case "恢复":
 		return "Recover"
+	case "回滚":
+		return "Rollback"
+	case "卸载":
+		return "Uninstall"
+	case "校验":
+		return "Verify"
 	default:
 		return component
 	}
@@ -712,6 +954,7 @@ func RuntimeText(locale Locale, text string) string {
 		"检查方案更新...":      "Checking scheme updates...",
 		"检查模型更新...":      "Checking model updates...",
 		"正在检查所有更新...":    "Checking all updates...",
+		"更新已取消":          "Update cancelled.",
 		"所有组件已是最新版本":     "All components are already up to date.",
 		"已是最新版本":         "Already up to date.",
 		"本地文件已是最新版本":     "The local file is already up to date.",
@@ -732,9 +975,26 @@ func RuntimeText(locale Locale, text string) string {
 		"正在更新方案...":      "Updating scheme...",
 		"正在更新词库...":      "Updating dictionary...",
 		"正在更新模型...":      "Updating model...",
+		"正在安装方案...":      "Installing scheme...",
+		"正在安装词库...":      "Installing dictionary...",
+		"正在安装模型...":      "Installing model...",
 		"尝试重启服务...":      "Trying to restart services...",
 		"所有更新已完成":        "All updates completed.",
 		"严重错误":           "Fatal error",
+		"正在生成更新预览...":    "Generating update preview...",
+		"正在分析文件变更...":    "Analyzing file changes...",
+		"更新预览已生成":        "Update preview generated.",
+		"正在备份将被修改的文件...": "Backing up files that will be modified...",
+		"正在恢复更新前的文件...":  "Restoring files from before the update...",
+		"更新失败，已恢复更新前的文件": "Update failed; files from before the update were restored.",
+		"正在替换方案文件...":    "Replacing scheme files...",
+		"正在替换词库文件...":    "Replacing dictionary files...",
+		"正在卸载万象文件...":    "Uninstalling Wanxiang files...",
+		"正在校验已安装文件...":   "Verifying installed files...",
+		"正在修复已安装文件...":   "Repairing installed files...",
+		"正在检查本地修改的文件...": "Checking for locally modified files...",
+		"正在读取历史版本...":    "Reading earlier version...",
+		"正在测试下载源速度...":   "Testing download source speed...",
 	}
 	if translated, ok := exact[text]; ok {
 		return translated
@@ -760,6 +1020,9 @@ func RuntimeText(locale Locale, text string) string {
 		{"处理嵌套目录失败: ", "Failed to process nested directory: "},
 		{"同步到其他引擎失败: ", "Failed to sync to other engines: "},
 		{"同步词库到其他引擎失败: ", "Failed to sync dictionary to other engines: "},
+		{"回滚失败，以下文件未能恢复: ", "Rollback failed, these files could not be restored: "},
+		{"更新包缺少关键文件: ", "Update package is missing a required file: "},
+		{"安装文件失败: ", "Failed to install files: "},
 		{"重命名失败: ", "Rename failed: "},
 		{"post-update hook 失败: ", "Post-update hook failed: "},
 		{"pre-update hook 失败，已取消更新: ", "Pre-update hook failed, update cancelled: "},
@@ -769,6 +1032,25 @@ func RuntimeText(locale Locale, text string) string {
 		{"正在部署到 ", "Deploying to "},
 		{"部署失败: ", "Deploy failed: "},
 		{"更新过程中出现错误: ", "Update finished with errors: "},
+		{"写入安装清单失败: ", "Failed to write install manifest: "},
+		{"缓存中没有更新包，无法修复，请执行完整更新", "No cached package to repair from; run a full update."},
+		{"没有安装清单，也没有缓存的更新包，无法校验: ", "No install manifest or cached package to verify against: "},
+		{"解压缓存的更新包失败: ", "Failed to extract the cached package: "},
+		{"缓存的更新包与已安装版本不一致，以下文件未能修复，请执行完整更新: ", "The cached package does not match the installed version; run a full update to restore: "},
+		{"将覆盖本地修改的文件: ", "Overwriting locally modified files: "},
+		{"已保留本地修改的文件: ", "Kept locally modified files: "},
+		{"已备份本地修改的文件 (.local): ", "Backed up locally modified files (.local): "},
+		{"保存历史版本失败: ", "Failed to keep the previous version: "},
+		{"已回滚到版本: ", "Rolled back to version: "},
+		{"回滚失败: ", "Rollback failed: "},
+		{"pre-update hook 失败，已取消回滚: ", "Pre-update hook failed, rollback cancelled: "},
+		{"复制历史版本失败: ", "Failed to copy the earlier version: "},
+		{"历史版本文件已损坏: ", "Earlier version file is corrupted: "},
+		{"找不到指定的历史版本", "Earlier version not found"},
+		{"将安装固定的版本: ", "Installing pinned version: "},
+		{"已固定版本: ", "Pinned at version: "},
+		{"已选择较快的下载源: ", "Selected the faster download source: "},
+		{"找不到固定的版本", "Pinned version not found"},
 	}
 	for _, prefix := range prefixes {
 		if strings.HasPrefix(text, prefix.old) {
@@ -0,0 +1,76 @@
+package releaseutil
+
+import (
+	"slices"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// 发布渠道
+const (
+	ChannelStable     = "stable"     // 只使用带版本号的正式发布
+	ChannelNightly    = "nightly"    // 同时接受 dict-nightly、LTS 等反复覆盖的滚动发布
+	ChannelPrerelease = "prerelease" // 在 nightly 的基础上同时接受标记为预发布的版本
+)
+
+// rollingTags 会被反复覆盖、不能用来区分版本的滚动发布 tag
+var rollingTags = []string{types.DICT_TAG, types.CNB_DICT_TAG, types.MODEL_TAG, types.CNB_MODEL_TAG}
+
+// Channels 返回所有发布渠道
+func Channels() []string {
+	return []string{ChannelStable, ChannelNightly, ChannelPrerelease}
+}
+
+// DefaultChannel 返回组件未配置渠道时使用的渠道：方案只使用正式发布，词库和模型只有滚动发布
+func DefaultChannel(componentID string) string {
+	if componentID == types.ComponentScheme {
+		return ChannelStable
+	}
+	return ChannelNightly
+}
+
+// IsRollingTag 判断 tag 是否为滚动发布 tag，空 tag 也视为滚动发布
+func IsRollingTag(tag string) bool {
+	return tag == "" || slices.Contains(rollingTags, tag)
+}
+
+// IncludesRolling 判断渠道是否接受滚动发布
+func IncludesRolling(channel string) bool {
+	return channel == ChannelNightly || channel == ChannelPrerelease
+}
+
+// AllowsRelease 判断渠道是否接受 release；未知渠道按 stable 处理
+func AllowsRelease(channel string, release types.GitHubRelease) bool {
+	rolling := IsRollingTag(release.TagName)
+	switch channel {
+	case ChannelNightly:
+		return !release.Prerelease
+	case ChannelPrerelease:
+		return true
+	default:
+		return !rolling && !release.Prerelease
+	}
+}
+
+// FindChannelAssetInfo 在渠道接受的发布中选择匹配资源更新时间最新的一个，时间相同时取列表中靠前的发布
+func FindChannelAssetInfo(
+	releases []types.GitHubRelease,
+	match func(name string) bool,
+	channel string,
+) (*types.UpdateInfo, bool) {
+	var best *types.UpdateInfo
+	for _, release := range releases {
+		if !AllowsRelease(channel, release) {
+			continue
+		}
+
+		info, ok := findAssetInfoWithTagFilter([]types.GitHubRelease{release}, match, func(string) bool {
+			return true
+		})
+		if ok && (best == nil || info.UpdateTime.After(best.UpdateTime)) {
+			best = info
+		}
+	}
+
+	return best, best != nil
+}
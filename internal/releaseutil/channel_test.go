@@ -0,0 +1,66 @@
+package releaseutil
+
+import (
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestAllowsRelease(t *testing.T) {
+	versioned := types.GitHubRelease{TagName: "v15.6.0"}
+	rolling := types.GitHubRelease{TagName: types.DICT_TAG}
+	prerelease := types.GitHubRelease{TagName: "v16.0.0-beta.1", Prerelease: true}
+
+	tests := []struct {
+		channel string
+		release types.GitHubRelease
+		want    bool
+	}{
+		{ChannelStable, versioned, true},
+		{ChannelStable, rolling, false},
+		{ChannelStable, prerelease, false},
+		{ChannelNightly, versioned, true},
+		{ChannelNightly, rolling, true},
+		{ChannelNightly, prerelease, false},
+		{ChannelPrerelease, versioned, true},
+		{ChannelPrerelease, rolling, true},
+		{ChannelPrerelease, prerelease, true},
+		{"unknown", rolling, false},
+	}
+
+	for _, tt := range tests {
+		if got := AllowsRelease(tt.channel, tt.release); got != tt.want {
+			t.Errorf("AllowsRelease(%q, %q) = %v, want %v", tt.channel, tt.release.TagName, got, tt.want)
+		}
+	}
+}
+
+func TestFindChannelAssetInfoPicksNewestAllowedAsset(t *testing.T) {
+	asset := func(day int) []types.GitHubAsset {
+		return []types.GitHubAsset{{Name: "base-dicts.zip", UpdatedAt: time.Date(2026, 4, day, 0, 0, 0, 0, time.UTC)}}
+	}
+	releases := []types.GitHubRelease{
+		{TagName: "v16.0.0-beta.1", Prerelease: true, Assets: asset(5)},
+		{TagName: "v15.6.0", Assets: asset(3)},
+		{TagName: types.DICT_TAG, Assets: asset(4)},
+		{TagName: "v15.5.0", Assets: asset(1)},
+	}
+	match := func(name string) bool { return name == "base-dicts.zip" }
+
+	tests := []struct {
+		channel string
+		want    string
+	}{
+		{ChannelStable, "v15.6.0"},
+		{ChannelNightly, types.DICT_TAG},
+		{ChannelPrerelease, "v16.0.0-beta.1"},
+	}
+
+	for _, tt := range tests {
+		info, ok := FindChannelAssetInfo(releases, match, tt.channel)
+		if !ok || info.Tag != tt.want {
+			t.Errorf("FindChannelAssetInfo(%q) = %+v, %v, want %q", tt.channel, info, ok, tt.want)
+		}
+	}
+}
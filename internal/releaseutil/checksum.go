@@ -0,0 +1,80 @@
+package releaseutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"strings"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// checksumFiles 发布中汇总各文件 SHA256 的文件名，不区分大小写
+var checksumFiles = []string{"SHA256SUMS", "SHA256SUMS.txt", "sha256sum.txt", "checksums.txt"}
+
+// NormalizeSHA256 规范化 SHA256 摘要：去掉 GitHub digest 的 "sha256:" 前缀并转为小写；
+// 不是 64 位十六进制时返回空字符串，避免把其他算法的摘要当作 SHA256 使用
+func NormalizeSHA256(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "sha256:")
+	if len(value) != 64 {
+		return ""
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// IsChecksumFile 判断文件名是否为汇总 SHA256 的文件（SHA256SUMS 等），不区分大小写
+func IsChecksumFile(name string) bool {
+	for _, file := range checksumFiles {
+		if strings.EqualFold(name, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChecksumURL 返回发布中 SHA256SUMS 文件的下载地址，没有时返回空字符串
+func ChecksumURL(release types.GitHubRelease) string {
+	for _, asset := range release.Assets {
+		if IsChecksumFile(asset.Name) {
+			return asset.BrowserDownloadURL
+		}
+	}
+	return ""
+}
+
+// ParseSHA256Sums 解析 SHA256SUMS 文件，返回文件名到 SHA256 的映射。
+// 支持 sha256sum 的输出格式（"<hash>  <name>"，二进制模式为 "<hash> *<name>"）和 BSD 格式（"SHA256 (<name>) = <hash>"），无法识别的行会被忽略
+func ParseSHA256Sums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var name, hash string
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			var found bool
+			name, hash, found = strings.Cut(rest, ") = ")
+			if !found {
+				continue
+			}
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			hash, name = fields[0], strings.TrimPrefix(fields[1], "*")
+		}
+
+		if hash = NormalizeSHA256(hash); hash != "" && name != "" {
+			sums[name] = hash
+		}
+	}
+	return sums
+}
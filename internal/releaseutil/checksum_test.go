@@ -0,0 +1,75 @@
+package releaseutil
+
+import (
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestNormalizeSHA256(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{hash, hash},
+		{"sha256:" + hash, hash},
+		{"SHA256:" + strings.ToUpper(hash), hash},
+		{" " + hash + "\n", hash},
+		{"md5:" + hash, ""},
+		{hash[:62], ""},
+		{strings.Repeat("zz", 32), ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeSHA256(tt.value); got != tt.want {
+			t.Errorf("NormalizeSHA256(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseSHA256Sums(t *testing.T) {
+	hashA := strings.Repeat("a", 64)
+	hashB := strings.Repeat("b", 64)
+	hashC := strings.Repeat("c", 64)
+	data := []byte("# checksums\n" +
+		hashA + "  scheme.zip\n" +
+		hashB + " *dicts.zip\n" +
+		"SHA256 (model.gram) = " + strings.ToUpper(hashC) + "\n" +
+		"not-a-hash  broken.zip\n" +
+		"\n")
+
+	sums := ParseSHA256Sums(data)
+	want := map[string]string{"scheme.zip": hashA, "dicts.zip": hashB, "model.gram": hashC}
+	if len(sums) != len(want) {
+		t.Fatalf("ParseSHA256Sums() = %v, want %v", sums, want)
+	}
+	for name, hash := range want {
+		if sums[name] != hash {
+			t.Errorf("ParseSHA256Sums()[%q] = %q, want %q", name, sums[name], hash)
+		}
+	}
+}
+
+func TestAssetInfoSetsChecksumURL(t *testing.T) {
+	release := types.GitHubRelease{
+		TagName: "v1.0.0",
+		Assets: []types.GitHubAsset{
+			{Name: "scheme.zip", BrowserDownloadURL: "https://example.com/scheme.zip"},
+			{Name: "sha256sums", BrowserDownloadURL: "https://example.com/sha256sums"},
+		},
+	}
+
+	info := AssetInfo(release, release.Assets[0])
+	if info.ChecksumURL != "https://example.com/sha256sums" {
+		t.Errorf("AssetInfo().ChecksumURL = %q, want the SHA256SUMS asset", info.ChecksumURL)
+	}
+
+	release.Assets = release.Assets[:1]
+	if info := AssetInfo(release, release.Assets[0]); info.ChecksumURL != "" {
+		t.Errorf("AssetInfo().ChecksumURL = %q, want empty without a SHA256SUMS asset", info.ChecksumURL)
+	}
+}
@@ -68,18 +68,24 @@ func findAssetInfoWithTagFilter(
 				continue
 			}
 
-			return &types.UpdateInfo{
-				Name:        asset.Name,
-				URL:         asset.BrowserDownloadURL,
-				UpdateTime:  asset.UpdatedAt,
-				Tag:         release.TagName,
-				Description: release.Body,
-				SHA256:      asset.SHA256,
-				ID:          asset.ID,
-				Size:        asset.Size,
-			}, true
+			return AssetInfo(release, asset), true
 		}
 	}
 
 	return nil, false
 }
+
+// AssetInfo 返回 release 中 asset 的更新信息；资源本身没有 SHA256 时可从 ChecksumURL 指向的 SHA256SUMS 中获取
+func AssetInfo(release types.GitHubRelease, asset types.GitHubAsset) *types.UpdateInfo {
+	return &types.UpdateInfo{
+		Name:        asset.Name,
+		URL:         asset.BrowserDownloadURL,
+		UpdateTime:  asset.UpdatedAt,
+		Tag:         release.TagName,
+		Description: release.Body,
+		SHA256:      asset.SHA256,
+		ID:          asset.ID,
+		Size:        asset.Size,
+		ChecksumURL: ChecksumURL(release),
+	}
+}
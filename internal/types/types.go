@@ -4,18 +4,80 @@ import "time"
 
 // 常量定义
 const (
-	VERSION      = "v0.6.22"
-	OWNER        = "amzxyz"
-	REPO         = "rime_wanxiang"
-	CNB_REPO     = "rime-wanxiang"
-	DICT_TAG     = "dict-nightly" // GitHub 词库 tag
-	CNB_DICT_TAG = "v1.0.0"       // CNB 词库 tag
-	MODEL_REPO   = "RIME-LMDG"
-	MODEL_TAG    = "LTS"
-	MODEL_FILE   = "wanxiang-lts-zh-hans.gram"
-	ZH_DICTS     = "dicts"
+	VERSION       = "v0.6.22"
+	OWNER         = "amzxyz"
+	REPO          = "rime_wanxiang"
+	CNB_REPO      = "rime-wanxiang"
+	DICT_TAG      = "dict-nightly" // GitHub 词库 tag
+	CNB_DICT_TAG  = "v1.0.0"       // CNB 词库 tag
+	MODEL_REPO    = "RIME-LMDG"
+	MODEL_TAG     = "LTS"
+	CNB_MODEL_TAG = "model" // CNB 模型 tag
+	MODEL_FILE    = "wanxiang-lts-zh-hans.gram"
+	MODEL_HANT    = "*zh-hant*.gram" // 繁体模型的文件名模式
+	ZH_DICTS      = "dicts"
 )
 
+// 组件 ID（与界面语言无关，用于命令行参数和 JSON 输出）
+const (
+	ComponentScheme = "scheme"
+	ComponentDict   = "dict"
+	ComponentModel  = "model"
+)
+
+// componentNames 组件 ID 与内部组件名的对应关系
+var componentNames = map[string]string{
+	ComponentScheme: "方案",
+	ComponentDict:   "词库",
+	ComponentModel:  "模型",
+}
+
+// componentIDs 按更新顺序排列的组件 ID，RegisterComponent 追加的组件排在内置组件之后
+var componentIDs = []string{ComponentScheme, ComponentDict, ComponentModel}
+
+// RegisterComponent 登记组件 ID 与内部组件名，供命令行参数、界面和更新历史识别；已登记的 ID 只更新名称。
+// 应在程序初始化时调用（通常经由 updater.Register）
+func RegisterComponent(id, name string) {
+	if _, ok := componentNames[id]; !ok {
+		componentIDs = append(componentIDs, id)
+	}
+	componentNames[id] = name
+}
+
+// UnregisterComponent 移除 RegisterComponent 登记的组件
+func UnregisterComponent(id string) {
+	if _, ok := componentNames[id]; !ok {
+		return
+	}
+	delete(componentNames, id)
+	for i, registered := range componentIDs {
+		if registered == id {
+			componentIDs = append(componentIDs[:i:i], componentIDs[i+1:]...)
+			break
+		}
+	}
+}
+
+// ComponentIDs 按更新顺序返回所有组件 ID
+func ComponentIDs() []string {
+	return append([]string(nil), componentIDs...)
+}
+
+// ComponentID 将内部组件名（方案/词库/模型）转换为组件 ID，未知名称原样返回
+func ComponentID(name string) string {
+	for id, componentName := range componentNames {
+		if componentName == name {
+			return id
+		}
+	}
+	return name
+}
+
+// ComponentName 将组件 ID 转换为内部组件名，未知 ID 返回空字符串
+func ComponentName(id string) string {
+	return componentNames[id]
+}
+
 // SchemeMap 方案映射
 var SchemeMap = map[string]string{
 	"1": "moqi",
@@ -39,7 +101,10 @@ type Config struct {
 	SchemeFile          string   `json:"scheme_file"`
 	DictFile            string   `json:"dict_file"`
 	UseMirror           bool     `json:"use_mirror"`
+	AutoSelectSource    bool     `json:"auto_select_source"` // 每次更新前测速，改用较快的下载源（GitHub 或 CNB 镜像）
 	GithubToken         string   `json:"github_token"`
+	GithubAPIURL        string   `json:"github_api_url"` // GitHub API 地址（如 api.github.com 的反向代理），空表示 https://api.github.com
+	GithubProxy         string   `json:"github_proxy"`   // GitHub 下载加速前缀，下载地址改写为 "<前缀>/<原地址>"，空表示直连
 	ExcludeFiles        []string `json:"exclude_files"`
 	AutoUpdate          bool     `json:"auto_update"`
 	AutoUpdateCountdown int      `json:"auto_update_countdown"` // 自动更新倒计时（秒）
@@ -53,6 +118,19 @@ type Config struct {
 	FcitxConflictPrompt bool     `json:"fcitx_conflict_prompt"` // Linux 专用：是否每次都提示（true）还是使用记忆的偏好（false）
 	PreUpdateHook       string   `json:"pre_update_hook"`       // 更新前执行的脚本路径
 	PostUpdateHook      string   `json:"post_update_hook"`      // 更新后执行的脚本路径
+	ModifiedFileAction  string   `json:"modified_file_action"`  // 本地修改过的万象文件处理方式 "keep"、"overwrite" 或 "backup"，空表示每次询问
+	KeepVersions        int      `json:"keep_versions"`         // 每个组件在缓存中保留的历史版本数量，用于回滚；0 表示使用默认值
+	DownloadConcurrency int      `json:"download_concurrency"`  // 组合更新时同时下载的文件数量上限；0 表示使用默认值
+	RequireChecksum     bool     `json:"require_checksum"`      // 要求校验所有下载：发布中没有可信的 SHA256 时拒绝安装
+
+	// 模型文件：要安装的模型文件名或通配符模式（如 "*zh-hant*.gram"），可同时安装多个；为空时只安装 MODEL_FILE
+	ModelFiles []string `json:"model_files,omitempty"`
+
+	// 版本固定：组件 ID -> 固定的版本（Tag、资源 ID 或 SHA256），固定后不再更新到其他版本
+	PinnedVersions map[string]string `json:"pinned_versions,omitempty"`
+
+	// 发布渠道：组件 ID -> "stable"、"nightly" 或 "prerelease"，未配置时使用组件的默认渠道
+	ReleaseChannels map[string]string `json:"release_channels,omitempty"`
 
 	// 主题配置
 	ThemeAdaptive bool   `json:"theme_adaptive"` // 是否启用自适应主题（根据终端明暗自动切换）
@@ -71,6 +149,11 @@ type UpdateInfo struct {
 	SHA256      string    `json:"sha256"`
 	ID          string    `json:"id"`
 	Size        int64     `json:"size"`
+	ChecksumURL string    `json:"checksum_url,omitempty"` // 发布中 SHA256SUMS 文件的地址，资源本身没有 SHA256 时从中获取
+	Source      string    `json:"source,omitempty"`       // 提供版本信息和文件的下载源（"GitHub" 或 "CNB"）
+
+	// Assets 同时更新多个文件（如多个模型）时各文件的版本信息，此时上面的字段为汇总信息
+	Assets []UpdateInfo `json:"assets,omitempty"`
 }
 
 // UpdateRecord 更新记录
@@ -81,6 +164,39 @@ type UpdateRecord struct {
 	ApplyTime  time.Time `json:"apply_time,omitzero"`
 	SHA256     string    `json:"sha256"`
 	CnbID      string    `json:"cnb_id"`
+	Source     string    `json:"source,omitempty"` // 提供文件的下载源（"GitHub" 或 "CNB"），见 UpdateInfo.Source
+}
+
+// HistoryEntry 更新历史中的一条记录，每次更新或回滚追加一条
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Component  string    `json:"component"` // 组件 ID（scheme/dict/model）
+	Action     string    `json:"action"`    // "update"、"rollback" 或 "import"
+	OldTag     string    `json:"old_tag,omitempty"`
+	NewTag     string    `json:"new_tag,omitempty"`
+	SHA256     string    `json:"sha256,omitempty"`
+	Source     string    `json:"source"` // "GitHub"、"CNB"、"cache"（回滚）或 "local"（离线导入）
+	DurationMS int64     `json:"duration_ms"`
+	Bytes      int64     `json:"bytes"`   // 本次实际下载的字节数
+	Outcome    string    `json:"outcome"` // "success"、"failed" 或 "rolled_back"
+	Error      string    `json:"error,omitempty"`
+}
+
+// ManifestFile 安装清单中的单个文件
+type ManifestFile struct {
+	Path   string `json:"path"` // 相对于清单 Root 的路径，使用 / 分隔
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// InstallManifest 安装清单：记录某个组件在某个引擎目录中安装的全部文件
+type InstallManifest struct {
+	Component   string         `json:"component"` // 组件 ID（scheme/dict/model）
+	Engine      string         `json:"engine"`
+	Root        string         `json:"root"` // 安装目录
+	Tag         string         `json:"tag"`
+	InstalledAt time.Time      `json:"installed_at,omitzero"`
+	Files       []ManifestFile `json:"files"`
 }
 
 // GitHubRelease GitHub Release 结构
@@ -89,6 +205,7 @@ type GitHubRelease struct {
 	Body        string        `json:"body"`
 	Assets      []GitHubAsset `json:"assets"`
 	PublishedAt time.Time     `json:"published_at,omitzero"`
+	Prerelease  bool          `json:"prerelease"`
 }
 
 // GitHubAsset GitHub Asset 结构
@@ -96,17 +213,19 @@ type GitHubAsset struct {
 	Name               string    `json:"name"`
 	BrowserDownloadURL string    `json:"browser_download_url"`
 	UpdatedAt          time.Time `json:"updated_at,omitzero"`
-	ID                 string    `json:"-"`
+	ID                 string    `json:"-"` // CNB 资源 ID；GitHub 的发布信息（包括 serve 缓存服务器提供的）中没有
 	SHA256             string    `json:"sha256"`
+	Digest             string    `json:"digest,omitempty"` // GitHub 提供的摘要，如 "sha256:<hash>"
 	Size               int64     `json:"size"`
 }
 
 // CNBRelease CNB Release 结构
 type CNBRelease struct {
-	Title  string     `json:"title"`
-	TagRef string     `json:"tag_ref"`
-	Body   string     `json:"body"`
-	Assets []CNBAsset `json:"assets"`
+	Title      string     `json:"title"`
+	TagRef     string     `json:"tag_ref"`
+	Body       string     `json:"body"`
+	Assets     []CNBAsset `json:"assets"`
+	Prerelease bool       `json:"prerelease"`
 }
 
 // CNBAsset CNB Asset 结构
@@ -125,10 +244,11 @@ type ProgressFunc func(message string, percent float64, source string, fileName
 
 // UpdateStatus 更新状态
 type UpdateStatus struct {
-	LocalVersion  string    // 本地版本
-	RemoteVersion string    // 远程版本
-	LocalTime     time.Time // 本地更新时间
-	RemoteTime    time.Time // 远程更新时间
-	NeedsUpdate   bool      // 是否需要更新
-	Message       string    // 状态消息
+	LocalVersion  string    `json:"local_version"`        // 本地版本
+	RemoteVersion string    `json:"remote_version"`       // 远程版本
+	LocalTime     time.Time `json:"local_time,omitzero"`  // 本地更新时间
+	RemoteTime    time.Time `json:"remote_time,omitzero"` // 远程更新时间
+	NeedsUpdate   bool      `json:"needs_update"`         // 是否需要更新
+	Message       string    `json:"message,omitempty"`    // 状态消息
+	Pinned        string    `json:"pinned,omitempty"`     // 固定的版本，未固定时为空
 }
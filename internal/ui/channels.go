@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// handleChannelsInput 处理发布渠道界面输入；←→ / Enter 在正式版、每日构建和预发布之间切换所选组件的渠道
+func (m Model) handleChannelsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ids := types.ComponentIDs()
+
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewToolsMenu
+		m.ChannelChoice = 0
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if m.ChannelChoice > 0 {
+			m.ChannelChoice--
+		}
+	case "down", "j":
+		if m.ChannelChoice < len(ids)-1 {
+			m.ChannelChoice++
+		}
+	case "right", "l", "enter", " ":
+		m.cycleChannel(ids[m.ChannelChoice], 1)
+	case "left", "h":
+		m.cycleChannel(ids[m.ChannelChoice], -1)
+	}
+
+	return m, nil
+}
+
+// cycleChannel 将组件切换到前一个或后一个发布渠道并保存配置；切换到默认渠道时从配置中删除
+func (m *Model) cycleChannel(componentID string, step int) {
+	channels := releaseutil.Channels()
+	current := slices.Index(channels, m.channel(componentID))
+	next := channels[(current+step+len(channels))%len(channels)]
+
+	cfg := m.Cfg.Config
+	if next == releaseutil.DefaultChannel(componentID) {
+		delete(cfg.ReleaseChannels, componentID)
+	} else {
+		if cfg.ReleaseChannels == nil {
+			cfg.ReleaseChannels = make(map[string]string)
+		}
+		cfg.ReleaseChannels[componentID] = next
+	}
+
+	if err := m.Cfg.SaveConfig(); err != nil {
+		m.Err = err
+	}
+}
+
+// channel 返回组件当前使用的发布渠道
+func (m Model) channel(componentID string) string {
+	return (&updater.BaseUpdater{Config: m.Cfg}).Channel(componentID)
+}
+
+func (m Model) renderChannels() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("⇅ "+m.t("channels.title")+" ⇅") + "\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(m.Styles.Muted).Render(m.t("channels.subtitle")) + "\n\n")
+
+	mutedStyle := lipgloss.NewStyle().Foreground(m.Styles.Muted)
+	lines := make([]string, 0, len(types.ComponentIDs()))
+	for i, id := range types.ComponentIDs() {
+		channel := m.channel(id)
+		state := m.t("channels." + channel)
+		if channel == releaseutil.DefaultChannel(id) {
+			state += mutedStyle.Render(m.t("channels.default"))
+		}
+
+		text := fmt.Sprintf("%-6s ‹ %s ›", m.componentLabel(types.ComponentName(id)), state)
+		if i == m.ChannelChoice {
+			lines = append(lines, m.Styles.DialogActiveButton.Render("► ")+text)
+		} else {
+			lines = append(lines, "  "+text)
+		}
+	}
+
+	content := strings.Join(lines, "\n")
+	if m.Err != nil {
+		content += "\n\n" + m.Styles.ErrorText.Render(m.Err.Error())
+	}
+	b.WriteString(m.renderPanel(content, m.Styles.Primary) + "\n")
+	b.WriteString("\n" + m.Styles.Grid.Render(gridLine) + "\n\n")
+	b.WriteString(m.renderHintStrip(m.t("ui.hint.nav"), m.t("channels.hint.change"), m.t("ui.hint.back")))
+
+	return m.renderScreen(b.String())
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/controller"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestConfigEditTestsConnection(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.State = ViewConfigEdit
+	m.CommandChan = commands
+	m.EditingKey = "github_proxy"
+	m.EditingValue = "https://proxy.example"
+
+	next, cmd := m.handleConfigEditInput(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = next.(Model)
+	if !m.ConnectionTesting || cmd == nil {
+		t.Fatalf("ctrl+t testing = %v, cmd nil = %v, want a running test", m.ConnectionTesting, cmd == nil)
+	}
+	cmd()
+	sent := <-commands
+	payload, _ := sent.Payload.(controller.ConnectionTestPayload)
+	if sent.Type != controller.CmdTestConnection || payload.Key != "github_proxy" || payload.Value != "https://proxy.example" {
+		t.Errorf("sent command = %v %+v, want CmdTestConnection for the edited proxy", sent.Type, sent.Payload)
+	}
+
+	next, _ = m.handleControllerEvent(controller.Event{
+		Type:    controller.EvtConnectionTested,
+		Payload: controller.ConnectionTestedPayload{Key: "github_proxy", Duration: 120 * time.Millisecond},
+	})
+	m = next.(Model)
+	if m.ConnectionTesting || m.ConnectionErr != nil || m.ConnectionResult == "" {
+		t.Errorf("after success testing = %v, err = %v, result = %q, want a success message", m.ConnectionTesting, m.ConnectionErr, m.ConnectionResult)
+	}
+
+	m.ConnectionTesting = true
+	next, _ = m.handleControllerEvent(controller.Event{
+		Type:    controller.EvtConnectionTested,
+		Payload: controller.ConnectionTestedPayload{Key: "github_proxy", Err: errors.New("timeout")},
+	})
+	m = next.(Model)
+	if m.ConnectionErr == nil || m.ConnectionResult != "" {
+		t.Errorf("after failure err = %v, result = %q, want the error", m.ConnectionErr, m.ConnectionResult)
+	}
+}
+
+func TestConfigEditRejectsInvalidURL(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.Cfg.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	m.State = ViewConfigEdit
+	m.CommandChan = commands
+	m.EditingKey = "github_api_url"
+	m.EditingValue = "gh-api.example.com"
+
+	next, cmd := m.handleConfigEditInput(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = next.(Model)
+	if cmd != nil || m.ConnectionTesting || m.ConnectionErr == nil {
+		t.Errorf("ctrl+t with invalid URL cmd nil = %v, testing = %v, err = %v, want a validation error", cmd == nil, m.ConnectionTesting, m.ConnectionErr)
+	}
+
+	next, _ = m.saveConfigEdit()
+	m = next.(Model)
+	if m.State != ViewConfigEdit || m.Err == nil || m.Cfg.Config.GithubAPIURL != "" {
+		t.Fatalf("saveConfigEdit(invalid) state = %v, err = %v, url = %q, want to stay with an error", m.State, m.Err, m.Cfg.Config.GithubAPIURL)
+	}
+
+	m.EditingValue = " https://gh-api.example.com "
+	next, _ = m.saveConfigEdit()
+	m = next.(Model)
+	if m.State != ViewConfig || m.Err != nil || m.Cfg.Config.GithubAPIURL != "https://gh-api.example.com" {
+		t.Errorf("saveConfigEdit() state = %v, err = %v, url = %q, want https://gh-api.example.com", m.State, m.Err, m.Cfg.Config.GithubAPIURL)
+	}
+}
@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dryRunViewportHeight 返回预览列表可见的行数
+func (m Model) dryRunViewportHeight() int {
+	if m.Height <= 0 {
+		return 16
+	}
+
+	height := m.Height - 22
+	if height < 6 {
+		return 6
+	}
+
+	return height
+}
+
+func (m Model) handleDryRunInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := len(m.dryRunLines()) - m.dryRunViewportHeight()
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewToolsMenu
+		m.DryRunScroll = 0
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.DryRunScroll--
+	case "down", "j":
+		m.DryRunScroll++
+	case "pgup", "b":
+		m.DryRunScroll -= m.dryRunViewportHeight()
+	case "pgdown", " ", "f":
+		m.DryRunScroll += m.dryRunViewportHeight()
+	case "home", "g":
+		m.DryRunScroll = 0
+	case "end", "G":
+		m.DryRunScroll = maxScroll
+	case "enter", "u":
+		if !m.DryRunResult.HasChanges() {
+			return m, nil
+		}
+		m.State = ViewUpdating
+		m.Updating = true
+		m.DryRunResult = nil
+		m.DryRunScroll = 0
+		m.ProgressMsg = m.runtimeText("检查所有更新...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdAutoUpdate})
+	}
+
+	m.DryRunScroll = max(0, min(m.DryRunScroll, maxScroll))
+	return m, nil
+}
+
+// dryRunLines 将预览结果展开为逐行文本
+func (m Model) dryRunLines() []string {
+	if m.DryRunResult == nil {
+		return nil
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(m.Styles.Primary).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.Styles.Muted)
+	markers := []struct {
+		mark  string
+		style lipgloss.Style
+		files func(updater.FileChanges) []string
+	}{
+		{"+", lipgloss.NewStyle().Foreground(m.Styles.Success), func(c updater.FileChanges) []string { return c.Added }},
+		{"~", lipgloss.NewStyle().Foreground(m.Styles.Warning), func(c updater.FileChanges) []string { return c.Overwritten }},
+		{"-", lipgloss.NewStyle().Foreground(m.Styles.Error), func(c updater.FileChanges) []string { return c.Deleted }},
+		{"=", mutedStyle, func(c updater.FileChanges) []string { return c.Excluded }},
+	}
+
+	var lines []string
+	for i, plan := range m.DryRunResult.Components {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		header := m.componentLabel(plan.Component)
+		if plan.Status != nil {
+			header = fmt.Sprintf("%s  %s → %s", header, m.localizedValue(plan.Status.LocalVersion), plan.Status.RemoteVersion)
+		}
+		lines = append(lines, titleStyle.Render(header))
+
+		switch {
+		case plan.Err != nil:
+			lines = append(lines, m.Styles.ErrorText.Render("  "+m.t("dryrun.error", m.runtimeText(plan.Err.Error()))))
+			continue
+		case !plan.NeedsUpdate():
+			lines = append(lines, mutedStyle.Render("  "+m.t("dryrun.up_to_date")))
+			continue
+		}
+
+		changes := plan.Changes
+		lines = append(lines, mutedStyle.Render("  "+m.t("dryrun.target", plan.TargetDir)))
+		lines = append(lines, mutedStyle.Render("  "+m.t("dryrun.counts",
+			len(changes.Added), len(changes.Overwritten), len(changes.Deleted), len(changes.Excluded))))
+		for _, marker := range markers {
+			for _, file := range marker.files(changes) {
+				lines = append(lines, marker.style.Render(fmt.Sprintf("  %s %s", marker.mark, file)))
+			}
+		}
+	}
+
+	return lines
+}
+
+func (m Model) renderDryRun() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("◇ "+m.t("dryrun.title")+" ◇") + "\n\n")
+
+	lines := m.dryRunLines()
+	height := m.dryRunViewportHeight()
+	start := min(m.DryRunScroll, max(0, len(lines)-height))
+	end := min(len(lines), start+height)
+
+	var body string
+	if len(lines) == 0 {
+		body = m.t("dryrun.empty")
+	} else {
+		body = strings.Join(lines[start:end], "\n")
+	}
+
+	border := m.Styles.Warning
+	if !m.DryRunResult.HasChanges() {
+		border = m.Styles.Success
+	}
+	b.WriteString(m.renderPanel(body, border) + "\n")
+
+	if len(lines) > height {
+		position := lipgloss.NewStyle().Foreground(m.Styles.Muted).
+			Render(m.t("dryrun.position", start+1, end, len(lines)))
+		b.WriteString(lipgloss.NewStyle().Width(m.pageWidth()).Align(lipgloss.Right).Render(position))
+	}
+	b.WriteString("\n" + m.Styles.Grid.Render(gridLine) + "\n\n")
+
+	hints := []string{m.t("ui.hint.scroll")}
+	if m.DryRunResult.HasChanges() {
+		hints = append(hints, m.t("dryrun.hint.apply"))
+	}
+	hints = append(hints, m.t("ui.hint.back"))
+	b.WriteString(m.renderHintStrip(hints...))
+
+	return m.renderScreen(b.String())
+}
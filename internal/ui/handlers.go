@@ -3,7 +3,11 @@ package ui
 import (
 	"fmt"
 	"runtime"
+	"strconv"
+	"strings"
 
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/controller"
 	"rime-wanxiang-updater/internal/types"
 
@@ -17,7 +21,7 @@ func (m Model) handleWizardInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "1":
 			m.SchemeChoice = "base"
-			m.WizardStep = WizardDownloadSource
+			m.WizardStep = WizardModel
 			return m, m.sendCommand(controller.Command{
 				Type: controller.CmdWizardSetScheme,
 				Payload: controller.WizardSchemePayload{
@@ -39,7 +43,7 @@ func (m Model) handleWizardInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		if variant, ok := types.SchemeMap[key]; ok {
 			m.SchemeChoice = variant
-			m.WizardStep = WizardDownloadSource
+			m.WizardStep = WizardModel
 			return m, m.sendCommand(controller.Command{
 				Type: controller.CmdWizardSetScheme,
 				Payload: controller.WizardSchemePayload{
@@ -49,6 +53,21 @@ func (m Model) handleWizardInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			})
 		}
 
+	case WizardModel:
+		key := msg.String()
+		if key == "q" || key == "ctrl+c" {
+			return m, tea.Quit
+		}
+		if files, ok := wizardModelChoices[key]; ok {
+			// 进入选择下载源的步骤时开始测速，结果显示在选项下方
+			m.WizardStep = WizardDownloadSource
+			next, probe := m.probeSources()
+			return next, tea.Batch(m.sendCommand(controller.Command{
+				Type:    controller.CmdWizardSetModel,
+				Payload: controller.WizardModelPayload{ModelFiles: files},
+			}), probe)
+		}
+
 	case WizardDownloadSource:
 		switch msg.String() {
 		case "1":
@@ -57,6 +76,8 @@ func (m Model) handleWizardInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "2":
 			m.MirrorChoice = false
 			return m.completeWizard()
+		case "t":
+			return m.probeSources()
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		}
@@ -64,6 +85,13 @@ func (m Model) handleWizardInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// wizardModelChoices 向导中按数字键选择的模型组合；简体模型使用默认配置
+var wizardModelChoices = map[string][]string{
+	"1": nil,
+	"2": {types.MODEL_HANT},
+	"3": {types.MODEL_FILE, types.MODEL_HANT},
+}
+
 // completeWizard 完成向导
 func (m Model) completeWizard() (tea.Model, tea.Cmd) {
 	// Send both commands: mirror choice first, then wizard complete
@@ -98,88 +126,71 @@ func (m Model) handleMenuInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		return m, nil
-	case "1":
-		m.State = ViewUpdating
-		m.Updating = true
-		m.ProgressMsg = m.runtimeText("检查所有更新...")
-		return m, m.sendCommand(controller.Command{Type: controller.CmdAutoUpdate})
-	case "2":
-		m.State = ViewUpdating
-		m.Updating = true
-		m.ProgressMsg = m.runtimeText("检查词库更新...")
-		return m, m.sendCommand(controller.Command{Type: controller.CmdUpdateDict})
-	case "3":
-		m.State = ViewUpdating
-		m.Updating = true
-		m.ProgressMsg = m.runtimeText("检查方案更新...")
-		return m, m.sendCommand(controller.Command{Type: controller.CmdUpdateScheme})
-	case "4":
-		m.State = ViewUpdating
-		m.Updating = true
-		m.ProgressMsg = m.runtimeText("检查模型更新...")
-		return m, m.sendCommand(controller.Command{Type: controller.CmdUpdateModel})
-	case "5":
-		m.State = ViewConfig
-		return m, nil
-	case "6":
-		m.State = ViewCustomMenu
-		return m, nil
 	case "a", "A":
 		m.State = ViewAbout
 		return m, nil
-	case "7":
-		m.State = ViewWizard
-		m.WizardStep = WizardSchemeType
-		return m, nil
-	case "8", "q", "ctrl+c":
+	case "q", "ctrl+c":
 		return m, tea.Quit
 	case "up", "k":
 		if m.MenuChoice > 0 {
 			m.MenuChoice--
 		}
 	case "down", "j":
-		if m.MenuChoice < 7 {
+		if m.MenuChoice < len(m.mainMenuItems())-1 {
 			m.MenuChoice++
 		}
 	case "enter":
-		switch m.MenuChoice {
-		case 0:
-			m.State = ViewUpdating
-			m.Updating = true
-			m.ProgressMsg = m.runtimeText("检查所有更新...")
-			return m, m.sendCommand(controller.Command{Type: controller.CmdAutoUpdate})
-		case 1:
-			m.State = ViewUpdating
-			m.Updating = true
-			m.ProgressMsg = m.runtimeText("检查词库更新...")
-			return m, m.sendCommand(controller.Command{Type: controller.CmdUpdateDict})
-		case 2:
-			m.State = ViewUpdating
-			m.Updating = true
-			m.ProgressMsg = m.runtimeText("检查方案更新...")
-			return m, m.sendCommand(controller.Command{Type: controller.CmdUpdateScheme})
-		case 3:
-			m.State = ViewUpdating
-			m.Updating = true
-			m.ProgressMsg = m.runtimeText("检查模型更新...")
-			return m, m.sendCommand(controller.Command{Type: controller.CmdUpdateModel})
-		case 4:
-			m.State = ViewConfig
-			return m, nil
-		case 5:
-			m.State = ViewCustomMenu
-			return m, nil
-		case 6:
-			m.State = ViewWizard
-			m.WizardStep = WizardSchemeType
-			return m, nil
-		case 7:
-			return m, tea.Quit
+		return m.applyMenuChoice()
+	default:
+		if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= len(m.mainMenuItems()) {
+			return m.applyMenuItem(m.mainMenuItems()[n-1].key)
 		}
 	}
 	return m, nil
 }
 
+func (m Model) applyMenuChoice() (tea.Model, tea.Cmd) {
+	items := m.mainMenuItems()
+	if m.MenuChoice < 0 || m.MenuChoice >= len(items) {
+		return m, nil
+	}
+	return m.applyMenuItem(items[m.MenuChoice].key)
+}
+
+// applyMenuItem 执行主菜单项，key 见 mainMenuItems
+func (m Model) applyMenuItem(key string) (tea.Model, tea.Cmd) {
+	if id, ok := componentMenuID(key); ok {
+		m.State = ViewUpdating
+		m.Updating = true
+		m.ProgressMsg = m.t("updating.checking_component", m.componentLabel(types.ComponentName(id)))
+		return m, m.sendCommand(controller.Command{
+			Type:    controller.CmdUpdateComponent,
+			Payload: controller.UpdateComponentPayload{Component: id},
+		})
+	}
+
+	switch key {
+	case "auto":
+		m.State = ViewUpdating
+		m.Updating = true
+		m.ProgressMsg = m.runtimeText("检查所有更新...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdAutoUpdate})
+	case "config":
+		m.State = ViewConfig
+	case "custom":
+		m.State = ViewCustomMenu
+	case "tools":
+		m.State = ViewToolsMenu
+		m.ToolsMenuChoice = 0
+	case "wizard":
+		m.State = ViewWizard
+		m.WizardStep = WizardSchemeType
+	case "quit":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
 func (m Model) handleAboutInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "esc":
@@ -232,8 +243,12 @@ func (m Model) handleConfigInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.Cfg.Config.ProxyEnabled {
 			maxChoice += 2 // ProxyType, ProxyAddress
 		}
+		maxChoice += 2 // GithubAPIURL, GithubProxy
 
 		maxChoice += 2 // PreUpdateHook, PostUpdateHook
+		maxChoice++    // ModelFiles
+		maxChoice++    // RequireChecksum
+		maxChoice += 2 // AutoSelectSource, ProbeSources
 		maxChoice++    // ExcludeFileManager
 
 		// 主题配置
@@ -283,7 +298,10 @@ func (m Model) startConfigEdit() (tea.Model, tea.Cmd) {
 		configItems = append(configItems, "proxy_type", "proxy_address")
 	}
 
+	configItems = append(configItems, "github_api_url", "github_proxy")
 	configItems = append(configItems, "pre_update_hook", "post_update_hook")
+	configItems = append(configItems, "model_files", "require_checksum")
+	configItems = append(configItems, "auto_select_source", "probe_sources")
 	configItems = append(configItems, "exclude_file_manager")
 
 	// 主题配置
@@ -304,6 +322,10 @@ func (m Model) startConfigEdit() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if selectedKey == "probe_sources" {
+			return m.probeSources()
+		}
+
 		if selectedKey == "exclude_file_manager" {
 			m.InitExcludeView()
 			m.State = ViewExcludeList
@@ -358,10 +380,28 @@ func (m Model) startConfigEdit() (tea.Model, tea.Cmd) {
 			m.EditingValue = m.Cfg.Config.ProxyType
 		case "proxy_address":
 			m.EditingValue = m.Cfg.Config.ProxyAddress
+		case "github_api_url":
+			m.EditingValue = m.Cfg.Config.GithubAPIURL
+		case "github_proxy":
+			m.EditingValue = m.Cfg.Config.GithubProxy
 		case "pre_update_hook":
 			m.EditingValue = m.Cfg.Config.PreUpdateHook
 		case "post_update_hook":
 			m.EditingValue = m.Cfg.Config.PostUpdateHook
+		case "model_files":
+			m.EditingValue = strings.Join(m.Cfg.Config.ModelFiles, ",")
+		case "require_checksum":
+			if m.Cfg.Config.RequireChecksum {
+				m.EditingValue = "true"
+			} else {
+				m.EditingValue = "false"
+			}
+		case "auto_select_source":
+			if m.Cfg.Config.AutoSelectSource {
+				m.EditingValue = "true"
+			} else {
+				m.EditingValue = "false"
+			}
 		case "theme_adaptive":
 			if m.Cfg.Config.ThemeAdaptive {
 				m.EditingValue = "true"
@@ -370,6 +410,9 @@ func (m Model) startConfigEdit() (tea.Model, tea.Cmd) {
 			}
 		}
 
+		m.ConnectionTesting = false
+		m.ConnectionResult = ""
+		m.ConnectionErr = nil
 		m.State = ViewConfigEdit
 	}
 	return m, nil
@@ -378,7 +421,8 @@ func (m Model) startConfigEdit() (tea.Model, tea.Cmd) {
 // handleConfigEditInput 处理配置编辑输入
 func (m Model) handleConfigEditInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	isBooleanField := m.EditingKey == "use_mirror" || m.EditingKey == "auto_update" || m.EditingKey == "proxy_enabled" ||
-		m.EditingKey == "fcitx_compat" || m.EditingKey == "fcitx_use_link" || m.EditingKey == "theme_adaptive"
+		m.EditingKey == "fcitx_compat" || m.EditingKey == "fcitx_use_link" || m.EditingKey == "theme_adaptive" ||
+		m.EditingKey == "require_checksum" || m.EditingKey == "auto_select_source"
 	isLanguageField := m.EditingKey == "language"
 
 	switch msg.String() {
@@ -391,6 +435,10 @@ func (m Model) handleConfigEditInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case "enter":
 		return m.saveConfigEdit()
+	case "ctrl+t":
+		if isConnectionField(m.EditingKey) {
+			return m.testConnection()
+		}
 	case "backspace":
 		if !isBooleanField && !isLanguageField && len(m.EditingValue) > 0 {
 			m.EditingValue = m.EditingValue[:len(m.EditingValue)-1]
@@ -515,10 +563,35 @@ func (m Model) saveConfigEdit() (tea.Model, tea.Cmd) {
 		m.Cfg.Config.ProxyType = m.EditingValue
 	case "proxy_address":
 		m.Cfg.Config.ProxyAddress = m.EditingValue
+	case "github_api_url", "github_proxy":
+		// 输入无效时留在编辑界面显示错误
+		if err := api.ValidateBaseURL(m.EditingValue); err != nil {
+			m.Err = err
+			return m, nil
+		}
+		if m.EditingKey == "github_api_url" {
+			m.Cfg.Config.GithubAPIURL = strings.TrimSpace(m.EditingValue)
+		} else {
+			m.Cfg.Config.GithubProxy = strings.TrimSpace(m.EditingValue)
+		}
+		m.Err = nil
 	case "pre_update_hook":
 		m.Cfg.Config.PreUpdateHook = m.EditingValue
 	case "post_update_hook":
 		m.Cfg.Config.PostUpdateHook = m.EditingValue
+	case "model_files":
+		// 输入无效时留在编辑界面显示错误
+		files, err := config.ParseModelFiles(m.EditingValue)
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		m.Cfg.Config.ModelFiles = files
+		m.Err = nil
+	case "require_checksum":
+		m.Cfg.Config.RequireChecksum = m.EditingValue == "true"
+	case "auto_select_source":
+		m.Cfg.Config.AutoSelectSource = m.EditingValue == "true"
 	case "theme_adaptive":
 		m.Cfg.Config.ThemeAdaptive = m.EditingValue == "true"
 		// 更新主题管理器
@@ -716,3 +789,39 @@ func (m *Model) InitEngineSelector() {
 	// 重置光标位置
 	m.EngineCursor = 0
 }
+
+// isConnectionField 判断配置项是否支持连接测试
+func isConnectionField(key string) bool {
+	return key == "github_api_url" || key == "github_proxy"
+}
+
+// testConnection 请求控制器用正在编辑（尚未保存）的地址测试连接，结果由 EvtConnectionTested 返回
+func (m Model) testConnection() (tea.Model, tea.Cmd) {
+	if m.ConnectionTesting {
+		return m, nil
+	}
+	if err := api.ValidateBaseURL(m.EditingValue); err != nil {
+		m.ConnectionResult = ""
+		m.ConnectionErr = err
+		return m, nil
+	}
+
+	m.ConnectionTesting = true
+	m.ConnectionResult = ""
+	m.ConnectionErr = nil
+	return m, m.sendCommand(controller.Command{
+		Type:    controller.CmdTestConnection,
+		Payload: controller.ConnectionTestPayload{Key: m.EditingKey, Value: m.EditingValue},
+	})
+}
+
+// probeSources 请求控制器测试各下载源的速度，结果由 EvtProbeComplete 返回；已在测速时不重复请求
+func (m Model) probeSources() (tea.Model, tea.Cmd) {
+	if m.Probing {
+		return m, nil
+	}
+
+	m.Probing = true
+	m.ProbeResults = nil
+	return m, m.sendCommand(controller.Command{Type: controller.CmdProbeSources})
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m Model) handleHistoryInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := len(m.historyLines()) - m.dryRunViewportHeight()
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewToolsMenu
+		m.HistoryEntries = nil
+		m.HistoryErr = nil
+		m.HistoryScroll = 0
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.HistoryScroll--
+	case "down", "j":
+		m.HistoryScroll++
+	case "pgup", "b":
+		m.HistoryScroll -= m.dryRunViewportHeight()
+	case "pgdown", " ", "f":
+		m.HistoryScroll += m.dryRunViewportHeight()
+	case "home", "g":
+		m.HistoryScroll = 0
+	case "end", "G":
+		m.HistoryScroll = maxScroll
+	}
+
+	m.HistoryScroll = max(0, min(m.HistoryScroll, maxScroll))
+	return m, nil
+}
+
+// historyLines 每条记录一行，失败的记录在下一行显示错误信息
+func (m Model) historyLines() []string {
+	if m.HistoryErr != nil {
+		return []string{m.Styles.ErrorText.Render(m.t("history.error", m.HistoryErr.Error()))}
+	}
+	mutedStyle := lipgloss.NewStyle().Foreground(m.Styles.Muted)
+	if len(m.HistoryEntries) == 0 {
+		return []string{mutedStyle.Render(m.t("history.empty"))}
+	}
+
+	outcomeStyles := map[string]lipgloss.Style{
+		updater.OutcomeSuccess:    lipgloss.NewStyle().Foreground(m.Styles.Success),
+		updater.OutcomeFailed:     lipgloss.NewStyle().Foreground(m.Styles.Error),
+		updater.OutcomeRolledBack: lipgloss.NewStyle().Foreground(m.Styles.Warning),
+	}
+
+	lines := make([]string, 0, len(m.HistoryEntries))
+	for _, entry := range m.HistoryEntries {
+		versions := fmt.Sprintf("%s → %s", orDash(entry.OldTag), orDash(entry.NewTag))
+		duration := (time.Duration(entry.DurationMS) * time.Millisecond).Round(100 * time.Millisecond)
+		details := fmt.Sprintf("%s  %s  %.2f MB", m.sourceLabel(entry.Source), duration, float64(entry.Bytes)/1024/1024)
+
+		lines = append(lines, fmt.Sprintf("%s  %-6s %-4s %-32s %s  %s",
+			mutedStyle.Render(entry.Time.Local().Format("2006-01-02 15:04")),
+			m.componentLabel(types.ComponentName(entry.Component)),
+			m.t("history.action."+entry.Action),
+			versions,
+			outcomeStyles[entry.Outcome].Render(m.t("history.outcome."+entry.Outcome)),
+			mutedStyle.Render(details),
+		))
+		if entry.Error != "" {
+			lines = append(lines, m.Styles.ErrorText.Render("    "+m.runtimeText(entry.Error)))
+		}
+	}
+	return lines
+}
+
+// orDash 空值显示为 -
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func (m Model) renderHistory() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("≡ "+m.t("history.title")+" ≡") + "\n\n")
+
+	lines := m.historyLines()
+	height := m.dryRunViewportHeight()
+	start := min(m.HistoryScroll, max(0, len(lines)-height))
+	end := min(len(lines), start+height)
+	b.WriteString(m.renderPanel(strings.Join(lines[start:end], "\n"), m.Styles.Primary) + "\n")
+
+	if len(lines) > height {
+		position := lipgloss.NewStyle().Foreground(m.Styles.Muted).
+			Render(m.t("dryrun.position", start+1, end, len(lines)))
+		b.WriteString(lipgloss.NewStyle().Width(m.pageWidth()).Align(lipgloss.Right).Render(position))
+	}
+	b.WriteString("\n" + m.Styles.Grid.Render(gridLine) + "\n\n")
+	b.WriteString(m.renderHintStrip(m.t("ui.hint.scroll"), m.t("ui.hint.back")))
+
+	return m.renderScreen(b.String())
+}
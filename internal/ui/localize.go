@@ -55,8 +55,14 @@ func (m Model) configFieldLabel(key string) string {
 		"proxy_enabled":         "config.field.proxy_enabled",
 		"proxy_type":            "config.field.proxy_type",
 		"proxy_address":         "config.field.proxy_address",
+		"github_api_url":        "config.field.github_api_url",
+		"github_proxy":          "config.field.github_proxy",
 		"pre_update_hook":       "config.field.pre_hook",
 		"post_update_hook":      "config.field.post_hook",
+		"model_files":           "config.field.model_files",
+		"require_checksum":      "config.field.require_checksum",
+		"auto_select_source":    "config.field.auto_select_source",
+		"probe_sources":         "config.field.probe_sources",
 		"exclude_file_manager":  "config.field.exclude",
 		"theme_adaptive":        "config.field.theme_adaptive",
 		"theme_light":           "config.field.theme_light",
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"strings"
+
+	"rime-wanxiang-updater/internal/termcolor"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+// mainMenuComponentPrefix 主菜单中单组件更新项的 key 前缀，后接组件 ID
+const mainMenuComponentPrefix = "update:"
+
+// componentMenuIcons 内置组件在主菜单中的图标（emoji 和回退字符），其他组件使用默认图标
+var componentMenuIcons = map[string][2]string{
+	types.ComponentScheme: {"📦", "▢"},
+	types.ComponentDict:   {"📚", "≡"},
+	types.ComponentModel:  {"🤖", "◈"},
+}
+
+// mainMenuItems 返回主菜单：自动更新、按注册顺序排列的各组件更新，之后是配置、定制、工具、向导和退出
+func (m Model) mainMenuItems() []customMenuItem {
+	items := []customMenuItem{{
+		key:  "auto",
+		icon: termcolor.GetFallbackIcon("⚡", "⟳"),
+		text: m.t("menu.auto_update.title"),
+		desc: m.t("menu.auto_update.desc"),
+	}}
+
+	for _, spec := range updater.Specs() {
+		items = append(items, m.componentMenuItem(spec.ID))
+	}
+
+	return append(items,
+		customMenuItem{
+			key:  "config",
+			icon: termcolor.GetFallbackIcon("⚙️", "⚙"),
+			text: m.t("menu.config.title"),
+			desc: m.t("menu.config.desc"),
+		},
+		customMenuItem{
+			key:  "custom",
+			icon: termcolor.GetFallbackIcon("🎨", "◐"),
+			text: m.t("menu.custom.title"),
+			desc: m.t("menu.custom.desc"),
+		},
+		customMenuItem{
+			key:  "tools",
+			icon: termcolor.GetFallbackIcon("🧰", "▤"),
+			text: m.t("menu.tools.title"),
+			desc: m.t("menu.tools.desc"),
+		},
+		customMenuItem{
+			key:  "wizard",
+			icon: termcolor.GetFallbackIcon("🧭", "◎"),
+			text: m.t("menu.wizard.title"),
+			desc: m.t("menu.wizard.desc"),
+		},
+		customMenuItem{
+			key:  "quit",
+			icon: termcolor.GetFallbackIcon("🚪", "×"),
+			text: m.t("menu.quit.title"),
+			desc: m.t("menu.quit.desc"),
+		},
+	)
+}
+
+// componentMenuItem 组件的更新菜单项；没有专门文案（menu.<id>_update.*）的组件使用通用文案
+func (m Model) componentMenuItem(id string) customMenuItem {
+	label := m.componentLabel(types.ComponentName(id))
+	item := customMenuItem{
+		key:  mainMenuComponentPrefix + id,
+		icon: termcolor.GetFallbackIcon("⬢", "•"),
+		text: m.menuText("menu."+id+"_update.title", "menu.component_update.title", label),
+		desc: m.menuText("menu."+id+"_update.desc", "menu.component_update.desc", label),
+	}
+	if icon, ok := componentMenuIcons[id]; ok {
+		item.icon = termcolor.GetFallbackIcon(icon[0], icon[1])
+	}
+	return item
+}
+
+// menuText 返回 key 的文案，目录中没有该 key 时用 label 填充通用文案 fallback
+func (m Model) menuText(key, fallback, label string) string {
+	if text := m.t(key); text != key {
+		return text
+	}
+	return m.t(fallback, label)
+}
+
+// componentMenuID 返回主菜单项对应的组件 ID，不是组件更新项时返回 false
+func componentMenuID(key string) (string, bool) {
+	return strings.CutPrefix(key, mainMenuComponentPrefix)
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMainMenuListsComponentsInRegistryOrder(t *testing.T) {
+	m := newToolsTestModel(t)
+
+	var keys []string
+	for _, item := range m.mainMenuItems() {
+		keys = append(keys, item.key)
+	}
+	want := "auto,update:scheme,update:dict,update:model,config,custom,tools,wizard,quit"
+	if got := strings.Join(keys, ","); got != want {
+		t.Errorf("mainMenuItems() keys = %s, want %s", got, want)
+	}
+	if got := m.mainMenuItems()[1].text; got != m.t("menu.scheme_update.title") {
+		t.Errorf("scheme item text = %q, want %q", got, m.t("menu.scheme_update.title"))
+	}
+}
+
+func TestMainMenuNumberKeySendsComponentUpdate(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.State = ViewMenu
+	m.CommandChan = commands
+
+	next, cmd := m.handleMenuInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	m = next.(Model)
+	if m.State != ViewUpdating || cmd == nil {
+		t.Fatalf("handleMenuInput(3) state = %v, cmd nil = %v, want %v and a command", m.State, cmd == nil, ViewUpdating)
+	}
+	cmd()
+	sent := <-commands
+	payload, _ := sent.Payload.(controller.UpdateComponentPayload)
+	if sent.Type != controller.CmdUpdateComponent || payload.Component != types.ComponentDict {
+		t.Errorf("sent command = %v %+v, want CmdUpdateComponent for %s", sent.Type, sent.Payload, types.ComponentDict)
+	}
+	if m.ProgressMsg != "检查词库更新..." {
+		t.Errorf("ProgressMsg = %q, want 检查词库更新...", m.ProgressMsg)
+	}
+}
+
+func TestMainMenuIncludesRegisteredComponent(t *testing.T) {
+	t.Cleanup(func() { updater.Unregister("fonts") })
+	updater.Register(updater.ComponentSpec{
+		ID:     "fonts",
+		Name:   "字体",
+		Weight: 0.1,
+		New: func(cfg *config.Manager) updater.Component {
+			return updater.NewModelUpdater(cfg)
+		},
+	})
+
+	m := newToolsTestModel(t)
+	items := m.mainMenuItems()
+	item := items[4]
+	if item.key != "update:fonts" {
+		t.Fatalf("items[4].key = %q, want update:fonts", item.key)
+	}
+	if item.text != "字体更新" || !strings.Contains(item.desc, "字体") {
+		t.Errorf("fonts item = %q / %q, want generic text for 字体", item.text, item.desc)
+	}
+	if last := items[len(items)-1].key; last != "quit" {
+		t.Errorf("last item = %q, want quit", last)
+	}
+
+	m.State = ViewMenu
+	m.MenuChoice = len(items) - 2
+	next, _ := m.handleMenuInput(tea.KeyMsg{Type: tea.KeyDown})
+	if got := next.(Model).MenuChoice; got != len(items)-1 {
+		t.Errorf("MenuChoice after down = %d, want %d", got, len(items)-1)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"rime-wanxiang-updater/internal/controller"
 	"rime-wanxiang-updater/internal/detector"
 	"rime-wanxiang-updater/internal/theme"
+	"rime-wanxiang-updater/internal/types"
 
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
@@ -128,10 +129,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleEnginePromptInput(msg)
 		case ViewResult:
 			return m.handleResultInput(msg)
+		case ViewToolsMenu:
+			return m.handleToolsMenuInput(msg)
+		case ViewDryRun:
+			return m.handleDryRunInput(msg)
+		case ViewUninstallConfirm:
+			return m.handleUninstallConfirmInput(msg)
+		case ViewVerify:
+			return m.handleVerifyInput(msg)
+		case ViewModifiedPrompt:
+			return m.handleModifiedPromptInput(msg)
+		case ViewRollback:
+			return m.handleRollbackInput(msg)
+		case ViewPins:
+			return m.handlePinsInput(msg)
+		case ViewHistory:
+			return m.handleHistoryInput(msg)
+		case ViewChannels:
+			return m.handleChannelsInput(msg)
 		case ViewUpdating:
 			switch msg.String() {
 			case "ctrl+c":
 				return m, tea.Quit
+			case "esc":
+				if m.Cancelling {
+					return m, nil
+				}
+				m.Cancelling = true
+				return m, m.sendCommand(controller.Command{Type: controller.CmdCancelUpdate})
 			}
 			return m, nil
 		}
@@ -202,6 +227,24 @@ func (m Model) View() string {
 		return m.renderEnginePrompt()
 	case ViewResult:
 		return m.renderResult()
+	case ViewToolsMenu:
+		return m.renderToolsMenu()
+	case ViewDryRun:
+		return m.renderDryRun()
+	case ViewUninstallConfirm:
+		return m.renderUninstallConfirm()
+	case ViewVerify:
+		return m.renderVerify()
+	case ViewModifiedPrompt:
+		return m.renderModifiedPrompt()
+	case ViewRollback:
+		return m.renderRollback()
+	case ViewPins:
+		return m.renderPins()
+	case ViewHistory:
+		return m.renderHistory()
+	case ViewChannels:
+		return m.renderChannels()
 	}
 	return ""
 }
@@ -231,6 +274,7 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 	case controller.EvtUpdateSuccess:
 		payload := evt.Payload.(controller.UpdateCompletePayload)
 		m.Updating = false
+		m.Cancelling = false
 		m.State = ViewResult
 		m.CurrentComponent = ""
 		m.IsDownloading = false
@@ -243,6 +287,7 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 		m.ResultSuccess = true
 		m.ResultSkipped = payload.Skipped
 		m.ResultMsg = m.runtimeText(payload.Message)
+		m.ResultHint = ""
 
 		if payload.UpdatedComponents != nil {
 			m.AutoUpdateResult = &AutoUpdateDetails{
@@ -259,6 +304,7 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 	case controller.EvtUpdateFailure:
 		payload := evt.Payload.(controller.UpdateCompletePayload)
 		m.Updating = false
+		m.Cancelling = false
 		m.State = ViewResult
 		m.CurrentComponent = ""
 		m.IsDownloading = false
@@ -270,6 +316,7 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 		m.DownloadSpeed = 0
 		m.ResultSuccess = false
 		m.ResultMsg = m.runtimeText(payload.Message)
+		m.ResultHint = m.failureHint(payload.Err)
 		m.AutoUpdateResult = nil
 
 		return m, listenForEvents(m.EventChan)
@@ -277,6 +324,7 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 	case controller.EvtUpdateSkipped:
 		payload := evt.Payload.(controller.UpdateCompletePayload)
 		m.Updating = false
+		m.Cancelling = false
 		m.State = ViewResult
 		m.CurrentComponent = ""
 		m.IsDownloading = false
@@ -289,6 +337,7 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 		m.ResultSuccess = true
 		m.ResultSkipped = true
 		m.ResultMsg = m.runtimeText(payload.Message)
+		m.ResultHint = ""
 
 		if payload.UpdatedComponents != nil {
 			m.AutoUpdateResult = &AutoUpdateDetails{
@@ -302,11 +351,98 @@ func (m Model) handleControllerEvent(evt controller.Event) (tea.Model, tea.Cmd)
 
 		return m, listenForEvents(m.EventChan)
 
+	case controller.EvtDryRunComplete:
+		payload := evt.Payload.(controller.DryRunCompletePayload)
+		m.Updating = false
+		m.Cancelling = false
+		m.State = ViewDryRun
+		m.CurrentComponent = ""
+		m.IsDownloading = false
+		m.DownloadSource = ""
+		m.DownloadURL = ""
+		m.DownloadFileName = ""
+		m.Downloaded = 0
+		m.TotalSize = 0
+		m.DownloadSpeed = 0
+		m.DryRunResult = payload.Result
+		m.DryRunScroll = 0
+
+		return m, listenForEvents(m.EventChan)
+
+	case controller.EvtUninstallComplete:
+		payload := evt.Payload.(controller.UninstallCompletePayload)
+		m.Updating = false
+		m.Cancelling = false
+		m.State = ViewResult
+		m.CurrentComponent = ""
+		m.ResultSkipped = false
+		m.ResultSuccess, m.ResultMsg = m.uninstallResultMessage(payload)
+		m.ResultHint = ""
+		m.AutoUpdateResult = nil
+
+		return m, listenForEvents(m.EventChan)
+
+	case controller.EvtVerifyComplete:
+		payload := evt.Payload.(controller.VerifyCompletePayload)
+		m.Updating = false
+		m.Cancelling = false
+		m.State = ViewVerify
+		m.CurrentComponent = ""
+		m.IsDownloading = false
+		m.VerifyReports = payload.Reports
+		m.VerifyScroll = 0
+
+		return m, listenForEvents(m.EventChan)
+
+	case controller.EvtVersionsLoaded:
+		payload := evt.Payload.(controller.VersionsLoadedPayload)
+		m.Updating = false
+		m.Cancelling = false
+		m.State = ViewRollback
+		m.CurrentComponent = ""
+		m.RollbackVersions = nil
+		for _, id := range types.ComponentIDs() {
+			m.RollbackVersions = append(m.RollbackVersions, payload.Versions[id]...)
+		}
+		m.RollbackChoice = 0
+		m.RollbackErr = payload.Err
+
+		return m, listenForEvents(m.EventChan)
+
+	case controller.EvtModifiedFiles:
+		payload := evt.Payload.(controller.ModifiedFilesPayload)
+		m.State = ViewModifiedPrompt
+		m.ModifiedComponent = payload.Component
+		m.ModifiedFiles = payload.Files
+		m.ModifiedChoice = 0
+		m.ModifiedRemember = false
+
+		return m, listenForEvents(m.EventChan)
+
 	case controller.EvtConfigUpdated:
 		// Configuration updated successfully
 		// Update is already in cfg, just continue listening
 		return m, listenForEvents(m.EventChan)
 
+	case controller.EvtProbeComplete:
+		payload := evt.Payload.(controller.ProbeCompletePayload)
+		m.Probing = false
+		m.ProbeResults = payload.Results
+		return m, listenForEvents(m.EventChan)
+
+	case controller.EvtConnectionTested:
+		payload := evt.Payload.(controller.ConnectionTestedPayload)
+		// 测试期间已离开编辑界面或改为编辑其他项时忽略结果
+		if payload.Key == m.EditingKey {
+			m.ConnectionTesting = false
+			m.ConnectionErr = payload.Err
+			m.ConnectionResult = ""
+			if payload.Err == nil {
+				m.ConnectionResult = m.t("config.connection.ok", payload.Duration.Milliseconds())
+			}
+		}
+		return m, listenForEvents(m.EventChan)
+
 	case controller.EvtWizardComplete:
 		m.State = ViewMenu
 		return m, listenForEvents(m.EventChan)
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWizardAsksForModelsAfterScheme(t *testing.T) {
+	commands := make(chan controller.Command, 2)
+	m := newToolsTestModel(t)
+	m.State = ViewWizard
+	m.CommandChan = commands
+
+	next, _ := m.handleWizardInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	m = next.(Model)
+	if m.WizardStep != WizardModel {
+		t.Fatalf("WizardStep after scheme = %v, want %v", m.WizardStep, WizardModel)
+	}
+
+	next, cmd := m.handleWizardInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'3'}})
+	m = next.(Model)
+	if m.WizardStep != WizardDownloadSource || cmd == nil {
+		t.Fatalf("handleWizardInput(3) step = %v, cmd nil = %v, want %v and a command", m.WizardStep, cmd == nil, WizardDownloadSource)
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			c()
+		}
+	}
+	sent := <-commands
+	payload, _ := sent.Payload.(controller.WizardModelPayload)
+	want := []string{types.MODEL_FILE, types.MODEL_HANT}
+	if sent.Type != controller.CmdWizardSetModel || !reflect.DeepEqual(payload.ModelFiles, want) {
+		t.Errorf("sent command = %v %+v, want CmdWizardSetModel with %v", sent.Type, sent.Payload, want)
+	}
+}
+
+func TestSaveConfigEditModelFiles(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.Cfg.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	m.State = ViewConfigEdit
+	m.EditingKey = "model_files"
+
+	m.EditingValue = "../evil.gram"
+	next, _ := m.saveConfigEdit()
+	m = next.(Model)
+	if m.State != ViewConfigEdit || m.Err == nil || m.Cfg.Config.ModelFiles != nil {
+		t.Fatalf("saveConfigEdit(invalid) state = %v, err = %v, files = %v, want to stay with an error", m.State, m.Err, m.Cfg.Config.ModelFiles)
+	}
+
+	m.EditingValue = types.MODEL_FILE + ", " + types.MODEL_HANT
+	next, _ = m.saveConfigEdit()
+	m = next.(Model)
+	want := []string{types.MODEL_FILE, types.MODEL_HANT}
+	if m.State != ViewConfig || m.Err != nil || !reflect.DeepEqual(m.Cfg.Config.ModelFiles, want) {
+		t.Errorf("saveConfigEdit() state = %v, err = %v, files = %v, want %v", m.State, m.Err, m.Cfg.Config.ModelFiles, want)
+	}
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"strings"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// modifiedPromptMaxFiles 对话框中最多列出的文件数
+const modifiedPromptMaxFiles = 6
+
+// modifiedActions 与 ModifiedChoice 0-2 对应的处理方式
+var modifiedActions = []string{updater.ModifiedKeep, updater.ModifiedOverwrite, updater.ModifiedBackup}
+
+// handleModifiedPromptInput 处理本地修改文件对话框输入；更新在收到回复前一直等待
+func (m Model) handleModifiedPromptInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.ModifiedChoice = 0
+		m.ModifiedRemember = false
+		return m.applyModifiedChoice()
+	case "up", "left", "k":
+		if m.ModifiedChoice > 0 {
+			m.ModifiedChoice--
+		}
+	case "down", "right", "j":
+		if m.ModifiedChoice < len(modifiedActions) {
+			m.ModifiedChoice++
+		}
+	case "1", "2", "3":
+		m.ModifiedChoice = int(msg.String()[0] - '1')
+	case " ":
+		if m.ModifiedChoice == len(modifiedActions) {
+			m.ModifiedRemember = !m.ModifiedRemember
+		}
+	case "enter":
+		if m.ModifiedChoice == len(modifiedActions) {
+			m.ModifiedRemember = !m.ModifiedRemember
+		} else {
+			return m.applyModifiedChoice()
+		}
+	}
+	return m, nil
+}
+
+// applyModifiedChoice 回复等待中的更新，勾选记住时写入配置
+func (m Model) applyModifiedChoice() (tea.Model, tea.Cmd) {
+	action := modifiedActions[m.ModifiedChoice]
+
+	if m.ModifiedRemember {
+		m.Cfg.Config.ModifiedFileAction = action
+		if err := m.Cfg.SaveConfig(); err != nil {
+			m.Err = err
+		}
+	}
+
+	m.State = ViewUpdating
+	m.ModifiedComponent = ""
+	m.ModifiedFiles = nil
+	return m, m.sendCommand(controller.Command{
+		Type:    controller.CmdResolveModified,
+		Payload: controller.ResolveModifiedPayload{Action: action},
+	})
+}
+
+// renderModifiedPrompt 渲染本地修改文件对话框
+func (m Model) renderModifiedPrompt() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("⚠ "+m.t("modified.title")+" ⚠") + "\n\n")
+
+	files := m.ModifiedFiles
+	if len(files) > modifiedPromptMaxFiles {
+		files = files[:modifiedPromptMaxFiles]
+	}
+	list := make([]string, 0, len(files)+1)
+	for _, file := range files {
+		list = append(list, "~ "+file)
+	}
+	if more := len(m.ModifiedFiles) - len(files); more > 0 {
+		list = append(list, m.t("modified.more", more))
+	}
+
+	question := m.Styles.WarningText.Render(m.t("modified.detected", m.componentLabel(m.ModifiedComponent), len(m.ModifiedFiles)))
+	question += "\n\n" + lipgloss.NewStyle().Foreground(m.Styles.Muted).Render(strings.Join(list, "\n"))
+	question += "\n\n" + m.Styles.ConfigValue.Render(m.t("modified.question"))
+
+	labels := []string{m.t("modified.keep"), m.t("modified.overwrite"), m.t("modified.backup")}
+	buttons := make([]string, 0, len(labels))
+	for i, label := range labels {
+		text := "[" + string(rune('1'+i)) + "] " + label
+		if m.ModifiedChoice == i {
+			buttons = append(buttons, m.Styles.DialogActiveButton.Render("► "+text))
+		} else {
+			buttons = append(buttons, m.Styles.DialogButton.Render(text))
+		}
+	}
+
+	checkbox := "[ ] " + m.t("modified.remember")
+	if m.ModifiedRemember {
+		checkbox = "[✓] " + m.t("modified.remember")
+	}
+	checkboxRendered := m.Styles.DialogCheckbox.Render(checkbox)
+	if m.ModifiedRemember {
+		checkboxRendered = m.Styles.NeonGreen.Render(checkbox)
+	}
+	if m.ModifiedChoice == len(modifiedActions) {
+		checkboxRendered = m.Styles.DialogActiveButton.Render("► " + checkbox)
+	}
+
+	questionBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.Styles.Warning).
+		Background(m.Styles.Surface).
+		Padding(1, 2).
+		Width(m.contentWidth(58)).
+		Render(question)
+	ui := lipgloss.JoinVertical(
+		lipgloss.Center,
+		questionBox,
+		"",
+		lipgloss.JoinVertical(lipgloss.Left, buttons...),
+		"",
+		checkboxRendered,
+	)
+
+	dialog := m.Styles.DialogBox.Width(m.contentWidth(66)).Render(ui)
+	b.WriteString(lipgloss.NewStyle().Width(m.pageWidth()).Align(lipgloss.Center).Render(dialog) + "\n\n")
+
+	b.WriteString(m.Styles.Grid.Render(gridLine) + "\n\n")
+	b.WriteString(m.Styles.Hint.Render(m.t("modified.hint")))
+
+	return m.renderScreen(b.String())
+}
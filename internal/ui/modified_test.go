@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestModifiedPromptSendsChoice(t *testing.T) {
+	tests := []struct {
+		name         string
+		keys         []tea.KeyMsg
+		wantAction   string
+		wantRemember bool
+	}{
+		{"esc keeps", []tea.KeyMsg{{Type: tea.KeyEsc}}, updater.ModifiedKeep, false},
+		{"shortcut", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune{'2'}}, {Type: tea.KeyEnter}}, updater.ModifiedOverwrite, false},
+		{
+			"remember backup",
+			[]tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeyDown}, {Type: tea.KeyDown}, {Type: tea.KeySpace}, {Type: tea.KeyUp}, {Type: tea.KeyEnter}},
+			updater.ModifiedBackup,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := make(chan controller.Command, 1)
+			m := newToolsTestModel(t)
+			m.Cfg.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+			m.State = ViewModifiedPrompt
+			m.CommandChan = commands
+			m.ModifiedComponent = "方案"
+			m.ModifiedFiles = []string{"wanxiang.schema.yaml"}
+
+			var next tea.Model = m
+			var cmd tea.Cmd
+			for _, key := range tt.keys {
+				next, cmd = next.(Model).handleModifiedPromptInput(key)
+			}
+			if got := next.(Model).State; got != ViewUpdating {
+				t.Fatalf("state = %v, want %v", got, ViewUpdating)
+			}
+			cmd()
+
+			sent := <-commands
+			payload, _ := sent.Payload.(controller.ResolveModifiedPayload)
+			if sent.Type != controller.CmdResolveModified || payload.Action != tt.wantAction {
+				t.Errorf("sent %v %+v, want %s", sent.Type, sent.Payload, tt.wantAction)
+			}
+			remembered := m.Cfg.Config.ModifiedFileAction == tt.wantAction
+			if remembered != tt.wantRemember {
+				t.Errorf("remembered action = %q, want remembered %v", m.Cfg.Config.ModifiedFileAction, tt.wantRemember)
+			}
+		})
+	}
+}
+
+func TestRenderModifiedPromptListsFiles(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewModifiedPrompt
+	m.ModifiedComponent = "方案"
+	m.ModifiedFiles = []string{"a.yaml", "b.yaml", "c.yaml", "d.yaml", "e.yaml", "f.yaml", "g.yaml", "h.yaml"}
+
+	rendered := m.renderModifiedPrompt()
+	for _, want := range []string{"~ a.yaml", m.t("modified.more", 2), m.t("modified.backup")} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("renderModifiedPrompt() missing %q", want)
+		}
+	}
+	if strings.Contains(rendered, "h.yaml") {
+		t.Errorf("renderModifiedPrompt() lists more than %d files", modifiedPromptMaxFiles)
+	}
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// handlePinsInput 处理版本固定界面输入；Enter / 空格将所选组件固定到当前安装的版本，已固定时取消固定
+func (m Model) handlePinsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ids := types.ComponentIDs()
+
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewToolsMenu
+		m.PinChoice = 0
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if m.PinChoice > 0 {
+			m.PinChoice--
+		}
+	case "down", "j":
+		if m.PinChoice < len(ids)-1 {
+			m.PinChoice++
+		}
+	case "enter", " ":
+		m.togglePin(ids[m.PinChoice])
+	}
+
+	return m, nil
+}
+
+// togglePin 固定或取消固定组件的版本并保存配置；组件未安装时不做任何改动
+func (m *Model) togglePin(componentID string) {
+	cfg := m.Cfg.Config
+	if _, pinned := cfg.PinnedVersions[componentID]; pinned {
+		delete(cfg.PinnedVersions, componentID)
+	} else {
+		pin := updater.PinForRecord(updater.InstalledRecord(m.Cfg, componentID))
+		if pin == "" {
+			return
+		}
+		if cfg.PinnedVersions == nil {
+			cfg.PinnedVersions = make(map[string]string)
+		}
+		cfg.PinnedVersions[componentID] = pin
+	}
+
+	if err := m.Cfg.SaveConfig(); err != nil {
+		m.Err = err
+	}
+}
+
+// pinLines 每个组件一行：组件名、当前安装的版本与固定状态
+func (m Model) pinLines() []string {
+	mutedStyle := lipgloss.NewStyle().Foreground(m.Styles.Muted)
+	pinnedStyle := lipgloss.NewStyle().Foreground(m.Styles.Warning)
+
+	lines := make([]string, 0, len(types.ComponentIDs()))
+	for i, id := range types.ComponentIDs() {
+		installed := m.t("pins.not_installed")
+		if record := updater.InstalledRecord(m.Cfg, id); record != nil {
+			installed = record.Tag
+		}
+
+		state := mutedStyle.Render(m.t("pins.unpinned"))
+		if pin := m.Cfg.Config.PinnedVersions[id]; pin != "" {
+			state = pinnedStyle.Render(m.t("pins.pinned", pin))
+		}
+
+		text := fmt.Sprintf("%-6s %-20s ", m.componentLabel(types.ComponentName(id)), installed)
+		if i == m.PinChoice {
+			lines = append(lines, m.Styles.DialogActiveButton.Render("► "+text)+state)
+		} else {
+			lines = append(lines, "  "+text+state)
+		}
+	}
+	return lines
+}
+
+func (m Model) renderPins() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("⊙ "+m.t("pins.title")+" ⊙") + "\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(m.Styles.Muted).Render(m.t("pins.subtitle")) + "\n\n")
+
+	content := strings.Join(m.pinLines(), "\n")
+	if m.Err != nil {
+		content += "\n\n" + m.Styles.ErrorText.Render(m.Err.Error())
+	}
+	b.WriteString(m.renderPanel(content, m.Styles.Primary) + "\n")
+	b.WriteString("\n" + m.Styles.Grid.Render(gridLine) + "\n\n")
+	b.WriteString(m.renderHintStrip(m.t("ui.hint.nav"), m.t("pins.hint.toggle"), m.t("ui.hint.back")))
+
+	return m.renderScreen(b.String())
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"rime-wanxiang-updater/internal/api"
+)
+
+// probeSourceLabel 返回测速结果对应的下载源名称
+func (m Model) probeSourceLabel(result api.ProbeResult) string {
+	if result.UseMirror {
+		return m.sourceLabel("CNB 镜像")
+	}
+	return m.sourceLabel("GitHub 官方源")
+}
+
+// renderProbeResults 渲染各下载源的测速详情和推荐的下载源，用于向导
+func (m Model) renderProbeResults() string {
+	if m.Probing {
+		return m.Styles.Hint.Render(m.t("probe.testing"))
+	}
+	if len(m.ProbeResults) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, result := range m.ProbeResults {
+		line := m.probeSourceLabel(result) + ": "
+		if result.Err != nil {
+			b.WriteString(m.Styles.ErrorText.Render(line+m.t("probe.failed", result.Err)) + "\n")
+			continue
+		}
+		line += m.t("probe.result", result.DNS.Milliseconds(), result.TLS.Milliseconds(),
+			result.FirstByte.Milliseconds(), result.Throughput/1024/1024)
+		b.WriteString(m.Styles.MenuItem.Render(line) + "\n")
+	}
+	if best, ok := api.FastestProbe(m.ProbeResults); ok {
+		b.WriteString(m.Styles.SuccessText.Render(m.t("probe.recommend", m.probeSourceLabel(best))) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// probeSummary 返回配置界面中测速一项的简要结果
+func (m Model) probeSummary() string {
+	if m.Probing {
+		return m.t("probe.testing")
+	}
+	if len(m.ProbeResults) == 0 {
+		return m.t("probe.not_run")
+	}
+
+	var parts []string
+	for _, result := range m.ProbeResults {
+		if result.Err != nil {
+			parts = append(parts, fmt.Sprintf("%s ✗", result.Source))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %.1f MB/s", result.Source, result.Throughput/1024/1024))
+	}
+	return strings.Join(parts, " · ")
+}
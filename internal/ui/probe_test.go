@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/controller"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWizardProbesSources(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.State = ViewWizard
+	m.WizardStep = WizardDownloadSource
+	m.CommandChan = commands
+
+	next, cmd := m.handleWizardInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = next.(Model)
+	if !m.Probing || cmd == nil {
+		t.Fatalf("handleWizardInput(t) probing = %v, cmd nil = %v, want a running probe", m.Probing, cmd == nil)
+	}
+	cmd()
+	if sent := <-commands; sent.Type != controller.CmdProbeSources {
+		t.Errorf("sent command = %v, want CmdProbeSources", sent.Type)
+	}
+	if _, cmd = m.probeSources(); cmd != nil {
+		t.Error("probeSources() while probing returned a command, want none")
+	}
+
+	next, _ = m.handleControllerEvent(controller.Event{
+		Type: controller.EvtProbeComplete,
+		Payload: controller.ProbeCompletePayload{Results: []api.ProbeResult{
+			{Source: "GitHub", Err: errors.New("timeout")},
+			{Source: "CNB", UseMirror: true, FirstByte: 80 * time.Millisecond, Throughput: 4 * 1024 * 1024},
+		}},
+	})
+	m = next.(Model)
+	if m.Probing || len(m.ProbeResults) != 2 {
+		t.Fatalf("after EvtProbeComplete probing = %v, results = %d, want 2 results", m.Probing, len(m.ProbeResults))
+	}
+	view := m.renderProbeResults()
+	if want := m.t("probe.recommend", m.sourceLabel("CNB 镜像")); !strings.Contains(view, want) {
+		t.Errorf("renderProbeResults() = %q, want recommendation %q", view, want)
+	}
+	if got := m.probeSummary(); got != "GitHub ✗ · CNB 4.0 MB/s" {
+		t.Errorf("probeSummary() = %q, want GitHub ✗ · CNB 4.0 MB/s", got)
+	}
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/types"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// handleRollbackInput 处理历史版本列表输入；Enter 回滚到所选版本
+func (m Model) handleRollbackInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewToolsMenu
+		m.RollbackVersions = nil
+		m.RollbackChoice = 0
+		m.RollbackErr = nil
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if m.RollbackChoice > 0 {
+			m.RollbackChoice--
+		}
+	case "down", "j":
+		if m.RollbackChoice < len(m.RollbackVersions)-1 {
+			m.RollbackChoice++
+		}
+	case "enter":
+		if m.RollbackChoice < 0 || m.RollbackChoice >= len(m.RollbackVersions) {
+			return m, nil
+		}
+		version := m.RollbackVersions[m.RollbackChoice]
+		m.State = ViewUpdating
+		m.Updating = true
+		m.CurrentComponent = types.ComponentName(version.Component)
+		m.ProgressMsg = m.runtimeText("正在读取历史版本...")
+		m.RollbackVersions = nil
+		m.RollbackChoice = 0
+		m.RollbackErr = nil
+		return m, m.sendCommand(controller.Command{Type: controller.CmdRollback, Payload: controller.RollbackPayload{Version: version}})
+	}
+
+	return m, nil
+}
+
+// rollbackLines 将历史版本展开为逐行文本，选中行高亮
+func (m Model) rollbackLines() []string {
+	mutedStyle := lipgloss.NewStyle().Foreground(m.Styles.Muted)
+
+	var lines []string
+	if m.RollbackErr != nil {
+		lines = append(lines, m.Styles.ErrorText.Render(m.t("rollback.error", m.runtimeText(m.RollbackErr.Error()))), "")
+	}
+	if len(m.RollbackVersions) == 0 {
+		return append(lines, mutedStyle.Render(m.t("rollback.empty")))
+	}
+
+	for i, version := range m.RollbackVersions {
+		text := fmt.Sprintf("%-6s %-20s %s", m.componentLabel(types.ComponentName(version.Component)),
+			version.Label(), version.ArchivedAt.Local().Format("2006-01-02 15:04"))
+		if i == m.RollbackChoice {
+			lines = append(lines, m.Styles.DialogActiveButton.Render("► "+text))
+		} else {
+			lines = append(lines, "  "+text)
+		}
+	}
+	return lines
+}
+
+func (m Model) renderRollback() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("↶ "+m.t("rollback.title")+" ↶") + "\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(m.Styles.Muted).Render(m.t("rollback.subtitle")) + "\n\n")
+
+	lines := m.rollbackLines()
+	height := m.dryRunViewportHeight()
+	selected := m.RollbackChoice + len(lines) - len(m.RollbackVersions)
+	start := max(0, min(selected-height+1, len(lines)-height))
+	end := min(len(lines), start+height)
+	b.WriteString(m.renderPanel(strings.Join(lines[start:end], "\n"), m.Styles.Primary) + "\n")
+	b.WriteString("\n" + m.Styles.Grid.Render(gridLine) + "\n\n")
+
+	hints := []string{m.t("ui.hint.nav")}
+	if len(m.RollbackVersions) > 0 {
+		hints = append(hints, m.t("rollback.hint.apply"))
+	}
+	hints = append(hints, m.t("ui.hint.back"))
+	b.WriteString(m.renderHintStrip(hints...))
+
+	return m.renderScreen(b.String())
+}
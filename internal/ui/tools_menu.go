@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m Model) toolsMenuItems() []customMenuItem {
+	return []customMenuItem{
+		{
+			key:  "dry_run",
+			icon: "◇",
+			text: m.t("tools.dry_run.title"),
+			desc: m.t("tools.dry_run.desc"),
+		},
+		{
+			key:  "verify",
+			icon: "◈",
+			text: m.t("tools.verify.title"),
+			desc: m.t("tools.verify.desc"),
+		},
+		{
+			key:  "rollback",
+			icon: "↶",
+			text: m.t("tools.rollback.title"),
+			desc: m.t("tools.rollback.desc"),
+		},
+		{
+			key:  "pins",
+			icon: "⊙",
+			text: m.t("tools.pins.title"),
+			desc: m.t("tools.pins.desc"),
+		},
+		{
+			key:  "channels",
+			icon: "⇅",
+			text: m.t("tools.channels.title"),
+			desc: m.t("tools.channels.desc"),
+		},
+		{
+			key:  "history",
+			icon: "≡",
+			text: m.t("tools.history.title"),
+			desc: m.t("tools.history.desc"),
+		},
+		{
+			key:  "uninstall",
+			icon: "◌",
+			text: m.t("tools.uninstall.title"),
+			desc: m.t("tools.uninstall.desc"),
+		},
+	}
+}
+
+func (m Model) handleToolsMenuInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.toolsMenuItems()
+
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewMenu
+		m.ToolsMenuChoice = 0
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if m.ToolsMenuChoice > 0 {
+			m.ToolsMenuChoice--
+		}
+	case "down", "j":
+		if m.ToolsMenuChoice < len(items)-1 {
+			m.ToolsMenuChoice++
+		}
+	case "enter":
+		return m.applyToolsMenuChoice()
+	default:
+		if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= len(items) {
+			m.ToolsMenuChoice = n - 1
+			return m.applyToolsMenuChoice()
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) applyToolsMenuChoice() (tea.Model, tea.Cmd) {
+	items := m.toolsMenuItems()
+	if m.ToolsMenuChoice < 0 || m.ToolsMenuChoice >= len(items) {
+		return m, nil
+	}
+
+	switch items[m.ToolsMenuChoice].key {
+	case "dry_run":
+		m.State = ViewUpdating
+		m.Updating = true
+		m.ProgressMsg = m.runtimeText("正在生成更新预览...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdDryRun})
+	case "verify":
+		m.State = ViewUpdating
+		m.Updating = true
+		m.ProgressMsg = m.runtimeText("正在校验已安装文件...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdVerify, Payload: controller.VerifyPayload{}})
+	case "rollback":
+		m.State = ViewUpdating
+		m.Updating = true
+		m.ProgressMsg = m.runtimeText("正在读取历史版本...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdListVersions})
+	case "pins":
+		m.State = ViewPins
+		m.PinChoice = 0
+		return m, nil
+	case "channels":
+		m.State = ViewChannels
+		m.ChannelChoice = 0
+		return m, nil
+	case "history":
+		m.State = ViewHistory
+		m.HistoryEntries, m.HistoryErr = updater.ReadHistory(m.Cfg.GetHistoryPath())
+		m.HistoryScroll = 0
+		return m, nil
+	case "uninstall":
+		m.State = ViewUninstallConfirm
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) renderToolsMenu() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("⚙ "+m.t("tools.menu.title")+" ⚙") + "\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(m.Styles.Muted).Render(m.t("tools.menu.subtitle")) + "\n\n")
+
+	items := m.toolsMenuItems()
+	menuItems := make([]menuEntry, 0, len(items))
+	for _, item := range items {
+		menuItems = append(menuItems, menuEntry{
+			icon: item.icon,
+			text: item.text,
+			desc: item.desc,
+		})
+	}
+
+	b.WriteString(m.renderChoiceList(menuItems, m.ToolsMenuChoice) + "\n\n")
+	b.WriteString(m.Styles.Grid.Render(gridLine) + "\n\n")
+	b.WriteString(m.renderHintStrip(m.t("ui.hint.nav"), m.t("ui.hint.select"), m.t("ui.hint.back")))
+
+	return m.renderScreen(b.String())
+}
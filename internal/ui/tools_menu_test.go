@@ -0,0 +1,337 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/theme"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newToolsTestModel(t *testing.T) Model {
+	t.Helper()
+
+	themeMgr := theme.NewManager()
+	if err := themeMgr.SetTheme("one-dark"); err != nil {
+		t.Fatalf("SetTheme() error = %v", err)
+	}
+
+	return Model{
+		Width:        80,
+		Height:       40,
+		ThemeManager: themeMgr,
+		Styles:       DefaultStyles(themeMgr),
+		Cfg: &config.Manager{
+			Config: &types.Config{Language: "zh-CN"},
+		},
+	}
+}
+
+func TestHandleMenuInputOpensToolsMenu(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewMenu
+
+	next, _ := m.handleMenuInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	if got := next.(Model).State; got != ViewToolsMenu {
+		t.Fatalf("handleMenuInput(7) state = %v, want %v", got, ViewToolsMenu)
+	}
+
+	next, _ = m.handleMenuInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'8'}})
+	if got := next.(Model).State; got != ViewWizard {
+		t.Fatalf("handleMenuInput(8) state = %v, want %v", got, ViewWizard)
+	}
+}
+
+func TestToolsMenuStartsDryRun(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.State = ViewToolsMenu
+	m.CommandChan = commands
+
+	next, cmd := m.handleToolsMenuInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	if got := next.(Model).State; got != ViewUpdating {
+		t.Fatalf("dry run state = %v, want %v", got, ViewUpdating)
+	}
+	if cmd == nil {
+		t.Fatal("dry run returned nil command")
+	}
+	cmd()
+
+	select {
+	case sent := <-commands:
+		if sent.Type != controller.CmdDryRun {
+			t.Fatalf("sent command = %v, want %v", sent.Type, controller.CmdDryRun)
+		}
+	default:
+		t.Fatal("dry run command was not sent")
+	}
+}
+
+func TestUpdatingViewEscCancelsOnce(t *testing.T) {
+	commands := make(chan controller.Command, 2)
+	m := newToolsTestModel(t)
+	m.State = ViewUpdating
+	m.CommandChan = commands
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+	if !m.Cancelling || m.State != ViewUpdating {
+		t.Fatalf("after esc Cancelling = %v, State = %v, want true, %v", m.Cancelling, m.State, ViewUpdating)
+	}
+	if cmd == nil {
+		t.Fatal("esc returned nil command")
+	}
+	cmd()
+	if sent := <-commands; sent.Type != controller.CmdCancelUpdate {
+		t.Fatalf("sent command = %v, want %v", sent.Type, controller.CmdCancelUpdate)
+	}
+	if !strings.Contains(m.renderUpdating(), m.t("updating.cancelling")) {
+		t.Error("renderUpdating() does not show the cancelling notice")
+	}
+
+	if _, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc}); cmd != nil {
+		t.Error("second esc returned a command, want the cancel to be sent only once")
+	}
+}
+
+func TestRenderDryRunListsChanges(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewDryRun
+	m.DryRunResult = &updater.DryRunResult{
+		Components: []*updater.ComponentPlan{
+			{
+				Component: "方案",
+				Status:    &types.UpdateStatus{LocalVersion: "v1", RemoteVersion: "v2", NeedsUpdate: true},
+				TargetDir: "/tmp/rime",
+				Changes: updater.FileChanges{
+					Added:    []string{"lua/new.lua"},
+					Deleted:  []string{"lua/old.lua"},
+					Excluded: []string{"default.custom.yaml"},
+				},
+			},
+			{
+				Component: "词库",
+				Status:    &types.UpdateStatus{LocalVersion: "d1", RemoteVersion: "d1"},
+			},
+			{
+				Component: "模型",
+				Err:       errors.New("network down"),
+			},
+		},
+	}
+
+	rendered := m.renderDryRun()
+	for _, want := range []string{"+ lua/new.lua", "- lua/old.lua", "= default.custom.yaml", m.t("dryrun.up_to_date"), "network down"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("renderDryRun() missing %q", want)
+		}
+	}
+}
+
+func TestHandleDryRunInputClampsScroll(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewDryRun
+	m.DryRunResult = &updater.DryRunResult{
+		Components: []*updater.ComponentPlan{{
+			Component: "方案",
+			Status:    &types.UpdateStatus{NeedsUpdate: true},
+			Changes:   updater.FileChanges{Added: make([]string, 100)},
+		}},
+	}
+
+	next, _ := m.handleDryRunInput(tea.KeyMsg{Type: tea.KeyUp})
+	if got := next.(Model).DryRunScroll; got != 0 {
+		t.Fatalf("scroll after up = %d, want 0", got)
+	}
+
+	next, _ = next.(Model).handleDryRunInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}})
+	want := len(m.dryRunLines()) - m.dryRunViewportHeight()
+	if got := next.(Model).DryRunScroll; got != want {
+		t.Fatalf("scroll after G = %d, want %d", got, want)
+	}
+}
+
+func TestVerifyRepairSendsCommand(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.State = ViewVerify
+	m.CommandChan = commands
+	m.VerifyReports = []*updater.VerifyReport{{
+		Component: "方案",
+		Reference: updater.VerifyByManifest,
+		Root:      "/tmp/rime",
+		Missing:   []string{"default.yaml"},
+		Modified:  []string{"lua/wanxiang.lua"},
+	}}
+
+	rendered := m.renderVerify()
+	for _, want := range []string{"- default.yaml", "~ lua/wanxiang.lua", m.t("verify.hint.repair")} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("renderVerify() missing %q", want)
+		}
+	}
+
+	next, cmd := m.handleVerifyInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := next.(Model).State; got != ViewUpdating {
+		t.Fatalf("repair state = %v, want %v", got, ViewUpdating)
+	}
+	cmd()
+
+	sent := <-commands
+	if payload, _ := sent.Payload.(controller.VerifyPayload); sent.Type != controller.CmdVerify || !payload.Repair {
+		t.Fatalf("sent command = %v %+v, want repair verify", sent.Type, sent.Payload)
+	}
+}
+
+func TestRollbackSendsSelectedVersion(t *testing.T) {
+	commands := make(chan controller.Command, 1)
+	m := newToolsTestModel(t)
+	m.State = ViewRollback
+	m.CommandChan = commands
+	m.RollbackVersions = []updater.ArchivedVersion{
+		{ID: "a", Component: types.ComponentScheme, Record: types.UpdateRecord{Tag: "v2"}},
+		{ID: "b", Component: types.ComponentDict, Record: types.UpdateRecord{Tag: "dict-v1"}},
+	}
+
+	rendered := m.renderRollback()
+	for _, want := range []string{"v2", "dict-v1", m.t("rollback.hint.apply")} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("renderRollback() missing %q", want)
+		}
+	}
+
+	next, _ := m.handleRollbackInput(tea.KeyMsg{Type: tea.KeyDown})
+	next, cmd := next.(Model).handleRollbackInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := next.(Model).State; got != ViewUpdating {
+		t.Fatalf("rollback state = %v, want %v", got, ViewUpdating)
+	}
+	cmd()
+
+	sent := <-commands
+	if payload, _ := sent.Payload.(controller.RollbackPayload); sent.Type != controller.CmdRollback || payload.Version.ID != "b" {
+		t.Fatalf("sent command = %v %+v, want rollback to b", sent.Type, sent.Payload)
+	}
+}
+
+func TestPinsToggleInstalledVersion(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewPins
+	tmpDir := t.TempDir()
+	m.Cfg.ConfigPath = filepath.Join(tmpDir, "config.json")
+	m.Cfg.CacheDir = tmpDir
+	m.Cfg.Config.SchemeFile = "scheme.zip"
+	record := `{"name": "scheme.zip", "tag": "v1.2.0"}`
+	if err := os.WriteFile(m.Cfg.GetSchemeRecordPath(), []byte(record), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	next, _ := m.handlePinsInput(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if got := m.Cfg.Config.PinnedVersions[types.ComponentScheme]; got != "v1.2.0" {
+		t.Fatalf("pinned scheme = %q, want %q", got, "v1.2.0")
+	}
+	if !strings.Contains(m.renderPins(), m.t("pins.pinned", "v1.2.0")) {
+		t.Errorf("renderPins() does not show the pinned version")
+	}
+
+	// 词库未安装，不能固定
+	next, _ = m.handlePinsInput(tea.KeyMsg{Type: tea.KeyDown})
+	next, _ = next.(Model).handlePinsInput(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if _, ok := m.Cfg.Config.PinnedVersions[types.ComponentDict]; ok {
+		t.Errorf("pinned a dictionary that is not installed")
+	}
+
+	next, _ = m.handlePinsInput(tea.KeyMsg{Type: tea.KeyUp})
+	next, _ = next.(Model).handlePinsInput(tea.KeyMsg{Type: tea.KeyEnter})
+	if pins := next.(Model).Cfg.Config.PinnedVersions; len(pins) != 0 {
+		t.Errorf("pins after unpinning = %v, want none", pins)
+	}
+}
+
+func TestToolsMenuOpensHistory(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewToolsMenu
+	m.Cfg.CacheDir = t.TempDir()
+	entries := make([]types.HistoryEntry, 0, 60)
+	for i := range 60 {
+		entries = append(entries, types.HistoryEntry{
+			Component: types.ComponentDict,
+			Action:    updater.HistoryUpdate,
+			NewTag:    fmt.Sprintf("d%d", i),
+			Outcome:   updater.OutcomeSuccess,
+		})
+	}
+	entries[59].Outcome = updater.OutcomeFailed
+	entries[59].Error = "下载失败: boom"
+	if err := updater.AppendHistory(m.Cfg.GetHistoryPath(), entries...); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range m.toolsMenuItems() {
+		if item.key == "history" {
+			break
+		}
+		m.ToolsMenuChoice++
+	}
+	next, _ := m.applyToolsMenuChoice()
+	m = next.(Model)
+	if m.State != ViewHistory || len(m.HistoryEntries) != 60 {
+		t.Fatalf("history state = %v with %d entries, want %v with 60", m.State, len(m.HistoryEntries), ViewHistory)
+	}
+
+	// 最新的记录在最前面
+	rendered := m.renderHistory()
+	for _, want := range []string{"- → d59", m.t("history.outcome.failed"), "boom"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("renderHistory() missing %q", want)
+		}
+	}
+
+	next, _ = m.handleHistoryInput(tea.KeyMsg{Type: tea.KeyEnd})
+	m = next.(Model)
+	if want := len(m.historyLines()) - m.dryRunViewportHeight(); m.HistoryScroll != want {
+		t.Errorf("HistoryScroll after End = %d, want %d", m.HistoryScroll, want)
+	}
+	if !strings.Contains(m.renderHistory(), "- → d0") {
+		t.Errorf("renderHistory() at the end does not show the oldest entry")
+	}
+}
+
+func TestChannelsCycleAndResetToDefault(t *testing.T) {
+	m := newToolsTestModel(t)
+	m.State = ViewChannels
+	m.Cfg.ConfigPath = filepath.Join(t.TempDir(), "config.json")
+	m.ChannelChoice = 1 // 词库，默认为 nightly
+
+	next, _ := m.handleChannelsInput(tea.KeyMsg{Type: tea.KeyRight})
+	m = next.(Model)
+	if got := m.Cfg.Config.ReleaseChannels[types.ComponentDict]; got != releaseutil.ChannelPrerelease {
+		t.Fatalf("dict channel = %q, want %q", got, releaseutil.ChannelPrerelease)
+	}
+	if !strings.Contains(m.renderChannels(), m.t("channels.prerelease")) {
+		t.Errorf("renderChannels() does not show the prerelease channel")
+	}
+
+	// 切回默认渠道时从配置中删除
+	next, _ = m.handleChannelsInput(tea.KeyMsg{Type: tea.KeyLeft})
+	m = next.(Model)
+	if _, ok := m.Cfg.Config.ReleaseChannels[types.ComponentDict]; ok {
+		t.Errorf("ReleaseChannels = %v, want dict removed when back on the default", m.Cfg.Config.ReleaseChannels)
+	}
+
+	next, _ = m.handleChannelsInput(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := next.(Model).Cfg.Config.ReleaseChannels[types.ComponentDict]; got != releaseutil.ChannelStable {
+		t.Errorf("dict channel = %q, want %q", got, releaseutil.ChannelStable)
+	}
+}
@@ -1,10 +1,13 @@
 package ui
 
 import (
+	"rime-wanxiang-updater/internal/api"
 	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/controller"
 	"rime-wanxiang-updater/internal/detector"
 	"rime-wanxiang-updater/internal/theme"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
 
 	"github.com/charmbracelet/bubbles/progress"
 )
@@ -32,8 +35,17 @@ const (
 	ViewFcitxThemeList
 	ViewFcitxThemeDefaultList
 	ViewFcitxThemeDeployPrompt
-	ViewEngineSelector // 引擎选择界面
-	ViewEnginePrompt   // 多引擎未配置提示对话框
+	ViewEngineSelector   // 引擎选择界面
+	ViewEnginePrompt     // 多引擎未配置提示对话框
+	ViewToolsMenu        // 维护工具子菜单
+	ViewDryRun           // 更新预览
+	ViewUninstallConfirm // 卸载确认
+	ViewVerify           // 文件校验结果
+	ViewModifiedPrompt   // 更新时发现本地修改的文件
+	ViewRollback         // 选择要回滚到的历史版本
+	ViewPins             // 固定或取消固定各组件的版本
+	ViewHistory          // 更新历史
+	ViewChannels         // 选择各组件的发布渠道
 )
 
 // WizardStep 向导步骤
@@ -42,6 +54,7 @@ type WizardStep int
 const (
 	WizardSchemeType WizardStep = iota
 	WizardSchemeVariant
+	WizardModel
 	WizardDownloadSource
 	WizardComplete
 )
@@ -69,6 +82,15 @@ type Model struct {
 	EditingKey   string
 	EditingValue string
 
+	// 连接测试（GitHub API 地址和下载代理），结果在编辑界面显示
+	ConnectionTesting bool
+	ConnectionResult  string
+	ConnectionErr     error
+
+	// 下载源测速（向导和配置界面）
+	Probing      bool
+	ProbeResults []api.ProbeResult
+
 	// Wizard UI state
 	SchemeChoice  string
 	VariantChoice string
@@ -86,11 +108,13 @@ type Model struct {
 	TotalSize        int64
 	DownloadSpeed    float64
 	IsDownloading    bool
+	Cancelling       bool // 已请求取消，等待控制器结束当前操作
 
 	// Result display (received from controller)
 	ResultMsg        string
 	ResultSuccess    bool
 	ResultSkipped    bool
+	ResultHint       string // 失败原因的补充说明（如更新包被安全检查拒绝）
 	AutoUpdateResult *AutoUpdateDetails
 
 	// Display state
@@ -138,6 +162,35 @@ type Model struct {
 	FcitxThemeDarkSelected  string
 	FcitxThemeCurrent       FcitxThemeConfig
 
+	// Tools menu and dry-run preview UI state
+	ToolsMenuChoice int
+	DryRunResult    *updater.DryRunResult
+	DryRunScroll    int
+	VerifyReports   []*updater.VerifyReport
+	VerifyScroll    int
+
+	// Rollback version list UI state
+	RollbackVersions []updater.ArchivedVersion // 各组件可回滚的历史版本，按方案、词库、模型排列
+	RollbackChoice   int
+	RollbackErr      error
+
+	// Version pin UI state
+	PinChoice int
+
+	// Release channel UI state
+	ChannelChoice int
+
+	// Update history UI state
+	HistoryEntries []types.HistoryEntry // 从新到旧
+	HistoryScroll  int
+	HistoryErr     error
+
+	// Locally modified files prompt (shown while an update waits for an answer)
+	ModifiedComponent string
+	ModifiedFiles     []string
+	ModifiedChoice    int // 0 保留 1 覆盖 2 备份 3 记住选择
+	ModifiedRemember  bool
+
 	// Engine selector UI state
 	EngineSelections map[string]bool // 引擎名 -> 是否选中
 	EngineCursor     int             // 当前光标位置
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m Model) handleUninstallConfirmInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.State = ViewUpdating
+		m.Updating = true
+		m.ProgressMsg = m.runtimeText("正在卸载万象文件...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdUninstall})
+	default:
+		m.State = ViewToolsMenu
+		return m, nil
+	}
+}
+
+func (m Model) renderUninstallConfirm() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("◌ "+m.t("uninstall.confirm_title")+" ◌") + "\n\n")
+	b.WriteString(m.renderPanel(m.t("uninstall.confirm_body"), m.Styles.Warning) + "\n\n")
+	b.WriteString(m.Styles.Grid.Render(gridLine) + "\n\n")
+	b.WriteString(m.renderHintStrip(m.t("uninstall.hint.confirm"), m.t("uninstall.hint.return")))
+
+	return m.renderScreen(b.String())
+}
+
+// uninstallResultMessage 汇总各组件的卸载结果；没有安装清单的组件视为跳过，不算失败
+func (m Model) uninstallResultMessage(payload controller.UninstallCompletePayload) (bool, string) {
+	if payload.Err != nil {
+		return false, m.t("uninstall.result.error", payload.Err)
+	}
+
+	success := true
+	lines := make([]string, 0, len(payload.Results)+1)
+	for _, result := range payload.Results {
+		label := m.componentLabel(result.Component)
+		switch {
+		case errors.Is(result.Err, updater.ErrNoManifest):
+			lines = append(lines, m.t("uninstall.result.no_manifest", label))
+		case result.Err != nil:
+			success = false
+			lines = append(lines, m.t("uninstall.result.failed", label, result.Err))
+		default:
+			lines = append(lines, m.t("uninstall.result.component", label, len(result.Removed), len(result.Kept)))
+		}
+	}
+	lines = append(lines, m.t("uninstall.result.redeploy"))
+
+	return success, strings.Join(lines, "\n")
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"rime-wanxiang-updater/internal/controller"
+	"rime-wanxiang-updater/internal/updater"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// verifyNeedsRepair 返回校验结果中是否还有可尝试修复的文件
+func (m Model) verifyNeedsRepair() bool {
+	for _, report := range m.VerifyReports {
+		if report.Err == nil && len(report.Unresolved()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) handleVerifyInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := len(m.verifyLines()) - m.dryRunViewportHeight()
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		m.State = ViewToolsMenu
+		m.VerifyReports = nil
+		m.VerifyScroll = 0
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.VerifyScroll--
+	case "down", "j":
+		m.VerifyScroll++
+	case "pgup", "b":
+		m.VerifyScroll -= m.dryRunViewportHeight()
+	case "pgdown", " ", "f":
+		m.VerifyScroll += m.dryRunViewportHeight()
+	case "home", "g":
+		m.VerifyScroll = 0
+	case "end", "G":
+		m.VerifyScroll = maxScroll
+	case "enter", "r":
+		if !m.verifyNeedsRepair() {
+			return m, nil
+		}
+		m.State = ViewUpdating
+		m.Updating = true
+		m.VerifyReports = nil
+		m.VerifyScroll = 0
+		m.ProgressMsg = m.runtimeText("正在修复已安装文件...")
+		return m, m.sendCommand(controller.Command{Type: controller.CmdVerify, Payload: controller.VerifyPayload{Repair: true}})
+	}
+
+	m.VerifyScroll = max(0, min(m.VerifyScroll, maxScroll))
+	return m, nil
+}
+
+// verifyLines 将校验结果展开为逐行文本
+func (m Model) verifyLines() []string {
+	titleStyle := lipgloss.NewStyle().Foreground(m.Styles.Primary).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(m.Styles.Muted)
+	markers := []struct {
+		mark  string
+		style lipgloss.Style
+		files func(*updater.VerifyReport) []string
+	}{
+		{"+", lipgloss.NewStyle().Foreground(m.Styles.Success), func(r *updater.VerifyReport) []string { return r.Repaired }},
+		{"-", lipgloss.NewStyle().Foreground(m.Styles.Error), func(r *updater.VerifyReport) []string { return r.Missing }},
+		{"~", lipgloss.NewStyle().Foreground(m.Styles.Warning), func(r *updater.VerifyReport) []string { return r.Modified }},
+		{"?", mutedStyle, func(r *updater.VerifyReport) []string { return r.Unexpected }},
+	}
+
+	var lines []string
+	for i, report := range m.VerifyReports {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		header := m.componentLabel(report.Component)
+		if report.Reference != "" {
+			header = fmt.Sprintf("%s  %s", header, m.t("verify.reference."+report.Reference))
+		}
+		lines = append(lines, titleStyle.Render(header))
+		if report.Root != "" {
+			lines = append(lines, mutedStyle.Render("  "+m.t("verify.target", report.Root)))
+		}
+		if report.Err != nil {
+			lines = append(lines, m.Styles.ErrorText.Render("  "+m.t("verify.error", m.runtimeText(report.Err.Error()))))
+		}
+		if report.Reference == "" {
+			continue
+		}
+
+		lines = append(lines, mutedStyle.Render("  "+m.t("verify.counts",
+			len(report.Missing), len(report.Modified), len(report.Unexpected), len(report.Repaired))))
+		if len(report.Missing)+len(report.Modified) == 0 {
+			lines = append(lines, lipgloss.NewStyle().Foreground(m.Styles.Success).Render("  "+m.t("verify.clean")))
+		}
+		for _, marker := range markers {
+			for _, file := range marker.files(report) {
+				lines = append(lines, marker.style.Render(fmt.Sprintf("  %s %s", marker.mark, file)))
+			}
+		}
+	}
+
+	return lines
+}
+
+func (m Model) renderVerify() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderHeaderBlock())
+	b.WriteString(m.renderTitle("◈ "+m.t("verify.title")+" ◈") + "\n\n")
+
+	lines := m.verifyLines()
+	height := m.dryRunViewportHeight()
+	start := min(m.VerifyScroll, max(0, len(lines)-height))
+	end := min(len(lines), start+height)
+
+	border := m.Styles.Success
+	if m.verifyNeedsRepair() {
+		border = m.Styles.Warning
+	}
+	b.WriteString(m.renderPanel(strings.Join(lines[start:end], "\n"), border) + "\n")
+
+	if len(lines) > height {
+		position := lipgloss.NewStyle().Foreground(m.Styles.Muted).
+			Render(m.t("dryrun.position", start+1, end, len(lines)))
+		b.WriteString(lipgloss.NewStyle().Width(m.pageWidth()).Align(lipgloss.Right).Render(position))
+	}
+	b.WriteString("\n" + m.Styles.Grid.Render(gridLine) + "\n\n")
+
+	hints := []string{m.t("ui.hint.scroll")}
+	if m.verifyNeedsRepair() {
+		hints = append(hints, m.t("verify.hint.repair"))
+	}
+	hints = append(hints, m.t("ui.hint.back"))
+	b.WriteString(m.renderHintStrip(hints...))
+
+	return m.renderScreen(b.String())
+}
@@ -1,13 +1,15 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 
-	"rime-wanxiang-updater/internal/termcolor"
+	"rime-wanxiang-updater/internal/fileutil"
 	"rime-wanxiang-updater/internal/types"
 	"rime-wanxiang-updater/internal/version"
 
@@ -523,6 +525,20 @@ func (m Model) renderWizard() string {
 		hint := m.Styles.Hint.Render(m.t("wizard.hint.1_7"))
 		b.WriteString(hint)
 
+	case WizardModel:
+		b.WriteString(m.renderTitle("⚡ "+m.t("wizard.title")+" ⚡") + "\n\n")
+
+		question := m.Styles.InfoBox.Render("▸ " + m.t("wizard.model"))
+		b.WriteString(question + "\n\n")
+
+		b.WriteString(m.Styles.MenuItem.Render("  [1] ► "+m.t("wizard.model.hans")) + "\n")
+		b.WriteString(m.Styles.MenuItem.Render("  [2] ► "+m.t("wizard.model.hant")) + "\n")
+		b.WriteString(m.Styles.MenuItem.Render("  [3] ► "+m.t("wizard.model.both")) + "\n\n")
+
+		b.WriteString(m.Styles.Grid.Render(gridLine) + "\n")
+		hint := m.Styles.Hint.Render(m.t("wizard.hint.1_3"))
+		b.WriteString(hint)
+
 	case WizardDownloadSource:
 		b.WriteString(m.renderTitle("⚡ "+m.t("wizard.title")+" ⚡") + "\n\n")
 
@@ -531,9 +547,12 @@ func (m Model) renderWizard() string {
 
 		b.WriteString(m.Styles.MenuItem.Render("  [1] ► "+m.t("wizard.source.cnb")) + "\n")
 		b.WriteString(m.Styles.MenuItem.Render("  [2] ► "+m.t("wizard.source.github")) + "\n\n")
+		if probe := m.renderProbeResults(); probe != "" {
+			b.WriteString(probe + "\n\n")
+		}
 
 		b.WriteString(m.Styles.Grid.Render(gridLine) + "\n")
-		hint := m.Styles.Hint.Render(m.t("wizard.hint.1_2"))
+		hint := m.Styles.Hint.Render(m.t("wizard.hint.source"))
 		b.WriteString(hint)
 	}
 
@@ -566,47 +585,10 @@ func (m Model) renderMenu() string {
 	})
 	b.WriteString(statusContent + "\n\n")
 
-	menuItems := []menuEntry{
-		{
-			termcolor.GetFallbackIcon("⚡", "⟳"),
-			m.t("menu.auto_update.title"),
-			m.t("menu.auto_update.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("📚", "≡"),
-			m.t("menu.dict_update.title"),
-			m.t("menu.dict_update.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("📦", "▢"),
-			m.t("menu.scheme_update.title"),
-			m.t("menu.scheme_update.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("🤖", "◈"),
-			m.t("menu.model_update.title"),
-			m.t("menu.model_update.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("⚙️", "⚙"),
-			m.t("menu.config.title"),
-			m.t("menu.config.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("🎨", "◐"),
-			m.t("menu.custom.title"),
-			m.t("menu.custom.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("🧭", "◎"),
-			m.t("menu.wizard.title"),
-			m.t("menu.wizard.desc"),
-		},
-		{
-			termcolor.GetFallbackIcon("🚪", "×"),
-			m.t("menu.quit.title"),
-			m.t("menu.quit.desc"),
-		},
+	items := m.mainMenuItems()
+	menuItems := make([]menuEntry, 0, len(items))
+	for _, item := range items {
+		menuItems = append(menuItems, menuEntry{item.icon, item.text, item.desc})
 	}
 
 	b.WriteString(m.renderMenuList(menuItems) + "\n")
@@ -746,16 +728,20 @@ func (m Model) renderUpdating() string {
 
 	b.WriteString(m.renderPanel(progressContent.String(), m.Styles.Primary) + "\n\n")
 
+	noticeKey := "updating.notice"
+	if m.Cancelling {
+		noticeKey = "updating.cancelling"
+	}
 	notice := lipgloss.NewStyle().
 		Foreground(m.Styles.Warning).
-		Render(m.t("updating.notice"))
+		Render(m.t(noticeKey))
 	b.WriteString(notice + "\n\n")
 
 	b.WriteString(m.Styles.Grid.Render(gridLine) + "\n\n")
 
 	hint := m.Styles.Hint.Render(m.t("updating.hint"))
 	b.WriteString(hint + "\n\n")
-	b.WriteString(m.renderHintStrip(m.t("ui.hint.exit"), m.t("ui.hint.live_progress")))
+	b.WriteString(m.renderHintStrip(m.t("ui.hint.cancel"), m.t("ui.hint.exit"), m.t("ui.hint.live_progress")))
 
 	return m.renderScreen(b.String())
 }
@@ -911,6 +897,30 @@ func (m Model) renderConfig() string {
 		editIndex += 2
 	}
 
+	githubAPIDisplay := m.Cfg.Config.GithubAPIURL
+	if githubAPIDisplay == "" {
+		githubAPIDisplay = m.t("config.value.default")
+	}
+	githubProxyDisplay := m.Cfg.Config.GithubProxy
+	if githubProxyDisplay == "" {
+		githubProxyDisplay = m.t("config.value.unset")
+	}
+	editableConfigs = append(editableConfigs,
+		struct {
+			key      string
+			value    string
+			editable bool
+			index    int
+		}{m.t("config.field.github_api_url"), githubAPIDisplay, true, editIndex},
+		struct {
+			key      string
+			value    string
+			editable bool
+			index    int
+		}{m.t("config.field.github_proxy"), githubProxyDisplay, true, editIndex + 1},
+	)
+	editIndex += 2
+
 	preHookDisplay := m.Cfg.Config.PreUpdateHook
 	if preHookDisplay == "" {
 		preHookDisplay = m.t("config.value.unset")
@@ -933,8 +943,32 @@ func (m Model) renderConfig() string {
 			editable bool
 			index    int
 		}{m.t("config.field.post_hook"), postHookDisplay, true, editIndex + 1},
+		struct {
+			key      string
+			value    string
+			editable bool
+			index    int
+		}{m.t("config.field.model_files"), strings.Join(m.Cfg.ModelPatterns(), ", "), true, editIndex + 2},
+		struct {
+			key      string
+			value    string
+			editable bool
+			index    int
+		}{m.t("config.field.require_checksum"), fmt.Sprintf("%v", m.Cfg.Config.RequireChecksum), true, editIndex + 3},
+		struct {
+			key      string
+			value    string
+			editable bool
+			index    int
+		}{m.t("config.field.auto_select_source"), fmt.Sprintf("%v", m.Cfg.Config.AutoSelectSource), true, editIndex + 4},
+		struct {
+			key      string
+			value    string
+			editable bool
+			index    int
+		}{m.t("config.field.probe_sources"), m.probeSummary(), true, editIndex + 5},
 	)
-	editIndex += 2
+	editIndex += 6
 
 	excludeCount := fmt.Sprintf("(%d个模式)", len(m.Cfg.Config.ExcludeFiles))
 	if string(m.locale()) == "en" {
@@ -1105,12 +1139,29 @@ func (m Model) renderConfigEdit() string {
 	case "proxy_address":
 		configName = m.configFieldLabel(m.EditingKey)
 		inputHint = m.t("config.edit.hint.proxy_addr")
+	case "github_api_url":
+		configName = m.configFieldLabel(m.EditingKey)
+		inputHint = m.t("config.edit.hint.github_api_url")
+	case "github_proxy":
+		configName = m.configFieldLabel(m.EditingKey)
+		inputHint = m.t("config.edit.hint.github_proxy")
 	case "pre_update_hook":
 		configName = m.configFieldLabel(m.EditingKey)
 		inputHint = m.t("config.edit.hint.pre_hook")
 	case "post_update_hook":
 		configName = m.configFieldLabel(m.EditingKey)
 		inputHint = m.t("config.edit.hint.post_hook")
+	case "model_files":
+		configName = m.configFieldLabel(m.EditingKey)
+		inputHint = m.t("config.edit.hint.model_files")
+	case "require_checksum":
+		configName = m.configFieldLabel(m.EditingKey)
+		inputHint = m.t("config.edit.hint.require_checksum")
+		isBooleanField = true
+	case "auto_select_source":
+		configName = m.configFieldLabel(m.EditingKey)
+		inputHint = m.t("config.edit.hint.auto_select_source")
+		isBooleanField = true
 	case "theme_adaptive":
 		configName = m.configFieldLabel(m.EditingKey)
 		inputHint = m.t("config.edit.hint.theme")
@@ -1171,6 +1222,19 @@ func (m Model) renderConfigEdit() string {
 	}
 
 	editContent.WriteString(m.Styles.Hint.Render(inputHint))
+	if m.Err != nil {
+		editContent.WriteString("\n\n" + m.Styles.ErrorText.Render(m.Err.Error()))
+	}
+	if isConnectionField(m.EditingKey) {
+		switch {
+		case m.ConnectionTesting:
+			editContent.WriteString("\n\n" + m.Styles.Hint.Render(m.t("config.connection.testing")))
+		case m.ConnectionErr != nil:
+			editContent.WriteString("\n\n" + m.Styles.ErrorText.Render(m.t("config.connection.failed", m.ConnectionErr)))
+		case m.ConnectionResult != "":
+			editContent.WriteString("\n\n" + m.Styles.SuccessText.Render(m.ConnectionResult))
+		}
+	}
 
 	editBoxRendered := editBox.Render(editContent.String())
 	b.WriteString(editBoxRendered + "\n\n")
@@ -1220,6 +1284,10 @@ func (m Model) renderResult() string {
 	var resultContent strings.Builder
 	resultContent.WriteString(headlineStyle.Render(headline) + "\n")
 	resultContent.WriteString(itemStyle.Render(m.ResultMsg))
+	if m.ResultHint != "" {
+		resultContent.WriteString("\n\n")
+		resultContent.WriteString(lipgloss.NewStyle().Foreground(m.Styles.Warning).Render(m.ResultHint))
+	}
 
 	if m.AutoUpdateResult != nil {
 		resultContent.WriteString("\n\n")
@@ -1272,6 +1340,35 @@ func (m Model) renderResult() string {
 	return m.renderScreen(b.String())
 }
 
+// failureHint 返回更新失败时结果页的补充说明
+func (m Model) failureHint(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return m.t("result.cancelled")
+	}
+	return m.archiveRefusalHint(err)
+}
+
+// archiveRefusalHint 为因安全检查被拒绝的更新包生成说明，其他错误返回空字符串
+func (m Model) archiveRefusalHint(err error) string {
+	var archiveErr *fileutil.ArchiveError
+	if !errors.As(err, &archiveErr) {
+		return ""
+	}
+
+	switch {
+	case errors.Is(archiveErr, fileutil.ErrUnsafePath):
+		return m.t("result.archive.unsafe_path", archiveErr.Entry)
+	case errors.Is(archiveErr, fileutil.ErrUnsupportedEntry):
+		return m.t("result.archive.unsupported", archiveErr.Entry)
+	case errors.Is(archiveErr, fileutil.ErrTooManyEntries):
+		return m.t("result.archive.too_many")
+	case errors.Is(archiveErr, fileutil.ErrCompressionRatio):
+		return m.t("result.archive.ratio", archiveErr.Entry)
+	default:
+		return m.t("result.archive.too_large", archiveErr.Entry)
+	}
+}
+
 // renderFcitxConflict 渲染 Fcitx 目录冲突对话框
 func (m Model) renderFcitxConflict() string {
 	var b strings.Builder
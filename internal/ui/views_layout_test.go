@@ -1,11 +1,15 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
 	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/detector"
+	"rime-wanxiang-updater/internal/fileutil"
 	"rime-wanxiang-updater/internal/theme"
 	"rime-wanxiang-updater/internal/types"
 	"rime-wanxiang-updater/internal/version"
@@ -215,3 +219,26 @@ func maxTrimmedLineWidth(text string) int {
 
 	return maxWidth
 }
+
+func TestArchiveRefusalHint(t *testing.T) {
+	m := Model{Cfg: &config.Manager{Config: &types.Config{Language: "zh-CN"}}}
+
+	refused := fmt.Errorf("更新失败: %w", &fileutil.ArchiveError{Entry: "../evil", Err: fileutil.ErrUnsafePath})
+	if got := m.archiveRefusalHint(refused); !strings.Contains(got, "../evil") {
+		t.Errorf("archiveRefusalHint() = %q, want entry name in hint", got)
+	}
+	if got := m.archiveRefusalHint(errors.New("network down")); got != "" {
+		t.Errorf("archiveRefusalHint() = %q, want empty for unrelated errors", got)
+	}
+}
+
+func TestFailureHintExplainsCancel(t *testing.T) {
+	m := Model{Cfg: &config.Manager{Config: &types.Config{Language: "zh-CN"}}}
+
+	if got := m.failureHint(fmt.Errorf("下载失败: %w", context.Canceled)); got != m.t("result.cancelled") {
+		t.Errorf("failureHint(canceled) = %q, want %q", got, m.t("result.cancelled"))
+	}
+	if got := m.failureHint(errors.New("network down")); got != "" {
+		t.Errorf("failureHint() = %q, want empty for unrelated errors", got)
+	}
+}
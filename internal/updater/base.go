@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -21,7 +22,21 @@ type BaseUpdater struct {
 	Config        *config.Manager
 	APIClient     *api.Client
 	Deployer      deployer.Deployer
-	SkipTerminate bool // 是否跳过终止进程步骤（用于组合更新）
+	SkipTerminate bool         // 是否跳过终止进程步骤（用于组合更新）
+	Transaction   *Transaction // 组合更新共享的事务，为 nil 时各更新器自行创建
+
+	// UpdateInfo 本次要安装的版本；为 nil 时由 prepareComponent 检查远程版本后填入
+	UpdateInfo *types.UpdateInfo
+
+	// ResolveModified 发现被用户修改过的万象文件、且配置中没有记住处理方式时调用，返回 ModifiedKeep 等处理方式。
+	// 为 nil 时（如命令行模式）备份后覆盖。
+	ResolveModified func(component string, files []string) string
+
+	transferred int64                // 本次运行实际下载的字节数，写入更新历史
+	history     []types.HistoryEntry // 组合更新中等待整批结果的历史记录，见 CombinedUpdater.flushHistory
+
+	fallbackSource string      // 主下载源失败后改用的下载源，为空时使用主下载源，见 Sources
+	primaryClient  *api.Client // 使用备用下载源期间保存的主下载源 API 客户端
 }
 
 // NewBaseUpdater 创建基础更新器
@@ -47,6 +62,30 @@ func (b *BaseUpdater) EnsureInstalledEngine() error {
 	return nil
 }
 
+// secondaryEngines 返回需要从主引擎目录同步的其他引擎（不含主引擎）
+func (b *BaseUpdater) secondaryEngines() []string {
+	// 未配置要更新的引擎列表时，默认更新所有已安装的引擎
+	updateEngines := b.Config.Config.UpdateEngines
+	if len(updateEngines) == 0 {
+		updateEngines = b.Config.Config.InstalledEngines
+	}
+
+	// 只有一个引擎需要更新时无需同步
+	if len(updateEngines) <= 1 {
+		return nil
+	}
+
+	primaryEngine := b.primaryEngine()
+
+	engines := make([]string, 0, len(updateEngines)-1)
+	for _, engine := range updateEngines {
+		if engine != primaryEngine {
+			engines = append(engines, engine)
+		}
+	}
+	return engines
+}
+
 // HasUpdate 检查是否有更新
 func (b *BaseUpdater) HasUpdate(updateInfo *types.UpdateInfo, recordPath string) bool {
 	if updateInfo == nil {
@@ -98,6 +137,7 @@ func (b *BaseUpdater) SaveRecord(recordPath string, propertyType, propertyName s
 		ApplyTime:  time.Now(),
 		SHA256:     info.SHA256,
 		CnbID:      info.ID,
+		Source:     info.Source,
 	}
 
 	data, err := json.MarshalIndent(record, "", "  ")
@@ -108,40 +148,39 @@ func (b *BaseUpdater) SaveRecord(recordPath string, propertyType, propertyName s
 	return os.WriteFile(recordPath, data, 0644)
 }
 
-// DownloadFile 下载文件
-func (b *BaseUpdater) DownloadFile(url, dest, fileName, source string, progress types.ProgressFunc) error {
+// DownloadFile 下载文件；ctx 取消时立即返回 ctx.Err()，已下载的部分保留在 dest 中。
+// dest 中留有上次中断的部分且服务器提供了 ETag 或 Last-Modified 时，使用 Range 和 If-Range 从中断处继续下载；
+// 服务器忽略范围请求或文件已变化时从头下载
+func (b *BaseUpdater) DownloadFile(ctx context.Context, url, dest, fileName, source string, progress types.ProgressFunc) error {
 	downloadClient := api.NewDownloadHTTPClient(b.Config.Config)
 
-	var (
-		resp *http.Response
-		err  error
-	)
-	for attempt := 1; attempt <= 3; attempt++ {
-		req, reqErr := http.NewRequest("GET", url, nil)
-		if reqErr != nil {
-			return fmt.Errorf("创建下载请求失败: %w", reqErr)
-		}
-
-		req.Header.Set("User-Agent", "RIME-Updater/1.0")
-
-		resp, err = downloadClient.Do(req)
-		if err == nil && !shouldRetryDownload(resp) {
-			break
-		}
+	offset, validator := loadResumeState(dest, url)
+	resp, err := b.requestDownload(ctx, downloadClient, url, offset, validator)
+	if err != nil {
+		return err
+	}
 
-		if resp != nil {
+	// 校验服务器对范围请求的响应，无法续传时从头下载
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, _, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || offset == 0 || start != offset {
 			resp.Body.Close()
-		}
-
-		if attempt == 3 {
-			if err != nil {
-				return fmt.Errorf("下载请求失败: %w", err)
+			offset = 0
+			if resp, err = b.requestDownload(ctx, downloadClient, url, 0, ""); err != nil {
+				return err
 			}
-
-			return fmt.Errorf("下载失败，HTTP 状态码: %d", resp.StatusCode)
 		}
-
-		time.Sleep(time.Duration(attempt) * time.Second)
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		// 上次已下载完整，只是没来得及清理续传状态
+		if _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && total == offset {
+			clearResumeState(dest)
+			return nil
+		}
+		offset = 0
+		if resp, err = b.requestDownload(ctx, downloadClient, url, 0, ""); err != nil {
+			return err
+		}
 	}
 	defer resp.Body.Close()
 
@@ -150,21 +189,22 @@ func (b *BaseUpdater) DownloadFile(url, dest, fileName, source string, progress
 		return fmt.Errorf("下载失败，HTTP 状态码: %d", resp.StatusCode)
 	}
 
-	// 获取文件总大小
+	// 获取文件总大小；续传时 Content-Length 只是剩余部分
 	totalSize := resp.ContentLength
-
-	// 检查是否支持断点续传
 	var downloaded int64 = 0
-	if info, err := os.Stat(dest); err == nil {
-		downloaded = info.Size()
-	}
-
-	// 检查服务器响应
 	var out *os.File
 	if resp.StatusCode == http.StatusPartialContent {
+		downloaded = offset
+		if _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && total >= 0 {
+			totalSize = total
+		} else if totalSize >= 0 {
+			totalSize += offset
+		}
 		out, err = os.OpenFile(dest, os.O_APPEND|os.O_WRONLY, 0644)
+		if progress != nil {
+			progress(fmt.Sprintf("继续下载: 已有 %.2f MB", float64(offset)/1024/1024), 0, source, fileName, downloaded, totalSize, 0, true)
+		}
 	} else {
-		downloaded = 0
 		out, err = os.Create(dest)
 	}
 
@@ -173,10 +213,14 @@ func (b *BaseUpdater) DownloadFile(url, dest, fileName, source string, progress
 	}
 	defer out.Close()
 
+	// 记录续传所需的校验信息，下载中断后下次可以继续
+	saveResumeState(dest, url, resp.Header)
+
 	// 下载文件并报告进度
 	buf := make([]byte, 32*1024)
 	startTime := time.Now()
 	lastUpdate := time.Now()
+	resumed := downloaded
 
 	for {
 		n, err := resp.Body.Read(buf)
@@ -185,11 +229,12 @@ func (b *BaseUpdater) DownloadFile(url, dest, fileName, source string, progress
 				return fmt.Errorf("写入文件失败: %w", err)
 			}
 			downloaded += int64(n)
+			b.transferred += int64(n)
 
 			// 每 100ms 更新一次进度
 			if progress != nil && time.Since(lastUpdate) > 100*time.Millisecond {
 				elapsed := time.Since(startTime).Seconds()
-				speed := float64(downloaded) / elapsed / 1024 / 1024 // MB/s
+				speed := float64(downloaded-resumed) / elapsed / 1024 / 1024 // MB/s
 
 				var percent float64
 				if totalSize > 0 {
@@ -215,15 +260,19 @@ func (b *BaseUpdater) DownloadFile(url, dest, fileName, source string, progress
 			break
 		}
 		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
 			return fmt.Errorf("读取数据失败: %w", err)
 		}
 	}
+	clearResumeState(dest)
 
 	// 下载完成
 	if progress != nil {
 		downloadedMB := float64(downloaded) / 1024 / 1024
 		elapsed := time.Since(startTime).Seconds()
-		speed := float64(downloaded) / elapsed / 1024 / 1024
+		speed := float64(downloaded-resumed) / elapsed / 1024 / 1024
 		msg := fmt.Sprintf("下载完成: %.2f MB (平均 %.2f MB/s)", downloadedMB, speed)
 		progress(msg, 1.0, "", "", 0, 0, 0, false)
 	}
@@ -231,6 +280,53 @@ func (b *BaseUpdater) DownloadFile(url, dest, fileName, source string, progress
 	return nil
 }
 
+// requestDownload 发送下载请求，遇到可重试的错误时最多尝试 3 次；offset 大于 0 时请求从 offset 开始的部分，
+// 并通过 If-Range 要求服务器在文件已变化时返回完整文件
+func (b *BaseUpdater) requestDownload(ctx context.Context, client *http.Client, url string, offset int64, validator string) (*http.Response, error) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	for attempt := 1; attempt <= 3; attempt++ {
+		req, reqErr := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if reqErr != nil {
+			return nil, fmt.Errorf("创建下载请求失败: %w", reqErr)
+		}
+
+		req.Header.Set("User-Agent", "RIME-Updater/1.0")
+		if offset > 0 && validator != "" {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", validator)
+		}
+
+		resp, err = client.Do(req)
+		if err == nil && !shouldRetryDownload(resp) {
+			return resp, nil
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		if attempt == 3 {
+			break
+		}
+
+		if err := api.Sleep(ctx, time.Duration(attempt)*time.Second); err != nil {
+			return nil, err
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("下载请求失败: %w", err)
+	}
+	return nil, fmt.Errorf("下载失败，HTTP 状态码: %d", resp.StatusCode)
+}
+
 func shouldRetryDownload(resp *http.Response) bool {
 	if resp == nil {
 		return true
@@ -244,9 +340,9 @@ func shouldRetryDownload(resp *http.Response) bool {
 }
 
 // DownloadFileWithValidation 下载文件并验证大小
-func (b *BaseUpdater) DownloadFileWithValidation(url, dest, fileName, source string, expectedSize int64, progress types.ProgressFunc) error {
+func (b *BaseUpdater) DownloadFileWithValidation(ctx context.Context, url, dest, fileName, source string, expectedSize int64, progress types.ProgressFunc) error {
 	// 调用下载方法
-	if err := b.DownloadFile(url, dest, fileName, source, progress); err != nil {
+	if err := b.DownloadFile(ctx, url, dest, fileName, source, progress); err != nil {
 		return err
 	}
 
@@ -260,7 +356,7 @@ func (b *BaseUpdater) DownloadFileWithValidation(url, dest, fileName, source str
 		actualSize := fileInfo.Size()
 		if actualSize != expectedSize {
 			// 删除损坏的文件
-			os.Remove(dest)
+			removePartial(dest)
 			return fmt.Errorf("文件大小不匹配：期望 %d 字节，实际 %d 字节，已删除损坏的文件", expectedSize, actualSize)
 		}
 	}
@@ -273,6 +369,31 @@ func (b *BaseUpdater) ExtractZip(src, dest string) error {
 	return fileutil.ExtractZip(src, dest, b.Config.Config.ExcludeFiles)
 }
 
+// stageArchive 将 archive 解压到 Rime 目录旁的暂存目录，处理镜像嵌套目录并校验关键文件。
+// 返回暂存目录路径，由调用方在安装后删除；失败时暂存目录已被清理，Rime 目录不受影响。
+func (b *BaseUpdater) stageArchive(archive, zipFileName string, keyFiles ...string) (string, error) {
+	staging := fileutil.StagingDir(b.Config.RimeDir, "staging")
+	if err := fileutil.ExtractZip(archive, staging, nil); err != nil {
+		os.RemoveAll(staging)
+		return "", fmt.Errorf("解压失败: %w", err)
+	}
+
+	// 处理 CNB 镜像的嵌套目录问题
+	if b.mayBeNested() {
+		if err := fileutil.HandleCNBNestedDir(staging, zipFileName); err != nil {
+			os.RemoveAll(staging)
+			return "", fmt.Errorf("处理嵌套目录失败: %w", err)
+		}
+	}
+
+	if err := fileutil.VerifyKeyFiles(staging, keyFiles...); err != nil {
+		os.RemoveAll(staging)
+		return "", err
+	}
+
+	return staging, nil
+}
+
 // CompareHash 比较文件哈希
 func (b *BaseUpdater) CompareHash(remoteHash, filePath string) bool {
 	if remoteHash == "" || !fileutil.FileExists(filePath) {
@@ -364,8 +485,11 @@ func (b *BaseUpdater) CleanOldFiles(oldZip, newZip, extractPath string, isDict b
 	// 找出需要删除的文件
 	toDelete := difference(oldFiles, newFiles)
 
-	// 删除文件
+	// 删除文件（跳过指向解压目录之外的条目）
 	for _, file := range toDelete {
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			continue
+		}
 		fullPath := filepath.Join(extractPath, file)
 		if fileutil.FileExists(fullPath) {
 			os.Remove(fullPath)
@@ -1,7 +1,9 @@
 package updater
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -373,6 +375,7 @@ func TestDownloadFileWithValidation(t *testing.T) {
 
 			// Test download with validation
 			err := updater.DownloadFileWithValidation(
+				context.Background(),
 				server.URL,
 				destFile,
 				"test.zip",
@@ -453,7 +456,7 @@ func TestDownloadFileWithProgress(t *testing.T) {
 		t.Logf("Progress: %s (%.2f%%)", msg, percent*100)
 	}
 
-	err := updater.DownloadFile(server.URL, destFile, "test.dat", "test-source", progressFunc)
+	err := updater.DownloadFile(context.Background(), server.URL, destFile, "test.dat", "test-source", progressFunc)
 	if err != nil {
 		t.Fatalf("DownloadFile() error = %v", err)
 	}
@@ -508,7 +511,7 @@ func TestDownloadFileRetriesRetryableStatus(t *testing.T) {
 
 	updater := NewBaseUpdater(cfg)
 
-	if err := updater.DownloadFile(server.URL, destFile, "retry.dat", "test-source", nil); err != nil {
+	if err := updater.DownloadFile(context.Background(), server.URL, destFile, "retry.dat", "test-source", nil); err != nil {
 		t.Fatalf("DownloadFile() error = %v, want nil", err)
 	}
 
@@ -530,3 +533,41 @@ func anyIndex(s, substr string) int {
 	}
 	return -1
 }
+
+func TestDownloadFileCancelKeepsPartialFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "2048")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(make([]byte, 1024))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // 剩余部分直到取消都不发送
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	destFile := filepath.Join(tmpDir, "partial.dat")
+	updater := NewBaseUpdater(&config.Manager{
+		ConfigPath: filepath.Join(tmpDir, "config.json"),
+		Config:     &types.Config{SchemeType: "base"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if info, err := os.Stat(destFile); err == nil && info.Size() > 0 {
+				break
+			}
+		}
+		cancel()
+	}()
+
+	err := updater.DownloadFile(ctx, server.URL, destFile, "partial.dat", "test-source", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("DownloadFile() error = %v, want %v", err, context.Canceled)
+	}
+
+	info, statErr := os.Stat(destFile)
+	if statErr != nil || info.Size() != 1024 {
+		t.Fatalf("partial file = %v, %v, want 1024 bytes kept", info, statErr)
+	}
+}
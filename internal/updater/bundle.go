@@ -0,0 +1,181 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/version"
+)
+
+// BundleManifestFile 离线更新包中记录各文件版本信息的清单文件名
+const BundleManifestFile = "bundle.json"
+
+// BundleManifest 离线更新包的清单，与更新包中的文件放在同一目录
+type BundleManifest struct {
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdaterVersion string        `json:"updater_version"`
+	Source         string        `json:"source"` // 主下载源："GitHub" 或 "CNB"；各文件实际使用的下载源见 Info.Source
+	Assets         []BundleAsset `json:"assets"`
+}
+
+// BundleAsset 离线更新包中的一个文件
+type BundleAsset struct {
+	Component string           `json:"component"` // 组件 ID
+	Info      types.UpdateInfo `json:"info"`      // 发布中的版本信息，Info.Name 为文件名，Info.SHA256 为文件的实际 SHA256
+}
+
+// IsBundleArchive 判断路径是否为打包成 .tar.gz 的离线更新包
+func IsBundleArchive(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// ExportBundle 按当前配置（方案、词库、模型、版本固定和发布渠道）下载各组件的最新版本，与清单一起写入 dest，供无法联网的机器导入。
+// dest 以 .tar.gz 或 .tgz 结尾时打包为单个文件，否则写入该目录。每个文件都按发布提供的 SHA256 校验，
+// 主下载源失败时与更新一样改用备用下载源（见 checkWithFailover、downloadWithFailover）
+func (c *CombinedUpdater) ExportBundle(ctx context.Context, dest string, progress func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)) (*BundleManifest, error) {
+	if progress == nil {
+		progress = func(string, string, float64, string, string, int64, int64, float64, bool) {}
+	}
+
+	dir := dest
+	if IsBundleArchive(dest) {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("创建目录失败: %w", err)
+		}
+		temp, err := os.MkdirTemp(filepath.Dir(dest), ".bundle-")
+		if err != nil {
+			return nil, fmt.Errorf("创建临时目录失败: %w", err)
+		}
+		defer os.RemoveAll(temp)
+		dir = temp
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	primary := Sources(c.Config.Config)[0]
+	manifest := &BundleManifest{
+		CreatedAt:      time.Now().UTC(),
+		UpdaterVersion: version.GetVersion(),
+		Source:         primary,
+		Assets:         []BundleAsset{},
+	}
+
+	span := 0.9 / float64(len(c.components))
+	for i, comp := range c.components {
+		name := types.ComponentName(comp.ID())
+		from := float64(i) * span
+		progress(name, fmt.Sprintf("正在检查%s版本 [%s]...", name, sourceLabel(primary)), from, "", "", 0, 0, 0, false)
+		info, err := checkWithFailover(ctx, comp)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s版本信息失败: %w", name, err)
+		}
+
+		b := comp.Base()
+		b.UpdateInfo = info
+		// 改用备用下载源时已下载的文件与备用下载源上的一致，不再重新下载，清单中保留实际提供它的下载源
+		fetched := make(map[string]types.UpdateInfo)
+		_, err = downloadWithFailover(ctx, comp, func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+			progress(name, message, from+percent*span, source, fileName, downloaded, total, speed, downloadMode)
+		}, func(ctx context.Context, progress types.ProgressFunc) (string, error) {
+			for _, asset := range modelAssets(b.UpdateInfo) {
+				if _, ok := fetched[asset.Name]; ok {
+					continue
+				}
+				if err := b.downloadVerified(ctx, asset, filepath.Join(dir, asset.Name), asset.Name, sourceLabel(asset.Source), progress); err != nil {
+					return "", err
+				}
+				entry := *asset
+				entry.Assets = nil
+				fetched[asset.Name] = entry
+			}
+			return "", nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("下载%s失败: %w", name, err)
+		}
+		for _, asset := range modelAssets(b.UpdateInfo) {
+			manifest.Assets = append(manifest.Assets, BundleAsset{Component: comp.ID(), Info: fetched[asset.Name]})
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化清单失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, BundleManifestFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("写入清单失败: %w", err)
+	}
+
+	if dir != dest {
+		progress("打包", "正在打包...", 0.95, "", "", 0, 0, 0, false)
+		if err := fileutil.CreateTarGz(dir, dest); err != nil {
+			return nil, err
+		}
+	}
+
+	progress("完成", "离线更新包已生成", 1.0, "", "", 0, 0, 0, false)
+	return manifest, nil
+}
+
+// readBundleManifest 读取目录中的离线更新包清单，返回文件名到版本信息的映射；没有清单时返回 nil
+func readBundleManifest(dir string) (map[string]BundleAsset, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BundleManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取离线更新包清单失败: %w", err)
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析离线更新包清单失败: %w", err)
+	}
+	assets := make(map[string]BundleAsset, len(manifest.Assets))
+	for _, asset := range manifest.Assets {
+		asset.Info.SHA256 = releaseutil.NormalizeSHA256(asset.Info.SHA256)
+		if asset.Info.Name == filepath.Base(asset.Info.Name) && asset.Info.SHA256 != "" {
+			assets[asset.Info.Name] = asset
+		}
+	}
+	return assets, nil
+}
+
+// UnpackBundles 将 paths 中打包成 .tar.gz 的离线更新包解压到临时目录，返回替换为解压目录后的路径；
+// 其他路径原样返回。导入完成后须调用 cleanup 删除临时目录
+func UnpackBundles(paths []string) (unpacked []string, cleanup func(), err error) {
+	var temps []string
+	cleanup = func() {
+		for _, temp := range temps {
+			os.RemoveAll(temp)
+		}
+	}
+
+	for _, path := range paths {
+		if !IsBundleArchive(path) {
+			unpacked = append(unpacked, path)
+			continue
+		}
+		temp, err := os.MkdirTemp("", "rime-bundle-")
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("创建临时目录失败: %w", err)
+		}
+		temps = append(temps, temp)
+		if err := fileutil.ExtractTarGz(path, temp); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("解压离线更新包失败: %w", err)
+		}
+		unpacked = append(unpacked, temp)
+	}
+	return unpacked, cleanup, nil
+}
@@ -0,0 +1,170 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestExportBundleRoundTrip(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	zipPath := filepath.Join(t.TempDir(), "scheme.zip")
+	writeTestZip(t, zipPath, schemeKeyFile)
+	content, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256Hex(string(content))
+	published := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + types.OWNER + "/" + types.REPO + "/releases":
+			json.NewEncoder(w).Encode([]types.GitHubRelease{{
+				TagName:     "v1.2.0",
+				PublishedAt: published,
+				Assets: []types.GitHubAsset{{
+					Name:               "scheme.zip",
+					BrowserDownloadURL: server.URL + "/scheme.zip",
+					UpdatedAt:          published,
+					Digest:             "sha256:" + hash,
+					Size:               int64(len(content)),
+				}},
+			}})
+		case "/scheme.zip":
+			w.Write(content)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	base.Config.Config.GithubAPIURL = server.URL
+	scheme := &SchemeUpdater{BaseUpdater: base}
+	scheme.APIClient = api.NewClient(base.Config.Config)
+	combined := &CombinedUpdater{Config: base.Config, base: base, components: []Component{scheme}}
+
+	bundle := filepath.Join(t.TempDir(), "out", "bundle.tar.gz")
+	manifest, err := combined.ExportBundle(context.Background(), bundle, nil)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	if len(manifest.Assets) != 1 || manifest.Assets[0].Info.Tag != "v1.2.0" || manifest.Assets[0].Info.SHA256 != hash {
+		t.Fatalf("ExportBundle() assets = %+v, want scheme.zip v1.2.0 with its SHA256", manifest.Assets)
+	}
+	if manifest.Source != "GitHub" {
+		t.Errorf("manifest.Source = %q, want GitHub", manifest.Source)
+	}
+
+	paths, cleanup, err := UnpackBundles([]string{bundle})
+	if err != nil {
+		t.Fatalf("UnpackBundles() error = %v", err)
+	}
+	defer cleanup()
+	files, err := FindLocalFiles(base.Config, paths)
+	if err != nil {
+		t.Fatalf("FindLocalFiles() error = %v", err)
+	}
+	if len(files) != 1 || !files[0].Verified || files[0].Info == nil {
+		t.Fatalf("FindLocalFiles() = %+v, want the verified scheme with bundle info", files)
+	}
+
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tempFile, info, err := scheme.copyImport(files[0], base.Config.CacheDir)
+	if err != nil {
+		t.Fatalf("copyImport() error = %v", err)
+	}
+	defer os.Remove(tempFile)
+	if info.Tag != "v1.2.0" || !info.UpdateTime.Equal(published) || info.SHA256 != hash {
+		t.Errorf("copyImport() info = %+v, want the release version", info)
+	}
+
+	// 清单中的 SHA256 与文件不一致时拒绝导入
+	dir := paths[0]
+	if err := os.WriteFile(filepath.Join(dir, "scheme.zip"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindLocalFiles(base.Config, []string{dir}); err == nil {
+		t.Error("FindLocalFiles(tampered bundle) error = nil, want a checksum error")
+	}
+}
+
+func TestExportBundleFailsOverPerAsset(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	content := map[string]string{"a.gram": "model a", "b.gram": "model b"}
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		// GitHub 上的 b.gram 下载失败，CNB 上的文件都可以下载
+		if r.URL.Path == "/github/b.gram" {
+			http.Error(w, "unavailable", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(content[path.Base(r.URL.Path)]))
+	}))
+	defer server.Close()
+
+	infoFrom := func(prefix string) *types.UpdateInfo {
+		info := &types.UpdateInfo{Name: "a.gram", Tag: "v2"}
+		for _, name := range []string{"a.gram", "b.gram"} {
+			info.Assets = append(info.Assets, types.UpdateInfo{
+				Name:   name,
+				Tag:    "v2",
+				URL:    server.URL + "/" + prefix + "/" + name,
+				SHA256: sha256Hex(content[name]),
+				Size:   int64(len(content[name])),
+			})
+		}
+		return info
+	}
+	comp := &sourceComponent{
+		ModelUpdater: &ModelUpdater{BaseUpdater: base},
+		infos:        map[string]*types.UpdateInfo{SourceGitHub: infoFrom("github"), SourceCNB: infoFrom("cnb")},
+	}
+	combined := &CombinedUpdater{Config: base.Config, base: base, components: []Component{comp}}
+
+	dir := t.TempDir()
+	manifest, err := combined.ExportBundle(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	if manifest.Source != SourceGitHub {
+		t.Errorf("manifest.Source = %q, want GitHub", manifest.Source)
+	}
+	want := map[string]string{"a.gram": SourceGitHub, "b.gram": SourceCNB}
+	if len(manifest.Assets) != len(want) {
+		t.Fatalf("manifest.Assets = %+v, want a.gram and b.gram", manifest.Assets)
+	}
+	for _, asset := range manifest.Assets {
+		if asset.Info.Source != want[asset.Info.Name] {
+			t.Errorf("%s source = %q, want %q", asset.Info.Name, asset.Info.Source, want[asset.Info.Name])
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, asset.Info.Name)); err != nil || string(data) != content[asset.Info.Name] {
+			t.Errorf("%s = %q, %v, want its content", asset.Info.Name, data, err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, request := range requests {
+		if request == "/cnb/a.gram" {
+			t.Error("a.gram was downloaded again from CNB after it was fetched from GitHub")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// cnbScanLimit 已找到滚动发布时，在 CNB 上最多再检查的带版本号的 tag 数量
+const cnbScanLimit = 3
+
+// Channel 返回组件使用的发布渠道，未配置或配置无效时返回组件的默认渠道
+func (b *BaseUpdater) Channel(component string) string {
+	channel := b.Config.Config.ReleaseChannels[component]
+	if slices.Contains(releaseutil.Channels(), channel) {
+		return channel
+	}
+	return releaseutil.DefaultChannel(component)
+}
+
+// rollingTag 返回组件在 GitHub 或 CNB 上的滚动发布 tag，没有时返回空字符串
+func rollingTag(component string, useMirror bool) string {
+	switch {
+	case component == types.ComponentDict && useMirror:
+		return types.CNB_DICT_TAG
+	case component == types.ComponentDict:
+		return types.DICT_TAG
+	case component == types.ComponentModel && useMirror:
+		return types.CNB_MODEL_TAG
+	case component == types.ComponentModel:
+		return types.MODEL_TAG
+	case useMirror:
+		return types.CNB_DICT_TAG
+	}
+	return ""
+}
+
+// findChannelRelease 按组件的发布渠道查找 file 的最新版本；repo 为 GitHub 仓库，CNB 统一使用 types.CNB_REPO
+func (b *BaseUpdater) findChannelRelease(ctx context.Context, component, repo, file string) (*types.UpdateInfo, error) {
+	channel := b.Channel(component)
+	match := func(name string) bool { return name == file }
+
+	releases, err := b.channelReleases(ctx, component, repo, channel, match)
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := releaseutil.FindChannelAssetInfo(releases, match, channel)
+	if !ok {
+		return nil, fmt.Errorf("发布渠道 %s 中未找到匹配的文件: %s", channel, file)
+	}
+	return info, nil
+}
+
+// findChannelAssets 按组件的发布渠道查找名称满足 match 的每个文件的最新版本，按文件名排序；发布列表只获取一次
+func (b *BaseUpdater) findChannelAssets(ctx context.Context, component, repo string, match func(string) bool) ([]*types.UpdateInfo, error) {
+	channel := b.Channel(component)
+	releases, err := b.channelReleases(ctx, component, repo, channel, match)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, release := range releases {
+		if !releaseutil.AllowsRelease(channel, release) {
+			continue
+		}
+		for _, asset := range release.Assets {
+			if match(asset.Name) && !slices.Contains(names, asset.Name) {
+				names = append(names, asset.Name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	infos := make([]*types.UpdateInfo, 0, len(names))
+	for _, name := range names {
+		if info, ok := releaseutil.FindChannelAssetInfo(releases, func(n string) bool { return n == name }, channel); ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// channelReleases 返回组件在当前下载源上的候选发布；match 用于在 CNB 上判断发布是否包含所需文件
+func (b *BaseUpdater) channelReleases(ctx context.Context, component, repo, channel string, match func(string) bool) ([]types.GitHubRelease, error) {
+	var releases []types.GitHubRelease
+	var err error
+	if b.usingMirror() {
+		releases, err = b.cnbChannelReleases(ctx, component, channel, match)
+	} else {
+		releases, err = b.githubChannelReleases(ctx, component, repo, channel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取版本信息失败: %w", err)
+	}
+	return releases, nil
+}
+
+// githubChannelReleases 返回 GitHub 发布列表；接受滚动发布的渠道另外获取滚动发布，避免它因创建时间较早而不在列表第一页
+func (b *BaseUpdater) githubChannelReleases(ctx context.Context, component, repo, channel string) ([]types.GitHubRelease, error) {
+	releases, err := b.APIClient.FetchGitHubReleases(ctx, types.OWNER, repo, "")
+
+	if tag := rollingTag(component, false); releaseutil.IncludesRolling(channel) && tag != "" {
+		rolling, rollingErr := b.APIClient.FetchGitHubReleases(ctx, types.OWNER, repo, tag)
+		if rollingErr == nil {
+			return append(rolling, releases...), nil
+		}
+		if err == nil {
+			return releases, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// cnbChannelReleases 返回候选的 CNB 发布：接受滚动发布的渠道的滚动发布，以及按 tag 顺序第一个渠道接受且包含所需文件的发布
+func (b *BaseUpdater) cnbChannelReleases(ctx context.Context, component, channel string, match func(string) bool) ([]types.GitHubRelease, error) {
+	var candidates []types.GitHubRelease
+	if tag := rollingTag(component, true); releaseutil.IncludesRolling(channel) {
+		if release, err := b.APIClient.FetchCNBReleaseByTag(ctx, types.OWNER, types.CNB_REPO, tag); err == nil && hasAsset(*release, match) {
+			candidates = append(candidates, *release)
+		}
+	}
+
+	// 已有滚动发布时只检查最近的几个版本，模型等不随版本发布的文件不必翻遍所有 tag
+	limit := 0
+	if len(candidates) > 0 {
+		limit = cnbScanLimit
+	}
+
+	checked := 0
+	totalPages := 1
+	for page := 1; page <= totalPages; page++ {
+		tags, pages, err := b.APIClient.FetchCNBReleaseTagsPage(ctx, types.OWNER, types.CNB_REPO, page)
+		if err != nil {
+			if len(candidates) > 0 {
+				return candidates, nil
+			}
+			return nil, err
+		}
+		if page == 1 {
+			totalPages = pages
+		}
+
+		for _, tag := range tags {
+			if releaseutil.IsRollingTag(tag) {
+				continue
+			}
+			if limit > 0 && checked == limit {
+				return candidates, nil
+			}
+			checked++
+
+			release, err := b.APIClient.FetchCNBReleaseByTag(ctx, types.OWNER, types.CNB_REPO, tag)
+			if err != nil {
+				if len(candidates) > 0 {
+					return candidates, nil
+				}
+				return nil, err
+			}
+			if releaseutil.AllowsRelease(channel, *release) && hasAsset(*release, match) {
+				return append(candidates, *release), nil
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// hasAsset 判断 release 中是否有名称满足 match 的资源
+func hasAsset(release types.GitHubRelease, match func(string) bool) bool {
+	return slices.ContainsFunc(release.Assets, func(asset types.GitHubAsset) bool {
+		return match(asset.Name)
+	})
+}
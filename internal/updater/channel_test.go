@@ -0,0 +1,106 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestChannelDefaultsAndOverrides(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	base.Config.Config.ReleaseChannels = map[string]string{
+		types.ComponentDict:  releaseutil.ChannelStable,
+		types.ComponentModel: "weekly",
+	}
+
+	tests := []struct {
+		component string
+		want      string
+	}{
+		{types.ComponentScheme, releaseutil.ChannelStable},
+		{types.ComponentDict, releaseutil.ChannelStable},
+		{types.ComponentModel, releaseutil.ChannelNightly}, // 无效的渠道使用默认值
+	}
+
+	for _, tt := range tests {
+		if got := base.Channel(tt.component); got != tt.want {
+			t.Errorf("Channel(%q) = %q, want %q", tt.component, got, tt.want)
+		}
+	}
+}
+
+func TestRollingTagPerSource(t *testing.T) {
+	tests := []struct {
+		component string
+		useMirror bool
+		want      string
+	}{
+		{types.ComponentScheme, false, ""},
+		{types.ComponentScheme, true, types.CNB_DICT_TAG},
+		{types.ComponentDict, false, types.DICT_TAG},
+		{types.ComponentDict, true, types.CNB_DICT_TAG},
+		{types.ComponentModel, false, types.MODEL_TAG},
+		{types.ComponentModel, true, types.CNB_MODEL_TAG},
+	}
+
+	for _, tt := range tests {
+		if got := rollingTag(tt.component, tt.useMirror); got != tt.want {
+			t.Errorf("rollingTag(%q, %v) = %q, want %q", tt.component, tt.useMirror, got, tt.want)
+		}
+	}
+}
+
+func TestPrereleaseChannelIncludesRollingReleases(t *testing.T) {
+	updated := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	rolling := map[string]types.GitHubRelease{
+		types.REPO: {TagName: types.DICT_TAG, Assets: []types.GitHubAsset{
+			{Name: "dict.zip", BrowserDownloadURL: "https://example.com/dict.zip", UpdatedAt: updated},
+		}},
+		types.MODEL_REPO: {TagName: types.MODEL_TAG, Assets: []types.GitHubAsset{
+			{Name: types.MODEL_FILE, BrowserDownloadURL: "https://example.com/model.gram", UpdatedAt: updated},
+		}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for repo, release := range rolling {
+			switch r.URL.Path {
+			case "/repos/" + types.OWNER + "/" + repo + "/releases/tags/" + release.TagName:
+				json.NewEncoder(w).Encode(release)
+				return
+			case "/repos/" + types.OWNER + "/" + repo + "/releases":
+				// 带版本号的发布中没有词库和模型文件
+				io.WriteString(w, `[{"tag_name": "v1.0.0", "assets": []}]`)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	base, _ := newManifestTestUpdater(t)
+	base.Config.Config.GithubAPIURL = server.URL
+	base.Config.Config.ReleaseChannels = map[string]string{
+		types.ComponentDict:  releaseutil.ChannelPrerelease,
+		types.ComponentModel: releaseutil.ChannelPrerelease,
+	}
+	base.APIClient = api.NewClient(base.Config.Config)
+
+	dict, err := base.findChannelRelease(context.Background(), types.ComponentDict, types.REPO, "dict.zip")
+	if err != nil || dict.Tag != types.DICT_TAG {
+		t.Errorf("findChannelRelease(dict) = %+v, %v, want tag %s", dict, err, types.DICT_TAG)
+	}
+
+	models, err := base.findChannelAssets(context.Background(), types.ComponentModel, types.MODEL_REPO, func(name string) bool {
+		return name == types.MODEL_FILE
+	})
+	if err != nil || len(models) != 1 || models[0].Tag != types.MODEL_TAG {
+		t.Errorf("findChannelAssets(model) = %+v, %v, want one asset from %s", models, err, types.MODEL_TAG)
+	}
+}
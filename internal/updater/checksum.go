@@ -0,0 +1,99 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+var (
+	// ErrChecksumMismatch 下载的文件与发布提供的 SHA256 不一致
+	ErrChecksumMismatch = errors.New("文件校验失败")
+	// ErrNoChecksum 配置要求校验，但发布没有提供该文件的 SHA256
+	ErrNoChecksum = errors.New("缺少可用于校验的 SHA256")
+)
+
+// maxChecksumFileSize SHA256SUMS 文件的大小上限
+const maxChecksumFileSize = 1 << 20
+
+// expectedSHA256 返回 info 对应文件可信的 SHA256：优先使用发布元数据中的摘要，其次从发布的 SHA256SUMS 文件中查找。
+// 都没有时返回空字符串；配置要求校验时返回 ErrNoChecksum
+func (b *BaseUpdater) expectedSHA256(ctx context.Context, info *types.UpdateInfo) (string, error) {
+	if hash := releaseutil.NormalizeSHA256(info.SHA256); hash != "" {
+		return hash, nil
+	}
+
+	if info.ChecksumURL != "" {
+		sums, err := b.fetchChecksums(ctx, info.ChecksumURL)
+		if err != nil {
+			if ctx.Err() != nil || b.Config.Config.RequireChecksum {
+				return "", err
+			}
+		} else if hash := sums[info.Name]; hash != "" {
+			return hash, nil
+		}
+	}
+
+	if b.Config.Config.RequireChecksum {
+		return "", fmt.Errorf("%w: %s，已拒绝安装（可在配置中关闭 require_checksum）", ErrNoChecksum, info.Name)
+	}
+	return "", nil
+}
+
+// fetchChecksums 下载并解析发布中的 SHA256SUMS 文件
+func (b *BaseUpdater) fetchChecksums(ctx context.Context, url string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建校验和请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", "RIME-Updater/1.0")
+
+	resp, err := api.NewDownloadHTTPClient(b.Config.Config).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取校验和文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取校验和文件失败，HTTP 状态码: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取校验和文件失败: %w", err)
+	}
+	return releaseutil.ParseSHA256Sums(data), nil
+}
+
+// downloadVerified 下载 info 指定的文件到 dest，并与发布提供的 SHA256 校验。
+// 校验失败时删除下载的文件并返回 ErrChecksumMismatch；成功后 info.SHA256 为下载文件的实际 SHA256
+func (b *BaseUpdater) downloadVerified(ctx context.Context, info *types.UpdateInfo, dest, fileName, source string, progress types.ProgressFunc) error {
+	expected, err := b.expectedSHA256(ctx, info)
+	if err != nil {
+		return err
+	}
+
+	if err := b.DownloadFileWithValidation(ctx, info.URL, dest, fileName, source, info.Size, progress); err != nil {
+		return err
+	}
+
+	progress("正在计算文件校验和...", 0.65, "", "", 0, 0, 0, false)
+	actual, err := fileutil.CalculateSHA256(dest)
+	if err != nil {
+		return fmt.Errorf("计算文件校验和失败: %w", err)
+	}
+	if expected != "" && actual != expected {
+		removePartial(dest)
+		return fmt.Errorf("%w: %s 期望 %s，实际 %s，已删除下载的文件", ErrChecksumMismatch, fileName, expected, actual)
+	}
+
+	info.SHA256 = actual
+	return nil
+}
@@ -0,0 +1,86 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func newChecksumTestServer(t *testing.T, content, sums string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/scheme.zip":
+			w.Write([]byte(content))
+		case "/SHA256SUMS":
+			w.Write([]byte(sums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadVerified(t *testing.T) {
+	const content = "scheme archive"
+	good := sha256Hex(content)
+	bad := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name     string
+		sha256   string
+		sums     string
+		require  bool
+		wantErr  error
+		wantFile bool
+	}{
+		{name: "metadata digest matches", sha256: "sha256:" + good, wantFile: true},
+		{name: "metadata digest mismatch", sha256: bad, wantErr: ErrChecksumMismatch},
+		{name: "SHA256SUMS matches", sums: good + "  scheme.zip\n", wantFile: true},
+		{name: "SHA256SUMS mismatch", sums: bad + "  scheme.zip\n", wantErr: ErrChecksumMismatch},
+		{name: "no digest allowed", wantFile: true},
+		{name: "no digest required", require: true, wantErr: ErrNoChecksum},
+		{name: "SHA256SUMS without the file required", sums: good + "  other.zip\n", require: true, wantErr: ErrNoChecksum},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := newManifestTestUpdater(t)
+			base.Config.Config.RequireChecksum = tt.require
+			server := newChecksumTestServer(t, content, tt.sums)
+
+			info := &types.UpdateInfo{Name: "scheme.zip", URL: server.URL + "/scheme.zip", SHA256: tt.sha256}
+			if tt.sums != "" {
+				info.ChecksumURL = server.URL + "/SHA256SUMS"
+			}
+			dest := filepath.Join(t.TempDir(), "scheme.zip")
+
+			err := base.downloadVerified(context.Background(), info, dest, "scheme.zip", "test", func(string, float64, string, string, int64, int64, float64, bool) {})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("downloadVerified() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, statErr := os.Stat(dest); (statErr == nil) != tt.wantFile {
+				t.Errorf("downloaded file exists = %v, want %v", statErr == nil, tt.wantFile)
+			}
+			if tt.wantErr == nil && info.SHA256 != good {
+				t.Errorf("info.SHA256 = %q, want %q", info.SHA256, good)
+			}
+		})
+	}
+}
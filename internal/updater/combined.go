@@ -1,91 +1,66 @@
 package updater
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"rime-wanxiang-updater/internal/api"
 	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
 )
 
-// CombinedUpdater 组合更新器
+// CombinedUpdater 组合更新器，按注册顺序管理所有组件
 type CombinedUpdater struct {
-	Config        *config.Manager
-	SchemeUpdater *SchemeUpdater
-	DictUpdater   *DictUpdater
-	ModelUpdater  *ModelUpdater
+	Config     *config.Manager
+	base       *BaseUpdater // 终止进程和部署等与组件无关的操作
+	components []Component
 }
 
-// NewCombinedUpdater 创建组合更新器
+// NewCombinedUpdater 为所有已注册的组件创建组合更新器，各组件共享同一个 API 客户端
 func NewCombinedUpdater(cfg *config.Manager) *CombinedUpdater {
+	sharedClient := api.NewClient(cfg.Config)
 	combined := &CombinedUpdater{
-		Config:        cfg,
-		SchemeUpdater: NewSchemeUpdater(cfg),
-		DictUpdater:   NewDictUpdater(cfg),
-		ModelUpdater:  NewModelUpdater(cfg),
+		Config: cfg,
+		base:   NewBaseUpdater(cfg),
+	}
+	combined.base.APIClient = sharedClient
+	for _, spec := range registry {
+		comp := spec.New(cfg)
+		comp.Base().APIClient = sharedClient
+		combined.components = append(combined.components, comp)
 	}
-
-	sharedClient := api.NewClient(cfg.Config)
-	combined.SchemeUpdater.APIClient = sharedClient
-	combined.DictUpdater.APIClient = sharedClient
-	combined.ModelUpdater.APIClient = sharedClient
 
 	return combined
 }
 
-// FetchAllUpdates 获取所有更新信息
-func (c *CombinedUpdater) FetchAllUpdates() error {
-	var errors []string
-
-	// 检查方案更新
-	schemeInfo, err := c.SchemeUpdater.CheckUpdate()
-	if err != nil {
-		errors = append(errors, fmt.Sprintf("方案: %v", err))
-	}
-	c.SchemeUpdater.UpdateInfo = schemeInfo
-
-	// 检查词库更新
-	dictInfo, err := c.DictUpdater.CheckUpdate()
-	if err != nil {
-		errors = append(errors, fmt.Sprintf("词库: %v", err))
-	}
-	c.DictUpdater.UpdateInfo = dictInfo
-
-	// 检查模型更新
-	modelInfo, err := c.ModelUpdater.CheckUpdate()
-	if err != nil {
-		errors = append(errors, fmt.Sprintf("模型: %v", err))
-	}
-	c.ModelUpdater.UpdateInfo = modelInfo
-
-	if len(errors) > 0 {
-		return fmt.Errorf("检查更新失败: %v", errors)
-	}
-
-	return nil
+// Components 按更新顺序返回所有组件的更新器
+func (c *CombinedUpdater) Components() []Component {
+	return append([]Component(nil), c.components...)
 }
 
-// HasAnyUpdate 检查是否有任何更新
-func (c *CombinedUpdater) HasAnyUpdate() bool {
-	// 检查方案更新 - 使用 GetStatus 来检查实际文件
-	if schemeStatus, err := c.SchemeUpdater.GetStatus(); err == nil && schemeStatus.NeedsUpdate {
-		return true
-	}
-
-	// 检查词库更新 - 使用 GetStatus 来检查实际文件
-	if dictStatus, err := c.DictUpdater.GetStatus(); err == nil && dictStatus.NeedsUpdate {
-		return true
+// Component 按 ID 返回组件的更新器
+func (c *CombinedUpdater) Component(id string) (Component, error) {
+	for _, comp := range c.components {
+		if comp.ID() == id {
+			return comp, nil
+		}
 	}
+	return nil, fmt.Errorf("未知的组件: %s", id)
+}
 
-	// 检查模型更新 - 保持原有逻辑
-	hasModel := c.ModelUpdater.UpdateInfo != nil &&
-		c.ModelUpdater.HasUpdate(c.ModelUpdater.UpdateInfo, c.Config.GetModelRecordPath())
-
-	return hasModel
+// Deploy 重新部署输入法
+func (c *CombinedUpdater) Deploy() error {
+	return c.base.Deploy()
 }
 
-// RunAll 执行所有更新
-func (c *CombinedUpdater) RunAll() error {
-	_, err := c.RunAllWithProgress(nil)
+// RunAll 获取更新计划并执行所有更新
+func (c *CombinedUpdater) RunAll(ctx context.Context) error {
+	plan, err := c.FetchAllUpdates(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.RunAllWithProgress(ctx, plan, nil)
 	return err
 }
 
@@ -97,25 +72,34 @@ type UpdateResult struct {
 	PreviousVersions  map[string]string // 更新前组件版本信息（组件名 -> 版本号）
 }
 
-func (c *CombinedUpdater) resetPlannedUpdateInfo(
-	needsSchemeUpdate bool,
-	needsDictUpdate bool,
-	needsModelUpdate bool,
-) {
-	if needsSchemeUpdate {
-		c.SchemeUpdater.UpdateInfo = nil
-	}
-	if needsDictUpdate {
-		c.DictUpdater.UpdateInfo = nil
-	}
-	if needsModelUpdate {
-		c.ModelUpdater.UpdateInfo = nil
+// batchError 组合更新的错误，保留导致回滚的组件错误，便于调用方用 errors.Is / errors.As 判断原因
+type batchError struct {
+	message string
+	cause   error
+}
+
+func (e *batchError) Error() string {
+	return e.message
+}
+
+func (e *batchError) Unwrap() error {
+	return e.cause
+}
+
+// setBatchMode 切换组合更新模式：跳过各组件单独终止进程，并共享同一个事务
+func (c *CombinedUpdater) setBatchMode(enabled bool, txn *Transaction) {
+	for _, comp := range c.components {
+		base := comp.Base()
+		base.SkipTerminate = enabled
+		base.Transaction = txn
 	}
 }
 
-// RunAllWithProgress 执行所有更新并报告进度
-func (c *CombinedUpdater) RunAllWithProgress(progress func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)) (*UpdateResult, error) {
+// RunAllWithProgress 按更新计划执行所有更新并报告进度；plan 由 FetchAllUpdates 生成，期间不再重新获取远程版本信息。
+// ctx 在下载期间或两个组件的安装之间取消时整批回滚，已下载的更新包保留在缓存目录中
+func (c *CombinedUpdater) RunAllWithProgress(ctx context.Context, plan *UpdatePlan, progress func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)) (*UpdateResult, error) {
 	var errors []string
+	var cause error // 导致整批回滚的组件错误
 	result := &UpdateResult{
 		UpdatedComponents: []string{},
 		SkippedComponents: []string{},
@@ -132,178 +116,199 @@ func (c *CombinedUpdater) RunAllWithProgress(progress func(component, message st
 		return result, fmt.Errorf("未检测到已安装的 Rime 引擎，请先安装并启用 Rime 输入法")
 	}
 
-	// 收集需要更新的项
-	needsSchemeUpdate := false
-	needsDictUpdate := false
-	needsModelUpdate := false
-
-	if c.SchemeUpdater.UpdateInfo != nil {
-		if schemeStatus, err := c.SchemeUpdater.GetStatus(); err == nil && schemeStatus.NeedsUpdate {
-			needsSchemeUpdate = true
-			result.PreviousVersions["方案"] = schemeStatus.LocalVersion
-		} else if err == nil {
-			result.SkippedComponents = append(result.SkippedComponents, "方案")
-			result.ComponentVersions["方案"] = schemeStatus.LocalVersion
-		}
-	}
-
-	if c.DictUpdater.UpdateInfo != nil {
-		if dictStatus, err := c.DictUpdater.GetStatus(); err == nil && dictStatus.NeedsUpdate {
-			needsDictUpdate = true
-			result.PreviousVersions["词库"] = dictStatus.LocalVersion
-		} else if err == nil {
-			result.SkippedComponents = append(result.SkippedComponents, "词库")
-			result.ComponentVersions["词库"] = dictStatus.LocalVersion
+	// 按计划收集需要更新的项，各更新器直接使用计划中的版本信息
+	var pending []Component
+	for _, comp := range c.components {
+		entry, ok := plan.Get(comp.ID())
+		if !ok || entry.Info == nil {
+			continue
 		}
-	}
-
-	if c.ModelUpdater.UpdateInfo != nil &&
-		c.ModelUpdater.HasUpdate(c.ModelUpdater.UpdateInfo, c.Config.GetModelRecordPath()) {
-		needsModelUpdate = true
-		if modelStatus, err := c.ModelUpdater.GetStatus(); err == nil {
-			result.PreviousVersions["模型"] = modelStatus.LocalVersion
-		}
-	} else if c.ModelUpdater.UpdateInfo != nil {
-		if modelStatus, err := c.ModelUpdater.GetStatus(); err == nil {
-			result.SkippedComponents = append(result.SkippedComponents, "模型")
-			result.ComponentVersions["模型"] = modelStatus.LocalVersion
+		name := types.ComponentName(comp.ID())
+		switch {
+		case entry.NeedsUpdate:
+			pending = append(pending, comp)
+			comp.Base().UpdateInfo = entry.Info
+			if entry.Status != nil {
+				result.PreviousVersions[name] = entry.Status.LocalVersion
+			}
+		case entry.Status != nil:
+			result.SkippedComponents = append(result.SkippedComponents, name)
+			result.ComponentVersions[name] = entry.Status.LocalVersion
 		}
 	}
 
 	// 如果没有任何更新，直接返回
-	if !needsSchemeUpdate && !needsDictUpdate && !needsModelUpdate {
+	if len(pending) == 0 {
 		progress("完成", "已是最新版本", 1.0, "", "", 0, 0, 0, false)
 		return result, nil
 	}
 
-	// FetchAllUpdates 可能在较早时刻缓存了 UpdateInfo。
-	// 真正执行下载前清掉这些快照，让 Run() 重新获取最新元数据，
-	// 避免“刚更新完下一次又补下一遍”的重复下载。
-	c.resetPlannedUpdateInfo(needsSchemeUpdate, needsDictUpdate, needsModelUpdate)
-
-	// 统一在开始前终止进程（只终止一次）
-	progress("准备", "正在终止相关进程...", 0.0, "", "", 0, 0, 0, false)
-	if err := c.SchemeUpdater.TerminateProcesses(); err != nil {
-		return result, fmt.Errorf("终止进程失败: %w", err)
+	// 整批更新共享一个事务：任一组件失败时所有组件一起回滚
+	txn, err := NewTransaction(c.Config.CacheDir)
+	if err != nil {
+		return result, err
 	}
 
 	// 标记为组合更新模式，让子更新器跳过终止进程步骤
-	c.SchemeUpdater.SkipTerminate = true
-	c.DictUpdater.SkipTerminate = true
-	c.ModelUpdater.SkipTerminate = true
-
-	defer func() {
-		// 恢复默认设置
-		c.SchemeUpdater.SkipTerminate = false
-		c.DictUpdater.SkipTerminate = false
-		c.ModelUpdater.SkipTerminate = false
-	}()
-
-	// 更新方案
-	if needsSchemeUpdate {
-		progress("方案", "正在更新方案...", 0.05, "", "", 0, 0, 0, false)
-		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
-			progress("方案", message, 0.05+percent*0.30, source, fileName, downloaded, total, speed, downloadMode) // 方案占 30%
-		}
-		if err := c.SchemeUpdater.Run(progressFunc); err != nil {
-			errors = append(errors, fmt.Sprintf("方案更新失败: %v", err))
-		} else {
-			result.UpdatedComponents = append(result.UpdatedComponents, "方案")
-			if c.SchemeUpdater.UpdateInfo != nil {
-				result.ComponentVersions["方案"] = c.SchemeUpdater.UpdateInfo.Tag
-			}
+	c.setBatchMode(true, txn)
+	defer c.setBatchMode(false, nil) // 恢复默认设置
+
+	jobs := newComponentJobs(pending)
+
+	// 依次执行更新前 hook 并确定各组件要安装的版本
+	for _, job := range jobs {
+		progress(job.name, fmt.Sprintf("正在更新%s...", job.name), 0.0, "", "", 0, 0, 0, false)
+		job.history = job.begin()
+		job.err = job.prepare(ctx, func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+			progress(job.name, message, percent*0.05, source, fileName, downloaded, total, speed, downloadMode) // 准备占 5%
+		})
+		if job.err != nil {
+			errors = append(errors, fmt.Sprintf("%s更新失败: %v", job.name, job.err))
+			cause = job.err
+			break
 		}
 	}
 
-	// 更新词库
-	if needsDictUpdate {
-		progress("词库", "正在更新词库...", 0.35, "", "", 0, 0, 0, false)
-		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
-			progress("词库", message, 0.35+percent*0.30, source, fileName, downloaded, total, speed, downloadMode) // 词库占 30%
-		}
-		if err := c.DictUpdater.Run(progressFunc); err != nil {
-			errors = append(errors, fmt.Sprintf("词库更新失败: %v", err))
-		} else {
-			result.UpdatedComponents = append(result.UpdatedComponents, "词库")
-			if c.DictUpdater.UpdateInfo != nil {
-				result.ComponentVersions["词库"] = c.DictUpdater.UpdateInfo.Tag
+	// 并行下载所有更新包，进度按总字节数汇总（下载占 55%）
+	if len(errors) == 0 {
+		downloadAll(ctx, jobs, c.downloadConcurrency(), sourceLabel(Sources(c.Config.Config)[0]), 0.05, 0.60, progress)
+		for _, job := range jobs {
+			if job.err != nil {
+				errors = append(errors, fmt.Sprintf("%s更新失败: %v", job.name, job.err))
+				if cause == nil {
+					cause = job.err
+				}
 			}
 		}
 	}
 
-	// 更新模型
-	if needsModelUpdate {
-		progress("模型", "正在更新模型...", 0.65, "", "", 0, 0, 0, false)
-		progressFunc := func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
-			progress("模型", message, 0.65+percent*0.25, source, fileName, downloaded, total, speed, downloadMode) // 模型占 25%
-		}
-		if err := c.ModelUpdater.Run(progressFunc); err != nil {
-			errors = append(errors, fmt.Sprintf("模型更新失败: %v", err))
-		} else {
-			result.UpdatedComponents = append(result.UpdatedComponents, "模型")
-			if c.ModelUpdater.UpdateInfo != nil {
-				result.ComponentVersions["模型"] = c.ModelUpdater.UpdateInfo.Tag
-			}
+	// 全部下载成功后才终止进程（只终止一次），下载期间以及下载失败或取消时输入法照常可用
+	terminated := false
+	if len(errors) == 0 {
+		progress("准备", "正在终止相关进程...", 0.60, "", "", 0, 0, 0, false)
+		if err := c.base.TerminateProcesses(); err != nil {
+			cause = fmt.Errorf("终止进程失败: %w", err)
+			errors = append(errors, cause.Error())
 		}
+		terminated = true
 	}
 
-	// 如果没有错误，执行部署（会重启服务）
+	// 按注册顺序安装（前面的组件失败时不再继续，整批回滚）
 	if len(errors) == 0 {
-		// 获取要部署的引擎列表
-		deployEngines := c.Config.Config.UpdateEngines
-		if len(deployEngines) == 0 {
-			// 未配置：默认部署所有已安装的引擎
-			deployEngines = c.Config.Config.InstalledEngines
-		}
-
-		// 检查是否有多个引擎需要部署
-		if len(deployEngines) > 1 {
-			// 定义接口用于类型断言
-			type multiEngineDeployer interface {
-				DeployToAllEnginesWithProgress(progressFunc func(engine string, index, total int)) error
-			}
-
-			if med, ok := c.SchemeUpdater.Deployer.(multiEngineDeployer); ok {
-				// 支持多引擎进度回调的 deployer
-				err := med.DeployToAllEnginesWithProgress(func(engine string, index, total int) {
-					deployMsg := fmt.Sprintf("正在部署到 %s (%d/%d)...", engine, index, total)
-					deployPercent := 0.90 + float64(index-1)/float64(total)*0.09 // 0.90-0.99
-					progress("部署", deployMsg, deployPercent, "", "", 0, 0, 0, false)
+		spans := installSpans(jobs, 0.30) // 安装共占 30%，按组件权重分配
+		start := 0.60
+		for i, job := range jobs {
+			from, span := start, spans[i]
+			if err := ctx.Err(); err != nil {
+				job.err = err
+			} else if job.tempFile != "" {
+				progress(job.name, fmt.Sprintf("正在安装%s...", job.name), from, "", "", 0, 0, 0, false)
+				job.err = job.apply(job.tempFile, func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+					progress(job.name, message, from+installRatio(percent)*span, source, fileName, downloaded, total, speed, downloadMode)
 				})
-				if err != nil {
-					errors = append(errors, fmt.Sprintf("部署失败: %v", err))
-				}
-			} else {
-				// 回退到单引擎部署
-				engineName := c.Config.GetEngineDisplayName()
-				progress("部署", fmt.Sprintf("正在部署到 %s...", engineName), 0.90, "", "", 0, 0, 0, false)
-				if err := c.SchemeUpdater.Deploy(); err != nil {
-					errors = append(errors, fmt.Sprintf("部署失败: %v", err))
-				}
+				job.tempFile = ""
 			}
-		} else {
-			// 单引擎部署
-			engineName := "输入法"
-			if len(deployEngines) == 1 {
-				engineName = deployEngines[0]
-			} else if c.Config.Config.PrimaryEngine != "" {
-				engineName = c.Config.Config.PrimaryEngine
+			if job.err != nil {
+				errors = append(errors, fmt.Sprintf("%s更新失败: %v", job.name, job.err))
+				cause = job.err
+				break
 			}
-			progress("部署", fmt.Sprintf("正在部署到 %s...", engineName), 0.90, "", "", 0, 0, 0, false)
-			if err := c.SchemeUpdater.Deploy(); err != nil {
-				errors = append(errors, fmt.Sprintf("部署失败: %v", err))
+			result.UpdatedComponents = append(result.UpdatedComponents, job.name)
+			if info := job.info(); info != nil {
+				result.ComponentVersions[job.name] = info.Tag
 			}
+			start += span
+		}
+	}
+
+	for _, job := range jobs {
+		// 未安装的更新包（模型为临时目录）不再需要；取消时保留，供下次更新继续使用
+		if job.tempFile != "" && ctx.Err() == nil {
+			os.RemoveAll(job.tempFile)
+		}
+		if job.history != nil {
+			job.base.endHistory(job.history, job.info(), job.err)
+		}
+	}
+
+	if len(errors) > 0 {
+		progress("回滚", "正在恢复更新前的文件...", 0.85, "", "", 0, 0, 0, false)
+		if err := txn.Rollback(); err != nil {
+			errors = append(errors, err.Error())
 		}
+		for _, component := range result.UpdatedComponents {
+			delete(result.ComponentVersions, component)
+		}
+		result.UpdatedComponents = []string{}
 	} else {
+		txn.Commit()
+	}
+	c.flushHistory(len(errors) > 0)
+
+	// 如果没有错误，执行部署（会重启服务）
+	if len(errors) == 0 {
+		errors = append(errors, c.deployEngines(progress)...)
+	} else if terminated {
 		// 即使有错误，也尝试重启服务，让用户能继续使用输入法
 		progress("恢复", "尝试重启服务...", 0.90, "", "", 0, 0, 0, false)
-		_ = c.SchemeUpdater.Deploy() // 忽略错误
+		_ = c.Deploy() // 忽略错误
 	}
 
 	if len(errors) > 0 {
-		return result, fmt.Errorf("更新过程中出现错误: %v", errors)
+		return result, &batchError{message: fmt.Sprintf("更新过程中出现错误: %v", errors), cause: cause}
 	}
 
 	progress("完成", "所有更新已完成", 1.0, "", "", 0, 0, 0, false)
 	return result, nil
 }
+
+// deployEngines 部署需要更新的所有引擎并报告进度，返回部署中出现的错误
+func (c *CombinedUpdater) deployEngines(progress func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)) []string {
+	var errors []string
+	// 获取要部署的引擎列表
+	deployEngines := c.Config.Config.UpdateEngines
+	if len(deployEngines) == 0 {
+		// 未配置：默认部署所有已安装的引擎
+		deployEngines = c.Config.Config.InstalledEngines
+	}
+
+	// 检查是否有多个引擎需要部署
+	if len(deployEngines) > 1 {
+		// 定义接口用于类型断言
+		type multiEngineDeployer interface {
+			DeployToAllEnginesWithProgress(progressFunc func(engine string, index, total int)) error
+		}
+
+		if med, ok := c.base.Deployer.(multiEngineDeployer); ok {
+			// 支持多引擎进度回调的 deployer
+			err := med.DeployToAllEnginesWithProgress(func(engine string, index, total int) {
+				deployMsg := fmt.Sprintf("正在部署到 %s (%d/%d)...", engine, index, total)
+				deployPercent := 0.90 + float64(index-1)/float64(total)*0.09 // 0.90-0.99
+				progress("部署", deployMsg, deployPercent, "", "", 0, 0, 0, false)
+			})
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("部署失败: %v", err))
+			}
+		} else {
+			// 回退到单引擎部署
+			engineName := c.Config.GetEngineDisplayName()
+			progress("部署", fmt.Sprintf("正在部署到 %s...", engineName), 0.90, "", "", 0, 0, 0, false)
+			if err := c.Deploy(); err != nil {
+				errors = append(errors, fmt.Sprintf("部署失败: %v", err))
+			}
+		}
+	} else {
+		// 单引擎部署
+		engineName := "输入法"
+		if len(deployEngines) == 1 {
+			engineName = deployEngines[0]
+		} else if c.Config.Config.PrimaryEngine != "" {
+			engineName = c.Config.Config.PrimaryEngine
+		}
+		progress("部署", fmt.Sprintf("正在部署到 %s...", engineName), 0.90, "", "", 0, 0, 0, false)
+		if err := c.Deploy(); err != nil {
+			errors = append(errors, fmt.Sprintf("部署失败: %v", err))
+		}
+	}
+	return errors
+}
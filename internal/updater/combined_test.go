@@ -1,27 +1,143 @@
 package updater
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"rime-wanxiang-updater/internal/types"
 )
 
-func TestResetPlannedUpdateInfoClearsOnlyComponentsMarkedForUpdate(t *testing.T) {
-	combined := &CombinedUpdater{
-		SchemeUpdater: &SchemeUpdater{UpdateInfo: &types.UpdateInfo{Tag: "scheme-stale"}},
-		DictUpdater:   &DictUpdater{UpdateInfo: &types.UpdateInfo{Tag: "dict-stale"}},
-		ModelUpdater:  &ModelUpdater{UpdateInfo: &types.UpdateInfo{Tag: "model-stale"}},
+func TestResolvePlanChecksComponentsConcurrently(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(3)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	failure := errors.New("timeout")
+	check := func(tag string, err error) func(context.Context) (*types.UpdateInfo, error) {
+		return func(context.Context) (*types.UpdateInfo, error) {
+			started.Done()
+			select {
+			case <-allStarted:
+			case <-time.After(time.Second):
+				t.Error("checks did not run concurrently")
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &types.UpdateInfo{Tag: tag}, nil
+		}
+	}
+	statusFor := func(info *types.UpdateInfo) *types.UpdateStatus {
+		return &types.UpdateStatus{RemoteVersion: info.Tag, NeedsUpdate: info.Tag == "v2"}
+	}
+	needs := func(_ *types.UpdateInfo, status *types.UpdateStatus) bool { return status.NeedsUpdate }
+
+	plan, err := resolvePlan(context.Background(), []componentResolver{
+		{types.ComponentScheme, check("v1", nil), statusFor, needs},
+		{types.ComponentDict, check("v2", nil), statusFor, needs},
+		{types.ComponentModel, check("", failure), statusFor, needs},
+	})
+	if err == nil || !strings.Contains(err.Error(), "模型: timeout") {
+		t.Fatalf("resolvePlan() error = %v, want model failure", err)
+	}
+
+	scheme, _ := plan.Get(types.ComponentScheme)
+	if scheme.Info.Tag != "v1" || scheme.NeedsUpdate {
+		t.Errorf("scheme = %+v, want up-to-date v1", scheme)
+	}
+	dict, _ := plan.Get(types.ComponentDict)
+	if dict.Status.RemoteVersion != "v2" || !dict.NeedsUpdate {
+		t.Errorf("dict = %+v, want update to v2", dict)
+	}
+	model, _ := plan.Get(types.ComponentModel)
+	if model.Err != failure || model.Info != nil || model.Status != nil {
+		t.Errorf("model = %+v, want only Err", model)
+	}
+	if !plan.HasAnyUpdate() {
+		t.Error("HasAnyUpdate() = false, want true")
+	}
+}
+
+func TestUpdatePlanGetReturnsCopies(t *testing.T) {
+	plan := &UpdatePlan{entries: map[string]PlannedUpdate{
+		types.ComponentScheme: {
+			Component: types.ComponentScheme,
+			Info:      &types.UpdateInfo{Tag: "v1"},
+			Status:    &types.UpdateStatus{LocalVersion: "v0"},
+		},
+	}}
+
+	entry, ok := plan.Get(types.ComponentScheme)
+	if !ok {
+		t.Fatal("Get() ok = false, want true")
+	}
+	entry.Info.SHA256 = "changed"
+	entry.Status.LocalVersion = "changed"
+
+	again, _ := plan.Get(types.ComponentScheme)
+	if again.Info.SHA256 != "" || again.Status.LocalVersion != "v0" {
+		t.Errorf("Get() after modifying a copy = %+v / %+v, want original values", again.Info, again.Status)
+	}
+	if _, ok := plan.Get(types.ComponentDict); ok {
+		t.Error("Get(dict) ok = true, want false")
 	}
+	if plan.HasAnyUpdate() {
+		t.Error("HasAnyUpdate() = true, want false")
+	}
+}
 
-	combined.resetPlannedUpdateInfo(true, false, true)
+// countingDeployer 记录调用次数的部署器
+type countingDeployer struct {
+	terminated int
+	deployed   int
+}
+
+func (d *countingDeployer) Deploy() error {
+	d.deployed++
+	return nil
+}
 
-	if combined.SchemeUpdater.UpdateInfo != nil {
-		t.Fatal("SchemeUpdater.UpdateInfo != nil, want nil when scheme refresh is required")
+func (d *countingDeployer) TerminateProcesses() error {
+	d.terminated++
+	return nil
+}
+
+// failingDownloadComponent 下载总是失败的组件
+type failingDownloadComponent struct {
+	*ModelUpdater
+}
+
+func (f *failingDownloadComponent) Download(context.Context, types.ProgressFunc) (string, error) {
+	return "", errors.New("network down")
+}
+
+func TestRunAllKeepsProcessesRunningWhenDownloadFails(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	base.Config.Config.InstalledEngines = []string{"fcitx5"}
+	deployer := &countingDeployer{}
+	comp := &failingDownloadComponent{ModelUpdater: &ModelUpdater{BaseUpdater: &BaseUpdater{Config: base.Config, Deployer: deployer}}}
+	combined := &CombinedUpdater{
+		Config:     base.Config,
+		base:       &BaseUpdater{Config: base.Config, Deployer: deployer},
+		components: []Component{comp},
 	}
-	if combined.DictUpdater.UpdateInfo == nil {
-		t.Fatal("DictUpdater.UpdateInfo = nil, want original value when dict refresh is not required")
+	plan := &UpdatePlan{entries: map[string]PlannedUpdate{
+		types.ComponentModel: {Component: types.ComponentModel, Info: &types.UpdateInfo{Tag: "v2"}, NeedsUpdate: true},
+	}}
+
+	if _, err := combined.RunAllWithProgress(context.Background(), plan, nil); err == nil {
+		t.Fatal("RunAllWithProgress() error = nil, want failure")
 	}
-	if combined.ModelUpdater.UpdateInfo != nil {
-		t.Fatal("ModelUpdater.UpdateInfo != nil, want nil when model refresh is required")
+	// 没有安装任何文件，输入法进程不应被终止或重新部署
+	if deployer.terminated != 0 || deployer.deployed != 0 {
+		t.Errorf("TerminateProcesses() called %d times, Deploy() %d times, want 0", deployer.terminated, deployer.deployed)
 	}
 }
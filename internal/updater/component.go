@@ -0,0 +1,228 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// Component 一种可更新的资源（方案、词库、模型……）。
+// 新的资源类型嵌入 *BaseUpdater、实现该接口并通过 Register 注册后，组合更新、控制器、命令行和界面菜单都会自动包含它；
+// Run 和 DryRun 通常直接调用 RunComponent 和 DryRunComponent，由它们按 Download、Apply 等步骤执行。
+type Component interface {
+	// ID 组件 ID，如 types.ComponentScheme
+	ID() string
+	// Base 返回组件的基础更新器，组合更新通过它共享 API 客户端、事务和更新历史
+	Base() *BaseUpdater
+
+	CheckUpdate(ctx context.Context) (*types.UpdateInfo, error)
+	GetStatus(ctx context.Context) (*types.UpdateStatus, error)
+	Run(ctx context.Context, progress types.ProgressFunc) error
+	DryRun(ctx context.Context, progress types.ProgressFunc) *ComponentPlan
+	Versions() ([]ArchivedVersion, error)
+	Rollback(version ArchivedVersion, progress types.ProgressFunc) error
+	Uninstall() *UninstallResult
+	Deploy() error
+
+	// 以下方法由组合更新、更新计划和预演调用，组件通常不需要直接使用
+
+	// RecordPath 本地版本记录的路径；FileName 远程资源的文件名
+	RecordPath() string
+	FileName() string
+	// StatusFor 比较远程版本和本地文件得到更新状态，不访问网络
+	StatusFor(info *types.UpdateInfo) *types.UpdateStatus
+	// NeedsUpdate 根据远程版本和更新状态判断是否需要安装，BaseUpdater 提供按 status.NeedsUpdate 判断的默认实现
+	NeedsUpdate(info *types.UpdateInfo, status *types.UpdateStatus) bool
+	// Download 下载 Base().UpdateInfo 指定的版本并返回临时文件路径；本地文件已是最新版本时返回空字符串
+	Download(ctx context.Context, progress types.ProgressFunc) (string, error)
+	// Apply 安装 Download 下载的临时文件
+	Apply(tempFile string, progress types.ProgressFunc) error
+	// DryRunFor 按已获取的远程版本信息和更新状态预演更新；err 为获取版本信息时的错误
+	DryRunFor(ctx context.Context, info *types.UpdateInfo, status *types.UpdateStatus, err error, progress types.ProgressFunc) *ComponentPlan
+}
+
+// verifier 支持按安装清单校验已安装文件的组件
+type verifier interface {
+	Verify(repair bool) *VerifyReport
+}
+
+// ComponentSpec 描述一种可注册的组件
+type ComponentSpec struct {
+	ID     string                              // 组件 ID，用于配置、命令行参数和更新历史
+	Name   string                              // 内部组件名，如 "方案"；界面显示时经 i18n.Component 翻译
+	Weight float64                             // 组合更新中安装和预演阶段占整体进度的相对比例
+	New    func(cfg *config.Manager) Component // 创建组件的更新器
+}
+
+// registry 已注册的组件，按更新顺序排列
+var registry = []ComponentSpec{
+	{
+		ID:     types.ComponentScheme,
+		Name:   types.ComponentName(types.ComponentScheme),
+		Weight: 0.45,
+		New:    func(cfg *config.Manager) Component { return NewSchemeUpdater(cfg) },
+	},
+	{
+		ID:     types.ComponentDict,
+		Name:   types.ComponentName(types.ComponentDict),
+		Weight: 0.45,
+		New:    func(cfg *config.Manager) Component { return NewDictUpdater(cfg) },
+	},
+	{
+		ID:     types.ComponentModel,
+		Name:   types.ComponentName(types.ComponentModel),
+		Weight: 0.1, // 单个文件，不需要解压和比较
+		New:    func(cfg *config.Manager) Component { return NewModelUpdater(cfg) },
+	},
+}
+
+// Register 注册一种组件，排在已注册的组件之后更新；同一 ID 重复注册时替换原来的定义。
+// 应在程序初始化时调用
+func Register(spec ComponentSpec) {
+	types.RegisterComponent(spec.ID, spec.Name)
+	for i := range registry {
+		if registry[i].ID == spec.ID {
+			registry[i] = spec
+			return
+		}
+	}
+	registry = append(registry, spec)
+}
+
+// Unregister 移除已注册的组件，主要供测试恢复注册表
+func Unregister(id string) {
+	types.UnregisterComponent(id)
+	for i, spec := range registry {
+		if spec.ID == id {
+			registry = append(registry[:i:i], registry[i+1:]...)
+			return
+		}
+	}
+}
+
+// Specs 按更新顺序返回已注册的组件
+func Specs() []ComponentSpec {
+	return append([]ComponentSpec(nil), registry...)
+}
+
+// LookupSpec 按 ID 查找已注册的组件
+func LookupSpec(id string) (ComponentSpec, bool) {
+	for _, spec := range registry {
+		if spec.ID == id {
+			return spec, true
+		}
+	}
+	return ComponentSpec{}, false
+}
+
+// NewComponent 按 ID 创建已注册组件的更新器
+func NewComponent(cfg *config.Manager, id string) (Component, error) {
+	spec, ok := LookupSpec(id)
+	if !ok {
+		return nil, fmt.Errorf("未知的组件: %s", id)
+	}
+	return spec.New(cfg), nil
+}
+
+// Base 返回基础更新器本身，嵌入 *BaseUpdater 的组件由此实现 Component.Base
+func (b *BaseUpdater) Base() *BaseUpdater {
+	return b
+}
+
+// NeedsUpdate Component.NeedsUpdate 的默认实现
+func (b *BaseUpdater) NeedsUpdate(_ *types.UpdateInfo, status *types.UpdateStatus) bool {
+	return status.NeedsUpdate
+}
+
+// resolveComponent 获取组件的远程版本信息并计算更新状态，只请求一次远程元数据
+func resolveComponent(ctx context.Context, comp Component) (*types.UpdateInfo, *types.UpdateStatus, error) {
+	if err := comp.Base().Config.ReconcileRuntimeState(); err != nil {
+		return nil, nil, err
+	}
+
+	// 获取远程版本信息
+	remoteInfo, err := checkWithFailover(ctx, comp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return remoteInfo, comp.StatusFor(remoteInfo), nil
+}
+
+// RunComponent 执行单个组件的更新，并将结果追加到更新历史
+func RunComponent(ctx context.Context, comp Component, progress types.ProgressFunc) error {
+	b := comp.Base()
+	run := b.beginHistory(comp.ID(), HistoryUpdate, comp.RecordPath(), comp.FileName())
+	err := runSteps(ctx, comp, progress)
+	b.endHistory(run, b.UpdateInfo, err)
+	return err
+}
+
+func runSteps(ctx context.Context, comp Component, progress types.ProgressFunc) error {
+	if progress == nil {
+		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
+	}
+
+	if err := prepareComponent(ctx, comp, progress); err != nil {
+		return err
+	}
+
+	tempFile, err := downloadWithFailover(ctx, comp, progress, comp.Download)
+	if err != nil || tempFile == "" {
+		return err
+	}
+
+	// 开始安装前最后一次响应取消，安装过程本身不中断，保证 Rime 目录一致
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return comp.Apply(tempFile, progress)
+}
+
+// prepareComponent 执行更新前 hook 并确定组件要安装的版本
+func prepareComponent(ctx context.Context, comp Component, progress types.ProgressFunc) error {
+	b := comp.Base()
+	name := types.ComponentName(comp.ID())
+	if err := b.EnsureInstalledEngine(); err != nil {
+		return err
+	}
+
+	// 执行更新前 hook
+	if b.Config.Config.PreUpdateHook != "" {
+		progress("执行更新前 hook...", 0.02, "", "", 0, 0, 0, false)
+		if err := b.Config.ExecutePreUpdateHook(); err != nil {
+			return fmt.Errorf("pre-update hook 失败，已取消更新: %w", err)
+		}
+	}
+
+	// 显示下载源
+	progress(fmt.Sprintf("正在检查%s更新 [%s]...", name, sourceLabel(Sources(b.Config.Config)[0])), 0.05, "", "", 0, 0, 0, false)
+
+	if b.UpdateInfo == nil {
+		info, err := checkWithFailover(ctx, comp)
+		if err != nil {
+			return err
+		}
+		b.UpdateInfo = info
+	}
+
+	if b.UpdateInfo == nil {
+		return fmt.Errorf("未找到%s更新", name)
+	}
+
+	return nil
+}
+
+// DryRunComponent 获取组件的更新状态并预演更新，不终止进程，也不修改 Rime 目录
+func DryRunComponent(ctx context.Context, comp Component, progress types.ProgressFunc) *ComponentPlan {
+	if progress == nil {
+		progress = func(string, float64, string, string, int64, int64, float64, bool) {}
+	}
+
+	name := types.ComponentName(comp.ID())
+	progress(fmt.Sprintf("正在检查%s更新 [%s]...", name, sourceLabel(Sources(comp.Base().Config.Config)[0])), 0.05, "", "", 0, 0, 0, false)
+	info, status, err := resolveComponent(ctx, comp)
+	return comp.DryRunFor(ctx, info, status, err, progress)
+}
@@ -0,0 +1,79 @@
+package updater_test
+
+import (
+	"context"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
+	"rime-wanxiang-updater/internal/updater"
+)
+
+// fontsComponent 在 updater 包之外实现的组件，只嵌入 *updater.BaseUpdater
+type fontsComponent struct {
+	*updater.BaseUpdater
+}
+
+var _ updater.Component = (*fontsComponent)(nil)
+
+func (f *fontsComponent) ID() string { return "fonts" }
+
+func (f *fontsComponent) CheckUpdate(context.Context) (*types.UpdateInfo, error) {
+	return &types.UpdateInfo{Name: "fonts.zip", Tag: "v1"}, nil
+}
+
+func (f *fontsComponent) GetStatus(ctx context.Context) (*types.UpdateStatus, error) {
+	info, err := f.CheckUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.StatusFor(info), nil
+}
+
+func (f *fontsComponent) Run(ctx context.Context, progress types.ProgressFunc) error {
+	return updater.RunComponent(ctx, f, progress)
+}
+
+func (f *fontsComponent) DryRun(ctx context.Context, progress types.ProgressFunc) *updater.ComponentPlan {
+	return updater.DryRunComponent(ctx, f, progress)
+}
+
+func (f *fontsComponent) Versions() ([]updater.ArchivedVersion, error) { return nil, nil }
+
+func (f *fontsComponent) Rollback(updater.ArchivedVersion, types.ProgressFunc) error { return nil }
+
+func (f *fontsComponent) Uninstall() *updater.UninstallResult { return &updater.UninstallResult{} }
+
+func (f *fontsComponent) RecordPath() string { return "" }
+
+func (f *fontsComponent) FileName() string { return "fonts.zip" }
+
+func (f *fontsComponent) StatusFor(info *types.UpdateInfo) *types.UpdateStatus {
+	return &types.UpdateStatus{RemoteVersion: info.Tag, NeedsUpdate: true}
+}
+
+func (f *fontsComponent) Download(context.Context, types.ProgressFunc) (string, error) {
+	return "", nil
+}
+
+func (f *fontsComponent) Apply(string, types.ProgressFunc) error { return nil }
+
+func (f *fontsComponent) DryRunFor(_ context.Context, info *types.UpdateInfo, status *types.UpdateStatus, err error, _ types.ProgressFunc) *updater.ComponentPlan {
+	return &updater.ComponentPlan{Component: "字体", Err: err}
+}
+
+func TestRegisterComponentFromAnotherPackage(t *testing.T) {
+	t.Cleanup(func() { updater.Unregister("fonts") })
+	updater.Register(updater.ComponentSpec{
+		ID:   "fonts",
+		Name: "字体",
+		New: func(cfg *config.Manager) updater.Component {
+			return &fontsComponent{BaseUpdater: updater.NewBaseUpdater(cfg)}
+		},
+	})
+
+	comps := updater.NewCombinedUpdater(&config.Manager{Config: &types.Config{}}).Components()
+	if last := comps[len(comps)-1]; last.ID() != "fonts" {
+		t.Fatalf("Components() last = %q, want fonts", last.ID())
+	}
+}
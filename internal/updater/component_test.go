@@ -0,0 +1,154 @@
+package updater
+
+import (
+	"reflect"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func mustComponent(t *testing.T, combined *CombinedUpdater, id string) Component {
+	t.Helper()
+	comp, err := combined.Component(id)
+	if err != nil {
+		t.Fatalf("Component(%q) error = %v", id, err)
+	}
+	return comp
+}
+
+// fakeComponent 测试用的组件，嵌入 ModelUpdater 复用其余实现
+type fakeComponent struct {
+	*ModelUpdater
+}
+
+func (f *fakeComponent) ID() string {
+	return "fake"
+}
+
+func TestBuiltinComponentsRegisteredInOrder(t *testing.T) {
+	var ids []string
+	for _, spec := range Specs() {
+		ids = append(ids, spec.ID)
+	}
+	want := []string{types.ComponentScheme, types.ComponentDict, types.ComponentModel}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Specs() IDs = %v, want %v", ids, want)
+	}
+
+	combined := NewCombinedUpdater(&config.Manager{Config: &types.Config{}})
+	ids = ids[:0]
+	for _, comp := range combined.Components() {
+		ids = append(ids, comp.ID())
+		if comp.Base().APIClient != combined.base.APIClient {
+			t.Errorf("component %s does not share the API client", comp.ID())
+		}
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Components() IDs = %v, want %v", ids, want)
+	}
+	if _, err := combined.Component("unknown"); err == nil {
+		t.Error("Component(unknown) error = nil, want error")
+	}
+}
+
+func TestRegisterAddsComponentEverywhere(t *testing.T) {
+	t.Cleanup(func() { Unregister("fake") })
+	Register(ComponentSpec{
+		ID:     "fake",
+		Name:   "假组件",
+		Weight: 0.2,
+		New: func(cfg *config.Manager) Component {
+			return &fakeComponent{ModelUpdater: NewModelUpdater(cfg)}
+		},
+	})
+
+	if got := types.ComponentName("fake"); got != "假组件" {
+		t.Errorf("ComponentName(fake) = %q, want 假组件", got)
+	}
+	if ids := types.ComponentIDs(); ids[len(ids)-1] != "fake" {
+		t.Errorf("ComponentIDs() = %v, want fake last", ids)
+	}
+
+	cfg := &config.Manager{Config: &types.Config{}}
+	if comp, err := NewComponent(cfg, "fake"); err != nil || comp.ID() != "fake" {
+		t.Errorf("NewComponent(fake) = %v, %v", comp, err)
+	}
+	combined := NewCombinedUpdater(cfg)
+	comps := combined.Components()
+	if len(comps) != 4 || comps[3].ID() != "fake" {
+		t.Fatalf("Components() = %d components, want fake appended", len(comps))
+	}
+
+	// 组合更新按注册的组件获取更新计划
+	ids := make([]string, 0, len(comps))
+	for _, r := range combined.resolvers() {
+		ids = append(ids, r.component)
+	}
+	if ids[len(ids)-1] != "fake" {
+		t.Errorf("resolvers() = %v, want fake last", ids)
+	}
+
+	// 重复注册替换原有定义
+	Register(ComponentSpec{ID: "fake", Name: "假组件", Weight: 0.3, New: func(cfg *config.Manager) Component {
+		return &fakeComponent{ModelUpdater: NewModelUpdater(cfg)}
+	}})
+	if spec, _ := LookupSpec("fake"); len(Specs()) != 4 || spec.Weight != 0.3 {
+		t.Errorf("re-Register() = %d specs, weight %v, want 4 specs, weight 0.3", len(Specs()), spec.Weight)
+	}
+
+	Unregister("fake")
+	if _, ok := LookupSpec("fake"); ok || types.ComponentName("fake") != "" || len(types.ComponentIDs()) != 3 {
+		t.Errorf("Unregister(fake) left the component registered")
+	}
+}
+
+func TestNewComponentRejectsUnknownID(t *testing.T) {
+	if _, err := NewComponent(&config.Manager{Config: &types.Config{}}, "unknown"); err == nil {
+		t.Error("NewComponent(unknown) error = nil, want error")
+	}
+}
+
+func TestWeightedSpans(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+		total   float64
+		want    []float64
+	}{
+		{"proportional", []float64{0.45, 0.45, 0.1}, 0.30, []float64{0.135, 0.135, 0.03}},
+		{"single", []float64{0.1}, 0.30, []float64{0.30}},
+		{"zero weights split evenly", []float64{0, 0}, 0.30, []float64{0.15, 0.15}},
+		{"zero weight among others", []float64{1, 0}, 0.30, []float64{0.30, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := weightedSpans(tt.weights, tt.total)
+			for i := range tt.want {
+				if diff := got[i] - tt.want[i]; diff > 1e-9 || diff < -1e-9 {
+					t.Errorf("weightedSpans(%v, %v) = %v, want %v", tt.weights, tt.total, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestModelNeedsUpdateUsesRecord(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	model := &ModelUpdater{BaseUpdater: base}
+	writeTestRecord(t, base, base.Config.GetModelRecordPath(), types.MODEL_FILE, "v1")
+
+	info := &types.UpdateInfo{Name: types.MODEL_FILE, Tag: "v1"}
+	status := &types.UpdateStatus{NeedsUpdate: true}
+	var comp Component = model
+	if comp.NeedsUpdate(info, status) != model.HasUpdate(info, base.Config.GetModelRecordPath()) {
+		t.Error("ModelUpdater.NeedsUpdate() does not follow HasUpdate()")
+	}
+
+	var scheme Component = &SchemeUpdater{BaseUpdater: base}
+	if !scheme.NeedsUpdate(info, status) {
+		t.Error("SchemeUpdater.NeedsUpdate() = false, want status.NeedsUpdate")
+	}
+}
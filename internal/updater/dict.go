@@ -1,10 +1,10 @@
 package updater
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/fileutil"
@@ -12,10 +12,12 @@ import (
 	"rime-wanxiang-updater/internal/types"
 )
 
+// dictKeyFile 词库包中必须存在的关键文件，缺失时视为词库未安装
+const dictKeyFile = "chengyu.txt"
+
 // DictUpdater 词库更新器
 type DictUpdater struct {
 	*BaseUpdater
-	UpdateInfo *types.UpdateInfo
 }
 
 // NewDictUpdater 创建词库更新器
@@ -25,20 +27,35 @@ func NewDictUpdater(cfg *config.Manager) *DictUpdater {
 	}
 }
 
-// GetStatus 获取更新状态
-func (d *DictUpdater) GetStatus() (*types.UpdateStatus, error) {
-	if err := d.Config.ReconcileRuntimeState(); err != nil {
-		return nil, err
-	}
+// ID 组件 ID
+func (d *DictUpdater) ID() string {
+	return types.ComponentDict
+}
 
-	// 获取远程版本信息
-	remoteInfo, err := d.CheckUpdate()
-	if err != nil {
-		return nil, err
-	}
+func (d *DictUpdater) RecordPath() string {
+	return d.Config.GetDictRecordPath()
+}
+
+func (d *DictUpdater) FileName() string {
+	return d.Config.Config.DictFile
+}
+
+// GetStatus 获取更新状态，固定了版本时在状态中注明
+func (d *DictUpdater) GetStatus(ctx context.Context) (*types.UpdateStatus, error) {
+	_, status, err := resolveComponent(ctx, d)
+	return status, err
+}
 
+// StatusFor 比较远程版本和本地文件得到更新状态，不访问网络；固定了版本时在状态中注明
+func (d *DictUpdater) StatusFor(remoteInfo *types.UpdateInfo) *types.UpdateStatus {
+	status := d.compareLocal(remoteInfo)
+	d.applyPin(types.ComponentDict, d.Config.GetDictRecordPath(), d.Config.Config.DictFile, status)
+	return status
+}
+
+func (d *DictUpdater) compareLocal(remoteInfo *types.UpdateInfo) *types.UpdateStatus {
 	// 检查关键文件是否存在
-	keyFile := filepath.Join(d.Config.GetDictExtractPath(), "chengyu.txt")
+	keyFile := filepath.Join(d.Config.GetDictExtractPath(), dictKeyFile)
 	keyFileExists := fileutil.FileExists(keyFile)
 
 	// 获取本地版本信息
@@ -55,7 +72,7 @@ func (d *DictUpdater) GetStatus() (*types.UpdateStatus, error) {
 	if !keyFileExists {
 		status.LocalVersion = "未安装"
 		status.Message = fmt.Sprintf("检测到可用版本: %s (关键文件缺失)", remoteInfo.Tag)
-		return status, nil
+		return status
 	}
 
 	if localRecord != nil {
@@ -64,7 +81,7 @@ func (d *DictUpdater) GetStatus() (*types.UpdateStatus, error) {
 			status.LocalVersion = fmt.Sprintf("已切换方案 (从 %s)", localRecord.Name)
 			status.Message = fmt.Sprintf("检测到可用版本: %s (方案已切换，需要更新)", remoteInfo.Tag)
 			status.NeedsUpdate = true
-			return status, nil
+			return status
 		}
 
 		status.LocalVersion = localRecord.Tag
@@ -87,68 +104,20 @@ func (d *DictUpdater) GetStatus() (*types.UpdateStatus, error) {
 		}
 	}
 
-	return status, nil
+	return status
 }
 
-// CheckUpdate 检查更新
-func (d *DictUpdater) CheckUpdate() (*types.UpdateInfo, error) {
-	var releases []types.GitHubRelease
-	var err error
-
-	if d.Config.Config.UseMirror {
-		latestTag, err := d.APIClient.FetchLatestCNBReleaseTag(types.OWNER, types.CNB_REPO)
-		if err != nil {
-			return nil, fmt.Errorf("获取版本信息失败: %w", err)
-		}
-
-		info, ok, err := d.findCNBDictInfoAtTag(latestTag)
-		if err != nil {
-			return nil, fmt.Errorf("获取版本信息失败: %w", err)
-		}
-		if ok {
-			return info, nil
-		}
-
-		info, ok, err = d.findCNBDictInfoAtTag(types.CNB_DICT_TAG)
-		if err != nil {
-			return nil, fmt.Errorf("获取版本信息失败: %w", err)
-		}
-		if ok {
-			return info, nil
-		}
-
-		return nil, fmt.Errorf("未找到匹配的词库文件: %s", d.Config.Config.DictFile)
-	}
-
-	// GitHub 使用 dict-nightly tag
-	releases, err = d.APIClient.FetchGitHubReleases(types.OWNER, types.REPO, types.DICT_TAG)
-	if err != nil {
-		return nil, fmt.Errorf("获取版本信息失败: %w", err)
-	}
-
-	if len(releases) == 0 {
-		return nil, fmt.Errorf("未找到任何发布版本")
-	}
-
-	for _, release := range releases {
-		for _, asset := range release.Assets {
-			if asset.Name != d.Config.Config.DictFile {
-				continue
-			}
-
-			return &types.UpdateInfo{
-				Name:       asset.Name,
-				URL:        asset.BrowserDownloadURL,
-				UpdateTime: asset.UpdatedAt,
-				Tag:        release.TagName,
-				SHA256:     asset.SHA256,
-				ID:         asset.ID,
-				Size:       asset.Size,
-			}, nil
-		}
-	}
+// CheckUpdate 检查更新；固定了版本时返回固定的版本
+func (d *DictUpdater) CheckUpdate(ctx context.Context) (*types.UpdateInfo, error) {
+	latest := func() (*types.UpdateInfo, error) { return d.checkLatest(ctx) }
+	return d.checkPinned(types.ComponentDict, d.Config.GetDictRecordPath(), d.Config.Config.DictFile, latest, func(pin string) (*types.UpdateInfo, bool, error) {
+		return d.findPinnedRelease(ctx, types.REPO, d.Config.Config.DictFile, pin)
+	})
+}
 
-	return nil, fmt.Errorf("未找到匹配的词库文件: %s", d.Config.Config.DictFile)
+// checkLatest 按词库的发布渠道检查最新版本
+func (d *DictUpdater) checkLatest(ctx context.Context) (*types.UpdateInfo, error) {
+	return d.findChannelRelease(ctx, types.ComponentDict, types.REPO, d.Config.Config.DictFile)
 }
 
 func findDictRelease(
@@ -173,105 +142,113 @@ func findDictRelease(
 	return releaseutil.FindAssetInfoByTag(releases, matchDict, types.CNB_DICT_TAG)
 }
 
-func (d *DictUpdater) findCNBDictInfoAtTag(tag string) (*types.UpdateInfo, bool, error) {
-	release, err := d.APIClient.FetchCNBReleaseByTag(types.OWNER, types.CNB_REPO, tag)
-	if err != nil {
-		return nil, false, err
-	}
+// Run 执行更新，并将结果追加到更新历史
+func (d *DictUpdater) Run(ctx context.Context, progress types.ProgressFunc) error {
+	return RunComponent(ctx, d, progress)
+}
 
-	for _, asset := range release.Assets {
-		if asset.Name != d.Config.Config.DictFile {
-			continue
-		}
+// Download 下载 prepareComponent 确定的版本并返回临时文件路径；本地文件已是最新版本时返回空字符串
+func (d *DictUpdater) Download(ctx context.Context, progress types.ProgressFunc) (string, error) {
+	source := sourceLabel(d.currentSource())
+	recordPath := d.Config.GetDictRecordPath()
+	targetFile := filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile)
 
-		return &types.UpdateInfo{
-			Name:       asset.Name,
-			URL:        asset.BrowserDownloadURL,
-			UpdateTime: asset.UpdatedAt,
-			Tag:        release.TagName,
-			SHA256:     asset.SHA256,
-			ID:         asset.ID,
-			Size:       asset.Size,
-		}, true, nil
+	// 校验本地文件：只有当版本未变化且缓存文件哈希匹配时才跳过下载
+	progress("正在校验本地文件...", 0.1, "", "", 0, 0, 0, false)
+	localRecord := d.GetLocalRecord(recordPath)
+	if d.canReuseCachedAsset(localRecord, d.UpdateInfo, targetFile, d.CompareHash) {
+		progress("本地文件已是最新版本", 1.0, "", "", 0, 0, 0, false)
+		return "", nil
 	}
 
-	return nil, false, nil
+	// 下载文件
+	progress(fmt.Sprintf("准备从 %s 下载词库...", source), 0.15, source, d.UpdateInfo.URL, 0, 0, 0, false)
+	tempFile := partialDownloadPath(d.Config.CacheDir, "temp_dict", ".zip", d.UpdateInfo)
+	if err := d.downloadVerified(ctx, d.UpdateInfo, tempFile, d.Config.Config.DictFile, source, progress); err != nil {
+		return "", fmt.Errorf("下载失败: %w", err)
+	}
+
+	return tempFile, nil
 }
 
-// Run 执行更新
-func (d *DictUpdater) Run(progress types.ProgressFunc) error {
-	if progress == nil {
-		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
-	}
+// Apply 安装 Download 下载的更新包
+func (d *DictUpdater) Apply(tempFile string, progress types.ProgressFunc) error {
+	return d.install(tempFile, filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile), progress)
+}
+
+// install 安装已下载到 tempFile 的更新包，更新和回滚共用；d.UpdateInfo 须为该更新包的版本信息
+func (d *DictUpdater) install(tempFile, targetFile string, progress types.ProgressFunc) error {
+	// 保留即将被替换的版本，供之后回滚
+	d.saveVersion(types.ComponentDict, targetFile, d.Config.GetDictRecordPath(), progress)
 
-	if err := d.EnsureInstalledEngine(); err != nil {
+	// 先解压到暂存目录并校验，确认更新包完整后才改动 Rime 目录
+	progress("正在解压词库文件...", 0.66, "", "", 0, 0, 0, false)
+	staging, err := d.stageArchive(tempFile, d.Config.Config.DictFile, dictKeyFile)
+	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(staging)
 
-	// 执行更新前 hook
-	if d.Config.Config.PreUpdateHook != "" {
-		progress("执行更新前 hook...", 0.02, "", "", 0, 0, 0, false)
-		if err := d.Config.ExecutePreUpdateHook(); err != nil {
-			return fmt.Errorf("pre-update hook 失败，已取消更新: %w", err)
-		}
+	txn, owned, err := d.beginTransaction()
+	if err != nil {
+		return err
 	}
 
-	// 显示下载源
-	source := "GitHub"
-	if d.Config.Config.UseMirror {
-		source = "CNB 镜像"
+	// 处理安装后被用户修改过的文件，须在清理和安装之前完成；备份的 .local 文件同样在失败时删除
+	kept, err := d.protectModified(txn, types.ComponentDict, d.Config.GetDictExtractPath(), staging, progress)
+	if err == nil {
+		// 备份将被改动的文件，之后任一步骤失败都会恢复
+		progress("正在备份将被修改的文件...", 0.68, "", "", 0, 0, 0, false)
+		err = d.trackFiles(txn, staging, targetFile)
 	}
-	progress(fmt.Sprintf("正在检查词库更新 [%s]...", source), 0.05, "", "", 0, 0, 0, false)
-
-	if d.UpdateInfo == nil {
-		info, err := d.CheckUpdate()
-		if err != nil {
-			return err
-		}
-		d.UpdateInfo = info
+	if err == nil {
+		// 清理旧文件
+		progress("正在清理旧文件...", 0.7, "", "", 0, 0, 0, false)
+		err = d.cleanOldInstall(staging, targetFile, tempFile)
 	}
-
-	if d.UpdateInfo == nil {
-		return fmt.Errorf("未找到词库更新")
+	if err == nil {
+		// 应用更新
+		progress("正在应用更新...", 0.8, "", "", 0, 0, 0, false)
+		err = d.applyUpdate(staging, tempFile, targetFile, kept, progress)
 	}
+	return endTransaction(txn, owned, err, progress)
+}
 
-	recordPath := d.Config.GetDictRecordPath()
-	targetFile := filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile)
-
-	// 校验本地文件：只有当版本未变化且缓存文件哈希匹配时才跳过下载
-	progress("正在校验本地文件...", 0.1, "", "", 0, 0, 0, false)
-	localRecord := d.GetLocalRecord(recordPath)
-	if d.canReuseCachedAsset(localRecord, d.UpdateInfo, targetFile, d.CompareHash) {
-		progress("本地文件已是最新版本", 1.0, "", "", 0, 0, 0, false)
-		return nil
+// trackFiles 登记本次更新会改动的文件：主引擎词库目录、其他引擎词库目录、缓存的更新包与版本记录
+func (d *DictUpdater) trackFiles(txn *Transaction, staging, target string) error {
+	dictDir := d.Config.GetDictExtractPath()
+	names, err := d.trackArchive(txn, staging, target, dictDir)
+	if err != nil {
+		return err
 	}
-
-	// 下载文件
-	progress(fmt.Sprintf("准备从 %s 下载词库...", source), 0.15, source, d.UpdateInfo.URL, 0, 0, 0, false)
-	tempFile := filepath.Join(d.Config.CacheDir, fmt.Sprintf("temp_dict_%d.zip", time.Now().Unix()))
-	if err := d.DownloadFileWithValidation(d.UpdateInfo.URL, tempFile, d.Config.Config.DictFile, source, d.UpdateInfo.Size, progress); err != nil {
-		return fmt.Errorf("下载失败: %w", err)
+	if err := d.trackSecondaryEngines(txn, dictDir, d.Config.ZhDictsDir, names); err != nil {
+		return err
 	}
-
-	// 计算下载文件的 SHA256
-	progress("正在计算文件校验和...", 0.65, "", "", 0, 0, 0, false)
-	if hash, err := fileutil.CalculateSHA256(tempFile); err == nil {
-		d.UpdateInfo.SHA256 = hash
+	if err := d.trackManifests(txn, types.ComponentDict, d.targets()); err != nil {
+		return err
 	}
-
-	// 清理旧文件
-	progress("正在清理旧文件...", 0.7, "", "", 0, 0, 0, false)
-	if fileutil.FileExists(targetFile) {
-		d.CleanOldFiles(targetFile, tempFile, d.Config.GetDictExtractPath(), true)
+	if err := txn.Track(target); err != nil {
+		return err
 	}
+	return txn.Track(d.Config.GetDictRecordPath())
+}
 
-	// 应用更新
-	progress("正在应用更新...", 0.8, "", "", 0, 0, 0, false)
-	return d.applyUpdate(tempFile, targetFile, progress)
+// targets 返回词库的安装目录：主引擎目录在前，其后是需要同步的其他引擎目录
+func (d *DictUpdater) targets() []installTarget {
+	return d.installTargets(d.Config.GetDictExtractPath(), d.Config.ZhDictsDir)
 }
 
-// applyUpdate 应用更新
-func (d *DictUpdater) applyUpdate(temp, target string, progress types.ProgressFunc) error {
+// cleanOldInstall 清理旧版本安装、但新的更新包中已不存在的文件
+func (d *DictUpdater) cleanOldInstall(staging, oldZip, newZip string) error {
+	names, err := listFiles(staging)
+	if err != nil {
+		return err
+	}
+	return d.cleanInstalled(types.ComponentDict, d.targets(), names, oldZip, newZip, true)
+}
+
+// applyUpdate 应用更新，staging 为已校验的暂存目录，kept 为保留了本地修改的文件（见 protectModified）
+func (d *DictUpdater) applyUpdate(staging, temp, target string, kept []types.ManifestFile, progress types.ProgressFunc) error {
 	// 终止进程（组合更新时跳过）
 	if !d.SkipTerminate {
 		progress("正在终止相关进程...", 0.85, "", "", 0, 0, 0, false)
@@ -280,32 +257,31 @@ func (d *DictUpdater) applyUpdate(temp, target string, progress types.ProgressFu
 		}
 	}
 
-	// 确保词库目录存在
+	// 将暂存目录中的文件移动到主引擎词库目录
 	dictDir := d.Config.GetDictExtractPath()
-	os.MkdirAll(dictDir, 0755)
-
-	// 解压文件到主引擎目录
-	progress("正在解压词库文件...", 0.9, "", "", 0, 0, 0, false)
-	if err := d.ExtractZip(temp, dictDir); err != nil {
-		return fmt.Errorf("解压失败: %w", err)
+	progress("正在替换词库文件...", 0.9, "", "", 0, 0, 0, false)
+	names, err := listFiles(staging)
+	if err != nil {
+		return err
 	}
-
-	// 处理 CNB 镜像的嵌套目录问题
-	if d.Config.Config.UseMirror {
-		if err := fileutil.HandleCNBNestedDir(dictDir, d.Config.Config.DictFile); err != nil {
-			return fmt.Errorf("处理嵌套目录失败: %w", err)
-		}
+	installed, err := fileutil.InstallStaged(staging, dictDir, d.Config.Config.ExcludeFiles)
+	if err != nil {
+		return fmt.Errorf("安装文件失败: %w", err)
 	}
 
 	// 同步到其他引擎目录
 	if len(d.Config.Config.InstalledEngines) > 1 {
 		progress("正在同步词库到其他引擎...", 0.92, "", "", 0, 0, 0, false)
 		if err := d.syncDictToOtherEngines(dictDir); err != nil {
-			// 只记录错误，不返回失败
-			progress(fmt.Sprintf("同步词库到其他引擎失败: %v", err), 0.92, "", "", 0, 0, 0, false)
+			return fmt.Errorf("同步词库到其他引擎失败: %w", err)
 		}
 	}
 
+	// 记录安装清单，供下次更新清理和卸载使用
+	if err := d.writeManifests(types.ComponentDict, d.targets(), names, installed, d.UpdateInfo.Tag, kept...); err != nil {
+		return fmt.Errorf("写入安装清单失败: %w", err)
+	}
+
 	// 重命名临时文件
 	progress("正在保存文件...", 0.95, "", "", 0, 0, 0, false)
 	if fileutil.FileExists(target) {
@@ -345,32 +321,10 @@ func (d *DictUpdater) applyUpdate(temp, target string, progress types.ProgressFu
 
 // syncDictToOtherEngines 同步词库到其他引擎目录
 func (d *DictUpdater) syncDictToOtherEngines(sourceDictDir string) error {
-	// 检查是否配置了要更新的引擎列表
-	updateEngines := d.Config.Config.UpdateEngines
-	if len(updateEngines) == 0 {
-		// 未配置：默认更新所有已安装的引擎
-		updateEngines = d.Config.Config.InstalledEngines
-	}
-
-	// 如果只有一个引擎需要更新，跳过同步
-	if len(updateEngines) <= 1 {
-		return nil
-	}
-
-	primaryEngine := d.Config.Config.PrimaryEngine
-	if primaryEngine == "" && len(updateEngines) > 0 {
-		primaryEngine = updateEngines[0]
-	}
-
 	var errors []string
 
-	// 遍历用户选择要更新的引擎
-	for _, engine := range updateEngines {
-		// 跳过主引擎（已经解压到主引擎目录）
-		if engine == primaryEngine {
-			continue
-		}
-
+	// 遍历用户选择要更新的其他引擎（主引擎已经解压完成）
+	for _, engine := range d.secondaryEngines() {
 		// 获取目标引擎的数据目录
 		targetRimeDir := config.GetEngineDataDir(engine)
 		if targetRimeDir == "" {
@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"context"
 	"testing"
 
 	"rime-wanxiang-updater/internal/config"
@@ -33,7 +34,7 @@ func TestDictUpdaterDifferentTypes(t *testing.T) {
 			updater := NewDictUpdater(cfg)
 
 			// 测试获取更新信息
-			info, err := updater.CheckUpdate()
+			info, err := updater.CheckUpdate(context.Background())
 			if err != nil {
 				t.Logf("获取更新信息失败（这可能是网络问题）: %v", err)
 				return
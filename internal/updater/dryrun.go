@@ -0,0 +1,344 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// FileChanges 更新将对目标目录产生的文件级变更（路径相对于目标目录）
+type FileChanges struct {
+	Added       []string // 新增的文件
+	Overwritten []string // 将被覆盖的已有文件
+	Deleted     []string // 旧版本中存在、新版本中已移除的文件
+	Excluded    []string // 匹配排除规则而保留本地版本的文件
+}
+
+// Total 返回变更文件总数（不含排除的文件）
+func (c FileChanges) Total() int {
+	return len(c.Added) + len(c.Overwritten) + len(c.Deleted)
+}
+
+// ComponentPlan 单个组件的预演结果
+type ComponentPlan struct {
+	Component  string              // 组件名（方案/词库/模型）
+	Status     *types.UpdateStatus // 本地与远程版本状态
+	UpdateInfo *types.UpdateInfo   // 将要应用的远程文件信息
+	TargetDir  string              // 更新写入的目录
+	Archive    string              // 预演时下载到缓存中的文件
+	Changes    FileChanges
+	Err        error
+}
+
+// NeedsUpdate 返回该组件是否会被更新
+func (p *ComponentPlan) NeedsUpdate() bool {
+	return p != nil && p.Err == nil && p.Status != nil && p.Status.NeedsUpdate
+}
+
+// DryRunResult 预演结果，按更新顺序排列
+type DryRunResult struct {
+	Components []*ComponentPlan
+}
+
+// HasChanges 返回是否有组件需要更新
+func (r *DryRunResult) HasChanges() bool {
+	if r == nil {
+		return false
+	}
+	for _, plan := range r.Components {
+		if plan.NeedsUpdate() {
+			return true
+		}
+	}
+	return false
+}
+
+// previewPath 返回预演下载文件在缓存目录中的路径
+func (b *BaseUpdater) previewPath(fileName string) string {
+	return filepath.Join(b.Config.CacheDir, "preview_"+fileName)
+}
+
+// downloadPreview 将远程文件下载到缓存目录，已存在且校验一致时直接复用
+func (b *BaseUpdater) downloadPreview(ctx context.Context, info *types.UpdateInfo, fileName, source string, progress types.ProgressFunc) (string, error) {
+	path := b.previewPath(fileName)
+	if info.SHA256 != "" && b.CompareHash(info.SHA256, path) {
+		progress("本地文件已是最新版本", 0.9, "", "", 0, 0, 0, false)
+		return path, nil
+	}
+
+	if err := os.MkdirAll(b.Config.CacheDir, 0755); err != nil {
+		return "", fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	progress(fmt.Sprintf("准备从 %s 下载...", source), 0.1, source, info.URL, 0, 0, 0, false)
+	if err := b.downloadVerified(ctx, info, path, fileName, source, progress); err != nil {
+		return "", fmt.Errorf("下载失败: %w", err)
+	}
+
+	return path, nil
+}
+
+// diffArchive 计算将 archive 解压到 extractPath 时产生的文件变更。
+// 判断规则与 fileutil.InstallStaged（排除规则）和 BaseUpdater.CleanOldFiles（删除旧文件）保持一致。
+func diffArchive(archive, previousArchive, extractPath string, excludeFiles []string, nestedDir string) (FileChanges, error) {
+	var changes FileChanges
+
+	newFiles, err := fileutil.GetZipFileList(archive)
+	if err != nil {
+		return changes, fmt.Errorf("获取新文件列表失败: %w", err)
+	}
+
+	excludePatterns, _ := config.ParseExcludePatterns(excludeFiles)
+	for _, name := range flattenNestedNames(newFiles, nestedDir) {
+		exists := fileutil.FileExists(filepath.Join(extractPath, name))
+		switch {
+		case exists && config.MatchAny(name, excludePatterns):
+			changes.Excluded = append(changes.Excluded, name)
+		case exists:
+			changes.Overwritten = append(changes.Overwritten, name)
+		default:
+			changes.Added = append(changes.Added, name)
+		}
+	}
+
+	if previousArchive != "" && fileutil.FileExists(previousArchive) {
+		oldFiles, err := fileutil.GetZipFileList(previousArchive)
+		if err != nil {
+			return changes, fmt.Errorf("获取旧文件列表失败: %w", err)
+		}
+		for _, name := range difference(oldFiles, newFiles) {
+			if fileutil.FileExists(filepath.Join(extractPath, name)) {
+				changes.Deleted = append(changes.Deleted, name)
+			}
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Overwritten)
+	sort.Strings(changes.Deleted)
+	sort.Strings(changes.Excluded)
+
+	return changes, nil
+}
+
+// applyManifest 主引擎目录有安装清单时，改为按清单计算将被删除的文件，与 cleanInstalled 保持一致：
+// 清单中有、新包中没有且内容未被修改的文件才会被删除
+func (b *BaseUpdater) applyManifest(changes *FileChanges, component, extractPath string) error {
+	manifest, err := LoadManifest(b.Config.GetManifestPath(component, b.primaryEngine()))
+	if err != nil || manifest == nil || filepath.Clean(manifest.Root) != filepath.Clean(extractPath) {
+		return err
+	}
+
+	keep := make(map[string]bool)
+	for _, names := range [][]string{changes.Added, changes.Overwritten, changes.Excluded} {
+		for _, name := range names {
+			keep[name] = true
+		}
+	}
+
+	changes.Deleted = nil
+	for _, file := range manifest.Files {
+		if keep[file.Path] || !filepath.IsLocal(filepath.FromSlash(file.Path)) {
+			continue
+		}
+		if b.CompareHash(file.SHA256, filepath.Join(extractPath, filepath.FromSlash(file.Path))) {
+			changes.Deleted = append(changes.Deleted, file.Path)
+		}
+	}
+	sort.Strings(changes.Deleted)
+	return nil
+}
+
+// flattenNestedNames 模拟 fileutil.HandleCNBNestedDir：
+// 当所有文件都位于 dir/dir/ 下时，去掉多余的一层目录
+func flattenNestedNames(names []string, dir string) []string {
+	if dir == "" {
+		return names
+	}
+
+	nested := dir + "/" + dir + "/"
+	for _, name := range names {
+		if !strings.HasPrefix(name, nested) {
+			return names
+		}
+	}
+
+	flattened := make([]string, 0, len(names))
+	for _, name := range names {
+		flattened = append(flattened, dir+"/"+strings.TrimPrefix(name, nested))
+	}
+	return flattened
+}
+
+// mayBeNested 判断 UpdateInfo 对应的压缩包是否可能有 CNB 镜像的嵌套目录：只有确认来自 GitHub 时不需要处理，
+// 离线导入的文件、来源不明的缓存更新包都检查
+func (b *BaseUpdater) mayBeNested() bool {
+	return b.UpdateInfo == nil || b.UpdateInfo.Source != SourceGitHub
+}
+
+// cnbNestedDir 返回镜像源压缩包可能存在的嵌套目录名
+func (b *BaseUpdater) cnbNestedDir(zipFileName string) string {
+	if !b.mayBeNested() {
+		return ""
+	}
+	return strings.TrimSuffix(zipFileName, ".zip")
+}
+
+// DryRun 预演方案更新：下载到缓存并计算文件变更，不终止进程，也不修改 Rime 目录
+func (s *SchemeUpdater) DryRun(ctx context.Context, progress types.ProgressFunc) *ComponentPlan {
+	return DryRunComponent(ctx, s, progress)
+}
+
+// DryRunFor 按已获取的远程版本信息和更新状态预演方案更新；err 为获取版本信息时的错误
+func (s *SchemeUpdater) DryRunFor(ctx context.Context, info *types.UpdateInfo, status *types.UpdateStatus, err error, progress types.ProgressFunc) *ComponentPlan {
+	plan := &ComponentPlan{Component: "方案", TargetDir: s.Config.GetExtractPath()}
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Status = status
+	if !status.NeedsUpdate {
+		return plan
+	}
+
+	s.UpdateInfo = info
+	s.useSource(info.Source)
+	plan.UpdateInfo = info
+
+	schemeFile := s.Config.Config.SchemeFile
+	if plan.Archive, err = s.downloadPreview(ctx, s.UpdateInfo, schemeFile, sourceLabel(s.currentSource()), progress); err != nil {
+		plan.Err = err
+		return plan
+	}
+
+	progress("正在分析文件变更...", 0.95, "", "", 0, 0, 0, false)
+	plan.Changes, plan.Err = diffArchive(
+		plan.Archive,
+		filepath.Join(s.Config.CacheDir, schemeFile),
+		plan.TargetDir,
+		s.Config.Config.ExcludeFiles,
+		s.cnbNestedDir(schemeFile),
+	)
+	if plan.Err == nil {
+		plan.Err = s.applyManifest(&plan.Changes, types.ComponentScheme, plan.TargetDir)
+	}
+	return plan
+}
+
+// DryRun 预演词库更新：下载到缓存并计算文件变更，不终止进程，也不修改 Rime 目录
+func (d *DictUpdater) DryRun(ctx context.Context, progress types.ProgressFunc) *ComponentPlan {
+	return DryRunComponent(ctx, d, progress)
+}
+
+// DryRunFor 按已获取的远程版本信息和更新状态预演词库更新；err 为获取版本信息时的错误
+func (d *DictUpdater) DryRunFor(ctx context.Context, info *types.UpdateInfo, status *types.UpdateStatus, err error, progress types.ProgressFunc) *ComponentPlan {
+	plan := &ComponentPlan{Component: "词库", TargetDir: d.Config.GetDictExtractPath()}
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Status = status
+	if !status.NeedsUpdate {
+		return plan
+	}
+
+	d.UpdateInfo = info
+	d.useSource(info.Source)
+	plan.UpdateInfo = info
+
+	dictFile := d.Config.Config.DictFile
+	if plan.Archive, err = d.downloadPreview(ctx, d.UpdateInfo, dictFile, sourceLabel(d.currentSource()), progress); err != nil {
+		plan.Err = err
+		return plan
+	}
+
+	progress("正在分析文件变更...", 0.95, "", "", 0, 0, 0, false)
+	plan.Changes, plan.Err = diffArchive(
+		plan.Archive,
+		filepath.Join(d.Config.CacheDir, dictFile),
+		plan.TargetDir,
+		d.Config.Config.ExcludeFiles,
+		d.cnbNestedDir(dictFile),
+	)
+	if plan.Err == nil {
+		plan.Err = d.applyManifest(&plan.Changes, types.ComponentDict, plan.TargetDir)
+	}
+	return plan
+}
+
+// DryRun 预演模型更新。模型均为单个文件，变更只取决于目标文件是否存在，因此不下载文件
+func (m *ModelUpdater) DryRun(ctx context.Context, progress types.ProgressFunc) *ComponentPlan {
+	return DryRunComponent(ctx, m, progress)
+}
+
+// DryRunFor 按已获取的远程版本信息和更新状态预演模型更新；err 为获取版本信息时的错误
+func (m *ModelUpdater) DryRunFor(ctx context.Context, info *types.UpdateInfo, status *types.UpdateStatus, err error, progress types.ProgressFunc) *ComponentPlan {
+	plan := &ComponentPlan{Component: "模型", TargetDir: m.Config.GetExtractPath()}
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Status = status
+	if !status.NeedsUpdate {
+		return plan
+	}
+
+	m.UpdateInfo = info
+	plan.UpdateInfo = info
+
+	assets := modelAssets(info)
+	for i, assetStatus := range m.assetStatuses(assets) {
+		name := assets[i].Name
+		switch {
+		case !assetStatus.NeedsUpdate:
+		case fileutil.FileExists(filepath.Join(plan.TargetDir, name)):
+			plan.Changes.Overwritten = append(plan.Changes.Overwritten, name)
+		default:
+			plan.Changes.Added = append(plan.Changes.Added, name)
+		}
+	}
+	plan.Changes.Deleted = m.staleModels(info)
+	return plan
+}
+
+// DryRun 先并发获取更新计划，再按注册顺序依次预演各组件的更新，不终止进程，也不修改 Rime 目录。
+// 单个组件失败不影响其他组件，错误记录在对应的 ComponentPlan.Err 中。
+func (c *CombinedUpdater) DryRun(ctx context.Context, progress func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)) *DryRunResult {
+	if progress == nil {
+		progress = func(string, string, float64, string, string, int64, int64, float64, bool) {}
+	}
+
+	progress("检查", "正在检查所有更新...", 0.0, "", "", 0, 0, 0, false)
+	updatePlan, _ := c.FetchAllUpdates(ctx) // 失败原因记录在各组件的 Err 中
+
+	// 检查占 5%，其余按组件权重分配
+	weights := make([]float64, len(c.components))
+	for i, comp := range c.components {
+		spec, _ := LookupSpec(comp.ID())
+		weights[i] = spec.Weight
+	}
+	spans := weightedSpans(weights, 0.95)
+
+	result := &DryRunResult{}
+	start := 0.05
+	for i, comp := range c.components {
+		name := types.ComponentName(comp.ID())
+		from, span := start, spans[i]
+		entry, _ := updatePlan.Get(comp.ID())
+		plan := comp.DryRunFor(ctx, entry.Info, entry.Status, entry.Err, func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+			progress(name, message, from+percent*span, source, fileName, downloaded, total, speed, downloadMode)
+		})
+		result.Components = append(result.Components, plan)
+		start += span
+	}
+
+	progress("完成", "更新预览已生成", 1.0, "", "", 0, 0, 0, false)
+	return result
+}
@@ -0,0 +1,128 @@
+package updater
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestZip(t *testing.T, path string, names ...string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, name := range names {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry %s: %v", name, err)
+		}
+		if _, err := entry.Write([]byte(name)); err != nil {
+			t.Fatalf("write zip entry %s: %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+}
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("local"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestDiffArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	rimeDir := filepath.Join(tmpDir, "rime")
+
+	oldZip := filepath.Join(tmpDir, "old.zip")
+	newZip := filepath.Join(tmpDir, "new.zip")
+	writeTestZip(t, oldZip, "default.yaml", "lua/old.lua", "user.custom.yaml", "gone.txt")
+	writeTestZip(t, newZip, "default.yaml", "lua/wanxiang.lua", "user.custom.yaml", "new.yaml")
+
+	writeTestFile(t, filepath.Join(rimeDir, "default.yaml"))
+	writeTestFile(t, filepath.Join(rimeDir, "lua", "old.lua"))
+	writeTestFile(t, filepath.Join(rimeDir, "user.custom.yaml"))
+	// gone.txt 不在本地，CleanOldFiles 不会删除它，也不应出现在预览中
+
+	changes, err := diffArchive(newZip, oldZip, rimeDir, []string{"*.custom.yaml"}, "")
+	if err != nil {
+		t.Fatalf("diffArchive() error = %v", err)
+	}
+
+	want := FileChanges{
+		Added:       []string{"lua/wanxiang.lua", "new.yaml"},
+		Overwritten: []string{"default.yaml"},
+		Deleted:     []string{"lua/old.lua"},
+		Excluded:    []string{"user.custom.yaml"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("diffArchive() = %+v, want %+v", changes, want)
+	}
+	if got := changes.Total(); got != 4 {
+		t.Errorf("Total() = %d, want 4", got)
+	}
+}
+
+func TestDiffArchiveExcludedFileAddedOnFirstInstall(t *testing.T) {
+	tmpDir := t.TempDir()
+	newZip := filepath.Join(tmpDir, "new.zip")
+	writeTestZip(t, newZip, "user.custom.yaml")
+
+	changes, err := diffArchive(newZip, "", filepath.Join(tmpDir, "rime"), []string{"*.custom.yaml"}, "")
+	if err != nil {
+		t.Fatalf("diffArchive() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(changes.Added, []string{"user.custom.yaml"}) || len(changes.Excluded) != 0 {
+		t.Errorf("diffArchive() = %+v, want excluded file added when missing locally", changes)
+	}
+}
+
+func TestFlattenNestedNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		dir   string
+		want  []string
+	}{
+		{
+			name:  "no nested dir configured",
+			names: []string{"base-dicts/base-dicts/a.txt"},
+			dir:   "",
+			want:  []string{"base-dicts/base-dicts/a.txt"},
+		},
+		{
+			name:  "nested dir flattened",
+			names: []string{"base-dicts/base-dicts/a.txt", "base-dicts/base-dicts/b/c.txt"},
+			dir:   "base-dicts",
+			want:  []string{"base-dicts/a.txt", "base-dicts/b/c.txt"},
+		},
+		{
+			name:  "mixed layout kept",
+			names: []string{"base-dicts/base-dicts/a.txt", "base-dicts/b.txt"},
+			dir:   "base-dicts",
+			want:  []string{"base-dicts/base-dicts/a.txt", "base-dicts/b.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flattenNestedNames(tt.names, tt.dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flattenNestedNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// 下载源名称，写入版本信息、版本记录和更新历史
+const (
+	SourceGitHub = "GitHub"
+	SourceCNB    = "CNB"
+)
+
+// Sources 返回有序的下载源列表：UseMirror 选择的主下载源在前，另一个下载源在主下载源获取版本信息或下载失败时使用
+func Sources(cfg *types.Config) []string {
+	if cfg.UseMirror {
+		return []string{SourceCNB, SourceGitHub}
+	}
+	return []string{SourceGitHub, SourceCNB}
+}
+
+// sourceLabel 返回下载源在进度中显示的名称
+func sourceLabel(source string) string {
+	if source == SourceCNB {
+		return "CNB 镜像"
+	}
+	return source
+}
+
+// currentSource 返回当前使用的下载源
+func (b *BaseUpdater) currentSource() string {
+	if b.fallbackSource != "" {
+		return b.fallbackSource
+	}
+	return Sources(b.Config.Config)[0]
+}
+
+// usingMirror 判断当前使用的下载源是否为 CNB 镜像
+func (b *BaseUpdater) usingMirror() bool {
+	return b.currentSource() == SourceCNB
+}
+
+// useSource 切换到下载源 source，之后获取版本信息和下载都使用它；source 为空时保持不变。
+// 备用下载源使用单独创建的 API 客户端，切换回主下载源时恢复原来的客户端
+func (b *BaseUpdater) useSource(source string) {
+	if source == "" || source == b.currentSource() {
+		return
+	}
+	if source == Sources(b.Config.Config)[0] {
+		b.APIClient, b.primaryClient, b.fallbackSource = b.primaryClient, nil, ""
+		return
+	}
+
+	cfg := *b.Config.Config
+	cfg.UseMirror = source == SourceCNB
+	if b.primaryClient == nil {
+		b.primaryClient = b.APIClient
+	}
+	b.APIClient, b.fallbackSource = api.NewClient(&cfg), source
+}
+
+// setSource 在版本信息（包括多个模型的各个文件）中注明提供它的下载源
+func setSource(info *types.UpdateInfo, source string) {
+	info.Source = source
+	for i := range info.Assets {
+		info.Assets[i].Source = source
+	}
+}
+
+// hasSHA256 判断版本信息中的每个文件是否都有 SHA256，没有时无法确认备用下载源上是同一个文件
+func hasSHA256(info *types.UpdateInfo) bool {
+	for _, asset := range modelAssets(info) {
+		if asset.SHA256 == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// sameAssets 判断两个下载源的版本信息是否为同一批文件：文件名相同，且 SHA256 都已知并一致
+func sameAssets(a, b *types.UpdateInfo) bool {
+	as, bs := modelAssets(a), modelAssets(b)
+	if len(as) != len(bs) || !hasSHA256(a) {
+		return false
+	}
+	for i := range as {
+		if as[i].Name != bs[i].Name || as[i].SHA256 != bs[i].SHA256 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWithFailover 按下载源顺序获取组件的远程版本信息：主下载源失败时改用备用下载源，
+// 之后的下载也使用该下载源。返回的版本信息注明实际提供它的下载源
+func checkWithFailover(ctx context.Context, comp Component) (*types.UpdateInfo, error) {
+	b := comp.Base()
+	sources := Sources(b.Config.Config)
+	b.useSource(sources[0])
+
+	info, err := comp.CheckUpdate(ctx)
+	if err != nil && ctx.Err() == nil {
+		b.useSource(sources[1])
+		fallbackInfo, fallbackErr := comp.CheckUpdate(ctx)
+		if fallbackErr != nil {
+			b.useSource(sources[0])
+			return nil, fmt.Errorf("%w；%s 也失败: %v", err, sourceLabel(sources[1]), fallbackErr)
+		}
+		info, err = fallbackInfo, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		setSource(info, b.currentSource())
+	}
+	return info, nil
+}
+
+// downloadWithFailover 用 download（通常为 comp.Download）从提供 UpdateInfo 的下载源下载组件；在主下载源上下载失败时，
+// 在备用下载源上重新获取版本信息，确认是同一批文件（文件名和 SHA256 一致）后改从备用下载源下载
+func downloadWithFailover(ctx context.Context, comp Component, progress types.ProgressFunc, download func(context.Context, types.ProgressFunc) (string, error)) (string, error) {
+	b := comp.Base()
+	b.useSource(b.UpdateInfo.Source)
+
+	tempFile, err := download(ctx, progress)
+	sources := Sources(b.Config.Config)
+	if err == nil || ctx.Err() != nil || b.currentSource() != sources[0] || !hasSHA256(b.UpdateInfo) {
+		return tempFile, err
+	}
+
+	b.useSource(sources[1])
+	info, checkErr := comp.CheckUpdate(ctx)
+	if checkErr != nil || info == nil || !sameAssets(b.UpdateInfo, info) {
+		b.useSource(sources[0])
+		return "", err
+	}
+	setSource(info, sources[1])
+	b.UpdateInfo = info
+
+	progress(fmt.Sprintf("下载失败，改从 %s 下载...", sourceLabel(sources[1])), 0.15, "", "", 0, 0, 0, false)
+	tempFile, fallbackErr := download(ctx, progress)
+	if fallbackErr != nil {
+		return "", fmt.Errorf("%w；%s 也失败: %v", err, sourceLabel(sources[1]), fallbackErr)
+	}
+	return tempFile, nil
+}
@@ -0,0 +1,156 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// sourceComponent 按下载源返回预设结果的组件；infos 中没有的下载源获取版本信息失败，failDownload 中的下载源下载失败
+type sourceComponent struct {
+	*ModelUpdater
+	infos        map[string]*types.UpdateInfo
+	failDownload map[string]bool
+	downloads    []string
+}
+
+func (c *sourceComponent) CheckUpdate(context.Context) (*types.UpdateInfo, error) {
+	info, ok := c.infos[c.currentSource()]
+	if !ok {
+		return nil, errors.New(c.currentSource() + " unavailable")
+	}
+	copied := *info
+	return &copied, nil
+}
+
+func (c *sourceComponent) Download(context.Context, types.ProgressFunc) (string, error) {
+	c.downloads = append(c.downloads, c.currentSource())
+	if c.failDownload[c.currentSource()] {
+		return "", errors.New(c.currentSource() + " download failed")
+	}
+	return "temp", nil
+}
+
+func TestSources(t *testing.T) {
+	if got := Sources(&types.Config{}); got[0] != SourceGitHub || got[1] != SourceCNB {
+		t.Errorf("Sources(GitHub) = %v", got)
+	}
+	if got := Sources(&types.Config{UseMirror: true}); got[0] != SourceCNB || got[1] != SourceGitHub {
+		t.Errorf("Sources(mirror) = %v", got)
+	}
+}
+
+func TestSourceFailover(t *testing.T) {
+	asset := func(sha string) *types.UpdateInfo {
+		return &types.UpdateInfo{Name: types.MODEL_FILE, Tag: "v2", SHA256: sha}
+	}
+	tests := []struct {
+		name          string
+		infos         map[string]*types.UpdateInfo
+		failDownload  map[string]bool
+		wantErr       string
+		wantSource    string
+		wantDownloads []string
+	}{
+		{
+			name:          "主下载源正常",
+			infos:         map[string]*types.UpdateInfo{SourceGitHub: asset("abc"), SourceCNB: asset("abc")},
+			wantSource:    SourceGitHub,
+			wantDownloads: []string{SourceGitHub},
+		},
+		{
+			name:          "获取版本信息失败时改用备用下载源",
+			infos:         map[string]*types.UpdateInfo{SourceCNB: asset("abc")},
+			wantSource:    SourceCNB,
+			wantDownloads: []string{SourceCNB},
+		},
+		{
+			name:    "两个下载源都无法获取版本信息",
+			infos:   map[string]*types.UpdateInfo{},
+			wantErr: "CNB unavailable",
+		},
+		{
+			name:          "下载失败时从备用下载源下载同一个文件",
+			infos:         map[string]*types.UpdateInfo{SourceGitHub: asset("abc"), SourceCNB: asset("abc")},
+			failDownload:  map[string]bool{SourceGitHub: true},
+			wantSource:    SourceCNB,
+			wantDownloads: []string{SourceGitHub, SourceCNB},
+		},
+		{
+			name:          "备用下载源的文件不一致时不改用",
+			infos:         map[string]*types.UpdateInfo{SourceGitHub: asset("abc"), SourceCNB: asset("def")},
+			failDownload:  map[string]bool{SourceGitHub: true},
+			wantErr:       "GitHub download failed",
+			wantDownloads: []string{SourceGitHub},
+		},
+		{
+			name:          "没有 SHA256 时不改用",
+			infos:         map[string]*types.UpdateInfo{SourceGitHub: asset(""), SourceCNB: asset("")},
+			failDownload:  map[string]bool{SourceGitHub: true},
+			wantErr:       "GitHub download failed",
+			wantDownloads: []string{SourceGitHub},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := newManifestTestUpdater(t)
+			primary := base.APIClient
+			comp := &sourceComponent{ModelUpdater: &ModelUpdater{BaseUpdater: base}, infos: tt.infos, failDownload: tt.failDownload}
+
+			info, err := checkWithFailover(context.Background(), comp)
+			if err == nil {
+				base.UpdateInfo = info
+				_, err = downloadWithFailover(context.Background(), comp, func(string, float64, string, string, int64, int64, float64, bool) {}, comp.Download)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if base.currentSource() != SourceGitHub || base.APIClient != primary {
+					t.Errorf("source = %s after failure, want the primary source restored", base.currentSource())
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("error = %v", err)
+				}
+				if base.UpdateInfo.Source != tt.wantSource || base.currentSource() != tt.wantSource {
+					t.Errorf("UpdateInfo.Source = %q, current source = %q, want %q", base.UpdateInfo.Source, base.currentSource(), tt.wantSource)
+				}
+			}
+			if strings.Join(comp.downloads, ",") != strings.Join(tt.wantDownloads, ",") {
+				t.Errorf("downloads = %v, want %v", comp.downloads, tt.wantDownloads)
+			}
+
+			// 下一次检查重新从主下载源开始
+			comp.infos = map[string]*types.UpdateInfo{SourceGitHub: asset("abc")}
+			if info, err := checkWithFailover(context.Background(), comp); err != nil || info.Source != SourceGitHub || base.APIClient != primary {
+				t.Errorf("next check = %+v, %v, want the primary source", info, err)
+			}
+		})
+	}
+}
+
+func TestFailoverSourceRecorded(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	recordPath := base.Config.GetModelRecordPath()
+	info := &types.UpdateInfo{Name: types.MODEL_FILE, Tag: "v2", SHA256: "abc"}
+	setSource(info, SourceCNB)
+
+	run := base.beginHistory(types.ComponentModel, HistoryUpdate, recordPath, types.MODEL_FILE)
+	base.endHistory(run, info, nil)
+	if err := base.SaveRecord(recordPath, "model_name", types.MODEL_FILE, info); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadHistory(base.Config.GetHistoryPath())
+	if err != nil || len(entries) != 1 || entries[0].Source != SourceCNB {
+		t.Errorf("history = %+v, %v, want the entry sourced from CNB", entries, err)
+	}
+	if record := base.GetLocalRecord(recordPath); record == nil || record.Source != SourceCNB {
+		t.Errorf("record = %+v, want source CNB", record)
+	}
+}
@@ -0,0 +1,156 @@
+package updater
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// 更新历史中的操作类型
+const (
+	HistoryUpdate   = "update"
+	HistoryRollback = "rollback"
+	HistoryImport   = "import" // 从本地文件离线导入
+)
+
+// 更新历史中的结果
+const (
+	OutcomeSuccess    = "success"
+	OutcomeFailed     = "failed"
+	OutcomeRolledBack = "rolled_back" // 组合更新中其他组件失败，已随整批恢复
+)
+
+// historyRun 一次正在进行的更新或回滚，结束时由 endHistory 写入更新历史
+type historyRun struct {
+	entry types.HistoryEntry
+	start time.Time
+}
+
+// beginHistory 开始记录一次更新或回滚：记下当前安装的版本并清零下载字节数
+func (b *BaseUpdater) beginHistory(component, action, recordPath, file string) *historyRun {
+	run := &historyRun{
+		entry: types.HistoryEntry{Component: component, Action: action},
+		start: time.Now(),
+	}
+	switch {
+	case action == HistoryRollback:
+		run.entry.Source = "cache"
+	case action == HistoryImport:
+		run.entry.Source = "local"
+	default:
+		run.entry.Source = b.currentSource()
+	}
+	if record := b.GetLocalRecord(recordPath); record != nil && record.Name == file {
+		run.entry.OldTag = record.Tag
+	}
+	b.transferred = 0
+	return run
+}
+
+// endHistory 结束记录：info 为安装的版本（失败时可能为 nil），err 为运行结果。
+// 组合更新中先暂存，等整批提交或回滚后由 CombinedUpdater.flushHistory 写入。
+func (b *BaseUpdater) endHistory(run *historyRun, info *types.UpdateInfo, err error) {
+	entry := run.entry
+	entry.Time = time.Now()
+	entry.DurationMS = time.Since(run.start).Milliseconds()
+	entry.Bytes = b.transferred
+	entry.Outcome = OutcomeSuccess
+	if info != nil {
+		entry.NewTag = info.Tag
+		entry.SHA256 = info.SHA256
+		if entry.Action == HistoryUpdate && info.Source != "" {
+			entry.Source = info.Source // 实际提供文件的下载源，见 downloadWithFailover
+		}
+	}
+	if err != nil {
+		entry.Outcome = OutcomeFailed
+		entry.Error = err.Error()
+	}
+
+	if b.Transaction != nil {
+		b.history = append(b.history, entry)
+		return
+	}
+	// 历史记录只用于查看，写入失败不影响更新结果
+	_ = AppendHistory(b.Config.GetHistoryPath(), entry)
+}
+
+// flushHistory 写入整批更新中暂存的历史记录；rolledBack 为 true 时成功的组件改记为已随整批恢复
+func (c *CombinedUpdater) flushHistory(rolledBack bool) {
+	var entries []types.HistoryEntry
+	for _, comp := range c.components {
+		base := comp.Base()
+		for _, entry := range base.history {
+			if rolledBack && entry.Outcome == OutcomeSuccess {
+				entry.Outcome = OutcomeRolledBack
+			}
+			entries = append(entries, entry)
+		}
+		base.history = nil
+	}
+	if len(entries) > 0 {
+		_ = AppendHistory(c.Config.GetHistoryPath(), entries...)
+	}
+}
+
+// AppendHistory 向更新历史文件追加记录，每条一行 JSON
+func AppendHistory(path string, entries ...types.HistoryEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开更新历史失败: %w", err)
+	}
+	defer f.Close()
+
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("序列化更新历史失败: %w", err)
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("写入更新历史失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadHistory 读取更新历史，从新到旧排列；文件不存在时返回空列表，无法解析的行会被跳过
+func ReadHistory(path string) ([]types.HistoryEntry, error) {
+	if !fileutil.FileExists(path) {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取更新历史失败: %w", err)
+	}
+	defer f.Close()
+
+	var entries []types.HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry types.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // 写入中断留下的半行等
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取更新历史失败: %w", err)
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
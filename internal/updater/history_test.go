@@ -0,0 +1,110 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestReadHistorySkipsBrokenLines(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	path := base.Config.GetHistoryPath()
+
+	if entries, err := ReadHistory(path); err != nil || len(entries) != 0 {
+		t.Fatalf("ReadHistory(missing) = %v, %v, want empty", entries, err)
+	}
+
+	if err := AppendHistory(path, types.HistoryEntry{NewTag: "v1"}, types.HistoryEntry{NewTag: "v2"}); err != nil {
+		t.Fatalf("AppendHistory() error = %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"new_tag\":\n") // 写入中断留下的半行
+	f.Close()
+	if err := AppendHistory(path, types.HistoryEntry{NewTag: "v3"}); err != nil {
+		t.Fatalf("AppendHistory() error = %v", err)
+	}
+
+	entries, err := ReadHistory(path)
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	var tags []string
+	for _, entry := range entries {
+		tags = append(tags, entry.NewTag)
+	}
+	if len(tags) != 3 || tags[0] != "v3" || tags[2] != "v1" {
+		t.Errorf("ReadHistory() tags = %v, want [v3 v2 v1]", tags)
+	}
+}
+
+func TestEndHistoryRecordsOutcome(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	recordPath := base.Config.GetSchemeRecordPath()
+	writeTestRecord(t, base, recordPath, "scheme.zip", "v1")
+	base.Config.Config.UseMirror = true
+
+	run := base.beginHistory(types.ComponentScheme, HistoryUpdate, recordPath, "scheme.zip")
+	base.transferred = 1024
+	base.endHistory(run, &types.UpdateInfo{Tag: "v2", SHA256: "abc"}, nil)
+
+	run = base.beginHistory(types.ComponentScheme, HistoryRollback, recordPath, "scheme.zip")
+	base.endHistory(run, nil, errors.New("boom"))
+
+	entries, err := ReadHistory(base.Config.GetHistoryPath())
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ReadHistory() = %+v, %v, want 2 entries", entries, err)
+	}
+	update, rollback := entries[1], entries[0]
+	if update.OldTag != "v1" || update.NewTag != "v2" || update.SHA256 != "abc" || update.Source != "CNB" ||
+		update.Bytes != 1024 || update.Outcome != OutcomeSuccess {
+		t.Errorf("update entry = %+v", update)
+	}
+	if rollback.Action != HistoryRollback || rollback.Source != "cache" || rollback.Bytes != 0 ||
+		rollback.Outcome != OutcomeFailed || rollback.Error != "boom" {
+		t.Errorf("rollback entry = %+v", rollback)
+	}
+}
+
+func TestFlushHistoryMarksRolledBackBatch(t *testing.T) {
+	scheme, _ := newManifestTestUpdater(t)
+	dict := &BaseUpdater{Config: scheme.Config}
+	combined := &CombinedUpdater{
+		Config: scheme.Config,
+		components: []Component{
+			&SchemeUpdater{BaseUpdater: scheme},
+			&DictUpdater{BaseUpdater: dict},
+			&ModelUpdater{BaseUpdater: &BaseUpdater{Config: scheme.Config}},
+		},
+	}
+	combined.setBatchMode(true, &Transaction{})
+
+	run := scheme.beginHistory(types.ComponentScheme, HistoryUpdate, scheme.Config.GetSchemeRecordPath(), "scheme.zip")
+	scheme.endHistory(run, &types.UpdateInfo{Tag: "v2"}, nil)
+	run = dict.beginHistory(types.ComponentDict, HistoryUpdate, scheme.Config.GetDictRecordPath(), "dict.zip")
+	dict.endHistory(run, nil, errors.New("boom"))
+
+	// 整批结果确定前不写入
+	if entries, _ := ReadHistory(scheme.Config.GetHistoryPath()); len(entries) != 0 {
+		t.Fatalf("history written before the batch finished: %+v", entries)
+	}
+
+	combined.flushHistory(true)
+	entries, err := ReadHistory(scheme.Config.GetHistoryPath())
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ReadHistory() = %+v, %v, want 2 entries", entries, err)
+	}
+	if entries[1].Component != types.ComponentScheme || entries[1].Outcome != OutcomeRolledBack {
+		t.Errorf("scheme entry = %+v, want rolled back", entries[1])
+	}
+	if entries[0].Component != types.ComponentDict || entries[0].Outcome != OutcomeFailed {
+		t.Errorf("dict entry = %+v, want failed", entries[0])
+	}
+	if len(scheme.history) != 0 || len(dict.history) != 0 {
+		t.Errorf("pending history not cleared after flush")
+	}
+}
@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -33,7 +34,7 @@ func TestFetchAllUpdatesPerformance(t *testing.T) {
 	// 单独测试每个组件
 	t.Log("\n=== 测试方案检查 ===")
 	schemeStart := time.Now()
-	schemeInfo, schemeErr := combined.SchemeUpdater.CheckUpdate()
+	schemeInfo, schemeErr := mustComponent(t, combined, types.ComponentScheme).CheckUpdate(context.Background())
 	schemeElapsed := time.Since(schemeStart)
 	t.Logf("方案检查耗时: %v", schemeElapsed)
 	if schemeErr != nil {
@@ -44,7 +45,7 @@ func TestFetchAllUpdatesPerformance(t *testing.T) {
 
 	t.Log("\n=== 测试词库检查 ===")
 	dictStart := time.Now()
-	dictInfo, dictErr := combined.DictUpdater.CheckUpdate()
+	dictInfo, dictErr := mustComponent(t, combined, types.ComponentDict).CheckUpdate(context.Background())
 	dictElapsed := time.Since(dictStart)
 	t.Logf("词库检查耗时: %v", dictElapsed)
 	if dictErr != nil {
@@ -55,7 +56,7 @@ func TestFetchAllUpdatesPerformance(t *testing.T) {
 
 	t.Log("\n=== 测试模型检查 ===")
 	modelStart := time.Now()
-	modelInfo, modelErr := combined.ModelUpdater.CheckUpdate()
+	modelInfo, modelErr := mustComponent(t, combined, types.ComponentModel).CheckUpdate(context.Background())
 	modelElapsed := time.Since(modelStart)
 	t.Logf("模型检查耗时: %v", modelElapsed)
 	if modelErr != nil {
@@ -66,7 +67,7 @@ func TestFetchAllUpdatesPerformance(t *testing.T) {
 
 	t.Log("\n=== 测试完整流程 ===")
 	fullStart := time.Now()
-	err := combined.FetchAllUpdates()
+	_, err := combined.FetchAllUpdates(context.Background())
 	fullElapsed := time.Since(fullStart)
 
 	totalElapsed := time.Since(start)
@@ -111,21 +112,21 @@ func TestIndividualComponentPerformance(t *testing.T) {
 			name:    "方案检查",
 			timeout: 15 * time.Second,
 			test: func() (interface{}, error) {
-				return NewSchemeUpdater(cfg).CheckUpdate()
+				return NewSchemeUpdater(cfg).CheckUpdate(context.Background())
 			},
 		},
 		{
 			name:    "词库检查",
 			timeout: 15 * time.Second,
 			test: func() (interface{}, error) {
-				return NewDictUpdater(cfg).CheckUpdate()
+				return NewDictUpdater(cfg).CheckUpdate(context.Background())
 			},
 		},
 		{
 			name:    "模型检查",
 			timeout: 1 * time.Second, // 模型应该很快
 			test: func() (interface{}, error) {
-				return NewModelUpdater(cfg).CheckUpdate()
+				return NewModelUpdater(cfg).CheckUpdate(context.Background())
 			},
 		},
 	}
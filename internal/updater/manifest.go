@@ -0,0 +1,287 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// ErrNoManifest 组件没有安装清单（未安装，或由不记录清单的旧版本安装）
+var ErrNoManifest = errors.New("install manifest not found")
+
+// installTarget 组件在某个引擎下的安装目录
+type installTarget struct {
+	engine string
+	root   string
+}
+
+// primaryEngine 返回主引擎名：优先使用配置的主引擎，否则取要更新的第一个引擎
+func (b *BaseUpdater) primaryEngine() string {
+	if b.Config.Config.PrimaryEngine != "" {
+		return b.Config.Config.PrimaryEngine
+	}
+	if len(b.Config.Config.UpdateEngines) > 0 {
+		return b.Config.Config.UpdateEngines[0]
+	}
+	if len(b.Config.Config.InstalledEngines) > 0 {
+		return b.Config.Config.InstalledEngines[0]
+	}
+	return ""
+}
+
+// installTargets 返回组件的所有安装目录：第一个为主引擎目录 root，其余为需要同步的其他引擎下的 subDir
+func (b *BaseUpdater) installTargets(root, subDir string) []installTarget {
+	targets := []installTarget{{engine: b.primaryEngine(), root: root}}
+	if len(b.Config.Config.InstalledEngines) <= 1 {
+		return targets
+	}
+
+	for _, engine := range b.secondaryEngines() {
+		dataDir := config.GetEngineDataDir(engine)
+		if dataDir == "" {
+			continue
+		}
+		targets = append(targets, installTarget{engine: engine, root: filepath.Join(dataDir, subDir)})
+	}
+	return targets
+}
+
+// LoadManifest 读取安装清单；清单不存在时返回 nil, nil
+func LoadManifest(path string) (*types.InstallManifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取安装清单失败: %w", err)
+	}
+
+	var manifest types.InstallManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析安装清单失败: %w", err)
+	}
+	return &manifest, nil
+}
+
+// SaveManifest 保存安装清单
+func SaveManifest(path string, manifest *types.InstallManifest) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建清单目录失败: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化安装清单失败: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// manifestIndex 按路径索引清单中的文件；manifest 为 nil 时返回空索引
+func manifestIndex(manifest *types.InstallManifest) map[string]types.ManifestFile {
+	index := make(map[string]types.ManifestFile)
+	if manifest == nil {
+		return index
+	}
+	for _, file := range manifest.Files {
+		index[file.Path] = file
+	}
+	return index
+}
+
+// buildManifest 计算 root 下 names 中各文件的大小与哈希。
+// owned 判断某个文件是否由本次安装写入，为 nil 时全部计入；不存在的文件会被跳过。
+func buildManifest(component, engine, root, tag string, names []string, owned func(name, hash string) bool) (*types.InstallManifest, error) {
+	manifest := &types.InstallManifest{
+		Component:   component,
+		Engine:      engine,
+		Root:        root,
+		Tag:         tag,
+		InstalledAt: time.Now(),
+		Files:       []types.ManifestFile{},
+	}
+
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取文件信息失败 %s: %w", name, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		hash, err := fileutil.CalculateSHA256(path)
+		if err != nil {
+			return nil, fmt.Errorf("计算文件哈希失败 %s: %w", name, err)
+		}
+		if owned != nil && !owned(name, hash) {
+			continue
+		}
+
+		manifest.Files = append(manifest.Files, types.ManifestFile{Path: name, Size: info.Size(), SHA256: hash})
+	}
+
+	return manifest, nil
+}
+
+// writeManifests 安装完成后为每个安装目录写入清单。
+// names 为更新包中的全部文件，installed 为主引擎目录中实际写入的文件：
+// 被排除规则保留的文件只有在旧清单中登记过且内容未变时才继续计入；
+// 其他引擎目录只登记与主引擎目录内容一致的文件，避免把同步时被排除的用户文件记为万象文件。
+// kept 为用户选择保留本地修改的文件在更新包中的版本，原样登记到主引擎清单中。
+func (b *BaseUpdater) writeManifests(component string, targets []installTarget, names, installed []string, tag string, kept ...types.ManifestFile) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	primary := targets[0]
+	primaryPath := b.Config.GetManifestPath(component, primary.engine)
+	previous, err := LoadManifest(primaryPath)
+	if err != nil {
+		return err
+	}
+	if previous != nil && filepath.Clean(previous.Root) != filepath.Clean(primary.root) {
+		previous = nil
+	}
+
+	installedSet := make(map[string]bool, len(installed))
+	for _, name := range installed {
+		installedSet[name] = true
+	}
+	previousIndex := manifestIndex(previous)
+	manifest, err := buildManifest(component, primary.engine, primary.root, tag, names, func(name, hash string) bool {
+		return installedSet[name] || previousIndex[name].SHA256 == hash
+	})
+	if err != nil {
+		return err
+	}
+	if len(kept) > 0 {
+		manifest.Files = append(manifest.Files, kept...)
+		sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	}
+	if err := SaveManifest(primaryPath, manifest); err != nil {
+		return err
+	}
+
+	primaryIndex := manifestIndex(manifest)
+	for _, target := range targets[1:] {
+		secondary, err := buildManifest(component, target.engine, target.root, tag, names, func(name, hash string) bool {
+			return primaryIndex[name].SHA256 == hash
+		})
+		if err != nil {
+			return err
+		}
+		if err := SaveManifest(b.Config.GetManifestPath(component, target.engine), secondary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanInstalled 删除旧版本安装、但新版本中已不存在的文件。
+// 有安装清单时按清单精确清理（包括其他引擎目录）；主引擎目录没有清单时（旧版本安装）退回到对比新旧压缩包。
+func (b *BaseUpdater) cleanInstalled(component string, targets []installTarget, names []string, oldZip, newZip string, isDict bool) error {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	for i, target := range targets {
+		manifest, err := LoadManifest(b.Config.GetManifestPath(component, target.engine))
+		if err != nil {
+			return err
+		}
+		if manifest == nil || filepath.Clean(manifest.Root) != filepath.Clean(target.root) {
+			if i == 0 && fileutil.FileExists(oldZip) {
+				b.CleanOldFiles(oldZip, newZip, target.root, isDict)
+			}
+			continue
+		}
+
+		removeManifestFiles(manifest, keep)
+	}
+	return nil
+}
+
+// removeManifestFiles 删除清单中不在 keep 中的文件，并清理因此变空的目录。
+// 内容与清单记录不一致的文件视为用户修改过，予以保留并返回在 kept 中。
+func removeManifestFiles(manifest *types.InstallManifest, keep map[string]bool) (removed, kept []string) {
+	root := filepath.Clean(manifest.Root)
+	dirs := make(map[string]bool)
+	for _, file := range manifest.Files {
+		if keep[file.Path] || !filepath.IsLocal(filepath.FromSlash(file.Path)) {
+			continue
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(file.Path))
+		if !fileutil.FileExists(path) {
+			continue
+		}
+		if hash, err := fileutil.CalculateSHA256(path); err != nil || hash != file.SHA256 {
+			kept = append(kept, path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			kept = append(kept, path)
+			continue
+		}
+		removed = append(removed, path)
+
+		for dir := filepath.Dir(path); len(dir) > len(root); dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	// 由深到浅删除空目录，非空目录说明其中还有用户文件
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, dir := range sorted {
+		os.Remove(dir)
+	}
+
+	return removed, kept
+}
+
+// trackManifests 登记清单文件本身，以及清单中可能在清理时被删除的文件
+func (b *BaseUpdater) trackManifests(txn *Transaction, component string, targets []installTarget) error {
+	for _, target := range targets {
+		path := b.Config.GetManifestPath(component, target.engine)
+		manifest, err := LoadManifest(path)
+		if err != nil {
+			return err
+		}
+		if err := txn.Track(path); err != nil {
+			return err
+		}
+		if manifest == nil || filepath.Clean(manifest.Root) != filepath.Clean(target.root) {
+			continue
+		}
+
+		names := make([]string, 0, len(manifest.Files))
+		for _, file := range manifest.Files {
+			if filepath.IsLocal(filepath.FromSlash(file.Path)) {
+				names = append(names, file.Path)
+			}
+		}
+		if err := txn.TrackAll(target.root, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func newManifestTestUpdater(t *testing.T) (*BaseUpdater, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	rimeDir := filepath.Join(tmpDir, "rime")
+	cfg := &config.Manager{
+		Config:   &types.Config{PrimaryEngine: "fcitx5", SchemeFile: "scheme.zip"},
+		RimeDir:  rimeDir,
+		CacheDir: filepath.Join(tmpDir, "cache"),
+	}
+	return &BaseUpdater{Config: cfg}, rimeDir
+}
+
+func TestCleanInstalledUsesManifest(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	targets := []installTarget{{engine: "fcitx5", root: rimeDir}}
+
+	for _, name := range []string{"default.yaml", "lua/old.lua", "opencc/old.txt", "opencc/edited.txt"} {
+		writeTestFile(t, filepath.Join(rimeDir, filepath.FromSlash(name)))
+	}
+	writeTestFile(t, filepath.Join(rimeDir, "user.dict.yaml"))
+	installed := []string{"default.yaml", "lua/old.lua", "opencc/old.txt", "opencc/edited.txt"}
+	if err := base.writeManifests(types.ComponentScheme, targets, installed, installed, "v1"); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(rimeDir, "opencc", "edited.txt"), []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有旧压缩包也能按清单清理
+	if err := base.cleanInstalled(types.ComponentScheme, targets, []string{"default.yaml"}, "", "", false); err != nil {
+		t.Fatalf("cleanInstalled() error = %v", err)
+	}
+
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"default.yaml", true},
+		{"lua/old.lua", false},
+		{"lua", false}, // 清理后变空的目录一并删除
+		{"opencc/old.txt", false},
+		{"opencc/edited.txt", true}, // 安装后被修改的文件保留
+		{"user.dict.yaml", true},    // 不在清单中的用户文件保留
+	}
+	for _, tt := range tests {
+		if got := fileutil.FileExists(filepath.Join(rimeDir, filepath.FromSlash(tt.file))); got != tt.want {
+			t.Errorf("%s exists = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestWriteManifestsKeepsExcludedFilesOnlyWhenUnchanged(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	targets := []installTarget{{engine: "fcitx5", root: rimeDir}}
+
+	names := []string{"default.yaml", "a.custom.yaml", "b.custom.yaml"}
+	for _, name := range names {
+		writeTestFile(t, filepath.Join(rimeDir, name))
+	}
+	if err := base.writeManifests(types.ComponentScheme, targets, names, names, "v1"); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+
+	// 第二次更新时两个排除文件都被保留，其中 b 已被用户修改
+	if err := os.WriteFile(filepath.Join(rimeDir, "b.custom.yaml"), []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := base.writeManifests(types.ComponentScheme, targets, names, []string{"default.yaml"}, "v2"); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+
+	manifest, err := LoadManifest(base.Config.GetManifestPath(types.ComponentScheme, "fcitx5"))
+	if err != nil || manifest == nil {
+		t.Fatalf("LoadManifest() = %v, %v", manifest, err)
+	}
+	index := manifestIndex(manifest)
+	if _, ok := index["a.custom.yaml"]; !ok {
+		t.Errorf("unchanged excluded file was dropped from the manifest")
+	}
+	if _, ok := index["b.custom.yaml"]; ok {
+		t.Errorf("user-modified excluded file was recorded as installed")
+	}
+	if manifest.Tag != "v2" {
+		t.Errorf("manifest tag = %q, want %q", manifest.Tag, "v2")
+	}
+}
+
+func TestSchemeUninstall(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	scheme := &SchemeUpdater{BaseUpdater: base}
+	targets := []installTarget{{engine: "fcitx5", root: rimeDir}}
+
+	installed := []string{"default.yaml", "lua/wanxiang.lua", "edited.yaml"}
+	for _, name := range installed {
+		writeTestFile(t, filepath.Join(rimeDir, filepath.FromSlash(name)))
+	}
+	writeTestFile(t, filepath.Join(rimeDir, "lua", "mine.lua"))
+	if err := base.writeManifests(types.ComponentScheme, targets, installed, installed, "v1"); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(rimeDir, "edited.yaml"), []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recordPath := base.Config.GetSchemeRecordPath()
+	writeTestFile(t, recordPath)
+
+	result := scheme.Uninstall()
+	if result.Err != nil {
+		t.Fatalf("Uninstall() error = %v", result.Err)
+	}
+	if len(result.Removed) != 2 || len(result.Kept) != 1 {
+		t.Errorf("Uninstall() removed %v, kept %v, want 2 removed and 1 kept", result.Removed, result.Kept)
+	}
+	for _, name := range []string{"edited.yaml", "lua/mine.lua"} {
+		if !fileutil.FileExists(filepath.Join(rimeDir, filepath.FromSlash(name))) {
+			t.Errorf("Uninstall() removed %s", name)
+		}
+	}
+	for _, path := range []string{recordPath, base.Config.GetManifestPath(types.ComponentScheme, "fcitx5")} {
+		if fileutil.FileExists(path) {
+			t.Errorf("Uninstall() left %s behind", path)
+		}
+	}
+
+	// 清单已删除，再次卸载时报告没有清单
+	if result := scheme.Uninstall(); !errors.Is(result.Err, ErrNoManifest) {
+		t.Errorf("second Uninstall() error = %v, want %v", result.Err, ErrNoManifest)
+	}
+}
@@ -1,19 +1,31 @@
 package updater
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/fileutil"
 	"rime-wanxiang-updater/internal/types"
 )
 
-// ModelUpdater 模型更新器
+// ModelUpdater 模型更新器。可以同时安装多个模型（见 config.Manager.ModelPatterns），每个模型文件有各自的版本记录
 type ModelUpdater struct {
 	*BaseUpdater
-	UpdateInfo *types.UpdateInfo
+
+	// download 下载的、等待 apply 安装的模型
+	downloaded []modelDownload
+}
+
+// modelDownload 已下载到临时文件的模型；temp 为空时本地文件已是该版本，安装时只更新版本记录
+type modelDownload struct {
+	info *types.UpdateInfo
+	temp string
 }
 
 // NewModelUpdater 创建模型更新器
@@ -23,20 +35,202 @@ func NewModelUpdater(cfg *config.Manager) *ModelUpdater {
 	}
 }
 
-// GetStatus 获取更新状态
-func (m *ModelUpdater) GetStatus() (*types.UpdateStatus, error) {
-	if err := m.Config.ReconcileRuntimeState(); err != nil {
-		return nil, err
+// ID 组件 ID
+func (m *ModelUpdater) ID() string {
+	return types.ComponentModel
+}
+
+// RecordPath 主模型的版本记录，见 primaryModel
+func (m *ModelUpdater) RecordPath() string {
+	return m.Config.GetModelFileRecordPath(m.primaryModel())
+}
+
+func (m *ModelUpdater) FileName() string {
+	return m.primaryModel()
+}
+
+// primaryModel 返回主模型文件，模型的版本号、版本固定和更新历史以主模型为准：
+// 已检查更新时为匹配到的第一个模型；否则按配置顺序取第一个具体的文件名或安装清单中匹配通配符模式的模型。
+// 只配置了通配符模式且还没有安装匹配的模型时返回空字符串
+func (m *ModelUpdater) primaryModel() string {
+	if m.UpdateInfo != nil {
+		return modelAssets(m.UpdateInfo)[0].Name
 	}
 
-	// 获取远程版本信息
-	remoteInfo, err := m.CheckUpdate()
-	if err != nil {
-		return nil, err
+	installed := m.installedModels()
+	for _, pattern := range m.Config.ModelPatterns() {
+		if !config.IsModelPattern(pattern) {
+			return pattern
+		}
+		for _, name := range installed {
+			if ok, _ := path.Match(pattern, name); ok {
+				return name
+			}
+		}
 	}
+	return ""
+}
+
+// modelAssets 返回版本信息中的各个模型，只有一个模型时为 info 本身
+func modelAssets(info *types.UpdateInfo) []*types.UpdateInfo {
+	if len(info.Assets) == 0 {
+		return []*types.UpdateInfo{info}
+	}
+	assets := make([]*types.UpdateInfo, len(info.Assets))
+	for i := range info.Assets {
+		assets[i] = &info.Assets[i]
+	}
+	return assets
+}
+
+// combineModelInfos 合并多个模型的版本信息：只有一个模型时原样返回；
+// 多个模型时以第一个为准，文件名、大小和更新时间为汇总值，各模型的信息放在 Assets 中
+func combineModelInfos(infos []*types.UpdateInfo) *types.UpdateInfo {
+	if len(infos) == 1 {
+		return infos[0]
+	}
+
+	combined := *infos[0]
+	combined.URL, combined.SHA256, combined.ID, combined.Size = "", "", "", 0
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name)
+		combined.Size += info.Size
+		if info.UpdateTime.After(combined.UpdateTime) {
+			combined.UpdateTime = info.UpdateTime
+		}
+		combined.Assets = append(combined.Assets, *info)
+	}
+	combined.Name = strings.Join(names, ", ")
+	return &combined
+}
+
+// matchModel 判断发布中的文件 name 是否为 patterns 选择的模型
+func matchModel(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	})
+}
+
+// modelFiles 将配置的模型文件名和通配符模式解析为具体的文件名，按配置顺序排列；
+// 只有通配符模式需要用到 latest 返回的发布渠道中的文件
+func modelFiles(patterns []string, latest func() ([]*types.UpdateInfo, error)) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		if !config.IsModelPattern(pattern) {
+			if !slices.Contains(files, pattern) {
+				files = append(files, pattern)
+			}
+			continue
+		}
+
+		assets, err := latest()
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, asset := range assets {
+			if ok, _ := path.Match(pattern, asset.Name); ok {
+				matched = true
+				if !slices.Contains(files, asset.Name) {
+					files = append(files, asset.Name)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("发布中没有匹配 %s 的模型文件", pattern)
+		}
+	}
+	return files, nil
+}
+
+// installedModels 返回主引擎安装清单中登记的模型文件
+func (m *ModelUpdater) installedModels() []string {
+	manifest, err := LoadManifest(m.Config.GetManifestPath(types.ComponentModel, m.primaryEngine()))
+	if err != nil || manifest == nil || filepath.Clean(manifest.Root) != filepath.Clean(m.Config.GetExtractPath()) {
+		return nil
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for _, file := range manifest.Files {
+		if filepath.IsLocal(filepath.FromSlash(file.Path)) {
+			names = append(names, file.Path)
+		}
+	}
+	return names
+}
+
+// staleModels 返回之前安装、但已不在 info 中的模型（用户取消选择的模型）；只包含安装清单中登记且仍存在的文件
+func (m *ModelUpdater) staleModels(info *types.UpdateInfo) []string {
+	var stale []string
+	for _, name := range m.installedModels() {
+		selected := slices.ContainsFunc(modelAssets(info), func(asset *types.UpdateInfo) bool {
+			return asset.Name == name
+		})
+		if !selected && fileutil.FileExists(filepath.Join(m.Config.GetExtractPath(), name)) {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+// modelNames 返回已安装和已选择的具体模型文件名，用于回滚和卸载
+func (m *ModelUpdater) modelNames() []string {
+	names := m.installedModels()
+	for _, name := range m.Config.ModelPatterns() {
+		if !config.IsModelPattern(name) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetStatus 获取更新状态，固定了版本时在状态中注明
+func (m *ModelUpdater) GetStatus(ctx context.Context) (*types.UpdateStatus, error) {
+	_, status, err := resolveComponent(ctx, m)
+	return status, err
+}
+
+// StatusFor 比较远程版本和本地文件得到更新状态，不访问网络；固定了版本时在状态中注明。
+// 安装多个模型时以第一个模型的版本为准，任一模型需要更新或有要移除的模型时即需要更新
+func (m *ModelUpdater) StatusFor(remoteInfo *types.UpdateInfo) *types.UpdateStatus {
+	assets := modelAssets(remoteInfo)
+	statuses := m.assetStatuses(assets)
+	stale := m.staleModels(remoteInfo)
+	if len(assets) == 1 && len(stale) == 0 {
+		return statuses[0]
+	}
+
+	status := *statuses[0]
+	messages := make([]string, 0, len(statuses)+1)
+	for i, s := range statuses {
+		status.NeedsUpdate = status.NeedsUpdate || s.NeedsUpdate
+		messages = append(messages, fmt.Sprintf("%s: %s", assets[i].Name, s.Message))
+	}
+	if len(stale) > 0 {
+		status.NeedsUpdate = true
+		messages = append(messages, fmt.Sprintf("将移除未选择的模型: %s", strings.Join(stale, ", ")))
+	}
+	status.Message = strings.Join(messages, "；")
+	return &status
+}
+
+// assetStatuses 分别比较每个模型的远程版本和本地文件
+func (m *ModelUpdater) assetStatuses(assets []*types.UpdateInfo) []*types.UpdateStatus {
+	statuses := make([]*types.UpdateStatus, 0, len(assets))
+	for _, asset := range assets {
+		status := m.compareLocal(asset)
+		m.applyPin(types.ComponentModel, m.Config.GetModelFileRecordPath(asset.Name), asset.Name, status)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
 
+// compareLocal 比较单个模型的远程版本和本地文件
+func (m *ModelUpdater) compareLocal(remoteInfo *types.UpdateInfo) *types.UpdateStatus {
 	// 获取本地版本信息
-	recordPath := m.Config.GetModelRecordPath()
+	recordPath := m.Config.GetModelFileRecordPath(remoteInfo.Name)
 	localRecord := m.GetLocalRecord(recordPath)
 
 	status := &types.UpdateStatus{
@@ -45,13 +239,13 @@ func (m *ModelUpdater) GetStatus() (*types.UpdateStatus, error) {
 		NeedsUpdate:   true,
 	}
 
-	targetPath := filepath.Join(m.Config.GetExtractPath(), types.MODEL_FILE)
+	targetPath := filepath.Join(m.Config.GetExtractPath(), remoteInfo.Name)
 	modelExists := fileutil.FileExists(targetPath)
 
 	if !modelExists {
 		status.LocalVersion = "未安装"
 		status.Message = fmt.Sprintf("检测到可用模型: %s", remoteInfo.Tag)
-		return status, nil
+		return status
 	}
 
 	if localRecord != nil {
@@ -82,142 +276,192 @@ func (m *ModelUpdater) GetStatus() (*types.UpdateStatus, error) {
 		status.Message = fmt.Sprintf("检测到可用模型: %s (无版本记录，将重新安装)", remoteInfo.Tag)
 	}
 
-	return status, nil
+	return status
 }
 
-// CheckUpdate 检查更新
-func (m *ModelUpdater) CheckUpdate() (*types.UpdateInfo, error) {
-	if m.Config.Config.UseMirror {
-		release, err := m.APIClient.FetchCNBReleaseByTag(types.OWNER, types.CNB_REPO, "model")
-		if err != nil {
-			return nil, fmt.Errorf("获取 CNB 模型版本信息失败: %w", err)
+// NeedsUpdate 模型按各自的版本记录判断是否需要更新（见 HasUpdate），有要移除的模型时也需要更新
+func (m *ModelUpdater) NeedsUpdate(info *types.UpdateInfo, _ *types.UpdateStatus) bool {
+	for _, asset := range modelAssets(info) {
+		if m.HasUpdate(asset, m.Config.GetModelFileRecordPath(asset.Name)) {
+			return true
 		}
+	}
+	return len(m.staleModels(info)) > 0
+}
 
-		info, ok := findModelRelease([]types.GitHubRelease{*release})
-		if !ok {
-			return nil, fmt.Errorf("未找到匹配的模型文件: %s", types.MODEL_FILE)
+// CheckUpdate 检查所选模型的更新，多个模型的版本信息见 types.UpdateInfo.Assets；固定了版本时返回固定的版本
+func (m *ModelUpdater) CheckUpdate(ctx context.Context) (*types.UpdateInfo, error) {
+	patterns := m.Config.ModelPatterns()
+
+	// 发布列表只获取一次，所有模型共用
+	var assets []*types.UpdateInfo
+	var assetsErr error
+	fetched := false
+	latestAssets := func() ([]*types.UpdateInfo, error) {
+		if !fetched {
+			assets, assetsErr = m.findChannelAssets(ctx, types.ComponentModel, types.MODEL_REPO, func(name string) bool {
+				return matchModel(patterns, name)
+			})
+			fetched = true
 		}
-
-		return info, nil
+		return assets, assetsErr
 	}
 
-	releases, err := m.APIClient.FetchGitHubReleases(types.OWNER, types.MODEL_REPO, types.MODEL_TAG)
+	files, err := modelFiles(patterns, latestAssets)
 	if err != nil {
-		return nil, fmt.Errorf("获取版本信息失败: %w", err)
-	}
-
-	if len(releases) == 0 {
-		return nil, fmt.Errorf("未找到任何发布版本")
-	}
-
-	info, ok := findModelRelease(releases)
-	if !ok {
-		return nil, fmt.Errorf("未找到匹配的模型文件: %s", types.MODEL_FILE)
+		return nil, err
 	}
 
-	return info, nil
-}
-
-func findModelRelease(releases []types.GitHubRelease) (*types.UpdateInfo, bool) {
-	for _, release := range releases {
-		for _, asset := range release.Assets {
-			if asset.Name != types.MODEL_FILE {
-				continue
+	infos := make([]*types.UpdateInfo, 0, len(files))
+	for _, file := range files {
+		latest := func() (*types.UpdateInfo, error) {
+			assets, err := latestAssets()
+			if err != nil {
+				return nil, err
 			}
-
-			return &types.UpdateInfo{
-				Name:       asset.Name,
-				URL:        asset.BrowserDownloadURL,
-				UpdateTime: asset.UpdatedAt,
-				Tag:        release.TagName,
-				SHA256:     asset.SHA256,
-				ID:         asset.ID,
-				Size:       asset.Size,
-			}, true
+			for _, info := range assets {
+				if info.Name == file {
+					return info, nil
+				}
+			}
+			return nil, fmt.Errorf("发布渠道 %s 中未找到匹配的文件: %s", m.Channel(types.ComponentModel), file)
+		}
+		info, err := m.checkPinned(types.ComponentModel, m.Config.GetModelFileRecordPath(file), file, latest, func(pin string) (*types.UpdateInfo, bool, error) {
+			return m.findPinnedRelease(ctx, types.MODEL_REPO, file, pin)
+		})
+		if err != nil {
+			return nil, err
 		}
+		infos = append(infos, info)
 	}
+	return combineModelInfos(infos), nil
+}
 
-	return nil, false
+// Run 执行更新，并将结果追加到更新历史
+func (m *ModelUpdater) Run(ctx context.Context, progress types.ProgressFunc) error {
+	return RunComponent(ctx, m, progress)
 }
 
-// Run 执行更新
-func (m *ModelUpdater) Run(progress types.ProgressFunc) error {
-	if progress == nil {
-		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
-	}
+// Download 下载 prepareComponent 确定的各个模型，返回存放临时文件的目录；
+// 所有模型都已是最新版本、也没有要移除的模型时返回空字符串
+func (m *ModelUpdater) Download(ctx context.Context, progress types.ProgressFunc) (string, error) {
+	source := sourceLabel(m.currentSource())
+	m.downloaded = nil
 
-	if err := m.EnsureInstalledEngine(); err != nil {
-		return err
-	}
+	// 校验本地文件
+	progress("正在校验本地文件...", 0.1, "", "", 0, 0, 0, false)
 
-	// 执行更新前 hook
-	if m.Config.Config.PreUpdateHook != "" {
-		progress("执行更新前 hook...", 0.02, "", "", 0, 0, 0, false)
-		if err := m.Config.ExecutePreUpdateHook(); err != nil {
-			return fmt.Errorf("pre-update hook 失败，已取消更新: %w", err)
+	var pending []*types.UpdateInfo
+	for _, info := range modelAssets(m.UpdateInfo) {
+		switch current, refresh := m.upToDate(info); {
+		case !current:
+			pending = append(pending, info)
+		case refresh:
+			m.downloaded = append(m.downloaded, modelDownload{info: info})
 		}
 	}
-
-	// 显示下载源
-	source := "GitHub"
-	if m.Config.Config.UseMirror {
-		source = "CNB 镜像"
+	if len(pending) == 0 && len(m.downloaded) == 0 && len(m.staleModels(m.UpdateInfo)) == 0 {
+		progress("本地文件已是最新版本", 1.0, "", "", 0, 0, 0, false)
+		return "", nil
 	}
-	progress(fmt.Sprintf("正在检查模型更新 [%s]...", source), 0.05, "", "", 0, 0, 0, false)
 
-	if m.UpdateInfo == nil {
-		info, err := m.CheckUpdate()
-		if err != nil {
-			return err
-		}
-		m.UpdateInfo = info
+	// 下载文件
+	tempDir := filepath.Join(m.Config.CacheDir, "temp_models")
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return "", fmt.Errorf("创建临时目录失败: %w", err)
 	}
-
-	if m.UpdateInfo == nil {
-		return fmt.Errorf("未找到模型更新")
+	for _, info := range pending {
+		progress(fmt.Sprintf("准备从 %s 下载模型...", source), 0.15, source, info.URL, 0, 0, 0, false)
+		tempFile := partialDownloadPath(tempDir, info.Name, ".tmp", info)
+		if err := m.downloadVerified(ctx, info, tempFile, info.Name, source, progress); err != nil {
+			return "", fmt.Errorf("下载失败: %w", err)
+		}
+		m.downloaded = append(m.downloaded, modelDownload{info: info, temp: tempFile})
 	}
 
-	recordPath := m.Config.GetModelRecordPath()
-	targetPath := filepath.Join(m.Config.GetExtractPath(), types.MODEL_FILE)
+	return tempDir, nil
+}
 
-	// 校验本地文件
-	progress("正在校验本地文件...", 0.1, "", "", 0, 0, 0, false)
+// upToDate 判断本地模型文件是否已是 info 指定的版本；refresh 表示文件按 SHA256 确认一致、
+// 但版本记录不是该版本，需要在安装时更新版本记录
+func (m *ModelUpdater) upToDate(info *types.UpdateInfo) (current, refresh bool) {
+	recordPath := m.Config.GetModelFileRecordPath(info.Name)
+	targetPath := filepath.Join(m.Config.GetExtractPath(), info.Name)
+	localRecord := m.GetLocalRecord(recordPath)
 
 	// 优先使用 SHA256 校验（如果有）
-	if m.UpdateInfo.SHA256 != "" && m.CompareHash(m.UpdateInfo.SHA256, targetPath) {
-		progress("本地文件已是最新版本", 1.0, "", "", 0, 0, 0, false)
-		m.SaveRecord(recordPath, "model_name", types.MODEL_FILE, m.UpdateInfo)
-		return nil
+	if info.SHA256 != "" && m.CompareHash(info.SHA256, targetPath) {
+		refresh = localRecord == nil || localRecord.Name != info.Name || localRecord.Tag != info.Tag ||
+			!strings.EqualFold(localRecord.SHA256, info.SHA256)
+		return true, refresh
 	}
 
 	// 如果没有 SHA256（如 CNB 镜像），检查文件是否存在且有本地记录
-	if m.UpdateInfo.SHA256 == "" && fileutil.FileExists(targetPath) {
-		localRecord := m.GetLocalRecord(recordPath)
-		if localRecord != nil && localRecord.Name == types.MODEL_FILE {
-			// 文件存在且有记录，认为已是最新版本（除非 UpdateTime 更新）
-			if !m.UpdateInfo.UpdateTime.After(localRecord.UpdateTime) {
-				progress("本地文件已是最新版本", 1.0, "", "", 0, 0, 0, false)
-				return nil
-			}
+	if info.SHA256 == "" && fileutil.FileExists(targetPath) {
+		// 文件存在且有记录，认为已是最新版本（除非 UpdateTime 更新）
+		return localRecord != nil && localRecord.Name == info.Name && !info.UpdateTime.After(localRecord.UpdateTime), false
+	}
+	return false, false
+}
+
+// Apply 安装 Download 下载的模型，并移除已取消选择的模型
+func (m *ModelUpdater) Apply(tempDir string, progress types.ProgressFunc) error {
+	defer os.Remove(tempDir) // 模型文件移走后目录为空
+	return m.install(m.downloaded, m.staleModels(m.UpdateInfo), progress)
+}
+
+// install 安装已下载的模型并移除 stale 中的模型，更新和回滚共用；m.UpdateInfo 须为本次安装的版本信息
+func (m *ModelUpdater) install(downloads []modelDownload, stale []string, progress types.ProgressFunc) error {
+	root := m.Config.GetExtractPath()
+
+	// 保留即将被替换的版本，供之后回滚
+	for _, d := range downloads {
+		if d.temp == "" {
+			continue
 		}
+		m.saveVersion(types.ComponentModel, filepath.Join(root, d.info.Name), m.Config.GetModelFileRecordPath(d.info.Name), progress)
 	}
 
-	// 下载文件
-	progress(fmt.Sprintf("准备从 %s 下载模型...", source), 0.15, source, m.UpdateInfo.URL, 0, 0, 0, false)
-	tempFile := filepath.Join(m.Config.CacheDir, fmt.Sprintf("%s_%s.tmp", types.MODEL_FILE, m.UpdateInfo.SHA256))
-	if err := m.DownloadFile(m.UpdateInfo.URL, tempFile, types.MODEL_FILE, source, progress); err != nil {
-		return fmt.Errorf("下载失败: %w", err)
+	// 备份模型文件与版本记录，替换失败时恢复
+	txn, owned, err := m.beginTransaction()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, d := range downloads {
+		names = append(names, d.info.Name)
+	}
+	for _, name := range append(names, stale...) {
+		if err = txn.Track(filepath.Join(root, name)); err == nil {
+			err = txn.Track(m.Config.GetModelFileRecordPath(name))
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = m.trackManifests(txn, types.ComponentModel, m.targets())
+	}
+	if err == nil {
+		// 应用更新
+		progress("正在应用更新...", 0.8, "", "", 0, 0, 0, false)
+		err = m.applyUpdate(downloads, stale, progress)
 	}
+	return endTransaction(txn, owned, err, progress)
+}
 
-	// 应用更新
-	progress("正在应用更新...", 0.8, "", "", 0, 0, 0, false)
-	return m.applyUpdate(tempFile, targetPath, progress)
+// targets 返回模型的安装目录；模型只安装到主引擎目录
+func (m *ModelUpdater) targets() []installTarget {
+	return []installTarget{{engine: m.primaryEngine(), root: m.Config.GetExtractPath()}}
 }
 
 // applyUpdate 应用更新
-func (m *ModelUpdater) applyUpdate(temp, target string, progress types.ProgressFunc) error {
-	// 终止进程（组合更新时跳过）
-	if !m.SkipTerminate {
+func (m *ModelUpdater) applyUpdate(downloads []modelDownload, stale []string, progress types.ProgressFunc) error {
+	root := m.Config.GetExtractPath()
+
+	// 终止进程（组合更新时跳过；只更新版本记录时不需要）
+	replacing := len(stale) > 0 || slices.ContainsFunc(downloads, func(d modelDownload) bool { return d.temp != "" })
+	if !m.SkipTerminate && replacing {
 		progress("正在终止相关进程...", 0.85, "", "", 0, 0, 0, false)
 		if err := m.TerminateProcesses(); err != nil {
 			return fmt.Errorf("终止进程失败: %w", err)
@@ -226,17 +470,43 @@ func (m *ModelUpdater) applyUpdate(temp, target string, progress types.ProgressF
 
 	// 覆盖目标文件
 	progress("正在保存模型文件...", 0.9, "", "", 0, 0, 0, false)
-	if fileutil.FileExists(target) {
-		os.Remove(target)
+	installed := make([]string, 0, len(downloads))
+	for _, d := range downloads {
+		target := filepath.Join(root, d.info.Name)
+		if d.temp == "" {
+			installed = append(installed, d.info.Name)
+			continue
+		}
+		if fileutil.FileExists(target) {
+			os.Remove(target)
+		}
+		if err := fileutil.MoveFile(d.temp, target); err != nil {
+			return fmt.Errorf("替换文件失败: %w", err)
+		}
+		installed = append(installed, d.info.Name)
 	}
-	if err := fileutil.MoveFile(temp, target); err != nil {
-		return fmt.Errorf("替换文件失败: %w", err)
+
+	// 移除已取消选择的模型及其版本记录
+	for _, name := range stale {
+		if err := os.Remove(filepath.Join(root, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("移除模型失败: %w", err)
+		}
+		os.Remove(m.Config.GetModelFileRecordPath(name))
 	}
 
-	// 保存记录
-	recordPath := m.Config.GetModelRecordPath()
-	if err := m.SaveRecord(recordPath, "model_name", types.MODEL_FILE, m.UpdateInfo); err != nil {
-		return err
+	// 记录安装清单，供卸载使用；清单中保留未更新的其他模型
+	names := slices.DeleteFunc(m.installedModels(), func(name string) bool {
+		return slices.Contains(stale, name) || slices.Contains(installed, name)
+	})
+	if err := m.writeManifests(types.ComponentModel, m.targets(), append(names, installed...), installed, m.UpdateInfo.Tag); err != nil {
+		return fmt.Errorf("写入安装清单失败: %w", err)
+	}
+
+	// 保存记录，包括本地文件已是该版本的模型
+	for _, d := range downloads {
+		if err := m.SaveRecord(m.Config.GetModelFileRecordPath(d.info.Name), "model_name", d.info.Name, d.info); err != nil {
+			return err
+		}
 	}
 
 	// 执行更新后 hook（失败不影响更新结果）
@@ -0,0 +1,234 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+const hantModel = "wanxiang-lts-zh-hant.gram"
+
+func newModelFilesTestUpdater(t *testing.T, files ...string) (*ModelUpdater, string) {
+	t.Helper()
+
+	base, rimeDir := newManifestTestUpdater(t)
+	base.Config.Config.ModelFiles = files
+	base.SkipTerminate = true
+	for _, dir := range []string{rimeDir, base.Config.CacheDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &ModelUpdater{BaseUpdater: base}, rimeDir
+}
+
+// writeModelDownload 模拟 download 下载得到的模型临时文件
+func writeModelDownload(t *testing.T, m *ModelUpdater, name, content string) modelDownload {
+	t.Helper()
+
+	temp := filepath.Join(m.Config.CacheDir, name+".tmp")
+	if err := os.WriteFile(temp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return modelDownload{info: &types.UpdateInfo{Name: name, Tag: "v1", UpdateTime: time.Now()}, temp: temp}
+}
+
+func TestModelFiles(t *testing.T) {
+	fetched := 0
+	latest := func() ([]*types.UpdateInfo, error) {
+		fetched++
+		return []*types.UpdateInfo{{Name: types.MODEL_FILE}, {Name: hantModel}, {Name: "other.gram"}}, nil
+	}
+
+	files, err := modelFiles([]string{types.MODEL_FILE}, latest)
+	if err != nil || !reflect.DeepEqual(files, []string{types.MODEL_FILE}) || fetched != 0 {
+		t.Errorf("modelFiles(literal) = %v, %v, fetched %d times, want no fetch", files, err, fetched)
+	}
+
+	files, err = modelFiles([]string{"*zh-hant*.gram", "*zh-han?.gram"}, latest)
+	want := []string{hantModel, types.MODEL_FILE}
+	if err != nil || !reflect.DeepEqual(files, want) {
+		t.Errorf("modelFiles(patterns) = %v, %v, want %v", files, err, want)
+	}
+
+	if _, err := modelFiles([]string{"*zh-yue*.gram"}, latest); err == nil {
+		t.Error("modelFiles(no match) error = nil, want error")
+	}
+
+	failing := func() ([]*types.UpdateInfo, error) { return nil, errors.New("offline") }
+	if _, err := modelFiles([]string{"*.gram"}, failing); err == nil {
+		t.Error("modelFiles() error = nil, want the fetch error")
+	}
+}
+
+func TestCombineModelInfos(t *testing.T) {
+	single := &types.UpdateInfo{Name: types.MODEL_FILE, SHA256: "abc"}
+	if got := combineModelInfos([]*types.UpdateInfo{single}); got != single {
+		t.Errorf("combineModelInfos(single) = %+v, want the info itself", got)
+	}
+
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	combined := combineModelInfos([]*types.UpdateInfo{
+		{Name: types.MODEL_FILE, Tag: "LTS", SHA256: "a", Size: 2, UpdateTime: older},
+		{Name: hantModel, Tag: "LTS", SHA256: "b", Size: 3, UpdateTime: newer},
+	})
+	if combined.Name != types.MODEL_FILE+", "+hantModel || combined.Size != 5 || !combined.UpdateTime.Equal(newer) ||
+		combined.SHA256 != "" || combined.Tag != "LTS" {
+		t.Errorf("combineModelInfos() = %+v, want summary of both models", combined)
+	}
+	assets := modelAssets(combined)
+	if len(assets) != 2 || assets[1].SHA256 != "b" {
+		t.Errorf("modelAssets(combined) = %v, want both models", assets)
+	}
+}
+
+func TestModelInstallSideBySideAndRemoveDeselected(t *testing.T) {
+	m, rimeDir := newModelFilesTestUpdater(t, types.MODEL_FILE, "*zh-hant*.gram")
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	downloads := []modelDownload{writeModelDownload(t, m, types.MODEL_FILE, "hans"), writeModelDownload(t, m, hantModel, "hant")}
+	m.UpdateInfo = combineModelInfos([]*types.UpdateInfo{downloads[0].info, downloads[1].info})
+	if err := m.install(downloads, nil, noop); err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	for _, name := range []string{types.MODEL_FILE, hantModel} {
+		if !fileutil.FileExists(filepath.Join(rimeDir, name)) {
+			t.Errorf("%s not installed", name)
+		}
+		if record := m.GetLocalRecord(m.Config.GetModelFileRecordPath(name)); record == nil || record.Name != name {
+			t.Errorf("record for %s = %+v, want its own record", name, record)
+		}
+	}
+	if got := m.installedModels(); !reflect.DeepEqual(got, []string{types.MODEL_FILE, hantModel}) {
+		t.Errorf("installedModels() = %v, want both models", got)
+	}
+
+	// 取消选择繁体模型
+	m.Config.Config.ModelFiles = []string{types.MODEL_FILE}
+	info := &types.UpdateInfo{Name: types.MODEL_FILE, Tag: "v1", UpdateTime: downloads[0].info.UpdateTime}
+	status := m.StatusFor(info)
+	if !status.NeedsUpdate || !strings.Contains(status.Message, hantModel) {
+		t.Errorf("StatusFor() = %+v, want an update removing %s", status, hantModel)
+	}
+	if !m.NeedsUpdate(info, status) {
+		t.Error("NeedsUpdate() = false, want true while a deselected model is installed")
+	}
+
+	m.UpdateInfo = info
+	if err := m.Apply(filepath.Join(m.Config.CacheDir, "temp_models"), noop); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if fileutil.FileExists(filepath.Join(rimeDir, hantModel)) || fileutil.FileExists(m.Config.GetModelFileRecordPath(hantModel)) {
+		t.Errorf("deselected model %s or its record was not removed", hantModel)
+	}
+	if !fileutil.FileExists(filepath.Join(rimeDir, types.MODEL_FILE)) {
+		t.Errorf("selected model %s was removed", types.MODEL_FILE)
+	}
+	if got := m.installedModels(); !slices.Equal(got, []string{types.MODEL_FILE}) {
+		t.Errorf("installedModels() = %v, want only %s", got, types.MODEL_FILE)
+	}
+	if m.NeedsUpdate(info, m.StatusFor(info)) {
+		t.Error("NeedsUpdate() = true after removing the deselected model, want false")
+	}
+}
+
+func TestModelRecordForMatchingFileWrittenOnInstall(t *testing.T) {
+	m, rimeDir := newModelFilesTestUpdater(t, types.MODEL_FILE)
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+	if err := os.WriteFile(filepath.Join(rimeDir, types.MODEL_FILE), []byte("hans"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recordPath := m.Config.GetModelFileRecordPath(types.MODEL_FILE)
+	m.UpdateInfo = &types.UpdateInfo{Name: types.MODEL_FILE, Tag: "v2", SHA256: sha256Hex("hans")}
+
+	// 本地文件与发布一致但没有版本记录：下载阶段不写记录，由安装步骤写入
+	tempDir, err := m.Download(context.Background(), noop)
+	if err != nil || tempDir == "" {
+		t.Fatalf("Download() = %q, %v, want a record-only install", tempDir, err)
+	}
+	if fileutil.FileExists(recordPath) {
+		t.Fatal("Download() wrote the version record outside the install step")
+	}
+	if err := m.Apply(tempDir, noop); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if record := m.GetLocalRecord(recordPath); record == nil || record.Tag != "v2" {
+		t.Errorf("record = %+v, want v2", record)
+	}
+	if data, err := os.ReadFile(filepath.Join(rimeDir, types.MODEL_FILE)); err != nil || string(data) != "hans" {
+		t.Errorf("model = %q, %v, want the local file kept", data, err)
+	}
+
+	// 记录已是该版本时无需安装
+	if tempDir, err := m.Download(context.Background(), noop); err != nil || tempDir != "" {
+		t.Errorf("Download() = %q, %v, want nothing to install", tempDir, err)
+	}
+}
+
+func TestPrimaryModelFromMatchedAssets(t *testing.T) {
+	m, _ := newModelFilesTestUpdater(t, "*zh-hant*.gram")
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	if got := m.primaryModel(); got != "" {
+		t.Errorf("primaryModel() before matching = %q, want empty", got)
+	}
+
+	// 检查更新后以匹配到的第一个模型为准
+	download := writeModelDownload(t, m, hantModel, "hant")
+	m.UpdateInfo = download.info
+	if got := m.primaryModel(); got != hantModel {
+		t.Errorf("primaryModel() with update info = %q, want %s", got, hantModel)
+	}
+	if err := m.install([]modelDownload{download}, nil, noop); err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	// 没有版本信息时从安装清单中找匹配的模型
+	m.UpdateInfo = nil
+	if got := m.primaryModel(); got != hantModel {
+		t.Errorf("primaryModel() from manifest = %q, want %s", got, hantModel)
+	}
+	if got, want := m.RecordPath(), m.Config.GetModelFileRecordPath(hantModel); got != want {
+		t.Errorf("RecordPath() = %q, want %q", got, want)
+	}
+	if record := InstalledRecord(m.Config, types.ComponentModel); record == nil || record.Name != hantModel {
+		t.Errorf("InstalledRecord() = %+v, want the record of %s", record, hantModel)
+	}
+
+	// 具体的文件名排在通配符模式之前时以它为准
+	m.Config.Config.ModelFiles = []string{types.MODEL_FILE, "*zh-hant*.gram"}
+	if got := m.primaryModel(); got != types.MODEL_FILE {
+		t.Errorf("primaryModel() with a literal first = %q, want %s", got, types.MODEL_FILE)
+	}
+}
+
+func TestModelDryRunListsEachModel(t *testing.T) {
+	m, rimeDir := newModelFilesTestUpdater(t)
+	writeTestFile(t, filepath.Join(rimeDir, types.MODEL_FILE))
+	writeTestFile(t, filepath.Join(rimeDir, "old.gram"))
+	if err := m.writeManifests(types.ComponentModel, m.targets(), []string{types.MODEL_FILE, "old.gram"}, []string{types.MODEL_FILE, "old.gram"}, "v1"); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+
+	info := combineModelInfos([]*types.UpdateInfo{
+		{Name: types.MODEL_FILE, Tag: "v2", UpdateTime: time.Now()},
+		{Name: hantModel, Tag: "v2", UpdateTime: time.Now()},
+	})
+	plan := m.DryRunFor(context.Background(), info, m.StatusFor(info), nil, nil)
+	if !slices.Equal(plan.Changes.Overwritten, []string{types.MODEL_FILE}) ||
+		!slices.Equal(plan.Changes.Added, []string{hantModel}) ||
+		!slices.Equal(plan.Changes.Deleted, []string{"old.gram"}) {
+		t.Errorf("DryRunFor() changes = %+v, want overwrite, add and delete one model each", plan.Changes)
+	}
+}
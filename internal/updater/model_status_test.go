@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -68,7 +69,7 @@ func TestModelStatusRequiresActualInstalledFile(t *testing.T) {
 		t.Fatalf("SaveRecord() error = %v", err)
 	}
 
-	status, err := updater.GetStatus()
+	status, err := updater.GetStatus(context.Background())
 	if err != nil {
 		if strings.Contains(err.Error(), "状态码: 429") {
 			t.Skipf("CNB rate limited this live test: %v", err)
@@ -1,11 +1,13 @@
 package updater
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
 
 	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/releaseutil"
 	"rime-wanxiang-updater/internal/types"
 )
 
@@ -45,7 +47,7 @@ func TestModelUpdaterCheckUpdate(t *testing.T) {
 
 			// 测试 CheckUpdate
 			start := time.Now()
-			info, err := updater.CheckUpdate()
+			info, err := updater.CheckUpdate(context.Background())
 			elapsed := time.Since(start)
 
 			// 验证结果
@@ -116,7 +118,7 @@ func TestModelUpdaterCheckUpdateTimeout(t *testing.T) {
 	// 运行多次确保稳定性
 	for i := 0; i < 3; i++ {
 		start := time.Now()
-		info, err := updater.CheckUpdate()
+		info, err := updater.CheckUpdate(context.Background())
 		elapsed := time.Since(start)
 
 		if err != nil {
@@ -139,7 +141,7 @@ func TestModelUpdaterCheckUpdateTimeout(t *testing.T) {
 	}
 }
 
-func TestFindModelReleaseUsesModelTagMetadata(t *testing.T) {
+func TestModelChannelUsesModelTagMetadata(t *testing.T) {
 	releases := []types.GitHubRelease{
 		{
 			TagName: "v1.0.0",
@@ -168,9 +170,11 @@ func TestFindModelReleaseUsesModelTagMetadata(t *testing.T) {
 		},
 	}
 
-	info, ok := findModelRelease(releases)
+	info, ok := releaseutil.FindChannelAssetInfo(releases, func(name string) bool {
+		return name == types.MODEL_FILE
+	}, releaseutil.DefaultChannel(types.ComponentModel))
 	if !ok {
-		t.Fatal("findModelRelease() = no match, want match")
+		t.Fatal("FindChannelAssetInfo() = no match, want match")
 	}
 
 	if info.Tag != "model" {
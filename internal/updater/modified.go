@@ -0,0 +1,140 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// 本地修改过的万象文件的处理方式
+const (
+	ModifiedKeep      = "keep"      // 保留本地文件，不安装更新包中的版本
+	ModifiedOverwrite = "overwrite" // 使用更新包中的版本覆盖
+	ModifiedBackup    = "backup"    // 将本地文件另存为 .local 后再覆盖
+)
+
+// ModifiedBackupSuffix 备份本地修改文件时追加的后缀
+const ModifiedBackupSuffix = ".local"
+
+// ValidModifiedAction 返回 action 是否为有效的处理方式
+func ValidModifiedAction(action string) bool {
+	switch action {
+	case ModifiedKeep, ModifiedOverwrite, ModifiedBackup:
+		return true
+	}
+	return false
+}
+
+// detectModified 返回主引擎目录 root 中安装后被用户修改、且本次更新会覆盖的文件（相对路径，/ 分隔）。
+// 只检查安装清单中登记的文件；排除规则匹配的文件本来就不会被覆盖，内容已与新版本一致的文件也不算冲突。
+func (b *BaseUpdater) detectModified(component, root, staging string) ([]string, error) {
+	manifest, err := LoadManifest(b.Config.GetManifestPath(component, b.primaryEngine()))
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil || filepath.Clean(manifest.Root) != filepath.Clean(root) {
+		return nil, nil
+	}
+
+	excludePatterns, _ := config.ParseExcludePatterns(b.Config.Config.ExcludeFiles)
+	var modified []string
+	for _, file := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(file.Path)) || config.MatchAny(file.Path, excludePatterns) {
+			continue
+		}
+
+		stagedPath := filepath.Join(staging, filepath.FromSlash(file.Path))
+		localPath := filepath.Join(root, filepath.FromSlash(file.Path))
+		if !fileutil.FileExists(stagedPath) || !fileutil.FileExists(localPath) {
+			continue
+		}
+
+		localHash, err := fileutil.CalculateSHA256(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("计算文件哈希失败 %s: %w", file.Path, err)
+		}
+		if localHash == file.SHA256 {
+			continue
+		}
+		stagedHash, err := fileutil.CalculateSHA256(stagedPath)
+		if err != nil {
+			return nil, fmt.Errorf("计算文件哈希失败 %s: %w", file.Path, err)
+		}
+		if localHash != stagedHash {
+			modified = append(modified, file.Path)
+		}
+	}
+
+	sort.Strings(modified)
+	return modified, nil
+}
+
+// modifiedAction 决定本地修改文件的处理方式：优先使用配置中记住的选择，其次询问 ResolveModified，都没有时备份后覆盖
+func (b *BaseUpdater) modifiedAction(component string, files []string) string {
+	action := b.Config.Config.ModifiedFileAction
+	if !ValidModifiedAction(action) && b.ResolveModified != nil {
+		action = b.ResolveModified(types.ComponentName(component), files)
+	}
+	if !ValidModifiedAction(action) {
+		return ModifiedBackup
+	}
+	return action
+}
+
+// protectModified 在安装前处理 root 中被用户修改过的万象文件，必须在清理旧文件和安装之前调用。
+// 选择保留时会从暂存目录删除对应文件，并返回这些文件在更新包中的版本，供 writeManifests 继续登记，
+// 这样下次更新时它们仍会被识别为本地修改。选择备份时新建的 .local 文件登记在 txn 中，更新失败回滚时一并删除。
+func (b *BaseUpdater) protectModified(txn *Transaction, component, root, staging string, progress types.ProgressFunc) ([]types.ManifestFile, error) {
+	progress("正在检查本地修改的文件...", 0.67, "", "", 0, 0, 0, false)
+	files, err := b.detectModified(component, root, staging)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+
+	switch b.modifiedAction(component, files) {
+	case ModifiedOverwrite:
+		progress(fmt.Sprintf("将覆盖本地修改的文件: %d", len(files)), 0.67, "", "", 0, 0, 0, false)
+		return nil, nil
+
+	case ModifiedKeep:
+		upstream, err := buildManifest(component, "", staging, "", files, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range files {
+			if err := os.Remove(filepath.Join(staging, filepath.FromSlash(name))); err != nil {
+				return nil, fmt.Errorf("跳过文件 %s 失败: %w", name, err)
+			}
+		}
+		progress(fmt.Sprintf("已保留本地修改的文件: %d", len(files)), 0.67, "", "", 0, 0, 0, false)
+		return upstream.Files, nil
+
+	default:
+		for _, name := range files {
+			localPath := filepath.Join(root, filepath.FromSlash(name))
+			backup := modifiedBackupPath(localPath)
+			if err := txn.Track(backup); err != nil {
+				return nil, err
+			}
+			if err := fileutil.CopyFile(localPath, backup); err != nil {
+				return nil, fmt.Errorf("备份文件 %s 失败: %w", name, err)
+			}
+		}
+		progress(fmt.Sprintf("已备份本地修改的文件 (%s): %d", ModifiedBackupSuffix, len(files)), 0.67, "", "", 0, 0, 0, false)
+		return nil, nil
+	}
+}
+
+// modifiedBackupPath 返回 path 的备份路径，已有备份时依次追加序号，不覆盖之前的备份
+func modifiedBackupPath(path string) string {
+	backup := path + ModifiedBackupSuffix
+	for i := 1; fileutil.FileExists(backup); i++ {
+		backup = fmt.Sprintf("%s%s.%d", path, ModifiedBackupSuffix, i)
+	}
+	return backup
+}
@@ -0,0 +1,186 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// setupModifiedTest 安装三个方案文件后模拟用户修改，并准备新版本的暂存目录：
+// wanxiang.schema.yaml 被用户修改；same.yaml 被改成与新版本相同；a.custom.yaml 受排除规则保护
+func setupModifiedTest(t *testing.T) (base *BaseUpdater, rimeDir, staging string) {
+	t.Helper()
+
+	base, rimeDir = newManifestTestUpdater(t)
+	base.Config.Config.ExcludeFiles = []string{"*.custom.yaml"}
+	names := []string{"wanxiang.schema.yaml", "same.yaml", "a.custom.yaml"}
+	installTestScheme(t, base, rimeDir, names...)
+
+	staging = t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(staging, name), []byte("upstream "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{
+		"wanxiang.schema.yaml": "user",
+		"same.yaml":            "upstream same.yaml",
+		"a.custom.yaml":        "user",
+	} {
+		if err := os.WriteFile(filepath.Join(rimeDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base, rimeDir, staging
+}
+
+func newModifiedTestTransaction(t *testing.T, base *BaseUpdater) *Transaction {
+	t.Helper()
+	txn, err := NewTransaction(base.Config.CacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txn
+}
+
+func TestDetectModified(t *testing.T) {
+	base, rimeDir, staging := setupModifiedTest(t)
+
+	got, err := base.detectModified(types.ComponentScheme, rimeDir, staging)
+	if err != nil {
+		t.Fatalf("detectModified() error = %v", err)
+	}
+	if want := []string{"wanxiang.schema.yaml"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("detectModified() = %v, want %v", got, want)
+	}
+}
+
+func TestProtectModified(t *testing.T) {
+	noProgress := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	tests := []struct {
+		action     string
+		wantStaged bool // 暂存目录中是否仍有新版本（即会被安装）
+		wantBackup bool
+		wantKept   int
+	}{
+		{ModifiedKeep, false, false, 1},
+		{ModifiedOverwrite, true, false, 0},
+		{ModifiedBackup, true, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			base, rimeDir, staging := setupModifiedTest(t)
+			base.Config.Config.ModifiedFileAction = tt.action
+			base.ResolveModified = func(string, []string) string {
+				t.Error("ResolveModified called although the action is remembered in config")
+				return ModifiedOverwrite
+			}
+
+			kept, err := base.protectModified(newModifiedTestTransaction(t, base), types.ComponentScheme, rimeDir, staging, noProgress)
+			if err != nil {
+				t.Fatalf("protectModified() error = %v", err)
+			}
+			if len(kept) != tt.wantKept {
+				t.Errorf("protectModified() kept = %v, want %d entries", kept, tt.wantKept)
+			}
+			if got := fileutil.FileExists(filepath.Join(staging, "wanxiang.schema.yaml")); got != tt.wantStaged {
+				t.Errorf("staged file exists = %v, want %v", got, tt.wantStaged)
+			}
+			if got := fileutil.FileExists(filepath.Join(rimeDir, "wanxiang.schema.yaml.local")); got != tt.wantBackup {
+				t.Errorf("backup exists = %v, want %v", got, tt.wantBackup)
+			}
+		})
+	}
+}
+
+func TestProtectModifiedAsksWithoutRememberedAction(t *testing.T) {
+	base, rimeDir, staging := setupModifiedTest(t)
+	noProgress := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	// 已有的备份不会被覆盖
+	writeTestFile(t, filepath.Join(rimeDir, "wanxiang.schema.yaml.local"))
+
+	var asked []string
+	base.ResolveModified = func(component string, files []string) string {
+		if component != "方案" {
+			t.Errorf("ResolveModified component = %q, want 方案", component)
+		}
+		asked = files
+		return ModifiedBackup
+	}
+	if _, err := base.protectModified(newModifiedTestTransaction(t, base), types.ComponentScheme, rimeDir, staging, noProgress); err != nil {
+		t.Fatalf("protectModified() error = %v", err)
+	}
+	if want := []string{"wanxiang.schema.yaml"}; !reflect.DeepEqual(asked, want) {
+		t.Errorf("ResolveModified files = %v, want %v", asked, want)
+	}
+	data, err := os.ReadFile(filepath.Join(rimeDir, "wanxiang.schema.yaml.local.1"))
+	if err != nil || string(data) != "user" {
+		t.Errorf("second backup = %q, %v, want %q", data, err, "user")
+	}
+}
+
+func TestProtectModifiedBackupRolledBack(t *testing.T) {
+	base, rimeDir, staging := setupModifiedTest(t)
+	base.Config.Config.ModifiedFileAction = ModifiedBackup
+	noProgress := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	txn := newModifiedTestTransaction(t, base)
+	if _, err := base.protectModified(txn, types.ComponentScheme, rimeDir, staging, noProgress); err != nil {
+		t.Fatalf("protectModified() error = %v", err)
+	}
+	backup := filepath.Join(rimeDir, "wanxiang.schema.yaml.local")
+	if !fileutil.FileExists(backup) {
+		t.Fatal("backup was not created")
+	}
+
+	// 更新失败回滚后不留下新建的备份
+	if err := txn.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if fileutil.FileExists(backup) {
+		t.Error("backup still exists after rollback")
+	}
+}
+
+func TestWriteManifestsRecordsKeptFiles(t *testing.T) {
+	base, rimeDir, staging := setupModifiedTest(t)
+	base.Config.Config.ModifiedFileAction = ModifiedKeep
+	noProgress := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	kept, err := base.protectModified(newModifiedTestTransaction(t, base), types.ComponentScheme, rimeDir, staging, noProgress)
+	if err != nil {
+		t.Fatalf("protectModified() error = %v", err)
+	}
+	names, err := listFiles(staging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installed, err := fileutil.InstallStaged(staging, rimeDir, base.Config.Config.ExcludeFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := []installTarget{{engine: "fcitx5", root: rimeDir}}
+	if err := base.writeManifests(types.ComponentScheme, targets, names, installed, "v2", kept...); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+
+	// 保留的文件仍按新版本登记，下次更新时再次被识别为本地修改
+	data, err := os.ReadFile(filepath.Join(rimeDir, "wanxiang.schema.yaml"))
+	if err != nil || string(data) != "user" {
+		t.Fatalf("kept file = %q, %v, want %q", data, err, "user")
+	}
+	manifest, err := LoadManifest(base.Config.GetManifestPath(types.ComponentScheme, "fcitx5"))
+	if err != nil || manifest == nil {
+		t.Fatalf("LoadManifest() = %v, %v", manifest, err)
+	}
+	if entry, ok := manifestIndex(manifest)["wanxiang.schema.yaml"]; !ok || entry.SHA256 != kept[0].SHA256 {
+		t.Errorf("manifest entry = %+v, want upstream hash %s", entry, kept[0].SHA256)
+	}
+}
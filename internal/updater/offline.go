@@ -0,0 +1,425 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// LocalFile 离线导入的本地文件，由 FindLocalFiles 匹配到组件并校验
+type LocalFile struct {
+	Component string    // 组件 ID
+	Path      string    // 本地文件路径
+	Name      string    // 文件名，与配置的 SchemeFile、DictFile 或模型文件一致
+	SHA256    string    // 文件的实际 SHA256
+	Verified  bool      // 已与同目录的离线更新包清单或 SHA256SUMS 核对
+	ModTime   time.Time // 文件的修改时间，没有清单时作为版本记录的更新时间
+	Size      int64
+
+	// Info 离线更新包清单中的版本信息，导入后据此保存版本记录；没有清单时为 nil
+	Info *types.UpdateInfo
+}
+
+// importer 支持离线导入本地文件的组件
+type importer interface {
+	importFiles(files []LocalFile, progress types.ProgressFunc) error
+}
+
+// FindLocalFiles 将本地文件或目录中的文件匹配到组件：文件名等于配置的 SchemeFile、DictFile，或符合要安装的模型文件。
+// 目录只查找第一层，无法识别的文件会被忽略；直接指定的文件无法识别时返回错误。
+// 文件所在目录有离线更新包清单（见 ExportBundle）或 SHA256SUMS 时逐一核对，不一致时返回 ErrChecksumMismatch；
+// 没有可用的 SHA256 且配置要求校验时返回 ErrNoChecksum。
+// 结果按组件的更新顺序排列，不访问网络
+func FindLocalFiles(cfg *config.Manager, paths []string) ([]LocalFile, error) {
+	var files []LocalFile
+	sums := make(map[string]map[string]string)         // 目录 -> 该目录 SHA256SUMS 中的文件名到 SHA256
+	bundles := make(map[string]map[string]BundleAsset) // 目录 -> 该目录离线更新包清单中的文件
+	add := func(path string, explicit bool) error {
+		name := filepath.Base(path)
+		component := localComponent(cfg, name)
+		if component == "" {
+			if explicit {
+				return fmt.Errorf("无法识别的文件: %s（应为 %s、%s 或模型文件）", path, cfg.Config.SchemeFile, cfg.Config.DictFile)
+			}
+			return nil
+		}
+		for _, file := range files {
+			if file.Name == name || (file.Component == component && component != types.ComponentModel) {
+				return fmt.Errorf("找到多个%s文件: %s 和 %s", types.ComponentName(component), file.Path, path)
+			}
+		}
+
+		dir := filepath.Dir(path)
+		if _, ok := sums[dir]; !ok {
+			var err error
+			if sums[dir], err = readLocalChecksums(dir); err != nil {
+				return err
+			}
+			if bundles[dir], err = readBundleManifest(dir); err != nil {
+				return err
+			}
+		}
+		expected := sums[dir][name]
+		asset, inBundle := bundles[dir][name]
+		if inBundle {
+			expected = asset.Info.SHA256
+		}
+		file, err := verifyLocalFile(cfg, path, expected)
+		if err != nil {
+			return err
+		}
+		file.Component = component
+		if inBundle {
+			file.Info = &asset.Info
+		}
+		files = append(files, file)
+		return nil
+	}
+
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取本地文件失败: %w", err)
+		}
+		if !stat.IsDir() {
+			if err := add(path, true); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取目录失败: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				if err := add(filepath.Join(path, entry.Name()), false); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("未找到可导入的文件（应为 %s、%s 或模型文件）", cfg.Config.SchemeFile, cfg.Config.DictFile)
+	}
+	order := types.ComponentIDs()
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Component != files[j].Component {
+			return slices.Index(order, files[i].Component) < slices.Index(order, files[j].Component)
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// localComponent 按文件名判断本地文件属于哪个组件，无法识别时返回空字符串
+func localComponent(cfg *config.Manager, name string) string {
+	switch {
+	case name == cfg.Config.SchemeFile:
+		return types.ComponentScheme
+	case name == cfg.Config.DictFile:
+		return types.ComponentDict
+	case matchModel(cfg.ModelPatterns(), name):
+		return types.ComponentModel
+	}
+	return ""
+}
+
+// readLocalChecksums 读取目录中的 SHA256SUMS 文件，没有时返回 nil
+func readLocalChecksums(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !releaseutil.IsChecksumFile(entry.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取校验和文件失败: %w", err)
+		}
+		if len(data) > maxChecksumFileSize {
+			return nil, fmt.Errorf("校验和文件过大: %s", entry.Name())
+		}
+		return releaseutil.ParseSHA256Sums(data), nil
+	}
+	return nil, nil
+}
+
+// verifyLocalFile 计算本地文件的 SHA256 并与 expected 核对；expected 为空时按 require_checksum 决定是否接受
+func verifyLocalFile(cfg *config.Manager, path, expected string) (LocalFile, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return LocalFile{}, fmt.Errorf("读取本地文件失败: %w", err)
+	}
+	if stat.Size() == 0 {
+		return LocalFile{}, fmt.Errorf("文件为空: %s", path)
+	}
+
+	hash, err := fileutil.CalculateSHA256(path)
+	if err != nil {
+		return LocalFile{}, fmt.Errorf("计算文件校验和失败: %w", err)
+	}
+	name := filepath.Base(path)
+	switch {
+	case expected != "" && hash != expected:
+		return LocalFile{}, fmt.Errorf("%w: %s 期望 %s，实际 %s", ErrChecksumMismatch, path, expected, hash)
+	case expected == "" && cfg.Config.RequireChecksum:
+		return LocalFile{}, fmt.Errorf("%w: %s，请使用离线更新包或将发布中的 SHA256SUMS 放在同一目录（或在配置中关闭 require_checksum）", ErrNoChecksum, name)
+	}
+
+	return LocalFile{
+		Path:     path,
+		Name:     name,
+		SHA256:   hash,
+		Verified: expected != "",
+		ModTime:  stat.ModTime(),
+		Size:     stat.Size(),
+	}, nil
+}
+
+// beginImport 导入前的准备：确认已安装引擎并执行更新前 hook
+func (b *BaseUpdater) beginImport(progress types.ProgressFunc) error {
+	if err := b.EnsureInstalledEngine(); err != nil {
+		return err
+	}
+
+	if b.Config.Config.PreUpdateHook != "" {
+		progress("执行更新前 hook...", 0.02, "", "", 0, 0, 0, false)
+		if err := b.Config.ExecutePreUpdateHook(); err != nil {
+			return fmt.Errorf("pre-update hook 失败，已取消导入: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyImport 将本地文件复制为缓存目录中的临时文件并再次校验哈希，返回临时文件和对应的版本信息。
+// 来自离线更新包的文件使用清单中的版本信息；其他本地文件没有版本号，版本记录以 SHA256 和文件修改时间为准
+func (b *BaseUpdater) copyImport(file LocalFile, dir string) (string, *types.UpdateInfo, error) {
+	tempFile := filepath.Join(dir, "temp_import_"+file.Name)
+	if err := fileutil.CopyFile(file.Path, tempFile); err != nil {
+		return "", nil, fmt.Errorf("复制本地文件失败: %w", err)
+	}
+
+	hash, err := fileutil.CalculateSHA256(tempFile)
+	if err != nil || hash != file.SHA256 {
+		os.Remove(tempFile)
+		return "", nil, fmt.Errorf("本地文件在校验后被修改: %s", file.Path)
+	}
+
+	if file.Info != nil {
+		info := *file.Info
+		info.SHA256 = file.SHA256
+		return tempFile, &info, nil
+	}
+	info := &types.UpdateInfo{
+		Name:       file.Name,
+		UpdateTime: file.ModTime,
+		SHA256:     file.SHA256,
+		Size:       file.Size,
+	}
+	return tempFile, info, nil
+}
+
+// importFiles 从本地更新包安装方案
+func (s *SchemeUpdater) importFiles(files []LocalFile, progress types.ProgressFunc) error {
+	if err := s.beginImport(progress); err != nil {
+		return err
+	}
+
+	progress("正在复制本地文件...", 0.3, "", "", 0, 0, 0, false)
+	tempFile, info, err := s.copyImport(files[0], s.Config.CacheDir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
+	s.UpdateInfo = info
+
+	return s.install(tempFile, filepath.Join(s.Config.CacheDir, s.Config.Config.SchemeFile), progress)
+}
+
+// importFiles 从本地更新包安装词库
+func (d *DictUpdater) importFiles(files []LocalFile, progress types.ProgressFunc) error {
+	if err := d.beginImport(progress); err != nil {
+		return err
+	}
+
+	progress("正在复制本地文件...", 0.3, "", "", 0, 0, 0, false)
+	tempFile, info, err := d.copyImport(files[0], d.Config.CacheDir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
+	d.UpdateInfo = info
+
+	return d.install(tempFile, filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile), progress)
+}
+
+// importFiles 安装本地的模型文件，未导入的已安装模型保持不变
+func (m *ModelUpdater) importFiles(files []LocalFile, progress types.ProgressFunc) error {
+	if err := m.beginImport(progress); err != nil {
+		return err
+	}
+
+	progress("正在复制本地文件...", 0.3, "", "", 0, 0, 0, false)
+	var (
+		downloads []modelDownload
+		infos     []*types.UpdateInfo
+	)
+	defer func() {
+		for _, d := range downloads {
+			os.Remove(d.temp)
+		}
+	}()
+	for _, file := range files {
+		tempFile, info, err := m.copyImport(file, m.Config.CacheDir)
+		if err != nil {
+			return err
+		}
+		downloads = append(downloads, modelDownload{info: info, temp: tempFile})
+		infos = append(infos, info)
+	}
+	m.UpdateInfo = combineModelInfos(infos)
+
+	return m.install(downloads, nil, progress)
+}
+
+// localVersions 返回导入结果中显示的版本：来自离线更新包的文件为版本号，其他本地文件没有版本号，以文件名代替
+func localVersions(files []LocalFile) string {
+	versions := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.Info != nil && file.Info.Tag != "" {
+			if !slices.Contains(versions, file.Info.Tag) {
+				versions = append(versions, file.Info.Tag)
+			}
+			continue
+		}
+		versions = append(versions, file.Name)
+	}
+	return strings.Join(versions, ", ")
+}
+
+// importJob 离线导入中的单个组件
+type importJob struct {
+	comp    Component
+	imp     importer
+	name    string
+	files   []LocalFile
+	history *historyRun
+	err     error
+}
+
+// Import 离线导入 FindLocalFiles 找到的本地文件，不访问网络。与更新相同：整批共享一个事务，任一组件失败时全部恢复；
+// 安装时应用排除规则、处理 CNB 镜像的嵌套目录、同步到其他引擎和 fcitx 目录并保存版本记录，全部成功后重新部署
+func (c *CombinedUpdater) Import(files []LocalFile, progress func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)) (*UpdateResult, error) {
+	result := &UpdateResult{
+		UpdatedComponents: []string{},
+		SkippedComponents: []string{},
+		ComponentVersions: make(map[string]string),
+		PreviousVersions:  make(map[string]string),
+	}
+
+	if progress == nil {
+		progress = func(string, string, float64, string, string, int64, int64, float64, bool) {}
+	}
+
+	if !c.Config.HasInstalledEngine() {
+		return result, fmt.Errorf("未检测到已安装的 Rime 引擎，请先安装并启用 Rime 输入法")
+	}
+
+	var jobs []*importJob
+	for _, comp := range c.components {
+		job := &importJob{comp: comp, name: types.ComponentName(comp.ID())}
+		for _, file := range files {
+			if file.Component == comp.ID() {
+				job.files = append(job.files, file)
+			}
+		}
+		if len(job.files) == 0 {
+			continue
+		}
+		imp, ok := comp.(importer)
+		if !ok {
+			return result, fmt.Errorf("%s不支持离线导入", job.name)
+		}
+		job.imp = imp
+		jobs = append(jobs, job)
+		result.PreviousVersions[job.name] = c.InstalledVersion(comp.ID())
+	}
+	if len(jobs) == 0 {
+		return result, fmt.Errorf("未找到可导入的文件")
+	}
+
+	// 统一在开始前终止进程（只终止一次）
+	progress("准备", "正在终止相关进程...", 0.0, "", "", 0, 0, 0, false)
+	if err := c.base.TerminateProcesses(); err != nil {
+		return result, fmt.Errorf("终止进程失败: %w", err)
+	}
+
+	txn, err := NewTransaction(c.Config.CacheDir)
+	if err != nil {
+		return result, err
+	}
+	c.setBatchMode(true, txn)
+	defer c.setBatchMode(false, nil)
+
+	// 按注册顺序安装，安装共占 90%
+	var errors []string
+	var cause error
+	span := 0.9 / float64(len(jobs))
+	for i, job := range jobs {
+		from := float64(i) * span
+		base := job.comp.Base()
+		progress(job.name, fmt.Sprintf("正在导入%s...", job.name), from, "", "", 0, 0, 0, false)
+		job.history = base.beginHistory(job.comp.ID(), HistoryImport, job.comp.RecordPath(), job.comp.FileName())
+		job.err = job.imp.importFiles(job.files, func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+			progress(job.name, message, from+percent*span, source, fileName, downloaded, total, speed, downloadMode)
+		})
+		base.endHistory(job.history, base.UpdateInfo, job.err)
+		if job.err != nil {
+			errors = append(errors, fmt.Sprintf("%s导入失败: %v", job.name, job.err))
+			cause = job.err
+			break
+		}
+		result.UpdatedComponents = append(result.UpdatedComponents, job.name)
+		result.ComponentVersions[job.name] = localVersions(job.files)
+	}
+
+	if len(errors) > 0 {
+		progress("回滚", "正在恢复导入前的文件...", 0.85, "", "", 0, 0, 0, false)
+		if err := txn.Rollback(); err != nil {
+			errors = append(errors, err.Error())
+		}
+		result.UpdatedComponents = []string{}
+	} else {
+		txn.Commit()
+	}
+	c.flushHistory(len(errors) > 0)
+
+	if len(errors) == 0 {
+		errors = append(errors, c.deployEngines(progress)...)
+	} else {
+		// 即使有错误，也尝试重启服务，让用户能继续使用输入法
+		progress("恢复", "尝试重启服务...", 0.90, "", "", 0, 0, 0, false)
+		_ = c.Deploy() // 忽略错误
+	}
+
+	if len(errors) > 0 {
+		return result, &batchError{message: fmt.Sprintf("导入过程中出现错误: %v", errors), cause: cause}
+	}
+
+	progress("完成", "导入完成", 1.0, "", "", 0, 0, 0, false)
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// writeChecksums 在 dir 中写入 SHA256SUMS，包含 files 中各文件的实际 SHA256
+func writeChecksums(t *testing.T, dir string, files ...string) {
+	t.Helper()
+
+	var sums string
+	for _, name := range files {
+		hash, err := fileutil.CalculateSHA256(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sums += fmt.Sprintf("%s  %s\n", hash, name)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SHA256SUMS"), []byte(sums), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindLocalFiles(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	base.Config.Config.DictFile = "dicts.zip"
+	dir := t.TempDir()
+	writeTestZip(t, filepath.Join(dir, "scheme.zip"), schemeKeyFile)
+	writeTestZip(t, filepath.Join(dir, "dicts.zip"), "dicts/base.dict.yaml")
+	for _, name := range []string{types.MODEL_FILE, "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeChecksums(t, dir, "scheme.zip", types.MODEL_FILE)
+
+	files, err := FindLocalFiles(base.Config, []string{dir})
+	if err != nil {
+		t.Fatalf("FindLocalFiles() error = %v", err)
+	}
+	want := []struct {
+		component string
+		name      string
+		verified  bool
+	}{
+		{types.ComponentScheme, "scheme.zip", true},
+		{types.ComponentDict, "dicts.zip", false},
+		{types.ComponentModel, types.MODEL_FILE, true},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("FindLocalFiles() = %+v, want %d files", files, len(want))
+	}
+	for i, w := range want {
+		if files[i].Component != w.component || files[i].Name != w.name || files[i].Verified != w.verified {
+			t.Errorf("files[%d] = %s %s verified=%v, want %s %s verified=%v",
+				i, files[i].Component, files[i].Name, files[i].Verified, w.component, w.name, w.verified)
+		}
+	}
+
+	if _, err := FindLocalFiles(base.Config, []string{filepath.Join(dir, "notes.txt")}); err == nil {
+		t.Error("FindLocalFiles(notes.txt) error = nil, want unrecognized file")
+	}
+	if _, err := FindLocalFiles(base.Config, []string{dir, filepath.Join(dir, "scheme.zip")}); err == nil {
+		t.Error("FindLocalFiles(duplicate scheme) error = nil, want an error")
+	}
+
+	base.Config.Config.RequireChecksum = true
+	if _, err := FindLocalFiles(base.Config, []string{dir}); !errors.Is(err, ErrNoChecksum) {
+		t.Errorf("FindLocalFiles(require_checksum) error = %v, want %v", err, ErrNoChecksum)
+	}
+	base.Config.Config.RequireChecksum = false
+
+	// 校验后文件被替换
+	writeTestZip(t, filepath.Join(dir, "scheme.zip"), schemeKeyFile, "changed.yaml")
+	if _, err := FindLocalFiles(base.Config, []string{dir}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("FindLocalFiles(modified scheme) error = %v, want %v", err, ErrChecksumMismatch)
+	}
+}
+
+func TestSchemeImportInstallsLocalArchive(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	base.SkipTerminate = true
+	scheme := &SchemeUpdater{BaseUpdater: base}
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "scheme.zip")
+	writeTestZip(t, local, schemeKeyFile, "offline.yaml")
+	files, err := FindLocalFiles(base.Config, []string{local})
+	if err != nil {
+		t.Fatalf("FindLocalFiles() error = %v", err)
+	}
+
+	tempFile, info, err := scheme.copyImport(files[0], base.Config.CacheDir)
+	if err != nil {
+		t.Fatalf("copyImport() error = %v", err)
+	}
+	scheme.UpdateInfo = info
+	if err := scheme.install(tempFile, filepath.Join(base.Config.CacheDir, "scheme.zip"), noop); err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(rimeDir, "offline.yaml")); err != nil {
+		t.Errorf("offline.yaml not installed: %v", err)
+	}
+	if _, err := os.Stat(local); err != nil {
+		t.Errorf("local archive should be kept: %v", err)
+	}
+	if record := base.GetLocalRecord(base.Config.GetSchemeRecordPath()); record == nil || record.SHA256 != files[0].SHA256 {
+		t.Errorf("record = %+v, want SHA256 %s", record, files[0].SHA256)
+	}
+
+	// 复制前本地文件被修改
+	writeTestZip(t, local, schemeKeyFile, "tampered.yaml")
+	if _, _, err := scheme.copyImport(files[0], base.Config.CacheDir); err == nil {
+		t.Error("copyImport(modified file) error = nil, want an error")
+	}
+}
+
+func TestImportUnnestsMirrorArchive(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"离线导入", "", "scheme/a.yaml"},
+		{"来自 CNB", SourceCNB, "scheme/a.yaml"},
+		{"来自 GitHub", SourceGitHub, "scheme/scheme/a.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := newManifestTestUpdater(t)
+			base.Config.Config.UseMirror = false
+			if err := os.MkdirAll(base.Config.RimeDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			archive := filepath.Join(t.TempDir(), "scheme.zip")
+			writeTestZip(t, archive, schemeKeyFile, "scheme/scheme/a.yaml")
+			base.UpdateInfo = &types.UpdateInfo{Name: "scheme.zip", Source: tt.source}
+
+			staging, err := base.stageArchive(archive, "scheme.zip", schemeKeyFile)
+			if err != nil {
+				t.Fatalf("stageArchive() error = %v", err)
+			}
+			defer os.RemoveAll(staging)
+			if _, err := os.Stat(filepath.Join(staging, filepath.FromSlash(tt.want))); err != nil {
+				t.Errorf("staged file %s missing: %v", tt.want, err)
+			}
+		})
+	}
+}
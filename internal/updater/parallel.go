@@ -0,0 +1,288 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// DefaultDownloadConcurrency 未配置 download_concurrency 时组合更新同时下载的文件数量
+const DefaultDownloadConcurrency = 3
+
+// batchProgressFunc 组合更新的进度回调，比 types.ProgressFunc 多一个组件名
+type batchProgressFunc = func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool)
+
+// componentJob 组合更新中的一个组件：先与其他组件并行下载，再按注册顺序安装
+type componentJob struct {
+	name     string  // 组件显示名称，如 "方案"
+	weight   float64 // 安装阶段占整体进度的相对比例，见 ComponentSpec.Weight
+	base     *BaseUpdater
+	fileName string
+	info     func() *types.UpdateInfo
+	prepare  func(context.Context, types.ProgressFunc) error
+	download func(context.Context, types.ProgressFunc) (string, error)
+	apply    func(string, types.ProgressFunc) error
+	begin    func() *historyRun
+
+	history  *historyRun // 开始准备后才记录更新历史
+	tempFile string      // 下载得到的临时文件，为空表示本地文件已是最新版本
+	err      error
+}
+
+// downloadConcurrency 返回组合更新同时下载的文件数量上限
+func (c *CombinedUpdater) downloadConcurrency() int {
+	if n := c.Config.Config.DownloadConcurrency; n > 0 {
+		return n
+	}
+	return DefaultDownloadConcurrency
+}
+
+// newComponentJobs 按 components 的顺序为需要更新的组件创建任务
+func newComponentJobs(components []Component) []*componentJob {
+	jobs := make([]*componentJob, 0, len(components))
+	for _, comp := range components {
+		comp, base := comp, comp.Base()
+		spec, _ := LookupSpec(comp.ID())
+		jobs = append(jobs, &componentJob{
+			name:     types.ComponentName(comp.ID()),
+			weight:   spec.Weight,
+			base:     base,
+			fileName: comp.FileName(),
+			info:     func() *types.UpdateInfo { return base.UpdateInfo },
+			prepare: func(ctx context.Context, progress types.ProgressFunc) error {
+				return prepareComponent(ctx, comp, progress)
+			},
+			download: func(ctx context.Context, progress types.ProgressFunc) (string, error) {
+				return downloadWithFailover(ctx, comp, progress, comp.Download)
+			},
+			apply: comp.Apply,
+			begin: func() *historyRun {
+				return base.beginHistory(comp.ID(), HistoryUpdate, comp.RecordPath(), comp.FileName())
+			},
+		})
+	}
+	return jobs
+}
+
+// installSpans 按组件权重把安装阶段的进度 total 分给各任务
+func installSpans(jobs []*componentJob, total float64) []float64 {
+	weights := make([]float64, len(jobs))
+	for i, job := range jobs {
+		weights[i] = job.weight
+	}
+	return weightedSpans(weights, total)
+}
+
+// weightedSpans 按权重比例分配 total；权重之和不大于 0 时平均分配
+func weightedSpans(weights []float64, total float64) []float64 {
+	spans := make([]float64, len(weights))
+	var sum float64
+	for _, w := range weights {
+		if w > 0 {
+			sum += w
+		}
+	}
+	for i, w := range weights {
+		switch {
+		case sum <= 0:
+			spans[i] = total / float64(len(weights))
+		case w > 0:
+			spans[i] = total * w / sum
+		}
+	}
+	return spans
+}
+
+// downloadAll 最多同时运行 limit 个下载，等待全部结束；各任务的结果写入 job.tempFile 和 job.err。
+// ctx 取消后尚未开始的下载不再启动，job.err 为 ctx.Err()
+func downloadAll(ctx context.Context, jobs []*componentJob, limit int, source string, from, to float64, progress batchProgressFunc) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	agg := newDownloadProgress(jobs, source, from, to, progress)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job *componentJob) {
+			defer wg.Done()
+			defer agg.finish(i)
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+			if err := ctx.Err(); err != nil {
+				job.err = err
+				return
+			}
+
+			job.tempFile, job.err = job.download(ctx, func(message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+				if downloadMode {
+					agg.update(i, source, fileName, downloaded, total, speed)
+					return
+				}
+				progress(job.name, message, agg.percent(), source, fileName, 0, 0, 0, false)
+			})
+		}(i, job)
+	}
+
+	wg.Wait()
+}
+
+// downloadProgress 汇总并行下载中各文件的进度，按总字节数换算为整体进度
+type downloadProgress struct {
+	mu       sync.Mutex
+	files    []fileProgress
+	source   string
+	from, to float64 // 下载阶段在整体进度中的范围
+	report   batchProgressFunc
+}
+
+// fileProgress 单个任务的下载进度；一个任务可能依次下载多个文件（如多个模型），
+// 已下载完的文件计入 done，total 不小于下载开始前的占位值
+type fileProgress struct {
+	name       string
+	downloaded int64
+	total      int64
+	speed      float64
+	active     bool
+
+	source     string // 实际提供文件的下载源，主下载源失败时为备用下载源
+	asset      string // 正在下载的文件
+	assetTotal int64  // 正在下载的文件的大小
+	done       int64  // 之前下载完的文件的字节数
+}
+
+func newDownloadProgress(jobs []*componentJob, source string, from, to float64, report batchProgressFunc) *downloadProgress {
+	p := &downloadProgress{source: source, from: from, to: to, report: report}
+	for _, job := range jobs {
+		// 下载开始前先用发布信息中的大小占位，避免后开始的文件让整体进度倒退
+		var size int64
+		if info := job.info(); info != nil {
+			size = info.Size
+		}
+		p.files = append(p.files, fileProgress{name: job.fileName, total: size})
+	}
+	return p
+}
+
+// update 记录第 i 个任务中从 source 下载的文件 asset 的进度并报告汇总结果
+func (p *downloadProgress) update(i int, source, asset string, downloaded, total int64, speed float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f := &p.files[i]
+	if source != "" {
+		f.source = source
+	}
+	if asset != f.asset {
+		// 开始下载任务中的下一个文件
+		if f.asset != "" {
+			f.done += f.assetTotal
+		}
+		f.asset, f.assetTotal = asset, 0
+	}
+	if total > 0 {
+		f.assetTotal = total
+	}
+	f.downloaded = f.done + downloaded
+	if sum := f.done + f.assetTotal; sum > f.total {
+		f.total = sum
+	}
+	f.speed = speed
+	f.active = true
+	p.emit()
+}
+
+// finish 标记第 i 个文件已结束（完成、跳过或失败），之后按已下载完计算
+func (p *downloadProgress) finish(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f := &p.files[i]
+	if f.total > 0 {
+		f.downloaded = f.total
+	}
+	f.speed = 0
+	f.active = false
+}
+
+// percent 返回当前的整体进度
+func (p *downloadProgress) percent() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	downloaded, total, _, _ := p.sum()
+	return p.scale(downloaded, total)
+}
+
+func (p *downloadProgress) emit() {
+	downloaded, total, speed, names := p.sum()
+	source := p.source
+	if sources := p.sources(); len(sources) > 0 {
+		source = strings.Join(sources, ", ")
+	}
+	downloadedMB := float64(downloaded) / 1024 / 1024
+	var msg string
+	if total > 0 {
+		totalMB := float64(total) / 1024 / 1024
+		msg = fmt.Sprintf("下载中: %.2f MB / %.2f MB (%.2f MB/s)", downloadedMB, totalMB, speed)
+	} else {
+		msg = fmt.Sprintf("下载中: %.2f MB (%.2f MB/s)", downloadedMB, speed)
+	}
+	p.report("下载", msg, p.scale(downloaded, total), source, strings.Join(names, ", "), downloaded, total, speed, true)
+}
+
+// sources 返回正在下载的文件实际使用的下载源，去掉重复；调用方须持有锁
+func (p *downloadProgress) sources() []string {
+	var sources []string
+	for _, f := range p.files {
+		if f.active && f.source != "" && !slices.Contains(sources, f.source) {
+			sources = append(sources, f.source)
+		}
+	}
+	return sources
+}
+
+// sum 汇总所有文件的字节数和正在下载的文件的速度、文件名；调用方须持有锁
+func (p *downloadProgress) sum() (downloaded, total int64, speed float64, names []string) {
+	for _, f := range p.files {
+		downloaded += f.downloaded
+		total += f.total
+		if f.active {
+			speed += f.speed
+			names = append(names, f.name)
+		}
+	}
+	return downloaded, total, speed, names
+}
+
+func (p *downloadProgress) scale(downloaded, total int64) float64 {
+	if total <= 0 {
+		return p.from
+	}
+	ratio := float64(downloaded) / float64(total)
+	if ratio > 1 {
+		ratio = 1
+	}
+	return p.from + ratio*(p.to-p.from)
+}
+
+// installRatio 将单组件更新中安装步骤的进度（约 0.65–1.0）换算为 0–1
+func installRatio(percent float64) float64 {
+	ratio := (percent - 0.65) / 0.35
+	if ratio < 0 {
+		return 0
+	}
+	if ratio > 1 {
+		return 1
+	}
+	return ratio
+}
@@ -0,0 +1,203 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestDownloadConcurrency(t *testing.T) {
+	tests := []struct {
+		configured int
+		want       int
+	}{
+		{0, DefaultDownloadConcurrency},
+		{-1, DefaultDownloadConcurrency},
+		{1, 1},
+		{5, 5},
+	}
+
+	for _, tt := range tests {
+		c := &CombinedUpdater{Config: &config.Manager{Config: &types.Config{DownloadConcurrency: tt.configured}}}
+		if got := c.downloadConcurrency(); got != tt.want {
+			t.Errorf("downloadConcurrency() with %d = %d, want %d", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadAllRespectsConcurrencyLimit(t *testing.T) {
+	var running, peak int32
+	var jobs []*componentJob
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("file-%d", i)
+		jobs = append(jobs, &componentJob{
+			name: name,
+			info: func() *types.UpdateInfo { return nil },
+			download: func(context.Context, types.ProgressFunc) (string, error) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return name + ".tmp", nil
+			},
+		})
+	}
+
+	downloadAll(context.Background(), jobs, 2, "GitHub", 0.05, 0.60, func(string, string, float64, string, string, int64, int64, float64, bool) {})
+
+	if peak > 2 {
+		t.Errorf("peak concurrent downloads = %d, want <= 2", peak)
+	}
+	for _, job := range jobs {
+		if job.tempFile != job.name+".tmp" || job.err != nil {
+			t.Errorf("job %s = (%q, %v), want (%q, nil)", job.name, job.tempFile, job.err, job.name+".tmp")
+		}
+	}
+}
+
+func TestDownloadAllKeepsEachJobError(t *testing.T) {
+	failure := fmt.Errorf("boom")
+	jobs := []*componentJob{
+		{name: "方案", info: func() *types.UpdateInfo { return nil }, download: func(context.Context, types.ProgressFunc) (string, error) { return "scheme.tmp", nil }},
+		{name: "词库", info: func() *types.UpdateInfo { return nil }, download: func(context.Context, types.ProgressFunc) (string, error) { return "", failure }},
+	}
+
+	downloadAll(context.Background(), jobs, 0, "GitHub", 0.05, 0.60, func(string, string, float64, string, string, int64, int64, float64, bool) {})
+
+	if jobs[0].err != nil || jobs[0].tempFile != "scheme.tmp" {
+		t.Errorf("scheme job = (%q, %v), want (\"scheme.tmp\", nil)", jobs[0].tempFile, jobs[0].err)
+	}
+	if jobs[1].err != failure {
+		t.Errorf("dict job err = %v, want %v", jobs[1].err, failure)
+	}
+}
+
+func TestDownloadAllStopsStartingJobsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started int32
+	download := func(ctx context.Context, _ types.ProgressFunc) (string, error) {
+		atomic.AddInt32(&started, 1)
+		cancel()
+		return "", ctx.Err()
+	}
+	jobs := []*componentJob{
+		{name: "方案", info: func() *types.UpdateInfo { return nil }, download: download},
+		{name: "词库", info: func() *types.UpdateInfo { return nil }, download: download},
+		{name: "模型", info: func() *types.UpdateInfo { return nil }, download: download},
+	}
+
+	downloadAll(ctx, jobs, 1, "GitHub", 0.05, 0.60, func(string, string, float64, string, string, int64, int64, float64, bool) {})
+
+	if started != 1 {
+		t.Errorf("started downloads = %d, want 1", started)
+	}
+	for _, job := range jobs {
+		if job.err != context.Canceled {
+			t.Errorf("job %s err = %v, want %v", job.name, job.err, context.Canceled)
+		}
+	}
+}
+
+func TestDownloadProgressAggregatesFiles(t *testing.T) {
+	type report struct {
+		component  string
+		percent    float64
+		source     string
+		fileName   string
+		downloaded int64
+		total      int64
+		speed      float64
+	}
+	var mu sync.Mutex
+	var reports []report
+	jobs := []*componentJob{
+		{fileName: "scheme.zip", info: func() *types.UpdateInfo { return &types.UpdateInfo{Size: 100} }},
+		{fileName: "model.gram", info: func() *types.UpdateInfo { return &types.UpdateInfo{Size: 300} }},
+	}
+	p := newDownloadProgress(jobs, "GitHub", 0.2, 0.6, func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, report{component, percent, source, fileName, downloaded, total, speed})
+	})
+
+	p.update(0, "GitHub", "scheme.zip", 50, 100, 1.5)
+	p.update(1, "CNB 镜像", "model.gram", 150, 300, 2)
+	p.finish(0)
+	p.update(1, "CNB 镜像", "model.gram", 200, 300, 2.5)
+
+	want := []report{
+		{"下载", 0.2 + 0.4*50/400, "GitHub", "scheme.zip", 50, 400, 1.5},
+		{"下载", 0.2 + 0.4*200/400, "GitHub, CNB 镜像", "scheme.zip, model.gram", 200, 400, 3.5},
+		{"下载", 0.2 + 0.4*300/400, "CNB 镜像", "model.gram", 300, 400, 2.5},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("reports = %d, want %d", len(reports), len(want))
+	}
+	for i, got := range reports {
+		w := want[i]
+		if got.component != w.component || got.source != w.source || got.fileName != w.fileName || got.downloaded != w.downloaded ||
+			got.total != w.total || got.speed != w.speed || math.Abs(got.percent-w.percent) > 1e-9 {
+			t.Errorf("report[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+
+	if got := p.percent(); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("percent() = %v, want 0.5", got)
+	}
+}
+
+func TestDownloadProgressMultipleFilesInJob(t *testing.T) {
+	var percents []float64
+	var totals []int64
+	jobs := []*componentJob{
+		{fileName: "a.gram", info: func() *types.UpdateInfo { return &types.UpdateInfo{Size: 400} }},
+	}
+	p := newDownloadProgress(jobs, "GitHub", 0, 1, func(component, message string, percent float64, source string, fileName string, downloaded int64, total int64, speed float64, downloadMode bool) {
+		percents = append(percents, percent)
+		totals = append(totals, total)
+	})
+
+	// 一个任务依次下载两个模型，汇总大小为占位的 400
+	p.update(0, "", "a.gram", 100, 100, 1)
+	p.update(0, "", "b.gram", 0, 300, 1)
+	p.update(0, "", "b.gram", 150, 300, 1)
+	p.update(0, "", "b.gram", 300, 300, 1)
+
+	want := []float64{0.25, 0.25, 0.625, 1}
+	for i := range want {
+		if math.Abs(percents[i]-want[i]) > 1e-9 || totals[i] != 400 {
+			t.Errorf("report[%d] = %v of %d, want %v of 400", i, percents[i], totals[i], want[i])
+		}
+	}
+}
+
+func TestInstallRatio(t *testing.T) {
+	tests := []struct {
+		percent float64
+		want    float64
+	}{
+		{0.1, 0},
+		{0.65, 0},
+		{0.825, 0.5},
+		{1.0, 1},
+		{1.2, 1},
+	}
+
+	for _, tt := range tests {
+		if got := installRatio(tt.percent); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("installRatio(%v) = %v, want %v", tt.percent, got, tt.want)
+		}
+	}
+}
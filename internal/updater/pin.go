@@ -0,0 +1,154 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// ErrPinNotFound 找不到固定的版本
+var ErrPinNotFound = errors.New("找不到固定的版本")
+
+// Pin 返回组件固定的版本（Tag、资源 ID 或 SHA256），未固定时返回空字符串
+func (b *BaseUpdater) Pin(component string) string {
+	return strings.TrimSpace(b.Config.Config.PinnedVersions[component])
+}
+
+// InstalledRecord 返回组件本地的更新记录，不访问网络；没有记录或记录属于之前选择的方案时返回 nil
+func InstalledRecord(cfg *config.Manager, componentID string) *types.UpdateRecord {
+	var recordPath, file string
+	switch componentID {
+	case types.ComponentScheme:
+		recordPath, file = cfg.GetSchemeRecordPath(), cfg.Config.SchemeFile
+	case types.ComponentDict:
+		recordPath, file = cfg.GetDictRecordPath(), cfg.Config.DictFile
+	case types.ComponentModel:
+		file = NewModelUpdater(cfg).primaryModel()
+		recordPath = cfg.GetModelFileRecordPath(file)
+	default:
+		return nil
+	}
+
+	record := (&BaseUpdater{Config: cfg}).GetLocalRecord(recordPath)
+	if record == nil || record.Name != file {
+		return nil
+	}
+	return record
+}
+
+// pinMatches 判断 pin 是否指向 tag、资源 ID 为 id、SHA256 为 sha 的版本
+func pinMatches(pin, tag, id, sha string) bool {
+	if pin == "" {
+		return false
+	}
+	return pin == tag || (id != "" && pin == id) || (sha != "" && strings.EqualFold(pin, sha))
+}
+
+// PinForRecord 返回固定 record 对应版本时使用的值：优先使用 Tag，Tag 会被复用（如 dict-nightly）时使用 SHA256
+func PinForRecord(record *types.UpdateRecord) string {
+	if record == nil {
+		return ""
+	}
+	if !releaseutil.IsRollingTag(record.Tag) {
+		return record.Tag
+	}
+	if record.SHA256 != "" {
+		return record.SHA256
+	}
+	return record.CnbID
+}
+
+// checkPinned 按固定的版本检查更新；未固定时直接返回 latest 的结果。
+// 本地已安装固定的版本时不访问网络，直接返回本地记录；否则依次在最新版本和 find 返回的版本中查找。
+func (b *BaseUpdater) checkPinned(
+	component string,
+	recordPath string,
+	file string,
+	latest func() (*types.UpdateInfo, error),
+	find func(pin string) (*types.UpdateInfo, bool, error),
+) (*types.UpdateInfo, error) {
+	pin := b.Pin(component)
+	if pin == "" {
+		return latest()
+	}
+
+	if record := b.GetLocalRecord(recordPath); record != nil && record.Name == file &&
+		pinMatches(pin, record.Tag, record.CnbID, record.SHA256) {
+		return &types.UpdateInfo{
+			Name:       record.Name,
+			UpdateTime: record.UpdateTime,
+			Tag:        record.Tag,
+			SHA256:     record.SHA256,
+			ID:         record.CnbID,
+		}, nil
+	}
+
+	info, err := latest()
+	if err == nil && pinMatches(pin, info.Tag, info.ID, info.SHA256) {
+		return info, nil
+	}
+
+	info, ok, findErr := find(pin)
+	if findErr != nil {
+		return nil, fmt.Errorf("获取版本信息失败: %w", findErr)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPinNotFound, pin)
+	}
+	return info, nil
+}
+
+// findPinnedRelease 在 GitHub 仓库 repo 的发布列表（使用镜像时为 CNB 中 Tag 为 pin 的发布）中查找 pin 指向的 file
+func (b *BaseUpdater) findPinnedRelease(ctx context.Context, repo, file, pin string) (*types.UpdateInfo, bool, error) {
+	var releases []types.GitHubRelease
+	if b.usingMirror() {
+		release, err := b.APIClient.FetchCNBReleaseByTag(ctx, types.OWNER, types.CNB_REPO, pin)
+		if err != nil {
+			return nil, false, nil // 没有以 pin 为 Tag 的发布
+		}
+		releases = []types.GitHubRelease{*release}
+	} else {
+		var err error
+		releases, err = b.APIClient.FetchGitHubReleases(ctx, types.OWNER, repo, "")
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for _, release := range releases {
+		for _, asset := range release.Assets {
+			if asset.Name != file || !pinMatches(pin, release.TagName, asset.ID, asset.SHA256) {
+				continue
+			}
+
+			return releaseutil.AssetInfo(release, asset), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// applyPin 在固定了版本的组件状态中注明固定的版本；本地不是固定的版本时标记为需要更新
+func (b *BaseUpdater) applyPin(component, recordPath, file string, status *types.UpdateStatus) {
+	pin := b.Pin(component)
+	if pin == "" {
+		return
+	}
+
+	status.Pinned = pin
+	record := b.GetLocalRecord(recordPath)
+	installed := record != nil && record.Name == file && pinMatches(pin, record.Tag, record.CnbID, record.SHA256)
+	if !installed {
+		status.NeedsUpdate = true
+	}
+
+	if status.NeedsUpdate {
+		status.Message = fmt.Sprintf("将安装固定的版本: %s", status.RemoteVersion)
+	} else {
+		status.Message = fmt.Sprintf("已固定版本: %s", pin)
+	}
+}
@@ -0,0 +1,118 @@
+package updater
+
+import (
+	"errors"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestPinMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		pin  string
+		want bool
+	}{
+		{"tag", "v1.2.0", true},
+		{"asset id", "12345", true},
+		{"sha256 ignores case", "ABCDEF0123", true},
+		{"other tag", "v1.1.0", false},
+		{"sha256 prefix", "abcdef", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pinMatches(tt.pin, "v1.2.0", "12345", "abcdef0123"); got != tt.want {
+				t.Errorf("pinMatches(%q) = %v, want %v", tt.pin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinForRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record *types.UpdateRecord
+		want   string
+	}{
+		{"versioned tag", &types.UpdateRecord{Tag: "v1.2.0", SHA256: "abc"}, "v1.2.0"},
+		{"rolling dict tag", &types.UpdateRecord{Tag: types.DICT_TAG, SHA256: "abc"}, "abc"},
+		{"rolling model tag without hash", &types.UpdateRecord{Tag: types.MODEL_TAG, CnbID: "42"}, "42"},
+		{"no record", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PinForRecord(tt.record); got != tt.want {
+				t.Errorf("PinForRecord() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPinned(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	recordPath := base.Config.GetSchemeRecordPath()
+	writeTestRecord(t, base, recordPath, "scheme.zip", "v1")
+
+	latestCalls := 0
+	latest := func() (*types.UpdateInfo, error) {
+		latestCalls++
+		return &types.UpdateInfo{Tag: "v3", URL: "latest"}, nil
+	}
+	find := func(pin string) (*types.UpdateInfo, bool, error) {
+		if pin == "v2" {
+			return &types.UpdateInfo{Tag: "v2", URL: "pinned"}, true, nil
+		}
+		return nil, false, nil
+	}
+	check := func(pin string) (*types.UpdateInfo, error) {
+		base.Config.Config.PinnedVersions = map[string]string{types.ComponentScheme: pin}
+		return base.checkPinned(types.ComponentScheme, recordPath, "scheme.zip", latest, find)
+	}
+
+	if info, err := check(""); err != nil || info.Tag != "v3" {
+		t.Errorf("unpinned checkPinned() = %+v, %v, want latest v3", info, err)
+	}
+
+	latestCalls = 0
+	if info, err := check("v1"); err != nil || info.Tag != "v1" || latestCalls != 0 {
+		t.Errorf("pinned to installed checkPinned() = %+v, %v (latest calls %d), want local v1 without network", info, err, latestCalls)
+	}
+	if info, err := check("v3"); err != nil || info.URL != "latest" {
+		t.Errorf("pinned to latest checkPinned() = %+v, %v, want latest", info, err)
+	}
+	if info, err := check("v2"); err != nil || info.URL != "pinned" {
+		t.Errorf("pinned to older checkPinned() = %+v, %v, want found v2", info, err)
+	}
+	if _, err := check("v0"); !errors.Is(err, ErrPinNotFound) {
+		t.Errorf("pinned to missing checkPinned() error = %v, want %v", err, ErrPinNotFound)
+	}
+}
+
+func TestApplyPin(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	recordPath := base.Config.GetSchemeRecordPath()
+	writeTestRecord(t, base, recordPath, "scheme.zip", "v1")
+
+	status := &types.UpdateStatus{LocalVersion: "v1", RemoteVersion: "v1"}
+	base.applyPin(types.ComponentScheme, recordPath, "scheme.zip", status)
+	if status.Pinned != "" || status.Message != "" {
+		t.Errorf("unpinned applyPin() changed status: %+v", status)
+	}
+
+	base.Config.Config.PinnedVersions = map[string]string{types.ComponentScheme: "v1"}
+	base.applyPin(types.ComponentScheme, recordPath, "scheme.zip", status)
+	if status.Pinned != "v1" || status.NeedsUpdate {
+		t.Errorf("applyPin() pinned to installed = %+v, want pinned without update", status)
+	}
+
+	// 模型按时间判断更新，固定到较旧版本时也要标记为需要更新
+	status = &types.UpdateStatus{LocalVersion: "v1", RemoteVersion: "v0"}
+	base.Config.Config.PinnedVersions[types.ComponentScheme] = "v0"
+	base.applyPin(types.ComponentScheme, recordPath, "scheme.zip", status)
+	if !status.NeedsUpdate {
+		t.Errorf("applyPin() pinned to another version = %+v, want needs update", status)
+	}
+}
@@ -0,0 +1,121 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// UpdatePlan 一次更新前解析好的各组件远程版本和更新状态。
+// 由 CombinedUpdater.FetchAllUpdates 创建后只读：判断是否有更新、执行更新和更新预览都使用同一份结果，不再重复请求远程元数据。
+type UpdatePlan struct {
+	entries map[string]PlannedUpdate
+}
+
+// PlannedUpdate 更新计划中的单个组件
+type PlannedUpdate struct {
+	Component   string              // 组件 ID，如 types.ComponentScheme
+	Info        *types.UpdateInfo   // 远程版本信息，获取失败时为 nil
+	Status      *types.UpdateStatus // 更新状态，获取失败时为 nil
+	NeedsUpdate bool                // 组合更新是否需要更新该组件
+	Err         error               // 获取远程版本信息失败的原因
+}
+
+// Get 返回组件的计划；返回的是副本，修改它不会影响计划本身
+func (p *UpdatePlan) Get(component string) (PlannedUpdate, bool) {
+	entry, ok := p.entries[component]
+	if !ok {
+		return PlannedUpdate{}, false
+	}
+	if entry.Info != nil {
+		info := *entry.Info
+		entry.Info = &info
+	}
+	if entry.Status != nil {
+		status := *entry.Status
+		entry.Status = &status
+	}
+	return entry, true
+}
+
+// HasAnyUpdate 判断是否有组件需要更新
+func (p *UpdatePlan) HasAnyUpdate() bool {
+	for _, entry := range p.entries {
+		if entry.NeedsUpdate {
+			return true
+		}
+	}
+	return false
+}
+
+// componentResolver 获取单个组件远程版本和更新状态的方法
+type componentResolver struct {
+	component string
+	check     func(context.Context) (*types.UpdateInfo, error)
+	statusFor func(*types.UpdateInfo) *types.UpdateStatus
+	needs     func(*types.UpdateInfo, *types.UpdateStatus) bool
+}
+
+func (c *CombinedUpdater) resolvers() []componentResolver {
+	resolvers := make([]componentResolver, 0, len(c.components))
+	for _, comp := range c.components {
+		check := func(ctx context.Context) (*types.UpdateInfo, error) {
+			return checkWithFailover(ctx, comp)
+		}
+		resolvers = append(resolvers, componentResolver{comp.ID(), check, comp.StatusFor, comp.NeedsUpdate})
+	}
+	return resolvers
+}
+
+// FetchAllUpdates 并发获取所有组件的远程版本信息并生成更新计划，同一份元数据只请求一次。
+// 部分组件失败时仍返回计划，失败原因记录在对应的 PlannedUpdate.Err 中，同时返回汇总的错误。
+func (c *CombinedUpdater) FetchAllUpdates(ctx context.Context) (*UpdatePlan, error) {
+	resolvers := c.resolvers()
+	if err := c.Config.ReconcileRuntimeState(); err != nil {
+		plan := &UpdatePlan{entries: make(map[string]PlannedUpdate, len(resolvers))}
+		for _, r := range resolvers {
+			plan.entries[r.component] = PlannedUpdate{Component: r.component, Err: err}
+		}
+		return plan, fmt.Errorf("检查更新失败: %w", err)
+	}
+
+	return resolvePlan(ctx, resolvers)
+}
+
+// resolvePlan 并发执行各组件的 check，再依次根据本地文件计算更新状态；ctx 取消时返回的错误包装 ctx.Err()
+func resolvePlan(ctx context.Context, resolvers []componentResolver) (*UpdatePlan, error) {
+	plan := &UpdatePlan{entries: make(map[string]PlannedUpdate, len(resolvers))}
+	infos := make([]*types.UpdateInfo, len(resolvers))
+	errs := make([]error, len(resolvers))
+	var wg sync.WaitGroup
+	for i, r := range resolvers {
+		wg.Add(1)
+		go func(i int, r componentResolver) {
+			defer wg.Done()
+			infos[i], errs[i] = r.check(ctx)
+		}(i, r)
+	}
+	wg.Wait()
+
+	var errors []string
+	for i, r := range resolvers {
+		entry := PlannedUpdate{Component: r.component, Info: infos[i], Err: errs[i]}
+		if entry.Err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", types.ComponentName(r.component), entry.Err))
+		} else {
+			entry.Status = r.statusFor(entry.Info)
+			entry.NeedsUpdate = r.needs(entry.Info, entry.Status)
+		}
+		plan.entries[r.component] = entry
+	}
+
+	if err := ctx.Err(); err != nil {
+		return plan, fmt.Errorf("检查更新失败: %w", err)
+	}
+	if len(errors) > 0 {
+		return plan, fmt.Errorf("检查更新失败: %v", errors)
+	}
+	return plan, nil
+}
@@ -0,0 +1,141 @@
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// resumeState 未完成下载的续传信息，保存在下载文件旁的 .resume 文件中
+type resumeState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func resumeStatePath(dest string) string {
+	return dest + ".resume"
+}
+
+// loadResumeState 返回 dest 中已下载的字节数和用于 If-Range 的校验值；
+// 没有可续传的部分、下载地址已变化或服务器没有提供强校验值时返回 0 和空字符串
+func loadResumeState(dest, url string) (int64, string) {
+	info, err := os.Stat(dest)
+	if err != nil || info.Size() == 0 {
+		return 0, ""
+	}
+
+	data, err := os.ReadFile(resumeStatePath(dest))
+	if err != nil {
+		return 0, ""
+	}
+	var state resumeState
+	if err := json.Unmarshal(data, &state); err != nil || state.URL != url {
+		return 0, ""
+	}
+
+	// If-Range 只接受强 ETag，弱 ETag 时改用 Last-Modified
+	validator := state.ETag
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = state.LastModified
+	}
+	if validator == "" {
+		return 0, ""
+	}
+	return info.Size(), validator
+}
+
+// saveResumeState 记录下载响应的 ETag 和 Last-Modified；两者都没有时无法安全续传，删除旧的续传信息
+func saveResumeState(dest, url string, header http.Header) {
+	state := resumeState{URL: url, ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+	if state.ETag == "" && state.LastModified == "" {
+		clearResumeState(dest)
+		return
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	os.WriteFile(resumeStatePath(dest), data, 0644)
+}
+
+// clearResumeState 删除 dest 的续传信息，下载完成后调用
+func clearResumeState(dest string) {
+	os.Remove(resumeStatePath(dest))
+}
+
+// removePartial 删除下载的文件及其续传信息
+func removePartial(dest string) {
+	os.Remove(dest)
+	clearResumeState(dest)
+}
+
+// parseContentRange 解析 "bytes <start>-<end>/<total>" 或 "bytes */<total>" 形式的 Content-Range，
+// total 未知（"*"）时为 -1；无法解析时 ok 为 false
+func parseContentRange(value string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	total = -1
+	if totalPart != "*" {
+		var err error
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil || total < 0 {
+			return 0, 0, false
+		}
+	}
+
+	if rangePart == "*" {
+		return 0, total, total >= 0
+	}
+	startPart, endPart, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	end, err := strconv.ParseInt(endPart, 10, 64)
+	if err != nil || end < start || (total >= 0 && end >= total) {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// downloadKey 返回标识远程文件内容的短键：优先使用 SHA256，没有时使用下载地址、资源 ID 和更新时间。
+// 同一版本的下载每次得到相同的文件名，中断后可以续传
+func downloadKey(info *types.UpdateInfo) string {
+	if hash := releaseutil.NormalizeSHA256(info.SHA256); hash != "" {
+		return hash[:16]
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{info.URL, info.ID, info.Tag, info.UpdateTime.UTC().String()}, "\n")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// partialDownloadPath 返回 info 在 dir 中的下载路径 <prefix>_<downloadKey><ext>，并删除同一前缀下其他版本遗留的未完成下载
+func partialDownloadPath(dir, prefix, ext string, info *types.UpdateInfo) string {
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s%s", prefix, downloadKey(info), ext))
+	if stale, err := filepath.Glob(filepath.Join(dir, prefix+"_*"+ext)); err == nil {
+		for _, file := range stale {
+			if file != path {
+				removePartial(file)
+			}
+		}
+	}
+	return path
+}
@@ -0,0 +1,221 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStart int64
+		wantTotal int64
+		wantOK    bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"bytes */200", 0, 200, true},
+		{"bytes 100-99/200", 0, 0, false},
+		{"bytes 100-200/200", 0, 0, false},
+		{"bytes */*", 0, -1, false},
+		{"items 0-1/2", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if ok != tt.wantOK || (ok && (start != tt.wantStart || total != tt.wantTotal)) {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v, want %d, %d, %v", tt.value, start, total, ok, tt.wantStart, tt.wantTotal, tt.wantOK)
+		}
+	}
+}
+
+// newResumeTestFile 在 dest 中写入 content 的前一半，并记录续传信息
+func newResumeTestFile(t *testing.T, dest, url string, content []byte, etag string) {
+	t.Helper()
+	if err := os.WriteFile(dest, content[:len(content)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	saveResumeState(dest, url, http.Header{"Etag": []string{etag}})
+}
+
+func TestDownloadFileResumes(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		etag      string // 本地记录的 ETag
+		handler   func(w http.ResponseWriter, r *http.Request)
+		wantRange bool // 是否应从中断处继续
+	}{
+		{
+			name: "same ETag resumes",
+			etag: `"v1"`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				http.ServeContent(w, r, "file", modified, bytes.NewReader(content))
+			},
+			wantRange: true,
+		},
+		{
+			name: "changed ETag restarts",
+			etag: `"v0"`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				http.ServeContent(w, r, "file", modified, bytes.NewReader(content))
+			},
+		},
+		{
+			name: "range ignored restarts",
+			etag: `"v1"`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write(content)
+			},
+		},
+		{
+			name: "mismatched Content-Range restarts",
+			etag: `"v1"`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "" {
+					w.Header().Set("Content-Range", fmt.Sprintf("bytes 10-%d/%d", len(content)-1, len(content)))
+					w.WriteHeader(http.StatusPartialContent)
+					w.Write(content[10:])
+					return
+				}
+				w.Write(content)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranged atomic.Bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "" {
+					if r.Header.Get("If-Range") == "" {
+						t.Errorf("Range sent without If-Range")
+					}
+					ranged.Store(true)
+				}
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			base, _ := newManifestTestUpdater(t)
+			dest := filepath.Join(t.TempDir(), "download.zip")
+			newResumeTestFile(t, dest, server.URL, content, tt.etag)
+
+			if err := base.DownloadFile(context.Background(), server.URL, dest, "download.zip", "test", nil); err != nil {
+				t.Fatalf("DownloadFile() error = %v", err)
+			}
+			if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+				t.Errorf("downloaded %d bytes, want the complete %d bytes", len(got), len(content))
+			}
+			if !ranged.Load() {
+				t.Errorf("DownloadFile() did not send a Range request")
+			}
+			wantTransferred := int64(len(content))
+			if tt.wantRange {
+				wantTransferred -= int64(len(content) / 2)
+			}
+			if base.transferred < wantTransferred || (tt.wantRange && base.transferred != wantTransferred) {
+				t.Errorf("transferred = %d, want %d", base.transferred, wantTransferred)
+			}
+			if _, err := os.Stat(resumeStatePath(dest)); !os.IsNotExist(err) {
+				t.Errorf("resume state kept after a complete download")
+			}
+		})
+	}
+}
+
+func TestDownloadFileWithoutValidatorRestarts(t *testing.T) {
+	content := []byte("complete content")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			t.Errorf("Range = %q, want no range without a validator", r.Header.Get("Range"))
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	base, _ := newManifestTestUpdater(t)
+	dest := filepath.Join(t.TempDir(), "download.zip")
+	if err := os.WriteFile(dest, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := base.DownloadFile(context.Background(), server.URL, dest, "download.zip", "test", nil); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+		t.Errorf("downloaded %q, want %q", got, content)
+	}
+}
+
+func TestDownloadFileCompleteAfterInterruptedCleanup(t *testing.T) {
+	content := []byte("already complete")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(content)))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	}))
+	defer server.Close()
+
+	base, _ := newManifestTestUpdater(t)
+	dest := filepath.Join(t.TempDir(), "download.zip")
+	if err := os.WriteFile(dest, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saveResumeState(dest, server.URL, http.Header{"Etag": []string{`"v1"`}})
+
+	if err := base.DownloadFile(context.Background(), server.URL, dest, "download.zip", "test", nil); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+		t.Errorf("file = %q, want %q", got, content)
+	}
+	if _, err := os.Stat(resumeStatePath(dest)); !os.IsNotExist(err) {
+		t.Errorf("resume state kept after a complete download")
+	}
+}
+
+func TestPartialDownloadPath(t *testing.T) {
+	dir := t.TempDir()
+	info := &types.UpdateInfo{Name: "scheme.zip", URL: "https://example.com/scheme.zip", SHA256: strings.Repeat("ab", 32)}
+
+	path := partialDownloadPath(dir, "temp_scheme", ".zip", info)
+	if want := filepath.Join(dir, "temp_scheme_"+strings.Repeat("ab", 8)+".zip"); path != want {
+		t.Fatalf("partialDownloadPath() = %q, want %q", path, want)
+	}
+	writeTestFile(t, path)
+	if again := partialDownloadPath(dir, "temp_scheme", ".zip", info); again != path {
+		t.Errorf("partialDownloadPath() = %q on the second call, want the stable %q", again, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("partial download of the same version removed: %v", err)
+	}
+
+	stale := filepath.Join(dir, "temp_scheme_1700000000.zip")
+	writeTestFile(t, stale)
+	saveResumeState(stale, info.URL, http.Header{"Etag": []string{`"old"`}})
+	other := &types.UpdateInfo{Name: "scheme.zip", URL: "https://example.com/v2/scheme.zip"}
+	if next := partialDownloadPath(dir, "temp_scheme", ".zip", other); next == path {
+		t.Fatalf("partialDownloadPath() = %q for a different version, want a new name", next)
+	}
+	for _, file := range []string{path, stale, resumeStatePath(stale)} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("%s kept, want stale downloads removed", filepath.Base(file))
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// DefaultKeepVersions 未配置 keep_versions 时每个组件保留的历史版本数量
+const DefaultKeepVersions = 3
+
+// versionInfoFile 历史版本目录中记录版本信息的文件名
+const versionInfoFile = "version.json"
+
+// ErrVersionNotFound 找不到指定的历史版本
+var ErrVersionNotFound = errors.New("找不到指定的历史版本")
+
+// ArchivedVersion 缓存中保留的组件历史版本，安装新版本前由 saveVersion 保存
+type ArchivedVersion struct {
+	ID         string             `json:"id"`
+	Component  string             `json:"component"` // 组件 ID
+	File       string             `json:"file"`      // 更新包（模型为模型文件）的文件名
+	SHA256     string             `json:"sha256"`
+	Record     types.UpdateRecord `json:"record"` // 该版本安装时的更新记录
+	ArchivedAt time.Time          `json:"archived_at"`
+
+	dir string // 历史版本目录
+}
+
+// Path 返回保存的更新包路径
+func (v ArchivedVersion) Path() string {
+	return filepath.Join(v.dir, v.File)
+}
+
+// Label 返回便于展示的版本号：优先使用 Tag，没有时使用 SHA256 前缀
+func (v ArchivedVersion) Label() string {
+	if v.Record.Tag != "" {
+		return v.Record.Tag
+	}
+	return shortHash(v.SHA256)
+}
+
+// Matches 判断 ref 是否指向该版本：完整 ID、Tag 或 SHA256 前缀（至少 7 位）
+func (v ArchivedVersion) Matches(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return false
+	}
+	if ref == v.ID || ref == v.Record.Tag {
+		return true
+	}
+	return len(ref) >= 7 && strings.HasPrefix(v.SHA256, strings.ToLower(ref))
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// keepVersions 返回每个组件保留的历史版本数量，配置为负数时不保留
+func (b *BaseUpdater) keepVersions() int {
+	switch keep := b.Config.Config.KeepVersions; {
+	case keep == 0:
+		return DefaultKeepVersions
+	case keep < 0:
+		return 0
+	default:
+		return keep
+	}
+}
+
+// listVersions 读取组件保存的全部历史版本，按保存时间从新到旧排列；损坏的目录会被忽略
+func (b *BaseUpdater) listVersions(component string) ([]ArchivedVersion, error) {
+	root := b.Config.GetVersionsDir(component)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取历史版本目录失败: %w", err)
+	}
+
+	var versions []ArchivedVersion
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, versionInfoFile))
+		if err != nil {
+			continue
+		}
+		var version ArchivedVersion
+		if err := json.Unmarshal(data, &version); err != nil || version.File != filepath.Base(version.File) {
+			continue
+		}
+		version.dir = dir
+		if fileutil.FileExists(version.Path()) {
+			versions = append(versions, version)
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].ArchivedAt.After(versions[j].ArchivedAt)
+	})
+	return versions, nil
+}
+
+// saveVersion 在安装新版本前保存当前版本：file 为当前的更新包（模型为已安装的模型文件），recordPath 为其更新记录。
+// 保存失败不影响更新，只通过 progress 提示。
+func (b *BaseUpdater) saveVersion(component, file, recordPath string, progress types.ProgressFunc) {
+	if err := b.archiveVersion(component, file, recordPath); err != nil {
+		progress(fmt.Sprintf("保存历史版本失败: %v", err), 0.66, "", "", 0, 0, 0, false)
+	}
+}
+
+func (b *BaseUpdater) archiveVersion(component, file, recordPath string) error {
+	keep := b.keepVersions()
+	if keep == 0 {
+		return nil
+	}
+
+	// 更新记录与文件不对应（如切换了方案）时无法确定版本，不保存
+	record := b.GetLocalRecord(recordPath)
+	if record == nil || record.Name != filepath.Base(file) || !fileutil.FileExists(file) {
+		return nil
+	}
+
+	hash, err := fileutil.CalculateSHA256(file)
+	if err != nil {
+		return err
+	}
+	versions, err := b.listVersions(component)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if version.SHA256 == hash {
+			return nil
+		}
+	}
+
+	now := time.Now()
+	version := ArchivedVersion{
+		ID:         now.Format("20060102-150405") + "-" + hash[:8],
+		Component:  component,
+		File:       filepath.Base(file),
+		SHA256:     hash,
+		Record:     *record,
+		ArchivedAt: now,
+	}
+	version.dir = filepath.Join(b.Config.GetVersionsDir(component), version.ID)
+	if err := os.MkdirAll(version.dir, 0755); err != nil {
+		return fmt.Errorf("创建历史版本目录失败: %w", err)
+	}
+	if err := fileutil.CopyFile(file, version.Path()); err != nil {
+		os.RemoveAll(version.dir)
+		return fmt.Errorf("复制文件失败: %w", err)
+	}
+	data, err := json.MarshalIndent(version, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(version.dir, versionInfoFile), data, 0644)
+	}
+	if err != nil {
+		os.RemoveAll(version.dir)
+		return fmt.Errorf("写入版本信息失败: %w", err)
+	}
+
+	return b.pruneVersions(component, keep)
+}
+
+// pruneVersions 只保留最新的 keep 个历史版本
+func (b *BaseUpdater) pruneVersions(component string, keep int) error {
+	versions, err := b.listVersions(component)
+	if err != nil || len(versions) <= keep {
+		return err
+	}
+	for _, version := range versions[keep:] {
+		if err := os.RemoveAll(version.dir); err != nil {
+			return fmt.Errorf("删除旧的历史版本失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// availableVersions 返回可回滚到的历史版本：只包含文件名为 file 的版本，并排除与 current 内容相同的版本
+func (b *BaseUpdater) availableVersions(component, file, current string) ([]ArchivedVersion, error) {
+	versions, err := b.listVersions(component)
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := ""
+	if fileutil.FileExists(current) {
+		currentHash, _ = fileutil.CalculateSHA256(current)
+	}
+
+	available := versions[:0]
+	for _, version := range versions {
+		if version.File == file && version.SHA256 != currentHash {
+			available = append(available, version)
+		}
+	}
+	return available, nil
+}
+
+// prepareRollback 回滚前的准备：执行更新前 hook，将历史版本复制为临时文件并校验。
+// 返回的临时文件交给 install 安装（安装成功后会被移走），返回的版本信息须设为 UpdateInfo。
+func (b *BaseUpdater) prepareRollback(version ArchivedVersion, file string, progress types.ProgressFunc) (string, *types.UpdateInfo, error) {
+	if version.File != file {
+		return "", nil, fmt.Errorf("历史版本 %s 与当前配置的文件 %s 不一致", version.File, file)
+	}
+	if err := b.EnsureInstalledEngine(); err != nil {
+		return "", nil, err
+	}
+
+	// 执行更新前 hook
+	if b.Config.Config.PreUpdateHook != "" {
+		progress("执行更新前 hook...", 0.02, "", "", 0, 0, 0, false)
+		if err := b.Config.ExecutePreUpdateHook(); err != nil {
+			return "", nil, fmt.Errorf("pre-update hook 失败，已取消回滚: %w", err)
+		}
+	}
+
+	progress("正在读取历史版本...", 0.3, "", "", 0, 0, 0, false)
+	return b.copyVersion(version)
+}
+
+// copyVersion 将历史版本复制为缓存目录中的临时文件并校验哈希，返回临时文件和该版本的更新信息
+func (b *BaseUpdater) copyVersion(version ArchivedVersion) (string, *types.UpdateInfo, error) {
+	tempFile := filepath.Join(b.Config.CacheDir, fmt.Sprintf("temp_rollback_%s", version.File))
+	if err := fileutil.CopyFile(version.Path(), tempFile); err != nil {
+		return "", nil, fmt.Errorf("复制历史版本失败: %w", err)
+	}
+
+	hash, err := fileutil.CalculateSHA256(tempFile)
+	if err != nil || hash != version.SHA256 {
+		os.Remove(tempFile)
+		return "", nil, fmt.Errorf("历史版本文件已损坏: %s", version.ID)
+	}
+
+	info := &types.UpdateInfo{
+		Name:       version.File,
+		UpdateTime: version.Record.UpdateTime,
+		Tag:        version.Record.Tag,
+		SHA256:     version.SHA256,
+		ID:         version.Record.CnbID,
+	}
+	return tempFile, info, nil
+}
+
+// Versions 返回方案可回滚到的历史版本，从新到旧排列
+func (s *SchemeUpdater) Versions() ([]ArchivedVersion, error) {
+	file := s.Config.Config.SchemeFile
+	return s.availableVersions(types.ComponentScheme, file, filepath.Join(s.Config.CacheDir, file))
+}
+
+// Rollback 重新安装方案的历史版本，不访问网络；与更新相同，会应用排除规则并同步到其他引擎和 fcitx 目录
+func (s *SchemeUpdater) Rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	run := s.beginHistory(types.ComponentScheme, HistoryRollback, s.Config.GetSchemeRecordPath(), s.Config.Config.SchemeFile)
+	err := s.rollback(version, progress)
+	s.endHistory(run, s.UpdateInfo, err)
+	return err
+}
+
+func (s *SchemeUpdater) rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	if progress == nil {
+		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
+	}
+
+	tempFile, info, err := s.prepareRollback(version, s.Config.Config.SchemeFile, progress)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
+	s.UpdateInfo = info
+
+	return s.install(tempFile, filepath.Join(s.Config.CacheDir, s.Config.Config.SchemeFile), progress)
+}
+
+// Versions 返回词库可回滚到的历史版本，从新到旧排列
+func (d *DictUpdater) Versions() ([]ArchivedVersion, error) {
+	file := d.Config.Config.DictFile
+	return d.availableVersions(types.ComponentDict, file, filepath.Join(d.Config.CacheDir, file))
+}
+
+// Rollback 重新安装词库的历史版本，不访问网络
+func (d *DictUpdater) Rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	run := d.beginHistory(types.ComponentDict, HistoryRollback, d.Config.GetDictRecordPath(), d.Config.Config.DictFile)
+	err := d.rollback(version, progress)
+	d.endHistory(run, d.UpdateInfo, err)
+	return err
+}
+
+func (d *DictUpdater) rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	if progress == nil {
+		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
+	}
+
+	tempFile, info, err := d.prepareRollback(version, d.Config.Config.DictFile, progress)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
+	d.UpdateInfo = info
+
+	return d.install(tempFile, filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile), progress)
+}
+
+// Versions 返回各个模型可回滚到的历史版本，从新到旧排列
+func (m *ModelUpdater) Versions() ([]ArchivedVersion, error) {
+	var versions []ArchivedVersion
+	for _, file := range m.modelNames() {
+		available, err := m.availableVersions(types.ComponentModel, file, filepath.Join(m.Config.GetExtractPath(), file))
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, available...)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].ArchivedAt.After(versions[j].ArchivedAt)
+	})
+	return versions, nil
+}
+
+// Rollback 重新安装模型的历史版本，不访问网络；只替换该版本对应的模型文件
+func (m *ModelUpdater) Rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	run := m.beginHistory(types.ComponentModel, HistoryRollback, m.Config.GetModelFileRecordPath(version.File), version.File)
+	err := m.rollback(version, progress)
+	m.endHistory(run, m.UpdateInfo, err)
+	return err
+}
+
+func (m *ModelUpdater) rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	if progress == nil {
+		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
+	}
+
+	// 只能回滚已安装或已选择的模型
+	file := version.File
+	if !slices.Contains(m.modelNames(), file) {
+		file = m.primaryModel()
+	}
+	tempFile, info, err := m.prepareRollback(version, file, progress)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile)
+	m.UpdateInfo = info
+
+	return m.install([]modelDownload{{info: info, temp: tempFile}}, nil, progress)
+}
+
+// rollbackTarget 单个组件的回滚能力
+type rollbackTarget interface {
+	Versions() ([]ArchivedVersion, error)
+	Rollback(version ArchivedVersion, progress types.ProgressFunc) error
+}
+
+func (c *CombinedUpdater) rollbackTarget(componentID string) (rollbackTarget, error) {
+	return c.Component(componentID)
+}
+
+// Versions 返回组件可回滚到的历史版本，从新到旧排列
+func (c *CombinedUpdater) Versions(componentID string) ([]ArchivedVersion, error) {
+	target, err := c.rollbackTarget(componentID)
+	if err != nil {
+		return nil, err
+	}
+	return target.Versions()
+}
+
+// FindVersion 按 ID、Tag 或 SHA256 前缀查找组件的历史版本；ref 为空时返回最新的一个
+func (c *CombinedUpdater) FindVersion(componentID, ref string) (ArchivedVersion, error) {
+	versions, err := c.Versions(componentID)
+	if err != nil {
+		return ArchivedVersion{}, err
+	}
+	for _, version := range versions {
+		if ref == "" || version.Matches(ref) {
+			return version, nil
+		}
+	}
+	if ref == "" {
+		return ArchivedVersion{}, ErrVersionNotFound
+	}
+	return ArchivedVersion{}, fmt.Errorf("%w: %s", ErrVersionNotFound, ref)
+}
+
+// Rollback 回滚组件到指定的历史版本；完成后需要调用方重新部署
+func (c *CombinedUpdater) Rollback(version ArchivedVersion, progress types.ProgressFunc) error {
+	target, err := c.rollbackTarget(version.Component)
+	if err != nil {
+		return err
+	}
+	return target.Rollback(version, progress)
+}
+
+// InstalledVersion 返回组件本地记录的版本号，不访问网络；没有记录时返回空字符串
+func (c *CombinedUpdater) InstalledVersion(componentID string) string {
+	if record := InstalledRecord(c.Config, componentID); record != nil {
+		return record.Tag
+	}
+	return ""
+}
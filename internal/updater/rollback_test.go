@@ -0,0 +1,147 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// writeTestRecord 写入 name 对应的版本记录
+func writeTestRecord(t *testing.T, base *BaseUpdater, recordPath, name, tag string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(recordPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := base.SaveRecord(recordPath, "scheme_file", name, &types.UpdateInfo{Tag: tag}); err != nil {
+		t.Fatalf("SaveRecord() error = %v", err)
+	}
+}
+
+func TestSaveVersionDedupesAndPrunes(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	base.Config.Config.KeepVersions = 2
+	zipPath := filepath.Join(base.Config.CacheDir, "scheme.zip")
+	recordPath := base.Config.GetSchemeRecordPath()
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	for i, tag := range []string{"v1", "v1", "v2", "v3"} {
+		if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestZip(t, zipPath, schemeKeyFile, tag+".yaml")
+		writeTestRecord(t, base, recordPath, "scheme.zip", tag)
+		base.saveVersion(types.ComponentScheme, zipPath, recordPath, noop)
+
+		versions, err := base.listVersions(types.ComponentScheme)
+		if err != nil {
+			t.Fatalf("listVersions() error = %v", err)
+		}
+		if want := []int{1, 1, 2, 2}[i]; len(versions) != want {
+			t.Fatalf("after saving %s listVersions() = %d versions, want %d", tag, len(versions), want)
+		}
+		if versions[0].Record.Tag != tag {
+			t.Errorf("newest version = %q, want %q", versions[0].Record.Tag, tag)
+		}
+		time.Sleep(10 * time.Millisecond) // 保证保存时间有先后
+	}
+
+	versions, _ := base.listVersions(types.ComponentScheme)
+	if versions[1].Record.Tag != "v2" {
+		t.Errorf("oldest kept version = %q, want %q", versions[1].Record.Tag, "v2")
+	}
+}
+
+func TestSaveVersionSkipsMismatchedRecord(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(base.Config.CacheDir, "scheme.zip")
+	recordPath := base.Config.GetSchemeRecordPath()
+	writeTestZip(t, zipPath, schemeKeyFile)
+	// 版本记录属于之前选择的另一个方案
+	writeTestRecord(t, base, recordPath, "other.zip", "v1")
+
+	if err := base.archiveVersion(types.ComponentScheme, zipPath, recordPath); err != nil {
+		t.Fatalf("archiveVersion() error = %v", err)
+	}
+	if versions, _ := base.listVersions(types.ComponentScheme); len(versions) != 0 {
+		t.Errorf("listVersions() = %d versions, want none", len(versions))
+	}
+}
+
+func TestSchemeInstallArchivesPreviousVersion(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	base.SkipTerminate = true
+	scheme := &SchemeUpdater{BaseUpdater: base}
+	combined := &CombinedUpdater{Config: base.Config, components: []Component{scheme}}
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(base.Config.CacheDir, "scheme.zip")
+	recordPath := base.Config.GetSchemeRecordPath()
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+
+	// 已安装 v1
+	writeTestZip(t, zipPath, schemeKeyFile, "old.yaml")
+	writeTestRecord(t, base, recordPath, "scheme.zip", "v1")
+	installTestScheme(t, base, rimeDir, schemeKeyFile, "old.yaml")
+
+	// 安装 v2 前会保存 v1
+	tempFile := filepath.Join(base.Config.CacheDir, "temp_scheme.zip")
+	writeTestZip(t, tempFile, schemeKeyFile, "new.yaml")
+	scheme.UpdateInfo = &types.UpdateInfo{Tag: "v2"}
+	if err := scheme.install(tempFile, zipPath, noop); err != nil {
+		t.Fatalf("install() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rimeDir, "new.yaml")); err != nil {
+		t.Fatalf("new.yaml not installed: %v", err)
+	}
+
+	versions, err := combined.Versions(types.ComponentScheme)
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].Record.Tag != "v1" {
+		t.Fatalf("Versions() = %+v, want only v1", versions)
+	}
+
+	version, err := combined.FindVersion(types.ComponentScheme, "v1")
+	if err != nil {
+		t.Fatalf("FindVersion(v1) error = %v", err)
+	}
+	if !version.Matches(version.SHA256[:7]) || version.Matches(version.SHA256[:6]) {
+		t.Errorf("Matches() should accept hash prefixes of at least 7 characters")
+	}
+	if _, err := combined.FindVersion(types.ComponentScheme, "v2"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("FindVersion(installed v2) error = %v, want %v", err, ErrVersionNotFound)
+	}
+
+	// 重新安装 v1：旧文件被清理，v2 也被保存以便再回到 v2
+	tempFile, info, err := scheme.copyVersion(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme.UpdateInfo = info
+	if err := scheme.install(tempFile, zipPath, noop); err != nil {
+		t.Fatalf("install(v1) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rimeDir, "old.yaml")); err != nil {
+		t.Errorf("old.yaml not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rimeDir, "new.yaml")); !os.IsNotExist(err) {
+		t.Errorf("new.yaml still present after rollback: %v", err)
+	}
+	if record := base.GetLocalRecord(recordPath); record == nil || record.Tag != "v1" {
+		t.Errorf("record after rollback = %+v, want tag v1", record)
+	}
+	versions, _ = combined.Versions(types.ComponentScheme)
+	if len(versions) != 1 || versions[0].Record.Tag != "v2" {
+		t.Errorf("Versions() after rollback = %+v, want only v2", versions)
+	}
+}
@@ -1,20 +1,22 @@
 package updater
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"rime-wanxiang-updater/internal/config"
 	"rime-wanxiang-updater/internal/fileutil"
 	"rime-wanxiang-updater/internal/types"
 )
 
+// schemeKeyFile 方案包中必须存在的关键文件，缺失时视为方案未安装
+const schemeKeyFile = "lua/wanxiang.lua"
+
 // SchemeUpdater 方案更新器
 type SchemeUpdater struct {
 	*BaseUpdater
-	UpdateInfo *types.UpdateInfo
 }
 
 // NewSchemeUpdater 创建方案更新器
@@ -24,20 +26,35 @@ func NewSchemeUpdater(cfg *config.Manager) *SchemeUpdater {
 	}
 }
 
-// GetStatus 获取更新状态
-func (s *SchemeUpdater) GetStatus() (*types.UpdateStatus, error) {
-	if err := s.Config.ReconcileRuntimeState(); err != nil {
-		return nil, err
-	}
+// ID 组件 ID
+func (s *SchemeUpdater) ID() string {
+	return types.ComponentScheme
+}
 
-	// 获取远程版本信息
-	remoteInfo, err := s.CheckUpdate()
-	if err != nil {
-		return nil, err
-	}
+func (s *SchemeUpdater) RecordPath() string {
+	return s.Config.GetSchemeRecordPath()
+}
+
+func (s *SchemeUpdater) FileName() string {
+	return s.Config.Config.SchemeFile
+}
+
+// GetStatus 获取更新状态，固定了版本时在状态中注明
+func (s *SchemeUpdater) GetStatus(ctx context.Context) (*types.UpdateStatus, error) {
+	_, status, err := resolveComponent(ctx, s)
+	return status, err
+}
+
+// StatusFor 比较远程版本和本地文件得到更新状态，不访问网络；固定了版本时在状态中注明
+func (s *SchemeUpdater) StatusFor(remoteInfo *types.UpdateInfo) *types.UpdateStatus {
+	status := s.compareLocal(remoteInfo)
+	s.applyPin(types.ComponentScheme, s.Config.GetSchemeRecordPath(), s.Config.Config.SchemeFile, status)
+	return status
+}
 
+func (s *SchemeUpdater) compareLocal(remoteInfo *types.UpdateInfo) *types.UpdateStatus {
 	// 检查关键文件是否存在
-	keyFile := filepath.Join(s.Config.GetExtractPath(), "lua", "wanxiang.lua")
+	keyFile := filepath.Join(s.Config.GetExtractPath(), filepath.FromSlash(schemeKeyFile))
 	keyFileExists := fileutil.FileExists(keyFile)
 
 	// 获取本地版本信息
@@ -54,7 +71,7 @@ func (s *SchemeUpdater) GetStatus() (*types.UpdateStatus, error) {
 	if !keyFileExists {
 		status.LocalVersion = "未安装"
 		status.Message = fmt.Sprintf("检测到可用版本: %s (关键文件缺失)", remoteInfo.Tag)
-		return status, nil
+		return status
 	}
 
 	if localRecord != nil {
@@ -63,7 +80,7 @@ func (s *SchemeUpdater) GetStatus() (*types.UpdateStatus, error) {
 			status.LocalVersion = fmt.Sprintf("已切换方案 (从 %s)", localRecord.Name)
 			status.Message = fmt.Sprintf("检测到可用版本: %s (方案已切换，需要更新)", remoteInfo.Tag)
 			status.NeedsUpdate = true
-			return status, nil
+			return status
 		}
 
 		status.LocalVersion = localRecord.Tag
@@ -86,123 +103,30 @@ func (s *SchemeUpdater) GetStatus() (*types.UpdateStatus, error) {
 		}
 	}
 
-	return status, nil
+	return status
 }
 
-// CheckUpdate 检查更新
-func (s *SchemeUpdater) CheckUpdate() (*types.UpdateInfo, error) {
-	var releases []types.GitHubRelease
-	var err error
-
-	if s.Config.Config.UseMirror {
-		info, err := s.APIClient.FindLatestCNBAssetInfo(
-			types.OWNER,
-			types.CNB_REPO,
-			func(name string) bool { return name == s.Config.Config.SchemeFile },
-			types.CNB_DICT_TAG,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("获取版本信息失败: %w", err)
-		}
-		return info, nil
-	}
-
-	releases, err = s.APIClient.FetchGitHubReleases(types.OWNER, types.REPO, "")
-	if err != nil {
-		return nil, fmt.Errorf("获取版本信息失败: %w", err)
-	}
-
-	if len(releases) == 0 {
-		return nil, fmt.Errorf("未找到任何发布版本")
-	}
-
-	if info, ok := findSchemeRelease(releases, s.Config.Config.SchemeFile); ok {
-		return info, nil
-	}
-
-	return nil, fmt.Errorf("未找到匹配的方案文件: %s", s.Config.Config.SchemeFile)
-}
-
-func findSchemeRelease(releases []types.GitHubRelease, schemeFile string) (*types.UpdateInfo, bool) {
-	if info, ok := findSchemeReleaseWithTagFilter(releases, schemeFile, func(tag string) bool {
-		return tag != types.CNB_DICT_TAG
-	}); ok {
-		return info, true
-	}
-
-	return findSchemeReleaseWithTagFilter(releases, schemeFile, func(string) bool {
-		return true
+// CheckUpdate 检查更新；固定了版本时返回固定的版本
+func (s *SchemeUpdater) CheckUpdate(ctx context.Context) (*types.UpdateInfo, error) {
+	latest := func() (*types.UpdateInfo, error) { return s.checkLatest(ctx) }
+	return s.checkPinned(types.ComponentScheme, s.Config.GetSchemeRecordPath(), s.Config.Config.SchemeFile, latest, func(pin string) (*types.UpdateInfo, bool, error) {
+		return s.findPinnedRelease(ctx, types.REPO, s.Config.Config.SchemeFile, pin)
 	})
 }
 
-func findSchemeReleaseWithTagFilter(
-	releases []types.GitHubRelease,
-	schemeFile string,
-	allowTag func(string) bool,
-) (*types.UpdateInfo, bool) {
-	for _, release := range releases {
-		if !allowTag(release.TagName) {
-			continue
-		}
-
-		for _, asset := range release.Assets {
-			if asset.Name != schemeFile {
-				continue
-			}
-
-			return &types.UpdateInfo{
-				Name:        asset.Name,
-				URL:         asset.BrowserDownloadURL,
-				UpdateTime:  asset.UpdatedAt,
-				Tag:         release.TagName,
-				Description: release.Body,
-				SHA256:      asset.SHA256,
-				ID:          asset.ID,
-				Size:        asset.Size,
-			}, true
-		}
-	}
-
-	return nil, false
+// checkLatest 按方案的发布渠道检查最新版本
+func (s *SchemeUpdater) checkLatest(ctx context.Context) (*types.UpdateInfo, error) {
+	return s.findChannelRelease(ctx, types.ComponentScheme, types.REPO, s.Config.Config.SchemeFile)
 }
 
-// Run 执行更新
-func (s *SchemeUpdater) Run(progress types.ProgressFunc) error {
-	if progress == nil {
-		progress = func(string, float64, string, string, int64, int64, float64, bool) {} // 空函数避免 nil 检查
-	}
-
-	if err := s.EnsureInstalledEngine(); err != nil {
-		return err
-	}
-
-	// 执行更新前 hook
-	if s.Config.Config.PreUpdateHook != "" {
-		progress("执行更新前 hook...", 0.02, "", "", 0, 0, 0, false)
-		if err := s.Config.ExecutePreUpdateHook(); err != nil {
-			return fmt.Errorf("pre-update hook 失败，已取消更新: %w", err)
-		}
-	}
-
-	// 显示下载源
-	source := "GitHub"
-	if s.Config.Config.UseMirror {
-		source = "CNB 镜像"
-	}
-	progress(fmt.Sprintf("正在检查方案更新 [%s]...", source), 0.05, "", "", 0, 0, 0, false)
-
-	if s.UpdateInfo == nil {
-		info, err := s.CheckUpdate()
-		if err != nil {
-			return err
-		}
-		s.UpdateInfo = info
-	}
-
-	if s.UpdateInfo == nil {
-		return fmt.Errorf("未找到方案更新")
-	}
+// Run 执行更新，并将结果追加到更新历史
+func (s *SchemeUpdater) Run(ctx context.Context, progress types.ProgressFunc) error {
+	return RunComponent(ctx, s, progress)
+}
 
+// Download 下载 prepareComponent 确定的版本并返回临时文件路径；本地文件已是最新版本时返回空字符串
+func (s *SchemeUpdater) Download(ctx context.Context, progress types.ProgressFunc) (string, error) {
+	source := sourceLabel(s.currentSource())
 	recordPath := s.Config.GetSchemeRecordPath()
 	targetFile := filepath.Join(s.Config.CacheDir, s.Config.Config.SchemeFile)
 
@@ -211,31 +135,60 @@ func (s *SchemeUpdater) Run(progress types.ProgressFunc) error {
 	localRecord := s.GetLocalRecord(recordPath)
 	if s.canReuseCachedAsset(localRecord, s.UpdateInfo, targetFile, s.CompareHash) {
 		progress("本地文件已是最新版本", 1.0, "", "", 0, 0, 0, false)
-		return nil
+		return "", nil
 	}
 
 	// 下载文件
 	progress(fmt.Sprintf("准备从 %s 下载方案...", source), 0.15, source, s.UpdateInfo.URL, 0, 0, 0, false)
-	tempFile := filepath.Join(s.Config.CacheDir, fmt.Sprintf("temp_scheme_%d.zip", time.Now().Unix()))
-	if err := s.DownloadFileWithValidation(s.UpdateInfo.URL, tempFile, s.Config.Config.SchemeFile, source, s.UpdateInfo.Size, progress); err != nil {
-		return fmt.Errorf("下载失败: %w", err)
+	tempFile := partialDownloadPath(s.Config.CacheDir, "temp_scheme", ".zip", s.UpdateInfo)
+	if err := s.downloadVerified(ctx, s.UpdateInfo, tempFile, s.Config.Config.SchemeFile, source, progress); err != nil {
+		return "", fmt.Errorf("下载失败: %w", err)
 	}
 
-	// 计算下载文件的 SHA256
-	progress("正在计算文件校验和...", 0.65, "", "", 0, 0, 0, false)
-	if hash, err := fileutil.CalculateSHA256(tempFile); err == nil {
-		s.UpdateInfo.SHA256 = hash
+	return tempFile, nil
+}
+
+// Apply 安装 Download 下载的更新包
+func (s *SchemeUpdater) Apply(tempFile string, progress types.ProgressFunc) error {
+	return s.install(tempFile, filepath.Join(s.Config.CacheDir, s.Config.Config.SchemeFile), progress)
+}
+
+// install 安装已下载到 tempFile 的更新包，更新和回滚共用；s.UpdateInfo 须为该更新包的版本信息
+func (s *SchemeUpdater) install(tempFile, targetFile string, progress types.ProgressFunc) error {
+	// 保留即将被替换的版本，供之后回滚
+	s.saveVersion(types.ComponentScheme, targetFile, s.Config.GetSchemeRecordPath(), progress)
+
+	// 先解压到暂存目录并校验，确认更新包完整后才改动 Rime 目录
+	progress("正在解压方案文件...", 0.66, "", "", 0, 0, 0, false)
+	staging, err := s.stageArchive(tempFile, s.Config.Config.SchemeFile, schemeKeyFile)
+	if err != nil {
+		return err
 	}
+	defer os.RemoveAll(staging)
 
-	// 清理旧文件
-	progress("正在清理旧文件...", 0.7, "", "", 0, 0, 0, false)
-	if fileutil.FileExists(targetFile) {
-		s.CleanOldFiles(targetFile, tempFile, s.Config.GetExtractPath(), false)
+	txn, owned, err := s.beginTransaction()
+	if err != nil {
+		return err
 	}
 
-	// 应用更新
-	progress("正在应用更新...", 0.8, "", "", 0, 0, 0, false)
-	if err := s.applyUpdate(tempFile, targetFile, progress); err != nil {
+	// 处理安装后被用户修改过的文件，须在清理和安装之前完成；备份的 .local 文件同样在失败时删除
+	kept, err := s.protectModified(txn, types.ComponentScheme, s.Config.GetExtractPath(), staging, progress)
+	if err == nil {
+		// 备份将被改动的文件，之后任一步骤失败都会恢复
+		progress("正在备份将被修改的文件...", 0.68, "", "", 0, 0, 0, false)
+		err = s.trackFiles(txn, staging, targetFile)
+	}
+	if err == nil {
+		// 清理旧文件
+		progress("正在清理旧文件...", 0.7, "", "", 0, 0, 0, false)
+		err = s.cleanOldInstall(staging, targetFile, tempFile)
+	}
+	if err == nil {
+		// 应用更新
+		progress("正在应用更新...", 0.8, "", "", 0, 0, 0, false)
+		err = s.applyUpdate(staging, tempFile, targetFile, kept, progress)
+	}
+	if err := endTransaction(txn, owned, err, progress); err != nil {
 		return err
 	}
 
@@ -246,8 +199,8 @@ func (s *SchemeUpdater) Run(progress types.ProgressFunc) error {
 	return nil
 }
 
-// applyUpdate 应用更新
-func (s *SchemeUpdater) applyUpdate(temp, target string, progress types.ProgressFunc) error {
+// applyUpdate 应用更新，staging 为已校验的暂存目录，kept 为保留了本地修改的文件（见 protectModified）
+func (s *SchemeUpdater) applyUpdate(staging, temp, target string, kept []types.ManifestFile, progress types.ProgressFunc) error {
 	// 终止进程（组合更新时跳过）
 	if !s.SkipTerminate {
 		progress("正在终止相关进程...", 0.85, "", "", 0, 0, 0, false)
@@ -256,28 +209,30 @@ func (s *SchemeUpdater) applyUpdate(temp, target string, progress types.Progress
 		}
 	}
 
-	// 解压文件到主引擎目录
-	progress("正在解压方案文件...", 0.9, "", "", 0, 0, 0, false)
-	if err := s.ExtractZip(temp, s.Config.GetExtractPath()); err != nil {
-		return fmt.Errorf("解压失败: %w", err)
+	// 将暂存目录中的文件移动到主引擎目录
+	progress("正在替换方案文件...", 0.9, "", "", 0, 0, 0, false)
+	names, err := listFiles(staging)
+	if err != nil {
+		return err
 	}
-
-	// 处理 CNB 镜像的嵌套目录问题
-	if s.Config.Config.UseMirror {
-		if err := fileutil.HandleCNBNestedDir(s.Config.GetExtractPath(), s.Config.Config.SchemeFile); err != nil {
-			return fmt.Errorf("处理嵌套目录失败: %w", err)
-		}
+	installed, err := fileutil.InstallStaged(staging, s.Config.GetExtractPath(), s.Config.Config.ExcludeFiles)
+	if err != nil {
+		return fmt.Errorf("安装文件失败: %w", err)
 	}
 
 	// 同步到其他引擎目录
 	if len(s.Config.Config.InstalledEngines) > 1 {
 		progress("正在同步到其他引擎...", 0.92, "", "", 0, 0, 0, false)
 		if err := s.syncToOtherEngines(); err != nil {
-			// 只记录错误，不返回失败
-			progress(fmt.Sprintf("同步到其他引擎失败: %v", err), 0.92, "", "", 0, 0, 0, false)
+			return fmt.Errorf("同步到其他引擎失败: %w", err)
 		}
 	}
 
+	// 记录安装清单，供下次更新清理和卸载使用
+	if err := s.writeManifests(types.ComponentScheme, s.targets(), names, installed, s.UpdateInfo.Tag, kept...); err != nil {
+		return fmt.Errorf("写入安装清单失败: %w", err)
+	}
+
 	// 重命名临时文件
 	progress("正在保存文件...", 0.93, "", "", 0, 0, 0, false)
 	if fileutil.FileExists(target) {
@@ -315,35 +270,46 @@ func (s *SchemeUpdater) applyUpdate(temp, target string, progress types.Progress
 	return nil
 }
 
-// syncToOtherEngines 同步文件到其他引擎目录
-func (s *SchemeUpdater) syncToOtherEngines() error {
-	// 检查是否配置了要更新的引擎列表
-	updateEngines := s.Config.Config.UpdateEngines
-	if len(updateEngines) == 0 {
-		// 未配置：默认更新所有已安装的引擎
-		updateEngines = s.Config.Config.InstalledEngines
+// trackFiles 登记本次更新会改动的文件：主引擎目录、其他引擎目录、缓存的更新包与版本记录
+func (s *SchemeUpdater) trackFiles(txn *Transaction, staging, target string) error {
+	extractPath := s.Config.GetExtractPath()
+	names, err := s.trackArchive(txn, staging, target, extractPath)
+	if err != nil {
+		return err
 	}
-
-	// 如果只有一个引擎需要更新，跳过同步
-	if len(updateEngines) <= 1 {
-		return nil
+	if err := s.trackSecondaryEngines(txn, extractPath, "", names); err != nil {
+		return err
+	}
+	if err := s.trackManifests(txn, types.ComponentScheme, s.targets()); err != nil {
+		return err
+	}
+	if err := txn.Track(target); err != nil {
+		return err
 	}
+	return txn.Track(s.Config.GetSchemeRecordPath())
+}
+
+// targets 返回方案的安装目录：主引擎目录在前，其后是需要同步的其他引擎目录
+func (s *SchemeUpdater) targets() []installTarget {
+	return s.installTargets(s.Config.GetExtractPath(), "")
+}
 
-	primaryEngine := s.Config.Config.PrimaryEngine
-	if primaryEngine == "" && len(updateEngines) > 0 {
-		primaryEngine = updateEngines[0]
+// cleanOldInstall 清理旧版本安装、但新的更新包中已不存在的文件
+func (s *SchemeUpdater) cleanOldInstall(staging, oldZip, newZip string) error {
+	names, err := listFiles(staging)
+	if err != nil {
+		return err
 	}
+	return s.cleanInstalled(types.ComponentScheme, s.targets(), names, oldZip, newZip, false)
+}
 
+// syncToOtherEngines 同步文件到其他引擎目录
+func (s *SchemeUpdater) syncToOtherEngines() error {
 	sourceDir := s.Config.GetExtractPath()
 	var errors []string
 
-	// 遍历用户选择要更新的引擎
-	for _, engine := range updateEngines {
-		// 跳过主引擎（已经解压到主引擎目录）
-		if engine == primaryEngine {
-			continue
-		}
-
+	// 遍历用户选择要更新的其他引擎（主引擎已经解压完成）
+	for _, engine := range s.secondaryEngines() {
 		// 获取目标引擎的数据目录
 		targetDir := config.GetEngineDataDir(engine)
 		if targetDir == "" {
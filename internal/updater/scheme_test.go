@@ -4,10 +4,15 @@ import (
 	"testing"
 	"time"
 
+	"rime-wanxiang-updater/internal/releaseutil"
 	"rime-wanxiang-updater/internal/types"
 )
 
-func TestFindSchemeReleasePrefersVersionedCNBRelease(t *testing.T) {
+func matchSchemeFile(name string) bool {
+	return name == "rime-wanxiang-base.zip"
+}
+
+func TestSchemeStableChannelPrefersVersionedCNBRelease(t *testing.T) {
 	releases := []types.GitHubRelease{
 		{
 			TagName: types.CNB_DICT_TAG,
@@ -31,17 +36,23 @@ func TestFindSchemeReleasePrefersVersionedCNBRelease(t *testing.T) {
 		},
 	}
 
-	info, ok := findSchemeRelease(releases, "rime-wanxiang-base.zip")
+	info, ok := releaseutil.FindChannelAssetInfo(releases, matchSchemeFile, releaseutil.DefaultChannel(types.ComponentScheme))
 	if !ok {
-		t.Fatal("findSchemeRelease() = no match, want match")
+		t.Fatal("FindChannelAssetInfo() = no match, want match")
 	}
 
 	if info.Tag != "v15.5.0" {
-		t.Fatalf("findSchemeRelease().Tag = %q, want %q", info.Tag, "v15.5.0")
+		t.Fatalf("FindChannelAssetInfo().Tag = %q, want %q", info.Tag, "v15.5.0")
+	}
+
+	// nightly 渠道接受更新的滚动预览
+	info, ok = releaseutil.FindChannelAssetInfo(releases, matchSchemeFile, releaseutil.ChannelNightly)
+	if !ok || info.Tag != types.CNB_DICT_TAG {
+		t.Fatalf("nightly FindChannelAssetInfo() = %+v, %v, want %q", info, ok, types.CNB_DICT_TAG)
 	}
 }
 
-func TestFindSchemeReleaseFallsBackToRollingPreview(t *testing.T) {
+func TestSchemeRollingPreviewOnlyOnNightlyChannel(t *testing.T) {
 	releases := []types.GitHubRelease{
 		{
 			TagName: types.CNB_DICT_TAG,
@@ -55,12 +66,17 @@ func TestFindSchemeReleaseFallsBackToRollingPreview(t *testing.T) {
 		},
 	}
 
-	info, ok := findSchemeRelease(releases, "rime-wanxiang-base.zip")
+	// stable 渠道只使用带版本号的发布
+	if info, ok := releaseutil.FindChannelAssetInfo(releases, matchSchemeFile, releaseutil.ChannelStable); ok {
+		t.Fatalf("stable FindChannelAssetInfo() = %+v, want no match", info)
+	}
+
+	info, ok := releaseutil.FindChannelAssetInfo(releases, matchSchemeFile, releaseutil.ChannelNightly)
 	if !ok {
-		t.Fatal("findSchemeRelease() = no match, want match")
+		t.Fatal("nightly FindChannelAssetInfo() = no match, want match")
 	}
 
 	if info.Tag != types.CNB_DICT_TAG {
-		t.Fatalf("findSchemeRelease().Tag = %q, want %q", info.Tag, types.CNB_DICT_TAG)
+		t.Fatalf("nightly FindChannelAssetInfo().Tag = %q, want %q", info.Tag, types.CNB_DICT_TAG)
 	}
 }
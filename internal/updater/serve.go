@@ -0,0 +1,474 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/releaseutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// DefaultServeInterval 缓存服务器从上游刷新发布信息的默认间隔
+const DefaultServeInterval = time.Hour
+
+// serveDirName 缓存服务器在 CacheDir 中存放资源的子目录
+const serveDirName = "serve"
+
+// CacheServer 局域网缓存服务器：以 GitHub API 的格式提供万象仓库的发布信息，其中资源的下载地址指向本服务器。
+// 资源首次被请求时从上游下载到 CacheDir/serve 并校验，之后直接从缓存提供，支持 Range 请求；
+// 其他机器将 github_api_url 设为本服务器的地址即可使用。上游始终为 GitHub（遵循本机的 github_api_url、github_proxy、令牌和代理设置）
+type CacheServer struct {
+	// Logf 输出刷新和下载的日志，为 nil 时不输出
+	Logf func(format string, args ...any)
+
+	cfg      *config.Manager
+	upstream *types.Config
+	dir      string
+	ctx      context.Context
+
+	mu       sync.Mutex
+	releases map[releaseKey][]types.GitHubRelease // 已缓存的发布信息
+	assets   map[string]*types.UpdateInfo         // 下载路径（repo/tag/version/name）-> 上游资源
+	fetching map[string]*cacheFetch               // 正在从上游下载的资源
+}
+
+// releaseKey 一次 GitHub Releases 请求：tag 为空表示发布列表
+type releaseKey struct {
+	repo string
+	tag  string
+}
+
+// cacheFetch 一次正在进行的资源下载，同一资源的并发请求等待同一次下载
+type cacheFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewCacheServer 创建缓存服务器，资源缓存在 cfg.CacheDir/serve 中
+func NewCacheServer(cfg *config.Manager) *CacheServer {
+	// 服务器以 GitHub 的格式提供发布信息，上游固定使用 GitHub
+	upstream := *cfg.Config
+	upstream.UseMirror = false
+
+	s := &CacheServer{
+		cfg:      cfg,
+		upstream: &upstream,
+		dir:      filepath.Join(cfg.CacheDir, serveDirName),
+		ctx:      context.Background(),
+		releases: make(map[releaseKey][]types.GitHubRelease),
+		assets:   make(map[string]*types.UpdateInfo),
+		fetching: make(map[string]*cacheFetch),
+	}
+	// 客户端默认请求的发布信息，启动后立即刷新；只有这些请求的结果会被缓存
+	for _, key := range []releaseKey{
+		{types.REPO, ""},
+		{types.REPO, types.DICT_TAG},
+		{types.MODEL_REPO, ""},
+		{types.MODEL_REPO, types.MODEL_TAG},
+	} {
+		s.releases[key] = nil
+	}
+	return s
+}
+
+// Run 在 addr 上提供服务，并每隔 interval 从上游刷新发布信息和已缓存的资源；ctx 取消时关闭服务器并返回 nil
+func (s *CacheServer) Run(ctx context.Context, addr string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultServeInterval
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", addr, err)
+	}
+	s.ctx = ctx
+
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go s.refreshLoop(ctx, interval)
+
+	s.logf("缓存服务器已启动: http://%s（每 %s 刷新一次）", listener.Addr(), interval)
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("缓存服务器异常退出: %w", err)
+	}
+	return nil
+}
+
+func (s *CacheServer) refreshLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			s.logf("刷新失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 从上游重新获取所有已缓存的发布信息，下载本机配置的方案、词库和模型在其中的最新文件，
+// 并删除不再出现在发布信息中的缓存文件。某个请求失败时保留其旧的发布信息，继续刷新其他请求
+func (s *CacheServer) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	keys := make([]releaseKey, 0, len(s.releases))
+	for key := range s.releases {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	// 每次刷新使用新的客户端，避免复用上次的请求结果
+	client := api.NewClient(s.upstream)
+	var errs []error
+	for _, key := range keys {
+		releases, err := client.FetchGitHubReleases(ctx, types.OWNER, key.repo, key.tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("获取 %s 的发布信息失败: %w", key, err))
+			continue
+		}
+		s.storeReleases(key, releases)
+	}
+
+	for _, key := range s.prefetchAssets() {
+		if _, err := s.cachedAsset(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := s.prune(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (k releaseKey) String() string {
+	if k.tag == "" {
+		return k.repo
+	}
+	return k.repo + "@" + k.tag
+}
+
+// storeReleases 保存发布信息，并重新登记所有发布信息中可以缓存的资源
+func (s *CacheServer) storeReleases(key releaseKey, releases []types.GitHubRelease) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releases[key] = releases
+
+	s.assets = make(map[string]*types.UpdateInfo)
+	for key, releases := range s.releases {
+		for _, release := range releases {
+			checksumURL := releaseutil.ChecksumURL(release)
+			for _, asset := range release.Assets {
+				if assetKey, ok := serveAssetKey(key.repo, release, asset); ok {
+					s.assets[assetKey] = &types.UpdateInfo{
+						Name:        asset.Name,
+						URL:         asset.BrowserDownloadURL,
+						UpdateTime:  asset.UpdatedAt,
+						Tag:         release.TagName,
+						SHA256:      asset.SHA256,
+						Size:        asset.Size,
+						ChecksumURL: checksumURL,
+					}
+				}
+			}
+		}
+	}
+}
+
+// prefetchAssets 返回本机配置的方案、词库和模型文件在各发布信息中最新的资源，刷新时预先下载
+func (s *CacheServer) prefetchAssets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var keys []string
+	for key, releases := range s.releases {
+		found := make(map[string]bool)
+		// GitHub 按从新到旧的顺序返回发布，每个文件只取第一次出现
+		for _, release := range releases {
+			for _, asset := range release.Assets {
+				if found[asset.Name] || localComponent(s.cfg, asset.Name) == "" {
+					continue
+				}
+				found[asset.Name] = true
+				if assetKey, ok := serveAssetKey(key.repo, release, asset); ok && !seen[assetKey] {
+					seen[assetKey] = true
+					keys = append(keys, assetKey)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// prune 删除缓存目录中不再出现在发布信息中的文件；仍在发布信息中的资源未下载完的部分保留，供下次续传
+func (s *CacheServer) prune() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := filepath.WalkDir(s.dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		partial := strings.TrimSuffix(strings.TrimSuffix(key, ".resume"), ".part")
+		if s.assets[key] != nil || s.assets[partial] != nil || s.fetching[partial] != nil {
+			return nil
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+		// 目录为空时一并删除
+		os.Remove(filepath.Dir(file))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("清理缓存失败: %w", err)
+	}
+	return nil
+}
+
+// serveAssetKey 返回资源在本服务器上的下载路径 repo/tag/version/name。
+// version 为资源的 SHA256（没有时为更新时间），滚动发布的 tag 更新文件后下载地址随之改变；
+// 任何一段不能安全地用作文件名时返回 false
+func serveAssetKey(repo string, release types.GitHubRelease, asset types.GitHubAsset) (string, bool) {
+	version := releaseutil.NormalizeSHA256(asset.SHA256)
+	if version == "" {
+		version = strconv.FormatInt(asset.UpdatedAt.Unix(), 10)
+	}
+	segments := []string{repo, release.TagName, version, asset.Name}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, `/\`) || strings.HasSuffix(segment, ".part") || strings.HasSuffix(segment, ".resume") {
+			return "", false
+		}
+	}
+	return path.Join(segments...), true
+}
+
+// Handler 返回缓存服务器的 HTTP 处理器
+func (s *CacheServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/{owner}/{repo}", s.handleRepo)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/releases", s.handleReleases)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/releases/tags/{tag...}", s.handleReleases)
+	mux.HandleFunc("GET /download/{asset...}", s.handleDownload)
+	return mux
+}
+
+// servedRepo 判断请求的是否为本服务器提供的万象仓库
+func servedRepo(r *http.Request) bool {
+	repo := r.PathValue("repo")
+	return r.PathValue("owner") == types.OWNER && (repo == types.REPO || repo == types.MODEL_REPO)
+}
+
+// handleRepo 提供仓库信息，供客户端检查 GitHub API 地址是否可用
+func (s *CacheServer) handleRepo(w http.ResponseWriter, r *http.Request) {
+	if !servedRepo(r) {
+		writeNotFound(w)
+		return
+	}
+	writeServeJSON(w, map[string]string{
+		"name":      r.PathValue("repo"),
+		"full_name": types.OWNER + "/" + r.PathValue("repo"),
+	})
+}
+
+// handleReleases 提供发布列表或指定 tag 的发布，资源下载地址改写为本服务器
+func (s *CacheServer) handleReleases(w http.ResponseWriter, r *http.Request) {
+	if !servedRepo(r) {
+		writeNotFound(w)
+		return
+	}
+	key := releaseKey{repo: r.PathValue("repo"), tag: r.PathValue("tag")}
+	releases, err := s.lookupReleases(r.Context(), key)
+	if err != nil {
+		s.logf("获取 %s 的发布信息失败: %v", key, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// 只改写已登记的资源，未缓存的发布中的其他资源仍从上游下载
+	base := requestBaseURL(r)
+	served := make([]types.GitHubRelease, len(releases))
+	s.mu.Lock()
+	for i, release := range releases {
+		served[i] = release
+		served[i].Assets = make([]types.GitHubAsset, len(release.Assets))
+		for j, asset := range release.Assets {
+			if assetKey, ok := serveAssetKey(key.repo, release, asset); ok && s.assets[assetKey] != nil {
+				asset.BrowserDownloadURL = base + "/download/" + escapeAssetKey(assetKey)
+			}
+			served[i].Assets[j] = asset
+		}
+	}
+	s.mu.Unlock()
+
+	if key.tag != "" {
+		if len(served) == 0 {
+			writeNotFound(w)
+			return
+		}
+		writeServeJSON(w, served[0])
+		return
+	}
+	writeServeJSON(w, served)
+}
+
+// lookupReleases 返回发布信息。NewCacheServer 登记的请求使用缓存，尚未获取时从上游获取并缓存；
+// 客户端请求的其他 tag 每次直接从上游获取，不缓存也不在刷新时更新，避免任意请求让缓存无限增长
+func (s *CacheServer) lookupReleases(ctx context.Context, key releaseKey) ([]types.GitHubRelease, error) {
+	s.mu.Lock()
+	releases, cached := s.releases[key]
+	s.mu.Unlock()
+	if cached && releases != nil {
+		return releases, nil
+	}
+
+	releases, err := api.NewClient(s.upstream).FetchGitHubReleases(ctx, types.OWNER, key.repo, key.tag)
+	if err != nil {
+		return nil, err
+	}
+	if cached {
+		s.storeReleases(key, releases)
+	}
+	return releases, nil
+}
+
+// handleDownload 从缓存提供资源，缓存中没有时先从上游下载
+func (s *CacheServer) handleDownload(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("asset")
+	file, err := s.cachedAsset(r.Context(), key)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		if r.Context().Err() == nil {
+			s.logf("%v", err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+		return
+	}
+	// ServeFile 处理 Range 和 If-Range，客户端可以断点续传
+	http.ServeFile(w, r, file)
+}
+
+// cachedAsset 返回资源在缓存中的路径，缓存中没有时从上游下载并校验；同一资源的并发请求只下载一次。
+// ctx 只控制等待，下载使用服务器的生命周期，发起请求的客户端断开后其他客户端仍可使用下载结果
+func (s *CacheServer) cachedAsset(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	info := s.assets[key]
+	if info == nil {
+		s.mu.Unlock()
+		return "", fmt.Errorf("资源 %s 不在发布信息中: %w", key, fs.ErrNotExist)
+	}
+	file := filepath.Join(s.dir, filepath.FromSlash(key))
+	if fileutil.FileExists(file) {
+		s.mu.Unlock()
+		return file, nil
+	}
+	fetch, ok := s.fetching[key]
+	if !ok {
+		fetch = &cacheFetch{done: make(chan struct{})}
+		s.fetching[key] = fetch
+		go s.fetchAsset(key, *info, file, fetch)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return file, fetch.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (s *CacheServer) fetchAsset(key string, info types.UpdateInfo, file string, fetch *cacheFetch) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.fetching, key)
+		s.mu.Unlock()
+		close(fetch.done)
+	}()
+
+	s.logf("正在从上游下载 %s", key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		fetch.err = fmt.Errorf("创建缓存目录失败: %w", err)
+		return
+	}
+	// 每次下载使用单独的 BaseUpdater，并发下载不共享已下载字节数等状态
+	base := &BaseUpdater{Config: s.cfg}
+	temp := file + ".part"
+	noop := func(string, float64, string, string, int64, int64, float64, bool) {}
+	if err := base.downloadVerified(s.ctx, &info, temp, info.Name, SourceGitHub, noop); err != nil {
+		fetch.err = fmt.Errorf("下载 %s 失败: %w", key, err)
+		return
+	}
+	if err := os.Rename(temp, file); err != nil {
+		fetch.err = fmt.Errorf("保存 %s 失败: %w", key, err)
+		return
+	}
+	s.logf("已缓存 %s (%s)", key, fileutil.FormatBytes(info.Size))
+}
+
+// requestBaseURL 返回客户端访问本服务器时使用的地址
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// escapeAssetKey 对下载路径的每一段做 URL 转义
+func escapeAssetKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func writeServeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeNotFound 返回与 GitHub API 相同格式的 404
+func writeNotFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+}
+
+func (s *CacheServer) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
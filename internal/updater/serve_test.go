@@ -0,0 +1,316 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func TestCacheServerServesAndRefreshes(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	content := "scheme archive v1"
+	published := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var downloads atomic.Int32
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + types.OWNER + "/" + types.REPO + "/releases":
+			json.NewEncoder(w).Encode([]types.GitHubRelease{{
+				TagName: "v1.0.0",
+				Assets: []types.GitHubAsset{{
+					Name:               "scheme.zip",
+					BrowserDownloadURL: upstream.URL + "/scheme.zip",
+					UpdatedAt:          published,
+					Digest:             "sha256:" + sha256Hex(content),
+					Size:               int64(len(content)),
+				}},
+			}})
+		case "/scheme.zip":
+			downloads.Add(1)
+			io.WriteString(w, content)
+		default:
+			// 词库和模型的发布没有本机配置的文件
+			if _, tag, ok := strings.Cut(r.URL.Path, "/releases/tags/"); ok {
+				json.NewEncoder(w).Encode(types.GitHubRelease{TagName: tag})
+			} else {
+				io.WriteString(w, "[]")
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	base.Config.Config.GithubAPIURL = upstream.URL
+	cacheServer := NewCacheServer(base.Config)
+	server := httptest.NewServer(cacheServer.Handler())
+	defer server.Close()
+
+	// 客户端把 github_api_url 指向缓存服务器，下载地址被改写为缓存服务器
+	client := api.NewClient(&types.Config{GithubAPIURL: server.URL})
+	releases, err := client.FetchGitHubReleases(context.Background(), types.OWNER, types.REPO, "")
+	if err != nil {
+		t.Fatalf("FetchGitHubReleases() error = %v", err)
+	}
+	if len(releases) != 1 || len(releases[0].Assets) != 1 {
+		t.Fatalf("FetchGitHubReleases() = %+v, want one release with one asset", releases)
+	}
+	asset := releases[0].Assets[0]
+	if !strings.HasPrefix(asset.BrowserDownloadURL, server.URL+"/download/") {
+		t.Fatalf("BrowserDownloadURL = %q, want it served by the cache server", asset.BrowserDownloadURL)
+	}
+	if asset.SHA256 != sha256Hex(content) {
+		t.Errorf("SHA256 = %q, want %q", asset.SHA256, sha256Hex(content))
+	}
+
+	get := func(rangeHeader string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest("GET", asset.BrowserDownloadURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get(""); status != http.StatusOK || body != content {
+		t.Fatalf("GET = %d %q, want 200 %q", status, body, content)
+	}
+	if status, body := get("bytes=7-"); status != http.StatusPartialContent || body != content[7:] {
+		t.Fatalf("GET with Range = %d %q, want 206 %q", status, body, content[7:])
+	}
+	if got := downloads.Load(); got != 1 {
+		t.Errorf("upstream downloads = %d, want 1", got)
+	}
+
+	// 滚动发布更新了文件：刷新后预先下载新文件，删除旧文件
+	oldFile := filepath.Join(base.Config.CacheDir, serveDirName, filepath.FromSlash(strings.TrimPrefix(asset.BrowserDownloadURL, server.URL+"/download/")))
+	content = "scheme archive v2"
+	if err := cacheServer.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := downloads.Load(); got != 2 {
+		t.Errorf("upstream downloads after refresh = %d, want 2", got)
+	}
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Errorf("old cached file still exists after refresh: %v", err)
+	}
+}
+
+func TestCacheServerDoesNotCacheOtherTags(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	var tagRequests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, tag, ok := strings.Cut(r.URL.Path, "/releases/tags/"); ok {
+			if tag == "v0.9" {
+				tagRequests.Add(1)
+			}
+			json.NewEncoder(w).Encode(types.GitHubRelease{
+				TagName: tag,
+				Assets:  []types.GitHubAsset{{Name: "old.zip", BrowserDownloadURL: "https://upstream.example/old.zip", Digest: "sha256:" + sha256Hex(tag)}},
+			})
+			return
+		}
+		io.WriteString(w, "[]")
+	}))
+	defer upstream.Close()
+
+	base.Config.Config.GithubAPIURL = upstream.URL
+	cacheServer := NewCacheServer(base.Config)
+	server := httptest.NewServer(cacheServer.Handler())
+	defer server.Close()
+
+	client := api.NewClient(&types.Config{GithubAPIURL: server.URL})
+	for i := 0; i < 2; i++ {
+		releases, err := client.FetchGitHubReleases(context.Background(), types.OWNER, types.REPO, "v0.9")
+		if err != nil || len(releases) != 1 || len(releases[0].Assets) != 1 {
+			t.Fatalf("FetchGitHubReleases(v0.9) = %+v, %v, want the upstream release", releases, err)
+		}
+		// 未缓存的发布直接使用上游的下载地址
+		if got := releases[0].Assets[0].BrowserDownloadURL; got != "https://upstream.example/old.zip" {
+			t.Errorf("BrowserDownloadURL = %q, want the upstream URL", got)
+		}
+		client = api.NewClient(&types.Config{GithubAPIURL: server.URL})
+	}
+	if got := tagRequests.Load(); got != 2 {
+		t.Errorf("upstream requests for v0.9 = %d, want 2 (not cached)", got)
+	}
+
+	if err := cacheServer.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got := tagRequests.Load(); got != 2 {
+		t.Errorf("upstream requests for v0.9 after refresh = %d, want 2 (not refreshed)", got)
+	}
+	if _, ok := cacheServer.releases[releaseKey{types.REPO, "v0.9"}]; ok {
+		t.Error("releases for v0.9 were stored")
+	}
+}
+
+func TestCacheServerPinsByTagOrSHA256(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	content := "scheme archive v1"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/"+types.OWNER+"/"+types.REPO+"/releases" {
+			io.WriteString(w, "[]")
+			return
+		}
+		json.NewEncoder(w).Encode([]types.GitHubRelease{{
+			TagName: "v1.0.0",
+			Assets: []types.GitHubAsset{{
+				Name:               "scheme.zip",
+				BrowserDownloadURL: "https://upstream.example/scheme.zip",
+				Digest:             "sha256:" + sha256Hex(content),
+			}},
+		}})
+	}))
+	defer upstream.Close()
+
+	base.Config.Config.GithubAPIURL = upstream.URL
+	server := httptest.NewServer(NewCacheServer(base.Config).Handler())
+	defer server.Close()
+
+	// 缓存服务器提供 GitHub 格式的发布信息，不含 CNB 镜像的资源 ID，版本固定须使用版本号或 SHA256
+	client, _ := newManifestTestUpdater(t)
+	client.Config.Config.GithubAPIURL = server.URL
+	client.APIClient = api.NewClient(client.Config.Config)
+	tests := []struct {
+		pin       string
+		wantFound bool
+	}{
+		{"v1.0.0", true},
+		{sha256Hex(content), true},
+		{"cnb-asset-id", false},
+	}
+	for _, tt := range tests {
+		info, found, err := client.findPinnedRelease(context.Background(), types.REPO, "scheme.zip", tt.pin)
+		if err != nil || found != tt.wantFound {
+			t.Errorf("findPinnedRelease(%q) = %v, %v, want found %v", tt.pin, found, err, tt.wantFound)
+			continue
+		}
+		if found && info.Tag != "v1.0.0" {
+			t.Errorf("findPinnedRelease(%q) tag = %q, want v1.0.0", tt.pin, info.Tag)
+		}
+	}
+}
+
+func TestCacheServerConcurrentFetches(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	names := []string{"a.zip", "b.zip", "c.zip"}
+	var arrived sync.WaitGroup
+	arrived.Add(len(names))
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/"+types.OWNER+"/"+types.REPO+"/releases" {
+			release := types.GitHubRelease{TagName: "v1.0.0"}
+			for _, name := range names {
+				release.Assets = append(release.Assets, types.GitHubAsset{
+					Name:               name,
+					BrowserDownloadURL: upstream.URL + "/" + name,
+					Digest:             "sha256:" + sha256Hex(name),
+				})
+			}
+			json.NewEncoder(w).Encode([]types.GitHubRelease{release})
+			return
+		}
+		// 等到所有资源都开始下载后才返回，让下载确实同时进行
+		arrived.Done()
+		arrived.Wait()
+		io.WriteString(w, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer upstream.Close()
+
+	base.Config.Config.GithubAPIURL = upstream.URL
+	server := httptest.NewServer(NewCacheServer(base.Config).Handler())
+	defer server.Close()
+
+	releases, err := api.NewClient(&types.Config{GithubAPIURL: server.URL}).FetchGitHubReleases(context.Background(), types.OWNER, types.REPO, "")
+	if err != nil || len(releases) != 1 {
+		t.Fatalf("FetchGitHubReleases() = %+v, %v", releases, err)
+	}
+
+	// 不同资源同时从上游下载
+	var wg sync.WaitGroup
+	for _, asset := range releases[0].Assets {
+		wg.Add(1)
+		go func(asset types.GitHubAsset) {
+			defer wg.Done()
+			resp, err := http.Get(asset.BrowserDownloadURL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != asset.Name {
+				t.Errorf("GET %s = %d %q, want 200 %q", asset.Name, resp.StatusCode, body, asset.Name)
+			}
+		}(asset)
+	}
+	wg.Wait()
+}
+
+func TestCacheServerRejectsOtherRepos(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	server := httptest.NewServer(NewCacheServer(base.Config).Handler())
+	defer server.Close()
+
+	for _, path := range []string{
+		"/repos/someone/" + types.REPO + "/releases",
+		"/repos/" + types.OWNER + "/other/releases",
+		"/download/" + types.REPO + "/v1/abc/missing.zip",
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestServeAssetKey(t *testing.T) {
+	updated := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		tag    string
+		asset  types.GitHubAsset
+		want   string
+		wantOK bool
+	}{
+		{"sha256", "v1", types.GitHubAsset{Name: "a.zip", SHA256: strings.Repeat("A", 64)}, types.REPO + "/v1/" + strings.Repeat("a", 64) + "/a.zip", true},
+		{"update time", "dict-nightly", types.GitHubAsset{Name: "d.zip", UpdatedAt: updated}, types.REPO + "/dict-nightly/1700000000/d.zip", true},
+		{"tag with slash", "a/b", types.GitHubAsset{Name: "a.zip"}, "", false},
+		{"dot dot name", "v1", types.GitHubAsset{Name: ".."}, "", false},
+		{"partial suffix", "v1", types.GitHubAsset{Name: "a.zip.part"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := serveAssetKey(types.REPO, types.GitHubRelease{TagName: tt.tag}, tt.asset)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("serveAssetKey() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package updater
+
+import (
+	"context"
+	"time"
+
+	"rime-wanxiang-updater/internal/api"
+	"rime-wanxiang-updater/internal/config"
+)
+
+// probeTimeout 自动选择下载源时测速的时间上限
+const probeTimeout = 20 * time.Second
+
+// FastestSource 测试 GitHub 和 CNB 镜像并返回较快的一个，供配置了 AutoSelectSource 时在更新前调整 UseMirror；
+// 全部测速失败时 ok 为 false，应保持原来的下载源
+func FastestSource(ctx context.Context, cfg *config.Manager) (best api.ProbeResult, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	return api.FastestProbe(api.ProbeSources(ctx, cfg.Config))
+}
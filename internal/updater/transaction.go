@@ -0,0 +1,237 @@
+package updater
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// Transaction 记录一次更新将要改动的文件。
+// 修改前先把文件复制到快照目录，任一步骤失败时按快照恢复到更新前的状态。
+type Transaction struct {
+	dir         string
+	existed     map[string]bool // 文件路径 -> 更新前是否存在
+	order       []string        // 按登记顺序保存的文件路径
+	createdDirs map[string]bool // 更新前不存在、回滚时需要删除的目录
+}
+
+// NewTransaction 在 cacheDir/rollback 下创建快照目录
+func NewTransaction(cacheDir string) (*Transaction, error) {
+	dir := filepath.Join(cacheDir, "rollback", strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建回滚快照目录失败: %w", err)
+	}
+
+	return &Transaction{
+		dir:         dir,
+		existed:     make(map[string]bool),
+		createdDirs: make(map[string]bool),
+	}, nil
+}
+
+// Track 登记一个将被修改、删除或新建的文件；同一路径只会快照一次
+func (t *Transaction) Track(path string) error {
+	path = filepath.Clean(path)
+	if _, ok := t.existed[path]; ok {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return nil
+	case err == nil:
+		if err := fileutil.CopyFile(path, t.backupPath(len(t.order))); err != nil {
+			return fmt.Errorf("备份文件失败 %s: %w", path, err)
+		}
+		t.existed[path] = true
+	case os.IsNotExist(err):
+		t.existed[path] = false
+		t.trackCreatedDirs(filepath.Dir(path))
+	default:
+		return fmt.Errorf("读取文件信息失败 %s: %w", path, err)
+	}
+
+	t.order = append(t.order, path)
+	return nil
+}
+
+// TrackAll 以 root 为基准登记一组相对路径（zip 内的 / 分隔路径）
+func (t *Transaction) TrackAll(root string, names []string) error {
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if err := t.Track(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackCreatedDirs 记录 dir 及其不存在的上级目录
+func (t *Transaction) trackCreatedDirs(dir string) {
+	for dir != "" && !t.createdDirs[dir] && !fileutil.FileExists(dir) {
+		t.createdDirs[dir] = true
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}
+
+func (t *Transaction) backupPath(index int) string {
+	return filepath.Join(t.dir, strconv.Itoa(index))
+}
+
+// Rollback 恢复所有登记的文件：原本存在的复制回去，新建的删除。
+// 单个文件恢复失败不会中断，最终汇总返回。
+func (t *Transaction) Rollback() error {
+	var errors []string
+
+	for i := len(t.order) - 1; i >= 0; i-- {
+		path := t.order[i]
+		if !t.existed[path] {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errors = append(errors, fmt.Sprintf("%s: %v", path, err))
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if err := fileutil.CopyFile(t.backupPath(i), path); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+
+	// 由深到浅删除更新中新建的目录，非空目录说明里面有不属于本次更新的文件，予以保留
+	dirs := make([]string, 0, len(t.createdDirs))
+	for dir := range t.createdDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range dirs {
+		os.Remove(dir)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("回滚失败，以下文件未能恢复: %v", errors)
+	}
+
+	os.RemoveAll(t.dir)
+	return nil
+}
+
+// Commit 确认更新成功，删除快照
+func (t *Transaction) Commit() error {
+	return os.RemoveAll(t.dir)
+}
+
+// beginTransaction 返回组合更新共享的事务；单独更新时新建一个，owned 为 true 表示由调用方负责结束
+func (b *BaseUpdater) beginTransaction() (txn *Transaction, owned bool, err error) {
+	if b.Transaction != nil {
+		return b.Transaction, false, nil
+	}
+
+	txn, err = NewTransaction(b.Config.CacheDir)
+	return txn, true, err
+}
+
+// endTransaction 根据 err 提交或回滚自己创建的事务；共享事务由 CombinedUpdater 统一结束
+func endTransaction(txn *Transaction, owned bool, err error, progress types.ProgressFunc) error {
+	if !owned {
+		return err
+	}
+	if err == nil {
+		txn.Commit()
+		return nil
+	}
+
+	progress("正在恢复更新前的文件...", 1.0, "", "", 0, 0, 0, false)
+	if rollbackErr := txn.Rollback(); rollbackErr != nil {
+		return fmt.Errorf("%w; %v", err, rollbackErr)
+	}
+	progress("更新失败，已恢复更新前的文件", 1.0, "", "", 0, 0, 0, false)
+	return err
+}
+
+// listFiles 返回 root 下所有文件的相对路径（/ 分隔）；root 不存在时返回空列表
+func listFiles(root string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取目录失败: %w", err)
+	}
+	return names, nil
+}
+
+// trackArchive 登记安装暂存目录到 extractPath 时会改动的文件：
+// 暂存目录中的文件，以及旧包中将被清理的文件
+func (b *BaseUpdater) trackArchive(txn *Transaction, staging, previousArchive, extractPath string) ([]string, error) {
+	names, err := listFiles(staging)
+	if err != nil {
+		return nil, err
+	}
+
+	if previousArchive != "" && fileutil.FileExists(previousArchive) {
+		oldNames, err := fileutil.GetZipFileList(previousArchive)
+		if err != nil {
+			return nil, fmt.Errorf("获取旧文件列表失败: %w", err)
+		}
+		names = append(names, oldNames...)
+	}
+
+	return names, txn.TrackAll(extractPath, names)
+}
+
+// trackSecondaryEngines 登记同步到其他引擎时会被覆盖的文件。
+// SyncDirectory 会复制源目录的全部内容，因此除了更新包中的文件，还要登记源目录中已有的文件。
+func (b *BaseUpdater) trackSecondaryEngines(txn *Transaction, sourceDir, subDir string, names []string) error {
+	engines := b.secondaryEngines()
+	if len(b.Config.Config.InstalledEngines) <= 1 || len(engines) == 0 {
+		return nil
+	}
+
+	existing, err := listFiles(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	for _, engine := range engines {
+		dataDir := config.GetEngineDataDir(engine)
+		if dataDir == "" {
+			continue
+		}
+		targetDir := filepath.Join(dataDir, subDir)
+		if err := txn.TrackAll(targetDir, existing); err != nil {
+			return err
+		}
+		if err := txn.TrackAll(targetDir, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
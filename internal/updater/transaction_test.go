@@ -0,0 +1,148 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestTransactionRollbackRestoresFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	rimeDir := filepath.Join(tmpDir, "rime")
+	existing := filepath.Join(rimeDir, "default.yaml")
+	removed := filepath.Join(rimeDir, "lua", "old.lua")
+	created := filepath.Join(rimeDir, "opencc", "new", "a.txt")
+	writeTestFile(t, existing)
+	writeTestFile(t, removed)
+
+	txn, err := NewTransaction(filepath.Join(tmpDir, "cache"))
+	if err != nil {
+		t.Fatalf("NewTransaction() error = %v", err)
+	}
+	for _, path := range []string{existing, removed, created} {
+		if err := txn.Track(path); err != nil {
+			t.Fatalf("Track(%s) error = %v", path, err)
+		}
+	}
+
+	// 模拟更新：覆盖、删除、新建
+	if err := os.WriteFile(existing, []byte("upstream"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, created)
+
+	if err := txn.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	if got := readTestFile(t, existing); got != "local" {
+		t.Errorf("overwritten file = %q, want %q", got, "local")
+	}
+	if !fileutil.FileExists(removed) {
+		t.Errorf("deleted file %s was not restored", removed)
+	}
+	if fileutil.FileExists(created) {
+		t.Errorf("new file %s was not removed", created)
+	}
+	if fileutil.FileExists(filepath.Join(rimeDir, "opencc")) {
+		t.Errorf("directory created by the update was not removed")
+	}
+	if fileutil.FileExists(txn.dir) {
+		t.Errorf("snapshot directory %s was not cleaned up", txn.dir)
+	}
+}
+
+func TestTransactionCommitKeepsChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "rime", "default.yaml")
+	writeTestFile(t, path)
+
+	txn, err := NewTransaction(filepath.Join(tmpDir, "cache"))
+	if err != nil {
+		t.Fatalf("NewTransaction() error = %v", err)
+	}
+	if err := txn.Track(path); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte("upstream"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 重复登记不应覆盖第一次的快照
+	if err := txn.Track(path); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if got := readTestFile(t, path); got != "upstream" {
+		t.Errorf("file after commit = %q, want %q", got, "upstream")
+	}
+	if fileutil.FileExists(txn.dir) {
+		t.Errorf("snapshot directory %s was not removed", txn.dir)
+	}
+}
+
+func TestTrackArchiveCoversCleanAndInstall(t *testing.T) {
+	tmpDir := t.TempDir()
+	rimeDir := filepath.Join(tmpDir, "rime")
+	oldZip := filepath.Join(tmpDir, "old.zip")
+	newZip := filepath.Join(tmpDir, "new.zip")
+	writeTestZip(t, oldZip, "default.yaml", "lua/old.lua")
+	writeTestZip(t, newZip, "default.yaml", "lua/wanxiang.lua")
+	writeTestFile(t, filepath.Join(rimeDir, "default.yaml"))
+	writeTestFile(t, filepath.Join(rimeDir, "lua", "old.lua"))
+
+	base := &BaseUpdater{Config: &config.Manager{Config: &types.Config{}, RimeDir: rimeDir}}
+	staging, err := base.stageArchive(newZip, "new.zip", "lua/wanxiang.lua")
+	if err != nil {
+		t.Fatalf("stageArchive() error = %v", err)
+	}
+	defer os.RemoveAll(staging)
+
+	txn, err := NewTransaction(filepath.Join(tmpDir, "cache"))
+	if err != nil {
+		t.Fatalf("NewTransaction() error = %v", err)
+	}
+	if _, err := base.trackArchive(txn, staging, oldZip, rimeDir); err != nil {
+		t.Fatalf("trackArchive() error = %v", err)
+	}
+
+	// 按更新器的顺序清理旧文件并安装
+	if err := base.CleanOldFiles(oldZip, newZip, rimeDir, false); err != nil {
+		t.Fatalf("CleanOldFiles() error = %v", err)
+	}
+	if _, err := fileutil.InstallStaged(staging, rimeDir, nil); err != nil {
+		t.Fatalf("InstallStaged() error = %v", err)
+	}
+
+	if err := txn.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if got := readTestFile(t, filepath.Join(rimeDir, "default.yaml")); got != "local" {
+		t.Errorf("default.yaml = %q, want %q", got, "local")
+	}
+	if !fileutil.FileExists(filepath.Join(rimeDir, "lua", "old.lua")) {
+		t.Errorf("lua/old.lua was not restored")
+	}
+	if fileutil.FileExists(filepath.Join(rimeDir, "lua", "wanxiang.lua")) {
+		t.Errorf("lua/wanxiang.lua was not removed")
+	}
+}
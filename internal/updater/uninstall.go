@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// UninstallResult 单个组件的卸载结果
+type UninstallResult struct {
+	Component string   // 内部组件名（方案/词库/模型）
+	Removed   []string // 已删除的文件
+	Kept      []string // 安装后被修改过、予以保留的文件
+	Err       error
+}
+
+// uninstall 按安装清单删除组件在所有引擎目录中安装的文件，随后删除清单和 cacheFiles（版本记录、缓存的更新包）。
+// 清单之外的文件和安装后被修改过的文件都不会被删除。
+func (b *BaseUpdater) uninstall(component string, cacheFiles ...string) *UninstallResult {
+	result := &UninstallResult{Component: types.ComponentName(component)}
+
+	paths, err := filepath.Glob(filepath.Join(b.Config.GetManifestDir(), component+"_*.json"))
+	if err != nil {
+		result.Err = fmt.Errorf("查找安装清单失败: %w", err)
+		return result
+	}
+	if len(paths) == 0 {
+		result.Err = fmt.Errorf("未找到%s的安装清单，只能卸载由本程序安装的文件: %w", result.Component, ErrNoManifest)
+		return result
+	}
+
+	var errors []string
+	for _, path := range paths {
+		manifest, err := LoadManifest(path)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if manifest != nil {
+			removed, kept := removeManifestFiles(manifest, nil)
+			result.Removed = append(result.Removed, removed...)
+			result.Kept = append(result.Kept, kept...)
+		}
+		if err := os.Remove(path); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	for _, path := range cacheFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if len(errors) > 0 {
+		result.Err = fmt.Errorf("卸载未完全完成: %v", errors)
+	}
+	return result
+}
+
+// Uninstall 卸载方案文件（不终止进程，由调用方负责）
+func (s *SchemeUpdater) Uninstall() *UninstallResult {
+	return s.uninstall(types.ComponentScheme,
+		s.Config.GetSchemeRecordPath(),
+		filepath.Join(s.Config.CacheDir, s.Config.Config.SchemeFile))
+}
+
+// Uninstall 卸载词库文件（不终止进程，由调用方负责）
+func (d *DictUpdater) Uninstall() *UninstallResult {
+	return d.uninstall(types.ComponentDict,
+		d.Config.GetDictRecordPath(),
+		filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile))
+}
+
+// Uninstall 卸载所有模型文件（不终止进程，由调用方负责）
+func (m *ModelUpdater) Uninstall() *UninstallResult {
+	records, _ := filepath.Glob(filepath.Join(m.Config.CacheDir, "model_record_*.json"))
+	return m.uninstall(types.ComponentModel, append([]string{m.Config.GetModelRecordPath()}, records...)...)
+}
+
+// Uninstall 终止相关进程后依次卸载 componentIDs 中的组件，返回各组件的结果
+func (c *CombinedUpdater) Uninstall(componentIDs []string) ([]*UninstallResult, error) {
+	if err := c.base.TerminateProcesses(); err != nil {
+		return nil, fmt.Errorf("终止进程失败: %w", err)
+	}
+
+	results := make([]*UninstallResult, 0, len(componentIDs))
+	for _, id := range componentIDs {
+		comp, err := c.Component(id)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, comp.Uninstall())
+	}
+	return results, nil
+}
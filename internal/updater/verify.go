@@ -0,0 +1,292 @@
+package updater
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rime-wanxiang-updater/internal/config"
+	"rime-wanxiang-updater/internal/fileutil"
+	"rime-wanxiang-updater/internal/types"
+)
+
+// 校验依据
+const (
+	VerifyByManifest = "manifest" // 安装清单
+	VerifyByArchive  = "archive"  // 缓存的更新包（由不记录清单的旧版本安装时）
+)
+
+// VerifyReport 单个组件的完整性校验结果，路径相对于 Root（/ 分隔）
+type VerifyReport struct {
+	Component  string   // 组件名（方案/词库）
+	Root       string   // 校验的目录
+	Reference  string   // 校验依据，见 VerifyByManifest / VerifyByArchive
+	Missing    []string // 安装后被删除的文件
+	Modified   []string // 内容与安装时不一致的文件（排除规则匹配的文件不计）
+	Unexpected []string // 位于万象目录中、但不属于安装内容的文件，仅作提示，不会被修复或删除
+	Repaired   []string // 已从缓存的更新包恢复的文件
+	Err        error
+}
+
+// Unresolved 返回尚未修复的缺失和被修改文件
+func (r *VerifyReport) Unresolved() []string {
+	repaired := make(map[string]bool, len(r.Repaired))
+	for _, name := range r.Repaired {
+		repaired[name] = true
+	}
+
+	var unresolved []string
+	for _, name := range append(append([]string(nil), r.Missing...), r.Modified...) {
+		if !repaired[name] {
+			unresolved = append(unresolved, name)
+		}
+	}
+	return unresolved
+}
+
+// verifyTarget 组件校验所需的路径信息
+type verifyTarget struct {
+	component   string   // 组件 ID
+	root        string   // 校验的目录
+	archive     string   // 缓存的更新包
+	zipFileName string   // 更新包文件名，用于处理镜像嵌套目录
+	keyFile     string   // 更新包中必须存在的关键文件
+	ownsRoot    bool     // root 是否整个属于该组件；Rime 用户目录根部还有大量用户文件，不检查多余文件
+	skipDirs    []string // 不参与校验的子目录（如由词库组件管理的词库目录、部署生成的 build 目录）
+}
+
+// skipped 返回 rel 是否位于不参与校验的子目录中
+func (t verifyTarget) skipped(rel string) bool {
+	for _, dir := range t.skipDirs {
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// verify 以安装清单（没有清单时以缓存的更新包）为依据校验 target.root，repair 为 true 时从缓存的更新包恢复缺失和被修改的文件
+func (b *BaseUpdater) verify(target verifyTarget, repair bool) *VerifyReport {
+	report := &VerifyReport{Component: types.ComponentName(target.component), Root: target.root}
+
+	manifest, err := LoadManifest(b.Config.GetManifestPath(target.component, b.primaryEngine()))
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	if manifest != nil && filepath.Clean(manifest.Root) != filepath.Clean(target.root) {
+		manifest = nil
+	}
+
+	// 修复或没有清单时都需要解压缓存的更新包
+	var staging string
+	if manifest == nil || repair {
+		if !fileutil.FileExists(target.archive) {
+			if manifest == nil {
+				report.Err = fmt.Errorf("没有安装清单，也没有缓存的更新包，无法校验: %w", ErrNoManifest)
+				return report
+			}
+		} else {
+			staging, err = b.stageArchive(target.archive, target.zipFileName, target.keyFile)
+			if err != nil {
+				report.Err = fmt.Errorf("解压缓存的更新包失败: %w", err)
+				return report
+			}
+			defer os.RemoveAll(staging)
+		}
+	}
+
+	report.Reference = VerifyByManifest
+	if manifest == nil {
+		names, err := listFiles(staging)
+		if err != nil {
+			report.Err = err
+			return report
+		}
+		if manifest, err = buildManifest(target.component, b.primaryEngine(), staging, "", names, nil); err != nil {
+			report.Err = err
+			return report
+		}
+		report.Reference = VerifyByArchive
+	}
+
+	if err := b.compareManifest(report, manifest, target); err != nil {
+		report.Err = err
+		return report
+	}
+
+	if repair && len(report.Unresolved()) > 0 {
+		if staging == "" {
+			report.Err = fmt.Errorf("缓存中没有更新包，无法修复，请执行完整更新")
+			return report
+		}
+		report.Repaired, report.Err = b.repairFiles(manifest, staging, target.root, report.Unresolved())
+	}
+
+	return report
+}
+
+// compareManifest 对比 root 中的文件与清单，填写缺失、被修改和多余的文件
+func (b *BaseUpdater) compareManifest(report *VerifyReport, manifest *types.InstallManifest, target verifyTarget) error {
+	excludePatterns, _ := config.ParseExcludePatterns(b.Config.Config.ExcludeFiles)
+	expected := manifestIndex(manifest)
+	ownedDirs := make(map[string]bool)
+
+	for _, file := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(file.Path)) || target.skipped(file.Path) {
+			continue
+		}
+		if dir := path.Dir(file.Path); dir != "." || target.ownsRoot {
+			ownedDirs[dir] = true
+		}
+
+		localPath := filepath.Join(target.root, filepath.FromSlash(file.Path))
+		info, err := os.Stat(localPath)
+		if os.IsNotExist(err) {
+			report.Missing = append(report.Missing, file.Path)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("读取文件信息失败 %s: %w", file.Path, err)
+		}
+		if info.IsDir() {
+			report.Missing = append(report.Missing, file.Path)
+			continue
+		}
+
+		// 排除规则匹配的文件允许用户修改
+		if config.MatchAny(file.Path, excludePatterns) {
+			continue
+		}
+		if info.Size() != file.Size {
+			report.Modified = append(report.Modified, file.Path)
+			continue
+		}
+		hash, err := fileutil.CalculateSHA256(localPath)
+		if err != nil {
+			return fmt.Errorf("计算文件哈希失败 %s: %w", file.Path, err)
+		}
+		if hash != file.SHA256 {
+			report.Modified = append(report.Modified, file.Path)
+		}
+	}
+
+	// 只在清单中出现过的目录里查找多余文件
+	err := filepath.WalkDir(target.root, func(localPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(target.root, localPath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if target.skipped(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := expected[rel]; ok || !ownedDirs[path.Dir(rel)] || config.MatchAny(rel, excludePatterns) {
+			return nil
+		}
+		report.Unexpected = append(report.Unexpected, rel)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取目录失败: %w", err)
+	}
+
+	sort.Strings(report.Missing)
+	sort.Strings(report.Modified)
+	sort.Strings(report.Unexpected)
+	return nil
+}
+
+// repairFiles 从暂存目录恢复 names 中的文件。
+// 暂存的文件必须与清单中记录的哈希一致，否则说明缓存的更新包不是当前安装的版本，该文件不予恢复。
+// 恢复过程使用事务，任一文件写入失败时全部还原。
+func (b *BaseUpdater) repairFiles(manifest *types.InstallManifest, staging, root string, names []string) ([]string, error) {
+	expected := manifestIndex(manifest)
+
+	var repairable, mismatched []string
+	for _, name := range names {
+		hash, err := fileutil.CalculateSHA256(filepath.Join(staging, filepath.FromSlash(name)))
+		if err != nil || hash != expected[name].SHA256 {
+			mismatched = append(mismatched, name)
+			continue
+		}
+		repairable = append(repairable, name)
+	}
+
+	txn, err := NewTransaction(b.Config.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := txn.TrackAll(root, repairable); err != nil {
+		txn.Rollback()
+		return nil, err
+	}
+	for _, name := range repairable {
+		target := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(target), 0755)
+		if err == nil {
+			err = fileutil.CopyFile(filepath.Join(staging, filepath.FromSlash(name)), target)
+		}
+		if err != nil {
+			if rollbackErr := txn.Rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("恢复文件 %s 失败: %w; %v", name, err, rollbackErr)
+			}
+			return nil, fmt.Errorf("恢复文件 %s 失败: %w", name, err)
+		}
+	}
+	txn.Commit()
+
+	if len(mismatched) > 0 {
+		return repairable, fmt.Errorf("缓存的更新包与已安装版本不一致，以下文件未能修复，请执行完整更新: %s", strings.Join(mismatched, ", "))
+	}
+	return repairable, nil
+}
+
+// Verify 校验方案文件，repair 为 true 时从缓存的更新包恢复缺失和被修改的文件
+func (s *SchemeUpdater) Verify(repair bool) *VerifyReport {
+	return s.verify(verifyTarget{
+		component:   types.ComponentScheme,
+		root:        s.Config.GetExtractPath(),
+		archive:     filepath.Join(s.Config.CacheDir, s.Config.Config.SchemeFile),
+		zipFileName: s.Config.Config.SchemeFile,
+		keyFile:     schemeKeyFile,
+		skipDirs:    []string{"build", filepath.ToSlash(s.Config.ZhDictsDir)},
+	}, repair)
+}
+
+// Verify 校验词库文件，repair 为 true 时从缓存的更新包恢复缺失和被修改的文件
+func (d *DictUpdater) Verify(repair bool) *VerifyReport {
+	return d.verify(verifyTarget{
+		component:   types.ComponentDict,
+		root:        d.Config.GetDictExtractPath(),
+		archive:     filepath.Join(d.Config.CacheDir, d.Config.Config.DictFile),
+		zipFileName: d.Config.Config.DictFile,
+		keyFile:     dictKeyFile,
+		ownsRoot:    true,
+	}, repair)
+}
+
+// Verify 依次校验 componentIDs 中的组件（支持实现了 Verify 的组件，如方案和词库）
+func (c *CombinedUpdater) Verify(componentIDs []string, repair bool) []*VerifyReport {
+	reports := make([]*VerifyReport, 0, len(componentIDs))
+	for _, id := range componentIDs {
+		comp, _ := c.Component(id)
+		if v, ok := comp.(verifier); ok {
+			reports = append(reports, v.Verify(repair))
+		} else {
+			reports = append(reports, &VerifyReport{Component: types.ComponentName(id), Err: fmt.Errorf("不支持校验的组件: %s", id)})
+		}
+	}
+	return reports
+}
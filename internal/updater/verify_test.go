@@ -0,0 +1,154 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rime-wanxiang-updater/internal/types"
+)
+
+// installTestScheme 按 writeTestZip 的内容写入方案文件并记录安装清单
+func installTestScheme(t *testing.T, base *BaseUpdater, rimeDir string, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		path := filepath.Join(rimeDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets := []installTarget{{engine: "fcitx5", root: rimeDir}}
+	if err := base.writeManifests(types.ComponentScheme, targets, names, names, "v1"); err != nil {
+		t.Fatalf("writeManifests() error = %v", err)
+	}
+}
+
+func TestSchemeVerifyAndRepair(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	base.Config.ZhDictsDir = "dicts"
+	scheme := &SchemeUpdater{BaseUpdater: base}
+
+	names := []string{"default.yaml", "lua/wanxiang.lua"}
+	installTestScheme(t, base, rimeDir, names...)
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestZip(t, filepath.Join(base.Config.CacheDir, "scheme.zip"), names...)
+
+	if err := os.Remove(filepath.Join(rimeDir, "default.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rimeDir, "lua", "wanxiang.lua"), []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(rimeDir, "lua", "extra.lua"))
+	writeTestFile(t, filepath.Join(rimeDir, "user.yaml"))         // 用户目录根部的用户文件不算多余
+	writeTestFile(t, filepath.Join(rimeDir, "dicts", "base.txt")) // 词库目录由词库组件校验
+
+	report := scheme.Verify(false)
+	if report.Err != nil {
+		t.Fatalf("Verify() error = %v", report.Err)
+	}
+	if report.Reference != VerifyByManifest {
+		t.Errorf("Verify() reference = %q, want %q", report.Reference, VerifyByManifest)
+	}
+	if want := []string{"default.yaml"}; !reflect.DeepEqual(report.Missing, want) {
+		t.Errorf("Verify() missing = %v, want %v", report.Missing, want)
+	}
+	if want := []string{"lua/wanxiang.lua"}; !reflect.DeepEqual(report.Modified, want) {
+		t.Errorf("Verify() modified = %v, want %v", report.Modified, want)
+	}
+	if want := []string{"lua/extra.lua"}; !reflect.DeepEqual(report.Unexpected, want) {
+		t.Errorf("Verify() unexpected = %v, want %v", report.Unexpected, want)
+	}
+
+	report = scheme.Verify(true)
+	if report.Err != nil {
+		t.Fatalf("Verify(repair) error = %v", report.Err)
+	}
+	if len(report.Repaired) != 2 || len(report.Unresolved()) != 0 {
+		t.Errorf("Verify(repair) repaired = %v, unresolved = %v", report.Repaired, report.Unresolved())
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(rimeDir, filepath.FromSlash(name)))
+		if err != nil || string(data) != name {
+			t.Errorf("%s after repair = %q, %v, want %q", name, data, err, name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(rimeDir, "lua", "extra.lua")); err != nil {
+		t.Errorf("repair removed an unexpected file: %v", err)
+	}
+}
+
+func TestSchemeVerifyRepairRefusesMismatchedArchive(t *testing.T) {
+	base, rimeDir := newManifestTestUpdater(t)
+	scheme := &SchemeUpdater{BaseUpdater: base}
+
+	installTestScheme(t, base, rimeDir, "default.yaml", "lua/wanxiang.lua")
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 缓存的更新包来自另一个版本，其中 default.yaml 内容不同
+	zipPath := filepath.Join(base.Config.CacheDir, "scheme.zip")
+	writeTestZip(t, zipPath, "lua/wanxiang.lua", "other.yaml")
+	if err := os.Remove(filepath.Join(rimeDir, "default.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	report := scheme.Verify(true)
+	if report.Err == nil {
+		t.Fatal("Verify(repair) error = nil, want mismatch error")
+	}
+	if len(report.Repaired) != 0 {
+		t.Errorf("Verify(repair) repaired = %v, want none", report.Repaired)
+	}
+	if want := []string{"default.yaml"}; !reflect.DeepEqual(report.Unresolved(), want) {
+		t.Errorf("Unresolved() = %v, want %v", report.Unresolved(), want)
+	}
+}
+
+func TestVerifyFallsBackToCachedArchive(t *testing.T) {
+	base, _ := newManifestTestUpdater(t)
+	dict := &DictUpdater{BaseUpdater: base}
+	base.Config.ZhDictsDir = "dicts"
+	base.Config.Config.DictFile = "dict.zip"
+	dictDir := base.Config.GetDictExtractPath()
+
+	report := dict.Verify(false)
+	if !errors.Is(report.Err, ErrNoManifest) {
+		t.Fatalf("Verify() without manifest or archive error = %v, want %v", report.Err, ErrNoManifest)
+	}
+
+	if err := os.MkdirAll(base.Config.CacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestZip(t, filepath.Join(base.Config.CacheDir, "dict.zip"), dictKeyFile, "base.dict.yaml")
+	if err := os.MkdirAll(dictDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dictDir, dictKeyFile), []byte(dictKeyFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dictDir, "mine.txt"))
+
+	report = dict.Verify(false)
+	if report.Err != nil {
+		t.Fatalf("Verify() error = %v", report.Err)
+	}
+	if report.Reference != VerifyByArchive {
+		t.Errorf("Verify() reference = %q, want %q", report.Reference, VerifyByArchive)
+	}
+	if want := []string{"base.dict.yaml"}; !reflect.DeepEqual(report.Missing, want) {
+		t.Errorf("Verify() missing = %v, want %v", report.Missing, want)
+	}
+	// 词库目录整个属于词库组件，根部的多余文件也会报告
+	if want := []string{"mine.txt"}; !reflect.DeepEqual(report.Unexpected, want) {
+		t.Errorf("Verify() unexpected = %v, want %v", report.Unexpected, want)
+	}
+}